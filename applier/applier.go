@@ -10,6 +10,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/skeema/fs"
 	"github.com/skeema/skeema/linter"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/tengo"
 )
 
@@ -70,31 +71,16 @@ func applyTarget(t *Target, printer *Printer) (Result, error) {
 	schemaFromInstance, err := t.SchemaFromInstance()
 	if err != nil {
 		result.SkipCount++
-		log.Errorf("Skipping %s schema %s for %s: %s", t.Instance, t.SchemaName, t.Dir, err)
+		t.logger().Errorf("Skipping %s schema %s for %s: %s", t.Instance, t.SchemaName, t.Dir, err)
 		return result, err
 	}
 
 	t.logApplyStart()
 	schemaFromDir := t.SchemaFromDir()
 
-	// Obtain StatementModifiers based on the dir's config
-	mods, err := StatementModifiersForDir(t.Dir)
+	mods, err := statementModifiersForTarget(t, schemaFromDir)
 	if err != nil {
-		return result, ConfigError(err.Error())
-	}
-	mods.Flavor = t.Instance.Flavor()
-	if mods.Partitioning == tengo.PartitioningRemove {
-		// With partitioning=remove, forcibly treat all filesystem definitions as if
-		// they didn't have a partitioning clause. This is designed to aid in the
-		// use-case of not running any partition management in a dev environment; if
-		// a table somehow manages to be partitioned there anyway by mistake, we
-		// intentionally want to de-partition it.
-		for _, table := range schemaFromDir.Tables {
-			if table.Partitioning != nil {
-				table.CreateStatement = table.UnpartitionedCreateStatement(mods.Flavor)
-				table.Partitioning = nil
-			}
-		}
+		return result, err
 	}
 
 	diff := tengo.NewSchemaDiff(schemaFromInstance, schemaFromDir)
@@ -119,18 +105,60 @@ func applyTarget(t *Target, printer *Printer) (Result, error) {
 			keys = append(keys, objDiff.ObjectKey())
 		} else if unsupportedErr, ok := err.(*tengo.UnsupportedDiffError); ok {
 			result.UnsupportedCount++
-			log.Warnf("Skipping %s: unable to generate DDL due to use of unsupported features. Use --debug for more information.", unsupportedErr.ObjectKey)
+			t.logger().WithField("object", unsupportedErr.ObjectKey.String()).Warnf("Skipping %s: unable to generate DDL due to use of unsupported features. Use --debug for more information.", unsupportedErr.ObjectKey)
 			DebugLogUnsupportedDiff(unsupportedErr)
 		} else {
 			result.SkipCount += len(objDiffs)
-			log.Errorf(err.Error())
+			t.logger().Errorf(err.Error())
 			if len(objDiffs) > 1 {
-				log.Warnf("Skipping %d additional operations for %s %s due to previous error", len(objDiffs)-1, t.Instance, t.SchemaName)
+				t.logger().Warnf("Skipping %d additional operations for %s %s due to previous error", len(objDiffs)-1, t.Instance, t.SchemaName)
 			}
 			return result, nil
 		}
 	}
 
+	// Build DML for rows of any tables listed in data-tables. These statements
+	// run after all DDL, so that they operate on the desired table definitions.
+	dmls, err := dataStatementsForTarget(t, schemaFromInstance, mods.AllowUnsafe)
+	if err != nil {
+		result.SkipCount += len(objDiffs) + 1
+		t.logger().Errorf("Unable to compare data for %s %s: %s", t.Instance, t.SchemaName, err)
+		return result, nil
+	}
+	if len(dmls) > 0 {
+		result.Differences = true
+	}
+
+	// Build ALTER DATABASE statements for any schema-level options beyond charset
+	// and collation. Some of these must run before other DDL, but after any
+	// CREATE DATABASE, which is always first if present.
+	pre, post, err := schemaOptionStatements(t, schemaFromInstance != nil, len(ddls)+len(dmls) > 0)
+	if unsupportedErr, ok := err.(UnsupportedSchemaOptionError); ok {
+		result.UnsupportedCount++
+		t.logger().Warnf("Skipping %s %s: %s", t.Instance, t.SchemaName, unsupportedErr)
+		return result, nil
+	} else if err != nil {
+		result.SkipCount += len(objDiffs) + 1
+		t.logger().Errorf("Unable to compare schema-level options for %s %s: %s", t.Instance, t.SchemaName, err)
+		return result, nil
+	}
+	var dbCount int
+	for dbCount < len(keys) && keys[dbCount].Type == tengo.ObjectTypeDatabase {
+		dbCount++
+	}
+	if dbCount+len(pre)+len(post) > 0 {
+		// Blank-named database key triggers schema-level lint rules
+		keys = append(keys, tengo.ObjectKey{Type: tengo.ObjectTypeDatabase})
+	}
+	if len(pre)+len(post) > 0 {
+		result.Differences = true
+		ordered := make([]*DDLStatement, 0, len(ddls)+len(pre))
+		ordered = append(ordered, ddls[:dbCount]...)
+		ordered = append(ordered, pre...)
+		ddls = append(ordered, ddls[dbCount:]...)
+		dmls = append(dmls, post...)
+	}
+
 	// Lint any modified objects; output the result; skip target if any
 	// annotations are at the error level
 	if t.Dir.Config.GetBool("lint") {
@@ -146,32 +174,33 @@ func applyTarget(t *Target, printer *Printer) (Result, error) {
 		}
 		if lintResult.ErrorCount > 0 {
 			result.SkipCount += len(objDiffs)
-			log.Warnf("Skipping %s %s due to %s", t.Instance, t.SchemaName, countAndNoun(lintResult.ErrorCount, "linter error"))
+			t.logger().Warnf("Skipping %s %s due to %s", t.Instance, t.SchemaName, util.CountAndNoun(lintResult.ErrorCount, "linter error"))
 			return result, nil
 		}
 	}
 
+	// Write migration files if requested, before any DDL is executed
+	if printer.migrations != nil {
+		m := migrationForTarget(t, ddls, dmls, schemaFromInstance, schemaFromDir, mods)
+		if len(m.Up) > 0 {
+			paths, err := printer.migrations.Write(m)
+			for _, path := range paths {
+				t.logger().Infof("Wrote migration file %s", path)
+			}
+			if err != nil {
+				result.SkipCount += len(objDiffs)
+				t.logger().Errorf("Unable to write migration for %s %s: %s", t.Instance, t.SchemaName, err)
+				return result, nil
+			}
+		}
+	}
+
 	// Print DDL; if not dry-run, execute it; final logging; return result
-	result.SkipCount += t.processDDL(ddls, printer)
+	result.SkipCount += t.processDDL(append(ddls, dmls...), printer)
 	t.logApplyEnd(result)
 	return result, nil
 }
 
-// supply 1 noun if pluralization is just adding an s, or 2 nouns if using
-// another word entirely
-func countAndNoun(n int, nouns ...string) string {
-	if n == 1 {
-		return fmt.Sprintf("1 %s", nouns[0])
-	}
-	var plural string
-	if len(nouns) == 1 {
-		plural = fmt.Sprintf("%ss", nouns[0])
-	} else {
-		plural = nouns[1]
-	}
-	return fmt.Sprintf("%d %s", n, plural)
-}
-
 // SumResults adds up the supplied results to return a single combined result.
 func SumResults(results []Result) Result {
 	var total Result
@@ -217,6 +246,32 @@ func StatementModifiersForDir(dir *fs.Dir) (mods tengo.StatementModifiers, err e
 	return
 }
 
+// statementModifiersForTarget returns StatementModifiers based on the config of
+// t's dir and the flavor of t's instance, for use in diffing schemaFromDir
+// against t's instance. With partitioning=remove, schemaFromDir's tables are
+// modified in-place to remove their partitioning clauses.
+func statementModifiersForTarget(t *Target, schemaFromDir *tengo.Schema) (tengo.StatementModifiers, error) {
+	mods, err := StatementModifiersForDir(t.Dir)
+	if err != nil {
+		return mods, ConfigError(err.Error())
+	}
+	mods.Flavor = t.Instance.Flavor()
+	if mods.Partitioning == tengo.PartitioningRemove {
+		// With partitioning=remove, forcibly treat all filesystem definitions as if
+		// they didn't have a partitioning clause. This is designed to aid in the
+		// use-case of not running any partition management in a dev environment; if
+		// a table somehow manages to be partitioned there anyway by mistake, we
+		// intentionally want to de-partition it.
+		for _, table := range schemaFromDir.Tables {
+			if table.Partitioning != nil {
+				table.CreateStatement = table.UnpartitionedCreateStatement(mods.Flavor)
+				table.Partitioning = nil
+			}
+		}
+	}
+	return mods, nil
+}
+
 // DebugLogUnsupportedDiff logs (at Debug level) the reason why an object is
 // unsupported for diff/alter operations.
 func DebugLogUnsupportedDiff(err *tengo.UnsupportedDiffError) {
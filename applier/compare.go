@@ -0,0 +1,320 @@
+package applier
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// SchemaPair represents a single schema that exists in two different
+// environments, for purposes of comparing the live schemas directly to one
+// another, without involving the filesystem's *.sql files.
+type SchemaPair struct {
+	FromInstance   *tengo.Instance
+	FromSchemaName string
+	ToInstance     *tengo.Instance
+	ToSchemaName   string
+	Dir            *fs.Dir // dir configured for the "to" environment
+}
+
+// String returns a human-readable description of the pair.
+func (sp *SchemaPair) String() string {
+	return fmt.Sprintf("%s %s vs %s %s", sp.FromInstance, sp.FromSchemaName, sp.ToInstance, sp.ToSchemaName)
+}
+
+// SchemaPairsForDirs examines the configuration of two versions of the same
+// dir, each parsed using a different environment, and returns a SchemaPair for
+// each schema that the dir maps to. It then recursively descends through the
+// dirs' subdirectories to do the same.
+//
+// Only the first reachable instance per dir is used in each environment. If
+// the dir maps to a single schema name in each environment, these schemas are
+// paired even if their names differ. Otherwise, schemas are paired by name, and
+// a schema which only exists in one environment is paired with a nonexistent
+// schema in the other.
+//
+// Errors are not fatal; a count of skipped dirs is returned instead.
+func SchemaPairsForDirs(fromDir, toDir *fs.Dir, maxDepth int) (pairs []*SchemaPair, skipCount int) {
+	if fromDir.ParseError != nil {
+		log.Warnf("Skipping %s: %s\n", fromDir.Path, fromDir.ParseError)
+		return nil, 1
+	}
+	if fromDir.HasSchema() || toDir.HasSchema() {
+		thisPairs, err := schemaPairsForDir(fromDir, toDir)
+		if err != nil {
+			log.Warnf("Skipping %s: %s\n", fromDir, err)
+			skipCount++
+		}
+		pairs = append(pairs, thisPairs...)
+	}
+
+	fromSubdirs, err := fromDir.Subdirs()
+	if err != nil {
+		log.Warnf("Skipping subdirs of %s: %s\n", fromDir, err)
+		skipCount++
+		return
+	}
+	toSubdirs, err := toDir.Subdirs()
+	if err != nil {
+		log.Warnf("Skipping subdirs of %s: %s\n", toDir, err)
+		skipCount++
+		return
+	} else if len(fromSubdirs) > 0 && maxDepth < 1 {
+		log.Warnf("Skipping subdirs of %s: max depth reached\n", fromDir)
+		skipCount += len(fromSubdirs)
+		return
+	}
+	toByPath := make(map[string]*fs.Dir, len(toSubdirs))
+	for _, subdir := range toSubdirs {
+		toByPath[subdir.Path] = subdir
+	}
+	for _, fromSubdir := range fromSubdirs {
+		toSubdir, ok := toByPath[fromSubdir.Path]
+		if !ok {
+			// Should only be possible if the filesystem was modified concurrently
+			log.Warnf("Skipping %s: directory changed during processing\n", fromSubdir)
+			skipCount++
+			continue
+		}
+		subPairs, subSkipCount := SchemaPairsForDirs(fromSubdir, toSubdir, maxDepth-1)
+		pairs = append(pairs, subPairs...)
+		skipCount += subSkipCount
+	}
+	return
+}
+
+func schemaPairsForDir(fromDir, toDir *fs.Dir) ([]*SchemaPair, error) {
+	fromInst, fromNames, err := instanceAndSchemaNames(fromDir)
+	if err != nil {
+		return nil, err
+	}
+	toInst, toNames, err := instanceAndSchemaNames(toDir)
+	if err != nil {
+		return nil, err
+	}
+	if fromInst == nil || toInst == nil {
+		return nil, nil
+	}
+	checkInstanceFlavor(fromInst, fromDir)
+	checkInstanceFlavor(toInst, toDir)
+
+	newPair := func(fromName, toName string) *SchemaPair {
+		return &SchemaPair{
+			FromInstance:   fromInst,
+			FromSchemaName: fromName,
+			ToInstance:     toInst,
+			ToSchemaName:   toName,
+			Dir:            toDir,
+		}
+	}
+	if len(fromNames) == 1 && len(toNames) == 1 {
+		return []*SchemaPair{newPair(fromNames[0], toNames[0])}, nil
+	}
+	seen := make(map[string]bool, len(fromNames)+len(toNames))
+	allNames := make([]string, 0, len(fromNames)+len(toNames))
+	for _, names := range [][]string{fromNames, toNames} {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				allNames = append(allNames, name)
+			}
+		}
+	}
+	sort.Strings(allNames)
+	pairs := make([]*SchemaPair, len(allNames))
+	for n, name := range allNames {
+		pairs[n] = newPair(name, name)
+	}
+	return pairs, nil
+}
+
+// SchemaPairForDSNs returns a SchemaPair for directly comparing the schemas
+// named in two DSNs, each of format "user:password@tcp(host:port)/schema", as
+// used by the Go MySQL driver. The supplied dir is not examined for hosts or
+// schema names; it is only used for its connect-options, as well as the
+// statement modifiers used in the comparison.
+func SchemaPairForDSNs(fromDSN, toDSN string, dir *fs.Dir) (*SchemaPair, error) {
+	fromInst, fromName, err := instanceAndSchemaForDSN(fromDSN, dir)
+	if err != nil {
+		return nil, err
+	}
+	toInst, toName, err := instanceAndSchemaForDSN(toDSN, dir)
+	if err != nil {
+		return nil, err
+	}
+	checkInstanceFlavor(fromInst, dir)
+	checkInstanceFlavor(toInst, dir)
+	return &SchemaPair{
+		FromInstance:   fromInst,
+		FromSchemaName: fromName,
+		ToInstance:     toInst,
+		ToSchemaName:   toName,
+		Dir:            dir,
+	}, nil
+}
+
+// instanceAndSchemaForDSN returns an instance and schema name for the supplied
+// DSN. The DSN must include a schema name, but not any connection parameters;
+// these are instead obtained from dir's connect-options. The instance is not
+// checked for connectivity.
+func instanceAndSchemaForDSN(dsn string, dir *fs.Dir) (*tengo.Instance, string, error) {
+	base, schemaName, err := splitSchemaDSN(dsn)
+	if err != nil {
+		return nil, "", err
+	}
+	params, err := dir.InstanceDefaultParams()
+	if err != nil {
+		return nil, "", ConfigError(fmt.Sprintf("Invalid connection options: %s", err))
+	}
+	inst, err := util.NewInstance("mysql", fmt.Sprintf("%s/?%s", base, params))
+	if err != nil {
+		return nil, "", fmt.Errorf("Invalid DSN %s: %s", redactDSN(base), err)
+	}
+	if ok, err := inst.CanConnect(); !ok {
+		return nil, "", fmt.Errorf("Unable to connect to %s: %s", inst, err)
+	}
+	return inst, schemaName, nil
+}
+
+// splitSchemaDSN splits a DSN of format "user:password@tcp(host:port)/schema"
+// into its base (everything before the final slash) and its schema name. An
+// error is returned if the schema name is missing, or if the DSN includes
+// connection parameters.
+func splitSchemaDSN(dsn string) (base, schemaName string, err error) {
+	slash := strings.LastIndex(dsn, "/")
+	if slash < 0 {
+		return "", "", fmt.Errorf("Invalid DSN %s: missing slash before schema name", redactDSN(dsn))
+	}
+	base, schemaName = dsn[:slash], dsn[slash+1:]
+	if strings.Contains(schemaName, "?") {
+		return "", "", fmt.Errorf("Invalid DSN %s: connection parameters are not supported in DSNs; use connect-options instead", redactDSN(base))
+	} else if schemaName == "" {
+		return "", "", fmt.Errorf("Invalid DSN %s: a schema name must be supplied after the final slash", redactDSN(base))
+	}
+	return base, schemaName, nil
+}
+
+// redactDSN returns dsn with any password replaced by asterisks, for use in
+// error messages.
+func redactDSN(dsn string) string {
+	at := strings.LastIndex(dsn, "@")
+	if at < 0 {
+		return dsn
+	}
+	if colon := strings.Index(dsn[:at], ":"); colon >= 0 {
+		return dsn[:colon] + ":*****" + dsn[at:]
+	}
+	return dsn
+}
+
+// instanceAndSchemaNames returns the first reachable instance for dir, along
+// with the schema names that the dir maps to on that instance. If the dir does
+// not define a host for its environment, a nil instance is returned, after
+// logging a warning.
+func instanceAndSchemaNames(dir *fs.Dir) (*tengo.Instance, []string, error) {
+	if !dir.Config.Changed("host") {
+		log.Warnf("Skipping %s: no host defined for environment \"%s\"\n", dir, dir.Config.Get("environment"))
+		return nil, nil, nil
+	}
+	inst, err := dir.FirstInstance()
+	if inst == nil || err != nil {
+		return nil, nil, err
+	}
+	names, err := dir.SchemaNames(inst)
+	return inst, names, err
+}
+
+// Statements introspects both schemas in the pair, and returns the DDL that
+// would transform the "from" schema into the "to" schema. A USE statement for
+// the "from" schema is included before the first statement that requires it,
+// after any CREATE DATABASE. Statement modifiers are obtained from the pair's
+// dir configuration, in the same manner as for `skeema diff`. Any diffs that
+// cannot be generated, either due to use of unsupported features or due to a
+// statement being forbidden with allow-unsafe=false, are logged and counted in
+// the returned Result, but do not prevent other objects' DDL from being
+// returned. Failure to introspect either schema results in an error.
+func (sp *SchemaPair) Statements() (stmts []string, result Result, err error) {
+	fromSchema, err := introspectSchema(sp.FromInstance, sp.FromSchemaName)
+	if err != nil {
+		return nil, result, err
+	}
+	toSchema, err := introspectSchema(sp.ToInstance, sp.ToSchemaName)
+	if err != nil {
+		return nil, result, err
+	}
+
+	mods, err := StatementModifiersForDir(sp.Dir)
+	if err != nil {
+		return nil, result, ConfigError(err.Error())
+	}
+	mods.Flavor = sp.FromInstance.Flavor()
+	if toSchema != nil {
+		// Operate on a copy, since Instance caches introspected schemas. The copy
+		// uses the "from" schema's name, so that any CREATE DATABASE refers to the
+		// schema that the output's USE statement refers to.
+		schemaCopy := *toSchema
+		schemaCopy.Name = sp.FromSchemaName
+		if mods.Partitioning == tengo.PartitioningRemove {
+			schemaCopy.Tables = make([]*tengo.Table, len(toSchema.Tables))
+			for n, table := range toSchema.Tables {
+				if table.Partitioning != nil {
+					tableCopy := *table
+					tableCopy.CreateStatement = table.UnpartitionedCreateStatement(mods.Flavor)
+					tableCopy.Partitioning = nil
+					table = &tableCopy
+				}
+				schemaCopy.Tables[n] = table
+			}
+		}
+		toSchema = &schemaCopy
+	}
+
+	var used bool
+	skipped := make(map[tengo.ObjectKey]bool)
+	diff := tengo.NewSchemaDiff(fromSchema, toSchema)
+	for _, objDiff := range diff.ObjectDiffs() {
+		key := objDiff.ObjectKey()
+		if skipped[key] {
+			// A modified routine is a DROP followed by a CREATE; if the DROP was
+			// skipped, the CREATE would fail, so it must be skipped as well
+			continue
+		}
+		stmt, err := objDiff.Statement(mods)
+		if stmt == "" && err == nil {
+			continue
+		}
+		result.Differences = true
+		if unsupportedErr, ok := err.(*tengo.UnsupportedDiffError); ok {
+			result.UnsupportedCount++
+			log.Warnf("Skipping %s in %s: unable to generate DDL due to use of unsupported features. Use --debug for more information.", unsupportedErr.ObjectKey, sp)
+			DebugLogUnsupportedDiff(unsupportedErr)
+		} else if err != nil {
+			result.SkipCount++
+			skipped[key] = true
+			log.Errorf("Skipping %s in %s: %s", key, sp, err)
+		} else {
+			if key.Type != tengo.ObjectTypeDatabase && !used {
+				stmts = append(stmts, fmt.Sprintf("USE %s", tengo.EscapeIdentifier(sp.FromSchemaName)))
+				used = true
+			}
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts, result, nil
+}
+
+// introspectSchema returns the named schema on inst, or nil if it does not
+// exist.
+func introspectSchema(inst *tengo.Instance, name string) (*tengo.Schema, error) {
+	schema, err := inst.Schema(name)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return schema, err
+}
@@ -0,0 +1,33 @@
+package applier
+
+import (
+	"testing"
+)
+
+func TestSplitSchemaDSN(t *testing.T) {
+	cases := []struct {
+		dsn        string
+		base       string
+		schemaName string
+		errText    string
+	}{
+		{"root:pw@tcp(db1:3306)/product", "root:pw@tcp(db1:3306)", "product", ""},
+		{"root:p/w@tcp(db1:3306)/product", "root:p/w@tcp(db1:3306)", "product", ""},
+		{"tcp(db1)/product", "tcp(db1)", "product", ""},
+		{"root:pw@tcp(db1:3306)/", "", "", "Invalid DSN root:*****@tcp(db1:3306): a schema name must be supplied after the final slash"},
+		{"root:pw@tcp(db1:3306)/product?timeout=1s", "", "", "Invalid DSN root:*****@tcp(db1:3306): connection parameters are not supported in DSNs; use connect-options instead"},
+		{"root:pw@tcp(db1:3306)", "", "", "Invalid DSN root:*****@tcp(db1:3306): missing slash before schema name"},
+	}
+	for _, c := range cases {
+		base, schemaName, err := splitSchemaDSN(c.dsn)
+		if c.errText != "" {
+			if err == nil || err.Error() != c.errText {
+				t.Errorf("splitSchemaDSN(%q): expected error %q, instead found %v", c.dsn, c.errText, err)
+			}
+		} else if err != nil {
+			t.Errorf("splitSchemaDSN(%q): unexpected error %v", c.dsn, err)
+		} else if base != c.base || schemaName != c.schemaName {
+			t.Errorf("splitSchemaDSN(%q): expected %q, %q; instead found %q, %q", c.dsn, c.base, c.schemaName, base, schemaName)
+		}
+	}
+}
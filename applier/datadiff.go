@@ -0,0 +1,148 @@
+package applier
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+// DataDiffStatements returns DML statements which transform the rows in from
+// into the rows in to, matching rows by primary key, using the collations of
+// to's primary key columns. DELETEs are returned
+// first, followed by UPDATEs and then INSERTs. from may be nil, indicating the
+// table does not exist yet, in which case only INSERTs are returned. The
+// primary key columns of to must also exist in from.
+func DataDiffStatements(from, to *fs.TableData) ([]string, error) {
+	fromRows := make(map[string][]sql.NullString)
+	fromCols := make([]int, len(to.Columns)) // position in from.Columns for each of to.Columns
+	if from != nil {
+		for n, col := range to.Columns {
+			fromCols[n] = from.ColumnIndex(col)
+		}
+		for _, pos := range to.PrimaryKey {
+			if fromCols[pos] < 0 {
+				return nil, fmt.Errorf("Unable to compare rows of table %s: primary key column %s does not exist in both versions", tengo.EscapeIdentifier(to.TableName), tengo.EscapeIdentifier(to.Columns[pos]))
+			}
+		}
+		keyed := &fs.TableData{
+			PrimaryKey: make([]int, len(to.PrimaryKey)),
+			Collations: make([]string, len(from.Columns)),
+		}
+		for n, pos := range to.PrimaryKey {
+			keyed.PrimaryKey[n] = fromCols[pos]
+			if pos < len(to.Collations) {
+				keyed.Collations[fromCols[pos]] = to.Collations[pos]
+			}
+		}
+		for _, row := range from.Rows {
+			fromRows[keyed.RowKey(row)] = row
+		}
+	}
+
+	table := tengo.EscapeIdentifier(to.TableName)
+	var deletes, updates, inserts []string
+	seen := make(map[string]bool, len(to.Rows))
+	for _, row := range to.Rows {
+		key := to.RowKey(row)
+		seen[key] = true
+		fromRow, exists := fromRows[key]
+		if !exists {
+			values := make([]string, len(row))
+			cols := make([]string, len(row))
+			for n, value := range row {
+				values[n] = to.Literal(n, value)
+				cols[n] = tengo.EscapeIdentifier(to.Columns[n])
+			}
+			inserts = append(inserts, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(cols, ", "), strings.Join(values, ", ")))
+			continue
+		}
+		var assignments []string
+		for n, value := range row {
+			if fromCols[n] < 0 || fromRow[fromCols[n]] != value {
+				assignments = append(assignments, fmt.Sprintf("%s = %s", tengo.EscapeIdentifier(to.Columns[n]), to.Literal(n, value)))
+			}
+		}
+		if len(assignments) > 0 {
+			updates = append(updates, fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(assignments, ", "), primaryKeyClause(to, row)))
+		}
+	}
+	if from != nil {
+		for _, row := range from.Rows {
+			// Re-key the row using to's column positions, so that the WHERE clause
+			// is consistent with other statements
+			toRow := make([]sql.NullString, len(to.Columns))
+			for n, pos := range fromCols {
+				if pos >= 0 {
+					toRow[n] = row[pos]
+				}
+			}
+			if !seen[to.RowKey(toRow)] {
+				deletes = append(deletes, fmt.Sprintf("DELETE FROM %s WHERE %s", table, primaryKeyClause(to, toRow)))
+			}
+		}
+	}
+
+	statements := make([]string, 0, len(deletes)+len(updates)+len(inserts))
+	statements = append(statements, deletes...)
+	statements = append(statements, updates...)
+	return append(statements, inserts...), nil
+}
+
+// primaryKeyClause returns a WHERE clause condition matching the supplied row
+// by its primary key.
+func primaryKeyClause(td *fs.TableData, row []sql.NullString) string {
+	conds := make([]string, len(td.PrimaryKey))
+	for n, pos := range td.PrimaryKey {
+		conds[n] = fmt.Sprintf("%s = %s", tengo.EscapeIdentifier(td.Columns[pos]), td.Literal(pos, row[pos]))
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// dataStatementsForTarget returns statements to apply row changes for each of
+// the target dir's data tables, comparing the rows from the workspace with the
+// rows in the live table. These statements should be executed after any DDL.
+// DELETEs are only permitted if allowUnsafe is true.
+func dataStatementsForTarget(t *Target, instSchema *tengo.Schema, allowUnsafe bool) ([]*DDLStatement, error) {
+	names := make([]string, 0, len(t.DesiredSchema.Data))
+	for name := range t.DesiredSchema.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result []*DDLStatement
+	for _, name := range names {
+		desired := t.DesiredSchema.Data[name]
+		var live *fs.TableData
+		if instSchema != nil && instSchema.Table(name) != nil {
+			db, err := t.Instance.Connect(t.SchemaName, "")
+			if err != nil {
+				return nil, err
+			}
+			if live, err = workspace.QueryTableData(db, instSchema.Table(name)); err != nil {
+				return nil, err
+			}
+		}
+		statements, err := DataDiffStatements(live, desired)
+		if err != nil {
+			return nil, err
+		}
+		for _, stmt := range statements {
+			if !allowUnsafe && strings.HasPrefix(stmt, "DELETE ") {
+				// Intentionally avoiding fmt.Errorf here to avoid golint complaining about capitalization
+				errorText := fmt.Sprintf("Destructive statement /* %s */ is considered unsafe. Use --allow-unsafe to permit this operation; see --help for more information.", stmt)
+				return nil, errors.New(errorText)
+			}
+			result = append(result, &DDLStatement{
+				stmt:       stmt,
+				instance:   t.Instance,
+				schemaName: t.SchemaName,
+			})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,90 @@
+package applier
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+)
+
+func TestDataDiffStatements(t *testing.T) {
+	str := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+	from := &fs.TableData{
+		TableName:  "colors",
+		Columns:    []string{"name", "id"},
+		Types:      []string{"varchar(20)", "int"},
+		PrimaryKey: []int{1},
+		Rows: [][]sql.NullString{
+			{str("red"), str("1")},
+			{str("green"), str("2")},
+			{str("blue"), str("3")},
+		},
+	}
+	to := &fs.TableData{
+		TableName:  "colors",
+		Columns:    []string{"id", "name", "hex"},
+		Types:      []string{"int", "varchar(20)", "char(6)"},
+		PrimaryKey: []int{0},
+		Rows: [][]sql.NullString{
+			{str("1"), str("red"), {}},
+			{str("3"), str("navy"), str("000080")},
+			{str("4"), str("teal"), {}},
+		},
+	}
+	expected := []string{
+		"DELETE FROM `colors` WHERE `id` = 2",
+		"UPDATE `colors` SET `hex` = NULL WHERE `id` = 1",
+		"UPDATE `colors` SET `name` = 'navy', `hex` = '000080' WHERE `id` = 3",
+		"INSERT INTO `colors` (`id`, `name`, `hex`) VALUES (4, 'teal', NULL)",
+	}
+	actual, err := DataDiffStatements(from, to)
+	if err != nil {
+		t.Fatalf("Unexpected error from DataDiffStatements: %s", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from DataDiffStatements:\n%v\nExpected:\n%v", actual, expected)
+	}
+
+	// Identical data should produce no statements, and nil from should produce
+	// only INSERTs
+	if actual, err := DataDiffStatements(to, to); err != nil || len(actual) != 0 {
+		t.Errorf("Expected no statements comparing identical data, instead found %v, %v", actual, err)
+	}
+	if actual, err := DataDiffStatements(nil, to); err != nil || len(actual) != 3 {
+		t.Errorf("Expected 3 INSERTs when table does not exist yet, instead found %v, %v", actual, err)
+	}
+
+	// Primary key values which differ only in case should be matched up under a
+	// case-insensitive collation, yielding an UPDATE instead of DELETE + INSERT
+	from = &fs.TableData{
+		TableName:  "codes",
+		Columns:    []string{"code", "label"},
+		Types:      []string{"varchar(10)", "varchar(20)"},
+		Collations: []string{"utf8mb4_general_ci", "utf8mb4_general_ci"},
+		PrimaryKey: []int{0},
+		Rows:       [][]sql.NullString{{str("abc"), str("old")}},
+	}
+	to = &fs.TableData{
+		TableName:  "codes",
+		Columns:    []string{"code", "label"},
+		Types:      []string{"varchar(10)", "varchar(20)"},
+		Collations: []string{"utf8mb4_general_ci", "utf8mb4_general_ci"},
+		PrimaryKey: []int{0},
+		Rows:       [][]sql.NullString{{str("ABC"), str("new")}},
+	}
+	expected = []string{"UPDATE `codes` SET `code` = 'ABC', `label` = 'new' WHERE `code` = 'ABC'"}
+	if actual, err := DataDiffStatements(from, to); err != nil || !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from DataDiffStatements with case-insensitive key:\n%v\nExpected:\n%v", actual, expected)
+	}
+	to.Collations[0] = "utf8mb4_bin"
+	if actual, err := DataDiffStatements(from, to); err != nil || len(actual) != 2 {
+		t.Errorf("Expected DELETE and INSERT with binary collation key, instead found %v, %v", actual, err)
+	}
+
+	// Primary key columns must exist on both sides
+	from.Columns[0] = "code_id"
+	if _, err := DataDiffStatements(from, to); err == nil {
+		t.Error("Expected error when primary key column missing from one side, but err was nil")
+	}
+}
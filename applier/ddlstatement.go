@@ -17,8 +17,9 @@ import (
 // It may represent an external command to shell out to, or a DDL statement to
 // run directly against a DB.
 type DDLStatement struct {
-	stmt     string
-	shellOut *util.ShellOut
+	stmt          string
+	printableStmt string // if non-empty, output instead of stmt, e.g. to omit credentials
+	shellOut      *util.ShellOut
 
 	instance      *tengo.Instance
 	schemaName    string
@@ -240,6 +241,8 @@ func (ddl *DDLStatement) IsShellOut() bool {
 func (ddl *DDLStatement) String() string {
 	if ddl.IsShellOut() {
 		return fmt.Sprintf("\\! %s\n", ddl.shellOut)
+	} else if ddl.printableStmt != "" {
+		return fs.AddDelimiter(ddl.printableStmt)
 	}
 	return fs.AddDelimiter(ddl.stmt)
 }
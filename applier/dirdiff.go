@@ -0,0 +1,243 @@
+package applier
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+// DirDiff represents the DDL needed to transform one version of a dir's
+// logical schema into another version, without involving any live database
+// instance.
+type DirDiff struct {
+	Path       string // path relative to the base of the dir trees being compared
+	SchemaName string // schema name, if it can be determined without a live instance
+	Statements []string
+}
+
+// DiffDirTrees compares two versions of the same dir tree, for example the
+// working tree vs a previous revision in version control, and returns a
+// DirDiff for each dir whose *.sql files would produce different DDL. Both
+// versions of each dir are executed in a workspace, which must not require a
+// live instance; for example, workspace=docker, workspace=offline, or
+// workspace=dedicated-host is permitted but workspace=temp-schema is not.
+// Statement modifiers are obtained from each dir's configuration in toBase.
+//
+// Errors are generally not fatal; the returned Result tracks counts of skipped
+// and unsupported operations instead, in the same manner as a push. However,
+// configuration problems result in a ConfigError.
+func DiffDirTrees(fromBase, toBase *fs.Dir, maxDepth int) (diffs []*DirDiff, result Result, err error) {
+	fromDirs, skipCount := dirsByRelPath(fromBase, maxDepth)
+	result.SkipCount += skipCount
+	toDirs, skipCount := dirsByRelPath(toBase, maxDepth)
+	result.SkipCount += skipCount
+
+	relPaths := make([]string, 0, len(fromDirs)+len(toDirs))
+	for relPath := range toDirs {
+		relPaths = append(relPaths, relPath)
+	}
+	for relPath := range fromDirs {
+		if _, ok := toDirs[relPath]; !ok {
+			relPaths = append(relPaths, relPath)
+		}
+	}
+	sort.Strings(relPaths)
+
+	for _, relPath := range relPaths {
+		fromDir, toDir := fromDirs[relPath], toDirs[relPath]
+		configDir := toDir
+		if configDir == nil {
+			configDir = fromDir
+		}
+		if wsType := configDir.Config.Get("workspace"); wsType == "temp-schema" {
+			return nil, result, ConfigError(fmt.Sprintf("Dir %s: comparing dirs without a live database requires workspace=docker, workspace=offline, or workspace=dedicated-host", configDir))
+		}
+		fromSchemas, err := execDirSchemas(fromDir)
+		if err != nil {
+			log.Errorf("Skipping %s: %s", relPath, err)
+			result.SkipCount++
+			continue
+		}
+		toSchemas, err := execDirSchemas(toDir)
+		if err != nil {
+			log.Errorf("Skipping %s: %s", relPath, err)
+			result.SkipCount++
+			continue
+		}
+		mods, err := StatementModifiersForDir(configDir)
+		if err != nil {
+			return nil, result, ConfigError(err.Error())
+		}
+		mods.Flavor = tengo.NewFlavor(configDir.Config.Get("flavor"))
+
+		// Each logical schema is compared separately. The dir's own schema has a
+		// blank logical schema name, and sorts first.
+		names := make([]string, 0, len(fromSchemas)+len(toSchemas))
+		for name := range toSchemas {
+			names = append(names, name)
+		}
+		for name := range fromSchemas {
+			if _, ok := toSchemas[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fromSchema, toSchema := fromSchemas[name], toSchemas[name]
+			if name == "" {
+				name = staticSchemaName(configDir)
+			}
+
+			// Both sides of the diff should refer to the same schema name, regardless
+			// of workspace naming, and a missing side is treated as an empty schema
+			dd := &DirDiff{Path: relPath, SchemaName: name}
+			fromSchema = renamedSchema(fromSchema, toSchema, dd.SchemaName)
+			toSchema = renamedSchema(toSchema, fromSchema, dd.SchemaName)
+			if mods.Partitioning == tengo.PartitioningRemove {
+				for _, table := range toSchema.Tables {
+					if table.Partitioning != nil {
+						table.CreateStatement = table.UnpartitionedCreateStatement(mods.Flavor)
+						table.Partitioning = nil
+					}
+				}
+			}
+			skipped := make(map[tengo.ObjectKey]bool)
+			diff := tengo.NewSchemaDiff(fromSchema, toSchema)
+			for _, objDiff := range diff.ObjectDiffs() {
+				if skipped[objDiff.ObjectKey()] {
+					// A modified routine is a DROP followed by a CREATE; if the DROP was
+					// skipped, the CREATE would fail, so it must be skipped as well
+					continue
+				}
+				stmt, err := objDiff.Statement(mods)
+				if unsupportedErr, ok := err.(*tengo.UnsupportedDiffError); ok {
+					result.UnsupportedCount++
+					log.Warnf("Skipping %s in %s: unable to generate DDL due to use of unsupported features. Use --debug for more information.", unsupportedErr.ObjectKey, relPath)
+					DebugLogUnsupportedDiff(unsupportedErr)
+				} else if err != nil {
+					result.SkipCount++
+					skipped[objDiff.ObjectKey()] = true
+					log.Errorf("%s: %s", relPath, err)
+				} else if stmt != "" {
+					dd.Statements = append(dd.Statements, stmt)
+				}
+			}
+			if len(dd.Statements) > 0 {
+				result.Differences = true
+				diffs = append(diffs, dd)
+			}
+		}
+	}
+	return diffs, result, nil
+}
+
+// dirsByRelPath returns a map of relative path to dir, for base and all of its
+// subdirs, including only dirs which define a schema.
+func dirsByRelPath(base *fs.Dir, maxDepth int) (result map[string]*fs.Dir, skipCount int) {
+	result = make(map[string]*fs.Dir)
+	var walk func(dir *fs.Dir, depth int)
+	walk = func(dir *fs.Dir, depth int) {
+		if dir.ParseError != nil {
+			log.Warnf("Skipping %s: %s\n", dir.Path, dir.ParseError)
+			skipCount++
+			return
+		}
+		if dir.HasSchema() {
+			relPath, err := filepath.Rel(base.Path, dir.Path)
+			if err != nil {
+				log.Warnf("Skipping %s: %s\n", dir.Path, err)
+				skipCount++
+				return
+			}
+			result[relPath] = dir
+		}
+		subdirs, err := dir.Subdirs()
+		if err != nil {
+			log.Warnf("Skipping subdirs of %s: %s\n", dir, err)
+			skipCount++
+			return
+		} else if len(subdirs) > 0 && depth < 1 {
+			log.Warnf("Skipping subdirs of %s: max depth reached\n", dir)
+			skipCount += len(subdirs)
+			return
+		}
+		for _, subdir := range subdirs {
+			walk(subdir, depth-1)
+		}
+	}
+	walk(base, maxDepth)
+	return result, skipCount
+}
+
+// execDirSchemas executes the dir's *.sql files in a workspace, returning the
+// resulting schema for each of the dir's logical schemas, keyed by logical
+// schema name. If dir is nil, a nil map is returned.
+func execDirSchemas(dir *fs.Dir) (map[string]*tengo.Schema, error) {
+	if dir == nil || len(dir.LogicalSchemas) == 0 {
+		return nil, nil
+	}
+	opts, err := workspace.OptionsForDir(dir, nil)
+	if err != nil {
+		return nil, ConfigError(err.Error())
+	}
+	schemas := make(map[string]*tengo.Schema, len(dir.LogicalSchemas))
+	var stmtErrCount int
+	for _, logicalSchema := range dir.LogicalSchemas {
+		wsSchema, err := workspace.ExecLogicalSchema(logicalSchema, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, stmtErr := range wsSchema.Failures {
+			log.Error(stmtErr.Error())
+		}
+		stmtErrCount += len(wsSchema.Failures)
+		schemas[logicalSchema.Name] = wsSchema.Schema
+	}
+	if stmtErrCount > 0 {
+		return nil, fmt.Errorf("%s executing *.sql files", util.CountAndNoun(stmtErrCount, "SQL error"))
+	}
+	return schemas, nil
+}
+
+// staticSchemaName returns the schema name that dir maps to, if this can be
+// determined from configuration alone. Otherwise, it returns an empty string.
+func staticSchemaName(dir *fs.Dir) string {
+	if len(dir.LogicalSchemas) > 0 && dir.LogicalSchemas[0].Name != "" {
+		return dir.LogicalSchemas[0].Name
+	}
+	rawValue := dir.Config.GetRaw("schema")
+	if strings.HasPrefix(rawValue, "`") {
+		return "" // shellout
+	}
+	names := dir.Config.GetSlice("schema", ',', true)
+	if len(names) != 1 || names[0] == "*" || strings.HasPrefix(names[0], "/") {
+		return ""
+	}
+	return names[0]
+}
+
+// renamedSchema returns a copy of schema with the supplied name. If schema is
+// nil, an empty schema with other's default character set and collation is
+// returned instead.
+func renamedSchema(schema, other *tengo.Schema, name string) *tengo.Schema {
+	var schemaCopy tengo.Schema
+	if schema != nil {
+		schemaCopy = *schema
+	} else {
+		schemaCopy.CharSet = other.CharSet
+		schemaCopy.Collation = other.Collation
+	}
+	if name != "" {
+		schemaCopy.Name = name
+	} else if schema == nil {
+		schemaCopy.Name = other.Name
+	}
+	return &schemaCopy
+}
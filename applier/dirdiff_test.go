@@ -0,0 +1,75 @@
+package applier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+)
+
+func TestDiffDirTrees(t *testing.T) {
+	fromBase := getDir(t, "testdata/dirdiff/from", "")
+	toBase := getDir(t, "testdata/dirdiff/to", "")
+	diffs, result, err := DiffDirTrees(fromBase, toBase, 5)
+	if err != nil {
+		t.Fatalf("Unexpected error from DiffDirTrees: %v", err)
+	}
+
+	// Dropping the analytics dir's table is unsafe, so it is skipped. Modifying
+	// the product dir's proc requires an unsafe DROP, so it is skipped entirely.
+	if result.SkipCount != 2 || result.UnsupportedCount != 0 || !result.Differences {
+		t.Errorf("Unexpected result from DiffDirTrees: %+v", result)
+	}
+	if len(diffs) != 1 {
+		t.Fatalf("Expected 1 DirDiff, instead found %d", len(diffs))
+	}
+	dd := diffs[0]
+	if dd.Path != "product" || dd.SchemaName != "product" {
+		t.Errorf("Unexpected path or schema name in DirDiff: %q, %q", dd.Path, dd.SchemaName)
+	}
+	if len(dd.Statements) != 2 {
+		t.Fatalf("Expected 2 statements, instead found %d: %v", len(dd.Statements), dd.Statements)
+	}
+	expectPrefixes := []string{"ALTER TABLE `users` ADD COLUMN `credits`", "CREATE TABLE `comments`"}
+	for n, prefix := range expectPrefixes {
+		if !strings.HasPrefix(dd.Statements[n], prefix) {
+			t.Errorf("Expected statement[%d] to begin with %q, instead found %q", n, prefix, dd.Statements[n])
+		}
+	}
+
+	// With allow-unsafe, the DROP is included as well
+	fromBase = getDir(t, "testdata/dirdiff/from", "--allow-unsafe")
+	toBase = getDir(t, "testdata/dirdiff/to", "--allow-unsafe")
+	diffs, result, err = DiffDirTrees(fromBase, toBase, 5)
+	if err != nil {
+		t.Fatalf("Unexpected error from DiffDirTrees: %v", err)
+	}
+	if result.SkipCount+result.UnsupportedCount != 0 || len(diffs) != 2 {
+		t.Fatalf("Unexpected result from DiffDirTrees: %+v, %d diffs", result, len(diffs))
+	}
+	if dd := diffs[0]; dd.Path != "analytics" || len(dd.Statements) != 1 || dd.Statements[0] != "DROP TABLE `pageviews`" {
+		t.Errorf("Unexpected DirDiff for analytics: %+v", *dd)
+	}
+	dd = diffs[1]
+	if len(dd.Statements) != 4 || dd.Statements[2] != "DROP PROCEDURE `bump_credits`" {
+		t.Fatalf("Unexpected DirDiff for product: %+v", *dd)
+	}
+	if create := fs.AddDelimiter(dd.Statements[3]); !strings.HasPrefix(create, "DELIMITER //\nCREATE ") || !strings.Contains(create, "SET credits = credits + 1") || !strings.HasSuffix(create, "END//\nDELIMITER ;\n") {
+		t.Errorf("Unexpected CREATE PROCEDURE output:\n%s", create)
+	}
+
+	// Comparing a tree to itself yields no differences
+	diffs, result, err = DiffDirTrees(toBase, toBase, 5)
+	if err != nil || len(diffs) != 0 || result.Differences || result.SkipCount+result.UnsupportedCount != 0 {
+		t.Errorf("Unexpected result from DiffDirTrees on identical trees: %d diffs, %+v, %v", len(diffs), result, err)
+	}
+
+	// workspace=temp-schema requires a live instance, so it is a config error
+	fromBase = getDir(t, "testdata/dirdiff/from", "--workspace=temp-schema")
+	toBase = getDir(t, "testdata/dirdiff/to", "--workspace=temp-schema")
+	if _, _, err := DiffDirTrees(fromBase, toBase, 5); err == nil {
+		t.Error("Expected error from DiffDirTrees with workspace=temp-schema, but err was nil")
+	} else if _, ok := err.(ConfigError); !ok {
+		t.Errorf("Expected error to be a ConfigError, instead found %T", err)
+	}
+}
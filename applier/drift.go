@@ -0,0 +1,187 @@
+package applier
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// DriftReport describes how the live schema for a single Target differs from
+// the desired schema expressed in the Target's dir.
+type DriftReport struct {
+	Instance    string        `json:"instance"`
+	Schema      string        `json:"schema"`
+	Dir         string        `json:"dir"`
+	Missing     []string      `json:"missing,omitempty"`   // objects in dir, but not in instance
+	Extra       []string      `json:"extra,omitempty"`     // objects in instance, but not in dir
+	Differing   []ObjectDrift `json:"differing,omitempty"` // objects in both, but with differences
+	Fingerprint string        `json:"fingerprint"`         // hash of live schema's object definitions
+}
+
+// ObjectDrift describes an object which exists in both an instance and a dir,
+// but with differences. Diff is a unified diff of the instance's CREATE
+// statement vs the dir's CREATE statement.
+type ObjectDrift struct {
+	Object string `json:"object"`
+	Diff   string `json:"diff"`
+}
+
+// HasDrift returns true if any differences were found.
+func (dr *DriftReport) HasDrift() bool {
+	return len(dr.Missing)+len(dr.Extra)+len(dr.Differing) > 0
+}
+
+// DriftForTarget introspects the target's schema on its instance, and returns
+// a report of all differences between it and the target's desired schema.
+// Differences which would not generate any DDL in `skeema diff`, given the
+// dir's configuration, are not included in the report.
+func DriftForTarget(t *Target) (*DriftReport, error) {
+	schemaFromInstance, err := t.SchemaFromInstance()
+	if err != nil {
+		return nil, err
+	}
+	schemaFromDir := t.SchemaFromDir()
+	report := &DriftReport{
+		Instance:    t.Instance.String(),
+		Schema:      t.SchemaName,
+		Dir:         t.Dir.RelPath(),
+		Fingerprint: SchemaFingerprint(schemaFromInstance),
+	}
+
+	mods, err := statementModifiersForTarget(t, schemaFromDir)
+	if err != nil {
+		return nil, err
+	}
+	// The generated statements are never executed, so destructive changes and
+	// auto-increment differences must not be filtered out
+	mods.AllowUnsafe = true
+	mods.NextAutoInc = tengo.NextAutoIncIgnore
+
+	// Determine which objects have differences that are relevant for the dir's
+	// configuration
+	drifted := make(map[tengo.ObjectKey]bool)
+	diff := tengo.NewSchemaDiff(schemaFromInstance, schemaFromDir)
+	for _, objDiff := range diff.ObjectDiffs() {
+		key := objDiff.ObjectKey()
+		if stmt, err := objDiff.Statement(mods); stmt != "" || err != nil {
+			drifted[key] = true
+		}
+	}
+
+	instDefs := schemaFromInstance.ObjectDefinitions() // nil-safe if schema doesn't exist on instance
+	dirDefs := schemaFromDir.ObjectDefinitions()
+	if schemaFromInstance != nil {
+		instDefs[tengo.ObjectKey{Type: tengo.ObjectTypeDatabase, Name: t.SchemaName}] = schemaFromInstance.CreateStatement()
+	}
+	dirDefs[tengo.ObjectKey{Type: tengo.ObjectTypeDatabase, Name: t.SchemaName}] = schemaFromDir.CreateStatement()
+	keys := make([]tengo.ObjectKey, 0, len(drifted))
+	for key := range drifted {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	for _, key := range keys {
+		instCreate, inInst := instDefs[key]
+		dirCreate, inDir := dirDefs[key]
+		if !inInst {
+			report.Missing = append(report.Missing, key.String())
+		} else if !inDir {
+			report.Extra = append(report.Extra, key.String())
+		} else {
+			fromName := fmt.Sprintf("%s %s", t.Instance, t.SchemaName)
+			toName := fmt.Sprintf("%s (filesystem)", t.Dir.RelPath())
+			if key.Type == tengo.ObjectTypeTable {
+				instCreate, _ = tengo.ParseCreateAutoInc(instCreate)
+				dirCreate, _ = tengo.ParseCreateAutoInc(dirCreate)
+			}
+			report.Differing = append(report.Differing, ObjectDrift{
+				Object: key.String(),
+				Diff:   util.UnifiedDiff(fromName, toName, instCreate, dirCreate, 3),
+			})
+		}
+	}
+	return report, nil
+}
+
+// SchemaFingerprint returns a hash of the default character set and collation
+// of schema, along with all of its object definitions, excluding next
+// auto-increment values. Two schemas with identical defaults and object
+// definitions will have the same fingerprint, regardless of schema name. A nil
+// schema has a fingerprint of an empty string.
+func SchemaFingerprint(schema *tengo.Schema) string {
+	if schema == nil {
+		return ""
+	}
+	defs := schema.ObjectDefinitions()
+	keys := make([]tengo.ObjectKey, 0, len(defs))
+	for key := range defs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	h := sha1.New()
+	fmt.Fprintf(h, "%s\n%s\n", schema.CharSet, schema.Collation)
+	for _, key := range keys {
+		create := defs[key]
+		if key.Type == tengo.ObjectTypeTable {
+			create, _ = tengo.ParseCreateAutoInc(create)
+		}
+		fmt.Fprintf(h, "%s\n%s\n", key, create)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Disagreement describes a set of instances which are all mapped to the same
+// dir and schema name, but whose live schemas are not all identical to one
+// another. Each element of Variants is a list of instances which share an
+// identical version of the schema.
+type Disagreement struct {
+	Dir      string     `json:"dir"`
+	Schema   string     `json:"schema"`
+	Variants [][]string `json:"variants"`
+}
+
+// FindDisagreements examines a set of DriftReports, and returns a
+// Disagreement for each dir and schema name whose live schemas vary between
+// instances.
+func FindDisagreements(reports []*DriftReport) []Disagreement {
+	type location struct{ dir, schema string }
+	variants := make(map[location]map[string][]string)
+	for _, dr := range reports {
+		loc := location{dir: dr.Dir, schema: dr.Schema}
+		if variants[loc] == nil {
+			variants[loc] = make(map[string][]string)
+		}
+		variants[loc][dr.Fingerprint] = append(variants[loc][dr.Fingerprint], dr.Instance)
+	}
+
+	var result []Disagreement
+	for loc, byFingerprint := range variants {
+		if len(byFingerprint) < 2 {
+			continue
+		}
+		d := Disagreement{Dir: loc.dir, Schema: loc.schema}
+		for _, instances := range byFingerprint {
+			sort.Strings(instances)
+			d.Variants = append(d.Variants, instances)
+		}
+		sort.Slice(d.Variants, func(i, j int) bool {
+			return strings.Join(d.Variants[i], ",") < strings.Join(d.Variants[j], ",")
+		})
+		result = append(result, d)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Dir == result[j].Dir {
+			return result[i].Schema < result[j].Schema
+		}
+		return result[i].Dir < result[j].Dir
+	})
+	return result
+}
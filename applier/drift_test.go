@@ -0,0 +1,70 @@
+package applier
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestSchemaFingerprint(t *testing.T) {
+	s1 := &tengo.Schema{
+		Name: "one",
+		Tables: []*tengo.Table{
+			{Name: "a", CreateStatement: "CREATE TABLE `a` (\n  `id` int NOT NULL\n) ENGINE=InnoDB AUTO_INCREMENT=12 DEFAULT CHARSET=latin1"},
+			{Name: "b", CreateStatement: "CREATE TABLE `b` (\n  `id` int NOT NULL\n) ENGINE=InnoDB"},
+		},
+	}
+	s2 := &tengo.Schema{
+		Name: "two",
+		Tables: []*tengo.Table{
+			{Name: "b", CreateStatement: "CREATE TABLE `b` (\n  `id` int NOT NULL\n) ENGINE=InnoDB"},
+			{Name: "a", CreateStatement: "CREATE TABLE `a` (\n  `id` int NOT NULL\n) ENGINE=InnoDB AUTO_INCREMENT=30 DEFAULT CHARSET=latin1"},
+		},
+	}
+	if SchemaFingerprint(s1) != SchemaFingerprint(s2) {
+		t.Error("Expected schemas differing only in name, table order, and auto-inc to have same fingerprint")
+	}
+	s2.Tables[0].CreateStatement = "CREATE TABLE `b` (\n  `id` bigint NOT NULL\n) ENGINE=InnoDB"
+	if SchemaFingerprint(s1) == SchemaFingerprint(s2) {
+		t.Error("Expected schemas with differing table definitions to have different fingerprints")
+	}
+	s2.Tables[0].CreateStatement = s1.Tables[1].CreateStatement
+	s2.CharSet, s2.Collation = "utf8mb4", "utf8mb4_general_ci"
+	if SchemaFingerprint(s1) == SchemaFingerprint(s2) {
+		t.Error("Expected schemas with differing default charset and collation to have different fingerprints")
+	}
+	if SchemaFingerprint(nil) != "" {
+		t.Error("Expected nil schema to have empty fingerprint")
+	}
+	if SchemaFingerprint(&tengo.Schema{}) == "" {
+		t.Error("Expected empty schema to have non-empty fingerprint")
+	}
+}
+
+func TestFindDisagreements(t *testing.T) {
+	reports := []*DriftReport{
+		{Instance: "db3:3306", Schema: "foo", Dir: "a", Fingerprint: "x"},
+		{Instance: "db1:3306", Schema: "foo", Dir: "a", Fingerprint: "x"},
+		{Instance: "db2:3306", Schema: "foo", Dir: "a", Fingerprint: "y"},
+		{Instance: "db1:3306", Schema: "bar", Dir: "a", Fingerprint: "x"},
+		{Instance: "db1:3306", Schema: "foo", Dir: "b", Fingerprint: "z"},
+		{Instance: "db2:3306", Schema: "foo", Dir: "b", Fingerprint: "z"},
+	}
+	expected := []Disagreement{
+		{
+			Dir:    "a",
+			Schema: "foo",
+			Variants: [][]string{
+				{"db1:3306", "db3:3306"},
+				{"db2:3306"},
+			},
+		},
+	}
+	if actual := FindDisagreements(reports); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from FindDisagreements: %+v", actual)
+	}
+	if actual := FindDisagreements(reports[3:]); len(actual) != 0 {
+		t.Errorf("Expected no disagreements, instead found %+v", actual)
+	}
+}
@@ -0,0 +1,856 @@
+package applier
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// Account represents a user or role, along with its privileges and granted
+// roles. Accounts are either declared in a grants file, or introspected from a
+// database instance.
+type Account struct {
+	User       string
+	Host       string
+	IsRole     bool
+	Privileges map[string]map[string]bool // object name -> set of privileges on that object
+	Roles      map[string]bool            // set of granted roles, in same form as Account.String()
+}
+
+func newAccount(user, host string, isRole bool) *Account {
+	return &Account{
+		User:       user,
+		Host:       host,
+		IsRole:     isRole,
+		Privileges: make(map[string]map[string]bool),
+		Roles:      make(map[string]bool),
+	}
+}
+
+// String returns the account name in the form `user`@`host`.
+func (acct *Account) String() string {
+	return accountString(acct.User, acct.Host)
+}
+
+func (acct *Account) addPrivilege(object, priv string) {
+	if acct.Privileges[object] == nil {
+		acct.Privileges[object] = make(map[string]bool)
+	}
+	acct.Privileges[object][priv] = true
+}
+
+func accountString(user, host string) string {
+	return tengo.EscapeIdentifier(user) + "@" + tengo.EscapeIdentifier(host)
+}
+
+// ParseGrantsFile parses the grants file at filePath, returning the declared
+// accounts keyed by their String() value. The file may contain CREATE USER,
+// CREATE ROLE, and GRANT statements. Every grantee of a GRANT must be declared
+// by a CREATE in the same file. CREATE USER statements may not include any
+// IDENTIFIED BY or other account options, since credentials should not be
+// stored in the filesystem; see the user-password-command option instead.
+func ParseGrantsFile(filePath string) (map[string]*Account, error) {
+	sf := fs.SQLFile{
+		Dir:      filepath.Dir(filePath),
+		FileName: filepath.Base(filePath),
+	}
+	tokenizedFile, err := sf.Tokenize()
+	if err != nil {
+		return nil, err
+	}
+
+	// Process all CREATEs before any GRANTs, so that the order of statements in
+	// the file does not matter
+	accounts := make(map[string]*Account)
+	grants := make(map[*fs.Statement]*grantStatement)
+	for _, stmt := range tokenizedFile.Statements {
+		if stmt.Type == fs.StatementTypeNoop {
+			continue
+		}
+		tokens, err := grantTokens(strings.TrimSpace(stmt.Body()))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", stmt.Location(), err)
+		}
+		if len(tokens) > 1 && strings.EqualFold(tokens[0], "CREATE") {
+			created, err := parseCreateAccount(tokens)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", stmt.Location(), err)
+			}
+			for _, acct := range created {
+				if _, already := accounts[acct.String()]; already {
+					return nil, fmt.Errorf("%s: account %s declared multiple times", stmt.Location(), acct)
+				}
+				accounts[acct.String()] = acct
+			}
+		} else if len(tokens) > 1 && strings.EqualFold(tokens[0], "GRANT") {
+			grant, err := parseGrant(tokens)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", stmt.Location(), err)
+			} else if grant == nil {
+				return nil, fmt.Errorf("%s: PROXY privileges are not supported", stmt.Location())
+			} else if len(grant.extra) > 0 {
+				return nil, fmt.Errorf("%s: unsupported clause %s", stmt.Location(), strings.Join(grant.extra, " "))
+			}
+			grants[stmt] = grant
+		} else {
+			return nil, fmt.Errorf("%s: only CREATE USER, CREATE ROLE, and GRANT statements are permitted in a grants file", stmt.Location())
+		}
+	}
+
+	for _, stmt := range tokenizedFile.Statements {
+		grant, ok := grants[stmt]
+		if !ok {
+			continue
+		}
+		for _, role := range grant.roles {
+			if acct := accounts[role]; acct == nil || !acct.IsRole {
+				return nil, fmt.Errorf("%s: role %s is not declared by a CREATE ROLE statement", stmt.Location(), role)
+			}
+		}
+		for _, grantee := range grant.grantees {
+			acct := accounts[grantee]
+			if acct == nil {
+				return nil, fmt.Errorf("%s: grantee %s is not declared by a CREATE USER or CREATE ROLE statement", stmt.Location(), grantee)
+			}
+			grant.applyTo(acct)
+		}
+	}
+	for _, acct := range accounts {
+		acct.simplify()
+	}
+	return accounts, nil
+}
+
+// AccountsFromInstance introspects the supplied accounts on instance, using
+// SHOW GRANTS. The result only includes accounts which already exist on the
+// instance, keyed by their String() value. IsRole is copied from the
+// corresponding supplied account.
+func AccountsFromInstance(instance *tengo.Instance, accounts map[string]*Account) (map[string]*Account, error) {
+	db, err := instance.Connect("", "")
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		User string `db:"User"`
+		Host string `db:"Host"`
+	}
+	if err := db.Select(&rows, "SELECT User, Host FROM mysql.user"); err != nil {
+		return nil, fmt.Errorf("Unable to query mysql.user: %s", err)
+	}
+	result := make(map[string]*Account)
+	for _, row := range rows {
+		desired, ok := accounts[accountString(row.User, row.Host)]
+		if !ok {
+			continue
+		}
+		acct := newAccount(row.User, row.Host, desired.IsRole)
+		var lines []string
+		if err := db.Select(&lines, "SHOW GRANTS FOR "+acct.String()); err != nil {
+			return nil, fmt.Errorf("Unable to query grants for %s: %s", acct, err)
+		}
+		if err := acct.applyShowGrants(lines, instance.Flavor()); err != nil {
+			return nil, err
+		}
+		result[acct.String()] = acct
+	}
+	return result, nil
+}
+
+// applyShowGrants adds the privileges and roles from the supplied SHOW GRANTS
+// output lines to acct. In MySQL 8, SHOW GRANTS expands a global ALL PRIVILEGES
+// into the full list of individual static and dynamic privileges, so this list
+// is collapsed back into ALL PRIVILEGES to match a grants file.
+func (acct *Account) applyShowGrants(lines []string, flavor tengo.Flavor) error {
+	for _, line := range lines {
+		tokens, err := grantTokens(line)
+		if err != nil || len(tokens) < 2 || !strings.EqualFold(tokens[0], "GRANT") {
+			// Ignore any partial revokes, or anything else unexpected
+			continue
+		}
+		grant, err := parseGrant(tokens)
+		if err != nil {
+			return fmt.Errorf("Unable to parse grants for %s: %s", acct, err)
+		} else if grant != nil {
+			grant.applyTo(acct)
+		}
+	}
+	if flavor.MySQLishMinVersion(8, 0) {
+		acct.collapseGlobalPrivileges()
+	}
+	acct.simplify()
+	return nil
+}
+
+// mysql80StaticGlobalPrivileges lists the static privileges that MySQL 8 shows
+// in place of ALL PRIVILEGES ON *.*.
+var mysql80StaticGlobalPrivileges = []string{
+	"SELECT", "INSERT", "UPDATE", "DELETE", "CREATE", "DROP", "RELOAD",
+	"SHUTDOWN", "PROCESS", "FILE", "REFERENCES", "INDEX", "ALTER",
+	"SHOW DATABASES", "SUPER", "CREATE TEMPORARY TABLES", "LOCK TABLES",
+	"EXECUTE", "REPLICATION SLAVE", "REPLICATION CLIENT", "CREATE VIEW",
+	"SHOW VIEW", "CREATE ROUTINE", "ALTER ROUTINE", "CREATE USER", "EVENT",
+	"TRIGGER", "CREATE TABLESPACE", "CREATE ROLE", "DROP ROLE",
+}
+
+// collapseGlobalPrivileges replaces a complete set of MySQL 8 static global
+// privileges with ALL PRIVILEGES. Dynamic privileges, such as BACKUP_ADMIN, are
+// then subsumed by ALL PRIVILEGES as well, and are removed by simplify. This is
+// only applicable to MySQL 8.0 and Percona Server 8.0; older versions report
+// ALL PRIVILEGES as-is, and MariaDB does not support grants-file at all.
+func (acct *Account) collapseGlobalPrivileges() {
+	privs := acct.Privileges["*.*"]
+	for _, priv := range mysql80StaticGlobalPrivileges {
+		if !privs[priv] {
+			return
+		}
+	}
+	for _, priv := range mysql80StaticGlobalPrivileges {
+		delete(privs, priv)
+	}
+	privs["ALL PRIVILEGES"] = true
+}
+
+// simplify removes any privileges which are redundant with ALL PRIVILEGES on
+// the same object, other than GRANT OPTION, which ALL PRIVILEGES does not
+// include. This way, a grants file may list privileges in addition to ALL
+// PRIVILEGES without causing differences from what the server reports.
+func (acct *Account) simplify() {
+	for _, privs := range acct.Privileges {
+		if !privs["ALL PRIVILEGES"] {
+			continue
+		}
+		for priv := range privs {
+			if priv != "ALL PRIVILEGES" && priv != "GRANT OPTION" {
+				delete(privs, priv)
+			}
+		}
+	}
+}
+
+// AccountDiffStatements returns statements which transform the existing
+// accounts into the desired ones. Accounts which exist but are not desired are
+// left untouched, as are the credentials of existing accounts. New roles are
+// created before new users; both are created before any GRANT or REVOKE. The
+// returned CREATE USER statements lack an IDENTIFIED BY clause, which must be
+// supplied by the caller. REVOKEs are returned before GRANTs for each account.
+func AccountDiffStatements(existing, desired map[string]*Account) []string {
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if desired[names[i]].IsRole != desired[names[j]].IsRole {
+			return desired[names[i]].IsRole
+		}
+		return names[i] < names[j]
+	})
+
+	var creates, changes []string
+	for _, name := range names {
+		to := desired[name]
+		from := existing[name]
+		if from == nil {
+			if to.IsRole {
+				creates = append(creates, "CREATE ROLE "+name)
+			} else {
+				creates = append(creates, "CREATE USER "+name)
+			}
+			from = newAccount(to.User, to.Host, to.IsRole)
+		}
+		changes = append(changes, privilegeStatements(from, to)...)
+		changes = append(changes, roleStatements(from, to)...)
+	}
+	return append(creates, changes...)
+}
+
+// privilegeStatements returns REVOKE and GRANT statements to transform the
+// privileges of from into those of to, grouped by object.
+func privilegeStatements(from, to *Account) (statements []string) {
+	objects := make(map[string]bool)
+	for object := range from.Privileges {
+		objects[object] = true
+	}
+	for object := range to.Privileges {
+		objects[object] = true
+	}
+	for _, object := range sortedKeys(objects) {
+		var revokes, grants []string
+		var grantOption bool
+		for _, priv := range sortedKeys(from.Privileges[object]) {
+			if !to.Privileges[object][priv] {
+				revokes = append(revokes, priv)
+			}
+		}
+		for _, priv := range sortedKeys(to.Privileges[object]) {
+			if from.Privileges[object][priv] {
+				continue
+			}
+			if priv == "GRANT OPTION" {
+				grantOption = true
+			} else {
+				grants = append(grants, priv)
+			}
+		}
+		if len(revokes) > 0 {
+			statements = append(statements, fmt.Sprintf("REVOKE %s ON %s FROM %s", strings.Join(revokes, ", "), object, from))
+		}
+		if len(grants) > 0 || grantOption {
+			if len(grants) == 0 {
+				grants = []string{"USAGE"}
+			}
+			stmt := fmt.Sprintf("GRANT %s ON %s TO %s", strings.Join(grants, ", "), object, to)
+			if grantOption {
+				stmt += " WITH GRANT OPTION"
+			}
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
+
+// roleStatements returns REVOKE and GRANT statements to transform the granted
+// roles of from into those of to.
+func roleStatements(from, to *Account) (statements []string) {
+	var revokes, grants []string
+	for _, role := range sortedKeys(from.Roles) {
+		if !to.Roles[role] {
+			revokes = append(revokes, role)
+		}
+	}
+	for _, role := range sortedKeys(to.Roles) {
+		if !from.Roles[role] {
+			grants = append(grants, role)
+		}
+	}
+	if len(revokes) > 0 {
+		statements = append(statements, fmt.Sprintf("REVOKE %s FROM %s", strings.Join(revokes, ", "), from))
+	}
+	if len(grants) > 0 {
+		statements = append(statements, fmt.Sprintf("GRANT %s TO %s", strings.Join(grants, ", "), to))
+	}
+	return statements
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// grantStatement represents a parsed GRANT statement, either of privileges or
+// of roles.
+type grantStatement struct {
+	privileges  []string // normalized, e.g. "SELECT" or "SELECT (`col`)"
+	object      string   // normalized, e.g. "*.*", "`db`.*", "`db`.`tbl`", or "PROCEDURE `db`.`proc`"
+	roles       []string // for role grants, in same form as Account.String()
+	grantees    []string // in same form as Account.String()
+	grantOption bool
+	extra       []string // any unparsed trailing tokens
+}
+
+func (grant *grantStatement) applyTo(acct *Account) {
+	for _, priv := range grant.privileges {
+		acct.addPrivilege(grant.object, priv)
+	}
+	if grant.grantOption {
+		acct.addPrivilege(grant.object, "GRANT OPTION")
+	}
+	for _, role := range grant.roles {
+		acct.Roles[role] = true
+	}
+}
+
+// parseGrant parses a tokenized GRANT statement. If the statement grants PROXY
+// privileges, nil is returned for both the grantStatement and error.
+func parseGrant(tokens []string) (*grantStatement, error) {
+	grant := &grantStatement{}
+	var on, to int
+	for n, token := range tokens {
+		if on == 0 && strings.EqualFold(token, "ON") {
+			on = n
+		} else if strings.EqualFold(token, "TO") {
+			to = n
+			break
+		}
+	}
+	if to == 0 || to == len(tokens)-1 {
+		return nil, errors.New("unable to parse GRANT statement")
+	}
+
+	if on > 0 {
+		// Privilege grant: GRANT priv [(cols)], ... ON [type] level TO grantees
+		for _, group := range splitTokenList(tokens[1:on]) {
+			privs, err := normalizePrivilege(group)
+			if err != nil {
+				return nil, err
+			} else if len(privs) == 1 && privs[0] == "PROXY" {
+				return nil, nil
+			}
+			grant.privileges = append(grant.privileges, privs...)
+		}
+		var err error
+		if grant.object, err = normalizeObject(tokens[on+1 : to]); err != nil {
+			return nil, err
+		}
+	} else {
+		// Role grant: GRANT role, ... TO grantees
+		for _, group := range splitTokenList(tokens[1:to]) {
+			if len(group) != 1 {
+				return nil, errors.New("unable to parse role list in GRANT statement")
+			}
+			user, host, err := parseAccountName(group[0])
+			if err != nil {
+				return nil, err
+			}
+			grant.roles = append(grant.roles, accountString(user, host))
+		}
+	}
+
+	n := to + 1
+	for n < len(tokens) {
+		user, host, err := parseAccountName(tokens[n])
+		if err != nil {
+			return nil, err
+		}
+		grant.grantees = append(grant.grantees, accountString(user, host))
+		if n+1 < len(tokens) && tokens[n+1] == "," {
+			n += 2
+		} else {
+			n++
+			break
+		}
+	}
+	rest := tokens[n:]
+	if len(rest) >= 3 && strings.EqualFold(strings.Join(rest[0:3], " "), "WITH GRANT OPTION") && on > 0 {
+		grant.grantOption = true
+		rest = rest[3:]
+	} else if len(rest) >= 3 && strings.EqualFold(strings.Join(rest[0:3], " "), "WITH ADMIN OPTION") && on == 0 {
+		// WITH ADMIN OPTION is not managed; it is tolerated here so that
+		// introspection of such grants does not fail
+		rest = rest[3:]
+	}
+	if len(rest) > 0 {
+		grant.extra = rest
+	}
+	return grant, nil
+}
+
+// parseCreateAccount parses a tokenized CREATE USER or CREATE ROLE statement,
+// returning the declared accounts. An error is returned if the statement
+// includes any account options, such as IDENTIFIED BY.
+func parseCreateAccount(tokens []string) ([]*Account, error) {
+	kind := strings.ToUpper(tokens[1])
+	var isRole bool
+	if kind == "ROLE" {
+		isRole = true
+	} else if kind != "USER" {
+		return nil, errors.New("only CREATE USER, CREATE ROLE, and GRANT statements are permitted in a grants file")
+	}
+	tokens = tokens[2:]
+	if len(tokens) >= 3 && strings.EqualFold(strings.Join(tokens[0:3], " "), "IF NOT EXISTS") {
+		tokens = tokens[3:]
+	}
+	groups := splitTokenList(tokens)
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("unable to parse CREATE %s statement", kind)
+	}
+	accounts := make([]*Account, 0, len(groups))
+	for _, group := range groups {
+		if len(group) > 1 {
+			return nil, errors.New("account options such as IDENTIFIED BY are not permitted in a grants file; use the user-password-command option to supply passwords")
+		}
+		user, host, err := parseAccountName(group[0])
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, newAccount(user, host, isRole))
+	}
+	return accounts, nil
+}
+
+// parseAccountName parses an account name such as 'user'@'host', `user`@`host`,
+// user@host, or user. If the host is omitted, it defaults to %.
+func parseAccountName(token string) (user, host string, err error) {
+	parts := splitQuoted(token, '@')
+	if len(parts) > 2 || parts[0] == "" {
+		return "", "", fmt.Errorf("unable to parse account name %s", token)
+	}
+	user = unquote(parts[0])
+	host = "%"
+	if len(parts) == 2 {
+		host = unquote(parts[1])
+	}
+	return user, host, nil
+}
+
+// normalizePrivilege converts a single privilege, expressed as a group of
+// tokens, into normalized form. Column-level privileges are expanded to one
+// entry per column. ALL is converted to ALL PRIVILEGES, and USAGE is dropped
+// entirely since it means "no privileges".
+func normalizePrivilege(group []string) ([]string, error) {
+	var words []string
+	var columns string
+	for n, token := range group {
+		if token[0] == '(' {
+			if n != len(group)-1 {
+				return nil, fmt.Errorf("unable to parse privilege %s", strings.Join(group, " "))
+			}
+			columns = token[1 : len(token)-1]
+		} else {
+			words = append(words, strings.ToUpper(token))
+		}
+	}
+	if len(words) == 0 {
+		return nil, errors.New("unable to parse privilege list in GRANT statement")
+	}
+	priv := strings.Join(words, " ")
+	if priv == "USAGE" {
+		return nil, nil
+	} else if priv == "ALL" {
+		priv = "ALL PRIVILEGES"
+	}
+	if columns == "" {
+		return []string{priv}, nil
+	}
+	var privs []string
+	for _, col := range splitQuoted(columns, ',') {
+		col = unquote(strings.TrimSpace(col))
+		privs = append(privs, fmt.Sprintf("%s (%s)", priv, tengo.EscapeIdentifier(col)))
+	}
+	return privs, nil
+}
+
+// normalizeObject converts the tokens between ON and TO in a GRANT statement
+// into normalized form. Unqualified names are not permitted, since grants files
+// are not associated with any particular schema.
+func normalizeObject(tokens []string) (string, error) {
+	var prefix string
+	if len(tokens) == 2 {
+		switch objType := strings.ToUpper(tokens[0]); objType {
+		case "TABLE":
+		case "FUNCTION", "PROCEDURE":
+			prefix = objType + " "
+		default:
+			return "", fmt.Errorf("unsupported object type %s", tokens[0])
+		}
+		tokens = tokens[1:]
+	}
+	if len(tokens) != 1 {
+		return "", errors.New("unable to parse object name in GRANT statement")
+	}
+	parts := splitQuoted(tokens[0], '.')
+	if len(parts) != 2 {
+		return "", fmt.Errorf("object name %s must be qualified with a schema name, or use *.*", tokens[0])
+	}
+	if parts[0] == "*" {
+		if parts[1] != "*" || prefix != "" {
+			return "", fmt.Errorf("unable to parse object name %s", tokens[0])
+		}
+		return "*.*", nil
+	}
+	object := prefix + tengo.EscapeIdentifier(unquote(parts[0])) + "."
+	if parts[1] == "*" {
+		return object + "*", nil
+	}
+	return object + tengo.EscapeIdentifier(unquote(parts[1])), nil
+}
+
+// grantTokens splits a CREATE USER, CREATE ROLE, or GRANT statement into
+// tokens. Quoted sections are kept intact as part of their surrounding token,
+// so that an account name like 'app'@'%' or an object name like `db`.`tbl`
+// remains a single token. Commas and parenthesized groups are returned as
+// separate tokens.
+func grantTokens(input string) ([]string, error) {
+	var tokens []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+	for n := 0; n < len(input); n++ {
+		c := input[n]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := closingQuote(input, n)
+			if end < 0 {
+				return nil, errors.New("unterminated quoted string")
+			}
+			cur.WriteString(input[n : end+1])
+			n = end
+		case c == '(':
+			flush()
+			end := n + 1
+			for ; end < len(input) && input[end] != ')'; end++ {
+				if input[end] == '\'' || input[end] == '"' || input[end] == '`' {
+					if end = closingQuote(input, end); end < 0 {
+						return nil, errors.New("unterminated quoted string")
+					}
+				}
+			}
+			if end >= len(input) {
+				return nil, errors.New("unterminated parenthesized list")
+			}
+			tokens = append(tokens, input[n:end+1])
+			n = end
+		case c == ',':
+			flush()
+			tokens = append(tokens, ",")
+		case unicode.IsSpace(rune(c)):
+			flush()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	flush()
+	return tokens, nil
+}
+
+// closingQuote returns the position of the quote character which terminates
+// the quoted string beginning at input[start], or -1 if the string is
+// unterminated. Doubled quote characters are treated as escaped, as are
+// backslash-escaped characters in non-identifier strings.
+func closingQuote(input string, start int) int {
+	quote := input[start]
+	for n := start + 1; n < len(input); n++ {
+		if input[n] == '\\' && quote != '`' {
+			n++
+		} else if input[n] == quote {
+			if n+1 < len(input) && input[n+1] == quote {
+				n++
+			} else {
+				return n
+			}
+		}
+	}
+	return -1
+}
+
+// splitQuoted splits input on each occurrence of sep which is not inside of a
+// quoted string.
+func splitQuoted(input string, sep byte) []string {
+	var parts []string
+	var start int
+	for n := 0; n < len(input); n++ {
+		if c := input[n]; c == '\'' || c == '"' || c == '`' {
+			if end := closingQuote(input, n); end > 0 {
+				n = end
+			}
+		} else if c == sep {
+			parts = append(parts, input[start:n])
+			start = n + 1
+		}
+	}
+	return append(parts, input[start:])
+}
+
+// unquote removes surrounding quotes from input, if present, and unescapes any
+// doubled or backslash-escaped quote characters.
+func unquote(input string) string {
+	if len(input) < 2 {
+		return input
+	}
+	quote := input[0]
+	if (quote != '\'' && quote != '"' && quote != '`') || input[len(input)-1] != quote {
+		return input
+	}
+	input = input[1 : len(input)-1]
+	input = strings.Replace(input, string([]byte{quote, quote}), string(quote), -1)
+	if quote != '`' {
+		input = strings.Replace(input, "\\"+string(quote), string(quote), -1)
+	}
+	return input
+}
+
+// splitTokenList splits a comma-separated list of tokens into groups.
+func splitTokenList(tokens []string) (groups [][]string) {
+	var cur []string
+	for _, token := range tokens {
+		if token == "," {
+			if len(cur) > 0 {
+				groups = append(groups, cur)
+			}
+			cur = nil
+		} else {
+			cur = append(cur, token)
+		}
+	}
+	if len(cur) > 0 {
+		groups = append(groups, cur)
+	}
+	return groups
+}
+
+// GrantTarget represents a database instance whose users, roles, and
+// privileges are managed by the grants file of Dir.
+type GrantTarget struct {
+	Instance *tengo.Instance
+	Dir      *fs.Dir
+}
+
+// CheckFlavor returns a ConfigError if gt.Instance has a flavor which does not
+// support managing accounts with a grants file. MariaDB is not supported, since
+// it stores roles without any host, and represents them differently than MySQL
+// 8 in SHOW GRANTS output.
+func (gt *GrantTarget) CheckFlavor() error {
+	if flavor := gt.Instance.Flavor(); flavor.Vendor == tengo.VendorMariaDB {
+		return ConfigError(fmt.Sprintf("Dir %s: grants-file is not supported on %s %s, since MariaDB roles are incompatible with Skeema's account handling. Only MySQL and Percona Server are supported.", gt.Dir, gt.Instance, flavor))
+	}
+	return nil
+}
+
+// GrantTargetsForDir examines dir and its subdirs, returning a GrantTarget for
+// each instance of each dir which configures the grants-file option. A count
+// of skipped dirs or instances is also returned. Dirs which could not be
+// parsed are not included in the count, since TargetsForDir already accounts
+// for them.
+func GrantTargetsForDir(dir *fs.Dir, maxDepth int) (targets []*GrantTarget, skipCount int) {
+	if dir.ParseError != nil {
+		return nil, 0
+	}
+	if dir.GrantsFile() != "" {
+		if dir.Config.Changed("host") {
+			var instances []*tengo.Instance
+			instances, skipCount = instancesForDir(dir)
+			for _, inst := range instances {
+				targets = append(targets, &GrantTarget{Instance: inst, Dir: dir})
+			}
+		} else {
+			log.Warnf("Skipping grants file for %s: no host defined for environment \"%s\"\n", dir, dir.Config.Get("environment"))
+		}
+	}
+
+	subdirs, err := dir.Subdirs()
+	if err != nil || maxDepth < 1 {
+		return
+	}
+	for _, subdir := range subdirs {
+		subTargets, subSkipCount := GrantTargetsForDir(subdir, maxDepth-1)
+		targets = append(targets, subTargets...)
+		skipCount += subSkipCount
+	}
+	return
+}
+
+// ApplyGrants compares the accounts declared in the grants file of gt.Dir to
+// those on gt.Instance, and then outputs and (if not dry-run) executes the
+// statements necessary to reconcile them. REVOKEs are only permitted with
+// allow-unsafe. Passwords for new users are obtained from the
+// user-password-command option or environment, and are never output.
+func ApplyGrants(gt *GrantTarget, printer *Printer) (result Result) {
+	t := &Target{Instance: gt.Instance, Dir: gt.Dir}
+	if t.dryRun() {
+		log.Infof("Generating diff of %s grants vs %s", gt.Instance, gt.Dir.GrantsFile())
+	} else {
+		log.Infof("Pushing grants from %s to %s", gt.Dir.GrantsFile(), gt.Instance)
+	}
+
+	desired, err := ParseGrantsFile(gt.Dir.GrantsFile())
+	if err != nil {
+		log.Errorf("Skipping grants for %s: %s", gt.Instance, err)
+		result.SkipCount++
+		return
+	}
+	existing, err := AccountsFromInstance(gt.Instance, desired)
+	if err != nil {
+		log.Errorf("Skipping grants for %s: %s", gt.Instance, err)
+		result.SkipCount++
+		return
+	}
+
+	statements := AccountDiffStatements(existing, desired)
+	allowUnsafe := gt.Dir.Config.GetBool("allow-unsafe")
+	ddls := make([]*DDLStatement, 0, len(statements))
+	for _, stmt := range statements {
+		if !allowUnsafe && strings.HasPrefix(stmt, "REVOKE ") {
+			log.Errorf("Skipping grants for %s: Destructive statement /* %s */ is considered unsafe. Use --allow-unsafe to permit this operation; see --help for more information.", gt.Instance, stmt)
+			result.Differences = true
+			result.SkipCount++
+			return
+		}
+		ddl := &DDLStatement{
+			stmt:     stmt,
+			instance: gt.Instance,
+		}
+		if strings.HasPrefix(stmt, "CREATE USER ") {
+			ddl.printableStmt = stmt + " IDENTIFIED BY '***'"
+			if !t.dryRun() {
+				acct := desired[strings.TrimPrefix(stmt, "CREATE USER ")]
+				password, err := accountPassword(acct, gt.Dir.Config)
+				if err != nil {
+					log.Errorf("Skipping grants for %s: %s", gt.Instance, err)
+					result.Differences = true
+					result.SkipCount++
+					return
+				}
+				ddl.stmt = fmt.Sprintf("%s IDENTIFIED BY '%s'", stmt, tengo.EscapeValueForCreateTable(password))
+			}
+		}
+		ddls = append(ddls, ddl)
+	}
+
+	if len(ddls) == 0 {
+		log.Infof("%s grants: No differences found\n", gt.Instance)
+		return
+	}
+	result.Differences = true
+	result.SkipCount += t.processDDL(ddls, printer)
+	return
+}
+
+// accountPassword returns the password to use when creating the supplied user
+// account. If the user-password-command option is set, its output is used.
+// Otherwise, the password is obtained from environment variable
+// SKEEMA_PASSWORD_<USER>, with the user name upper-cased and any characters
+// other than letters or digits converted to underscores. An error is returned
+// if no password can be obtained.
+func accountPassword(acct *Account, config *mybase.Config) (string, error) {
+	if config.Changed("user-password-command") {
+		variables := map[string]string{
+			"USER":        acct.User,
+			"HOST":        acct.Host,
+			"ENVIRONMENT": config.Get("environment"),
+		}
+		shellOut, err := util.NewInterpolatedShellOut(config.Get("user-password-command"), variables)
+		if err != nil {
+			return "", err
+		}
+		output, err := shellOut.RunCapture()
+		if err != nil {
+			return "", fmt.Errorf("user-password-command failed for %s: %s", acct, err)
+		}
+		if password := strings.TrimRight(output, "\r\n"); password != "" {
+			return password, nil
+		}
+		return "", fmt.Errorf("user-password-command returned an empty password for %s", acct)
+	}
+	envVar := passwordEnvVar(acct.User)
+	if password := os.Getenv(envVar); password != "" {
+		return password, nil
+	}
+	return "", fmt.Errorf("No password available for new user %s: set user-password-command, or environment variable %s", acct, envVar)
+}
+
+func passwordEnvVar(user string) string {
+	mapper := func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}
+	return "SKEEMA_PASSWORD_" + strings.Map(mapper, strings.ToUpper(user))
+}
@@ -0,0 +1,291 @@
+package applier
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestParseGrantsFile(t *testing.T) {
+	accounts, err := ParseGrantsFile("testdata/grants/grants.sql")
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseGrantsFile: %s", err)
+	}
+	if len(accounts) != 4 {
+		t.Fatalf("Expected 4 accounts, instead found %d: %v", len(accounts), accounts)
+	}
+
+	if acct := accounts["`app_read`@`%`"]; acct == nil || !acct.IsRole {
+		t.Errorf("Expected app_read to be declared as a role, instead found %+v", acct)
+	} else if !reflect.DeepEqual(acct.Privileges, map[string]map[string]bool{"`product`.*": {"SELECT": true}}) {
+		t.Errorf("Unexpected privileges for %s: %v", acct, acct.Privileges)
+	}
+	if acct := accounts["`app_write`@`%`"]; acct == nil || !acct.IsRole {
+		t.Errorf("Expected app_write to be declared as a role, instead found %+v", acct)
+	} else if !reflect.DeepEqual(acct.Privileges, map[string]map[string]bool{"`product`.*": {"INSERT": true, "UPDATE": true, "DELETE": true}}) {
+		t.Errorf("Unexpected privileges for %s: %v", acct, acct.Privileges)
+	}
+
+	acct := accounts["`app`@`10.%`"]
+	if acct == nil || acct.IsRole {
+		t.Fatalf("Expected app@10.%% to be declared as a user, instead found %+v", acct)
+	}
+	expectPrivs := map[string]map[string]bool{
+		"`product`.`users`":             {"SELECT (`id`)": true, "SELECT (`name`)": true},
+		"PROCEDURE `product`.`cleanup`": {"EXECUTE": true},
+	}
+	if !reflect.DeepEqual(acct.Privileges, expectPrivs) {
+		t.Errorf("Unexpected privileges for %s: %v", acct, acct.Privileges)
+	}
+	if expectRoles := map[string]bool{"`app_read`@`%`": true, "`app_write`@`%`": true}; !reflect.DeepEqual(acct.Roles, expectRoles) {
+		t.Errorf("Unexpected roles for %s: %v", acct, acct.Roles)
+	}
+
+	acct = accounts["`admin`@`localhost`"]
+	if acct == nil {
+		t.Fatal("Expected admin@localhost to be declared, but it was not found")
+	}
+	if expectPrivs := map[string]map[string]bool{"*.*": {"ALL PRIVILEGES": true, "GRANT OPTION": true}}; !reflect.DeepEqual(acct.Privileges, expectPrivs) {
+		t.Errorf("Unexpected privileges for %s: %v", acct, acct.Privileges)
+	}
+
+	// Test error conditions
+	for _, name := range []string{"undeclared", "identified", "unqualified", "other"} {
+		if _, err := ParseGrantsFile("testdata/grants/" + name + ".sql"); err == nil {
+			t.Errorf("Expected error parsing testdata/grants/%s.sql, but err was nil", name)
+		}
+	}
+	if _, err := ParseGrantsFile("testdata/grants/doesnt-exist.sql"); err == nil {
+		t.Error("Expected error parsing nonexistent file, but err was nil")
+	}
+}
+
+func TestParseGrant(t *testing.T) {
+	cases := map[string]*grantStatement{
+		"GRANT USAGE ON *.* TO `app`@`%`": {
+			object:   "*.*",
+			grantees: []string{"`app`@`%`"},
+		},
+		"GRANT SELECT, INSERT ON `my``db`.* TO 'app'@'localhost', reader WITH GRANT OPTION": {
+			privileges:  []string{"SELECT", "INSERT"},
+			object:      "`my``db`.*",
+			grantees:    []string{"`app`@`localhost`", "`reader`@`%`"},
+			grantOption: true,
+		},
+		"GRANT BACKUP_ADMIN,REPLICATION SLAVE ON *.* TO `repl`@`%`": {
+			privileges: []string{"BACKUP_ADMIN", "REPLICATION SLAVE"},
+			object:     "*.*",
+			grantees:   []string{"`repl`@`%`"},
+		},
+		"GRANT UPDATE (`a`, `b,c`) ON `db`.`t` TO 'app'@'%' IDENTIFIED BY PASSWORD '*ABC'": {
+			privileges: []string{"UPDATE (`a`)", "UPDATE (`b,c`)"},
+			object:     "`db`.`t`",
+			grantees:   []string{"`app`@`%`"},
+			extra:      []string{"IDENTIFIED", "BY", "PASSWORD", "'*ABC'"},
+		},
+		"GRANT `r1`@`%`,`r2`@`localhost` TO `app`@`%` WITH ADMIN OPTION": {
+			roles:    []string{"`r1`@`%`", "`r2`@`localhost`"},
+			grantees: []string{"`app`@`%`"},
+		},
+		"GRANT PROXY ON ''@'' TO 'root'@'localhost' WITH GRANT OPTION": nil,
+	}
+	for input, expected := range cases {
+		tokens, err := grantTokens(input)
+		if err != nil {
+			t.Errorf("Unexpected error tokenizing %q: %s", input, err)
+			continue
+		}
+		actual, err := parseGrant(tokens)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %q: %s", input, err)
+		} else if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Unexpected result parsing %q: expected %+v, found %+v", input, expected, actual)
+		}
+	}
+
+	for _, input := range []string{
+		"GRANT SELECT ON * TO app",
+		"GRANT SELECT ON db.* TO",
+		"GRANT SELECT ON EVENT db.* TO app",
+		"GRANT SELECT ON *.tbl TO app",
+		"GRANT SELECT ON db.* TO a@b@c",
+	} {
+		tokens, err := grantTokens(input)
+		if err == nil {
+			_, err = parseGrant(tokens)
+		}
+		if err == nil {
+			t.Errorf("Expected error parsing %q, but err was nil", input)
+		}
+	}
+	if _, err := grantTokens("GRANT SELECT ON `db.* TO app"); err == nil {
+		t.Error("Expected error tokenizing unterminated quote, but err was nil")
+	}
+}
+
+func TestAccountDiffStatements(t *testing.T) {
+	desired, err := ParseGrantsFile("testdata/grants/grants.sql")
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseGrantsFile: %s", err)
+	}
+
+	// With no existing accounts, everything should be created
+	expected := []string{
+		"CREATE ROLE `app_read`@`%`",
+		"CREATE ROLE `app_write`@`%`",
+		"CREATE USER `admin`@`localhost`",
+		"CREATE USER `app`@`10.%`",
+		"GRANT SELECT ON `product`.* TO `app_read`@`%`",
+		"GRANT DELETE, INSERT, UPDATE ON `product`.* TO `app_write`@`%`",
+		"GRANT ALL PRIVILEGES ON *.* TO `admin`@`localhost` WITH GRANT OPTION",
+		"GRANT EXECUTE ON PROCEDURE `product`.`cleanup` TO `app`@`10.%`",
+		"GRANT SELECT (`id`), SELECT (`name`) ON `product`.`users` TO `app`@`10.%`",
+		"GRANT `app_read`@`%`, `app_write`@`%` TO `app`@`10.%`",
+	}
+	if actual := AccountDiffStatements(map[string]*Account{}, desired); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from AccountDiffStatements:\nexpected %v\nfound    %v", expected, actual)
+	}
+
+	// Compare against existing accounts with some differences. Undeclared
+	// accounts must be ignored.
+	existing := map[string]*Account{
+		"`app_read`@`%`":      newAccount("app_read", "%", true),
+		"`app_write`@`%`":     newAccount("app_write", "%", true),
+		"`admin`@`localhost`": newAccount("admin", "localhost", false),
+		"`app`@`10.%`":        newAccount("app", "10.%", false),
+		"`other`@`%`":         newAccount("other", "%", false),
+	}
+	existing["`app_read`@`%`"].addPrivilege("`product`.*", "SELECT")
+	existing["`app_write`@`%`"].addPrivilege("`product`.*", "INSERT")
+	existing["`app_write`@`%`"].addPrivilege("`product`.*", "DROP")
+	existing["`admin`@`localhost`"].addPrivilege("*.*", "ALL PRIVILEGES")
+	existing["`app`@`10.%`"].addPrivilege("PROCEDURE `product`.`cleanup`", "EXECUTE")
+	existing["`app`@`10.%`"].addPrivilege("`product`.`users`", "SELECT (`id`)")
+	existing["`app`@`10.%`"].addPrivilege("`product`.`users`", "SELECT (`email`)")
+	existing["`app`@`10.%`"].Roles["`app_read`@`%`"] = true
+	existing["`app`@`10.%`"].Roles["`legacy`@`%`"] = true
+	existing["`other`@`%`"].addPrivilege("*.*", "SUPER")
+	expected = []string{
+		"REVOKE DROP ON `product`.* FROM `app_write`@`%`",
+		"GRANT DELETE, UPDATE ON `product`.* TO `app_write`@`%`",
+		"GRANT USAGE ON *.* TO `admin`@`localhost` WITH GRANT OPTION",
+		"REVOKE SELECT (`email`) ON `product`.`users` FROM `app`@`10.%`",
+		"GRANT SELECT (`name`) ON `product`.`users` TO `app`@`10.%`",
+		"REVOKE `legacy`@`%` FROM `app`@`10.%`",
+		"GRANT `app_write`@`%` TO `app`@`10.%`",
+	}
+	if actual := AccountDiffStatements(existing, desired); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected result from AccountDiffStatements:\nexpected %v\nfound    %v", expected, actual)
+	}
+
+	// Comparing desired to itself should yield no statements
+	if actual := AccountDiffStatements(desired, desired); len(actual) > 0 {
+		t.Errorf("Expected no statements, instead found %v", actual)
+	}
+}
+
+func TestApplyShowGrants(t *testing.T) {
+	desired, err := ParseGrantsFile("testdata/grants/grants.sql")
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseGrantsFile: %s", err)
+	}
+	admin := desired["`admin`@`localhost`"]
+
+	// MySQL 8 expands ALL PRIVILEGES ON *.* into static and dynamic privileges
+	mysql80Lines := []string{
+		"GRANT SELECT, INSERT, UPDATE, DELETE, CREATE, DROP, RELOAD, SHUTDOWN, PROCESS, FILE, REFERENCES, INDEX, ALTER, SHOW DATABASES, SUPER, CREATE TEMPORARY TABLES, LOCK TABLES, EXECUTE, REPLICATION SLAVE, REPLICATION CLIENT, CREATE VIEW, SHOW VIEW, CREATE ROUTINE, ALTER ROUTINE, CREATE USER, EVENT, TRIGGER, CREATE TABLESPACE, CREATE ROLE, DROP ROLE ON *.* TO `admin`@`localhost` WITH GRANT OPTION",
+		"GRANT APPLICATION_PASSWORD_ADMIN,AUDIT_ADMIN,BACKUP_ADMIN,BINLOG_ADMIN,CONNECTION_ADMIN,ROLE_ADMIN,SYSTEM_VARIABLES_ADMIN,XA_RECOVER_ADMIN ON *.* TO `admin`@`localhost` WITH GRANT OPTION",
+	}
+	acct := newAccount("admin", "localhost", false)
+	if err := acct.applyShowGrants(mysql80Lines, tengo.FlavorMySQL80); err != nil {
+		t.Fatalf("Unexpected error from applyShowGrants: %v", err)
+	}
+	if !reflect.DeepEqual(acct.Privileges, admin.Privileges) {
+		t.Errorf("Expected MySQL 8 privileges to collapse to %v, instead found %v", admin.Privileges, acct.Privileges)
+	}
+	existing := map[string]*Account{acct.String(): acct}
+	if actual := AccountDiffStatements(existing, map[string]*Account{admin.String(): admin}); len(actual) > 0 {
+		t.Errorf("Expected no statements, instead found %v", actual)
+	}
+
+	// An incomplete set of static privileges should not be collapsed, nor should
+	// the expanded list in flavors which don't expand ALL PRIVILEGES themselves
+	acct = newAccount("admin", "localhost", false)
+	acct.applyShowGrants([]string{strings.Replace(mysql80Lines[0], " SUPER,", "", 1)}, tengo.FlavorMySQL80)
+	if privs := acct.Privileges["*.*"]; privs["ALL PRIVILEGES"] || !privs["SELECT"] || privs["SUPER"] {
+		t.Errorf("Unexpected privileges after applyShowGrants: %v", privs)
+	}
+	acct = newAccount("admin", "localhost", false)
+	acct.applyShowGrants(mysql80Lines[0:1], tengo.FlavorMySQL57)
+	if privs := acct.Privileges["*.*"]; privs["ALL PRIVILEGES"] || !privs["SUPER"] {
+		t.Errorf("Unexpected privileges after applyShowGrants: %v", privs)
+	}
+
+	// MySQL 5.7 and MariaDB report ALL PRIVILEGES as-is; any other privileges on
+	// the same object are redundant
+	acct = newAccount("admin", "localhost", false)
+	acct.applyShowGrants([]string{
+		"GRANT ALL PRIVILEGES ON *.* TO 'admin'@'localhost' WITH GRANT OPTION",
+		"GRANT SELECT ON `product`.* TO 'admin'@'localhost'",
+	}, tengo.FlavorMariaDB103)
+	expected := map[string]map[string]bool{"*.*": {"ALL PRIVILEGES": true, "GRANT OPTION": true}, "`product`.*": {"SELECT": true}}
+	if !reflect.DeepEqual(acct.Privileges, expected) {
+		t.Errorf("Unexpected privileges after applyShowGrants: %v", acct.Privileges)
+	}
+}
+
+func TestGrantTargetCheckFlavor(t *testing.T) {
+	inst, err := tengo.NewInstance("mysql", "root@tcp(127.0.0.1:3306)/")
+	if err != nil {
+		t.Fatalf("Unexpected error from NewInstance: %v", err)
+	}
+	gt := &GrantTarget{Instance: inst, Dir: &fs.Dir{Path: "/tmp/mydb"}}
+	for _, flavor := range []tengo.Flavor{tengo.FlavorMySQL57, tengo.FlavorMySQL80, tengo.FlavorPercona80} {
+		inst.ForceFlavor(flavor)
+		if err := gt.CheckFlavor(); err != nil {
+			t.Errorf("Unexpected error from CheckFlavor with flavor %s: %v", flavor, err)
+		}
+	}
+	inst.ForceFlavor(tengo.FlavorMariaDB103)
+	if err := gt.CheckFlavor(); err == nil {
+		t.Error("Expected error from CheckFlavor with MariaDB, but err was nil")
+	} else if _, ok := err.(ConfigError); !ok {
+		t.Errorf("Expected error to be a ConfigError, instead found %T", err)
+	}
+}
+
+func TestAccountPassword(t *testing.T) {
+	acct := newAccount("app-rw", "%", false)
+	getConfig := func(cliFlags string) *mybase.Config {
+		cmd := mybase.NewCommand("grantstest", "", "", nil)
+		cmd.AddOption(mybase.StringOption("user-password-command", 0, "", ""))
+		cmd.AddArg("environment", "production", false)
+		return mybase.ParseFakeCLI(t, cmd, "grantstest "+cliFlags)
+	}
+
+	os.Unsetenv("SKEEMA_PASSWORD_APP_RW")
+	if _, err := accountPassword(acct, getConfig("")); err == nil || !strings.Contains(err.Error(), "SKEEMA_PASSWORD_APP_RW") {
+		t.Errorf("Expected error mentioning environment variable, instead found %v", err)
+	}
+	os.Setenv("SKEEMA_PASSWORD_APP_RW", "s3cret")
+	defer os.Unsetenv("SKEEMA_PASSWORD_APP_RW")
+	if password, err := accountPassword(acct, getConfig("")); password != "s3cret" || err != nil {
+		t.Errorf("Unexpected return from accountPassword: %q, %v", password, err)
+	}
+
+	// user-password-command takes precedence over environment variable
+	if password, err := accountPassword(acct, getConfig("--user-password-command='echo {USER}-{HOST}-{ENVIRONMENT}' staging")); password != "app-rw-%-staging" || err != nil {
+		t.Errorf("Unexpected return from accountPassword: %q, %v", password, err)
+	}
+	if _, err := accountPassword(acct, getConfig("--user-password-command='true'")); err == nil {
+		t.Error("Expected error from empty command output, but err was nil")
+	}
+	if _, err := accountPassword(acct, getConfig("--user-password-command='false'")); err == nil {
+		t.Error("Expected error from failing command, but err was nil")
+	}
+}
@@ -0,0 +1,192 @@
+package applier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// MigrationFormat determines the file naming and layout conventions used when
+// writing migration files.
+type MigrationFormat string
+
+// Constants enumerating valid migration formats
+const (
+	MigrationFormatFlyway        MigrationFormat = "flyway"         // V{version}__{desc}.sql and U{version}__{desc}.sql
+	MigrationFormatGoose         MigrationFormat = "goose"          // {version}_{desc}.sql with Up and Down sections
+	MigrationFormatGolangMigrate MigrationFormat = "golang-migrate" // {version}_{desc}.up.sql and {version}_{desc}.down.sql
+)
+
+// Migration represents the changes for a single target, along with statements
+// to revert them.
+type Migration struct {
+	Instance   string
+	SchemaName string
+	Up         []string
+	Down       []string
+	Notes      []string // changes which could not be reverted in Down
+}
+
+// MigrationWriter writes Migrations to versioned files in a directory. It is
+// safe for use by multiple workers concurrently.
+type MigrationWriter struct {
+	Dir     string
+	Format  MigrationFormat
+	start   time.Time
+	count   int
+	written map[string]string // description -> up contents
+	*sync.Mutex
+}
+
+// NewMigrationWriter returns a pointer to a new MigrationWriter. Migration
+// versions are timestamps based on now, incremented by one second for each
+// subsequent migration written, so that versions are unique.
+func NewMigrationWriter(dirPath string, format MigrationFormat, now time.Time) *MigrationWriter {
+	return &MigrationWriter{
+		Dir:     dirPath,
+		Format:  format,
+		start:   now.UTC().Truncate(time.Second),
+		written: make(map[string]string),
+		Mutex:   new(sync.Mutex),
+	}
+}
+
+var reUnsafeFileChars = regexp.MustCompile(`[^0-9A-Za-z]+`)
+
+// Write creates the files for m, returning their paths. If a migration for the
+// same schema has already been written with identical contents, for example by
+// another instance in the same environment, no files are written. Existing
+// files are never overwritten.
+func (mw *MigrationWriter) Write(m *Migration) (paths []string, err error) {
+	mw.Lock()
+	defer mw.Unlock()
+
+	desc := strings.Trim(reUnsafeFileChars.ReplaceAllString(m.SchemaName, "_"), "_")
+	up := mw.render(m.Up, nil)
+	if prev, already := mw.written[desc]; already {
+		if prev == up {
+			return nil, nil
+		}
+		desc = desc + "_" + strings.Trim(reUnsafeFileChars.ReplaceAllString(m.Instance, "_"), "_")
+	}
+	down := mw.render(m.Down, m.Notes)
+	version := mw.start.Add(time.Duration(mw.count) * time.Second).Format("20060102150405")
+
+	var names, contents []string
+	switch mw.Format {
+	case MigrationFormatFlyway:
+		names = []string{fmt.Sprintf("V%s__%s.sql", version, desc), fmt.Sprintf("U%s__%s.sql", version, desc)}
+		contents = []string{up, down}
+	case MigrationFormatGoose:
+		names = []string{fmt.Sprintf("%s_%s.sql", version, desc)}
+		contents = []string{"-- +goose Up\n" + up + "\n-- +goose Down\n" + down}
+	default:
+		names = []string{fmt.Sprintf("%s_%s.up.sql", version, desc), fmt.Sprintf("%s_%s.down.sql", version, desc)}
+		contents = []string{up, down}
+	}
+
+	if err := os.MkdirAll(mw.Dir, 0755); err != nil {
+		return nil, err
+	}
+	for n, name := range names {
+		path := filepath.Join(mw.Dir, name)
+		if err := writeNewFile(path, contents[n]); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	mw.written[desc] = up
+	mw.count++
+	return paths, nil
+}
+
+// render returns the file contents for statements in the writer's format,
+// preceded by any notes as comments.
+func (mw *MigrationWriter) render(statements, notes []string) string {
+	var b strings.Builder
+	for _, note := range notes {
+		fmt.Fprintf(&b, "-- %s\n", note)
+	}
+	for _, stmt := range statements {
+		delimited := fs.AddDelimiter(stmt)
+		switch {
+		case mw.Format == MigrationFormatFlyway:
+			// Flyway handles DELIMITER commands the same way as the mysql client
+			b.WriteString(delimited)
+		case delimited == stmt+";\n":
+			b.WriteString(delimited)
+		case mw.Format == MigrationFormatGoose:
+			fmt.Fprintf(&b, "-- +goose StatementBegin\n%s;\n-- +goose StatementEnd\n", stmt)
+		default:
+			// golang-migrate sends each file to the server as-is, and the server
+			// parses compound statements without any DELIMITER command
+			fmt.Fprintf(&b, "%s;\n", stmt)
+		}
+	}
+	return b.String()
+}
+
+// writeNewFile writes contents to a new file at path, returning an error if the
+// file already exists.
+func writeNewFile(path, contents string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err = f.WriteString(contents); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// migrationForTarget returns a Migration containing the supplied DDL and DML
+// for t, along with DDL to revert it, obtained by diffing in the opposite
+// direction. Database-level statements are excluded, since migration tools run
+// within an existing schema.
+func migrationForTarget(t *Target, ddls, dmls []*DDLStatement, schemaFromInstance, schemaFromDir *tengo.Schema, mods tengo.StatementModifiers) *Migration {
+	m := &Migration{
+		Instance:   t.Instance.String(),
+		SchemaName: t.SchemaName,
+	}
+	for _, ddl := range ddls {
+		if ddl.schemaName != "" {
+			m.Up = append(m.Up, ddl.stmt)
+		}
+	}
+	var dataChanges bool
+	for _, dml := range dmls {
+		if dml.schemaName != "" {
+			m.Up = append(m.Up, dml.stmt)
+			dataChanges = true
+		}
+	}
+	if dataChanges {
+		m.Notes = append(m.Notes, "Changes to rows of data-tables are not reverted")
+	}
+
+	// Reverting the changes may be destructive even if applying them was not
+	mods.AllowUnsafe = true
+	mods.NextAutoInc = tengo.NextAutoIncIgnore
+	for _, objDiff := range tengo.NewSchemaDiff(schemaFromDir, schemaFromInstance).ObjectDiffs() {
+		if objDiff.ObjectKey().Type == tengo.ObjectTypeDatabase {
+			continue
+		}
+		stmt, err := objDiff.Statement(mods)
+		if err != nil {
+			log.Debugf("Unable to generate DDL to revert %s: %s", objDiff.ObjectKey(), err)
+			m.Notes = append(m.Notes, fmt.Sprintf("Unable to generate DDL to revert %s", objDiff.ObjectKey()))
+		} else if stmt != "" {
+			m.Down = append(m.Down, stmt)
+		}
+	}
+	return m
+}
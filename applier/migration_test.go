@@ -0,0 +1,73 @@
+package applier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMigrationWriter(t *testing.T) {
+	now := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	proc := "CREATE PROCEDURE cleanup()\nBEGIN\n  DELETE FROM posts;\nEND"
+	m := &Migration{
+		Instance:   "db1:3306",
+		SchemaName: "product-db",
+		Up:         []string{"CREATE TABLE posts (id int)", proc},
+		Down:       []string{"DROP PROCEDURE cleanup", "DROP TABLE posts"},
+		Notes:      []string{"Changes to rows of data-tables are not reverted"},
+	}
+	cases := map[MigrationFormat]map[string]string{
+		MigrationFormatGolangMigrate: {
+			"20200304050607_product_db.up.sql":   "CREATE TABLE posts (id int);\n" + proc + ";\n",
+			"20200304050607_product_db.down.sql": "-- Changes to rows of data-tables are not reverted\nDROP PROCEDURE cleanup;\nDROP TABLE posts;\n",
+		},
+		MigrationFormatFlyway: {
+			"V20200304050607__product_db.sql": "CREATE TABLE posts (id int);\nDELIMITER //\n" + proc + "//\nDELIMITER ;\n",
+			"U20200304050607__product_db.sql": "-- Changes to rows of data-tables are not reverted\nDROP PROCEDURE cleanup;\nDROP TABLE posts;\n",
+		},
+		MigrationFormatGoose: {
+			"20200304050607_product_db.sql": "-- +goose Up\nCREATE TABLE posts (id int);\n-- +goose StatementBegin\n" + proc + ";\n-- +goose StatementEnd\n\n-- +goose Down\n-- Changes to rows of data-tables are not reverted\nDROP PROCEDURE cleanup;\nDROP TABLE posts;\n",
+		},
+	}
+	for format, expected := range cases {
+		dirPath, err := ioutil.TempDir("", "migrations")
+		if err != nil {
+			t.Fatalf("Unable to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(dirPath)
+		mw := NewMigrationWriter(filepath.Join(dirPath, "sub"), format, now)
+		paths, err := mw.Write(m)
+		if err != nil || len(paths) != len(expected) {
+			t.Fatalf("Format %s: unexpected return from Write: %v, %v", format, paths, err)
+		}
+		for name, contents := range expected {
+			actual, err := ioutil.ReadFile(filepath.Join(dirPath, "sub", name))
+			if err != nil {
+				t.Errorf("Format %s: unable to read %s: %v", format, name, err)
+			} else if string(actual) != contents {
+				t.Errorf("Format %s: unexpected contents of %s.\nExpected:\n%s\nActual:\n%s", format, name, contents, actual)
+			}
+		}
+
+		// Identical migration for another instance should be skipped; a different
+		// one should get a new version and an instance suffix
+		if paths, err := mw.Write(&Migration{Instance: "db2:3306", SchemaName: "product-db", Up: m.Up}); err != nil || len(paths) != 0 {
+			t.Errorf("Format %s: expected duplicate migration to be skipped, instead found %v, %v", format, paths, err)
+		}
+		paths, err = mw.Write(&Migration{Instance: "db3:3306", SchemaName: "product-db", Up: []string{"DROP TABLE posts"}})
+		if err != nil || len(paths) == 0 {
+			t.Fatalf("Format %s: unexpected return from Write: %v, %v", format, paths, err)
+		}
+		if fileNames, _ := filepath.Glob(filepath.Join(dirPath, "sub", "*20200304050608*product_db_db3_3306*")); len(fileNames) != len(expected) {
+			t.Errorf("Format %s: expected %d files for second migration, found %v", format, len(expected), fileNames)
+		}
+
+		// Existing files must not be overwritten
+		mw = NewMigrationWriter(filepath.Join(dirPath, "sub"), format, now)
+		if _, err := mw.Write(m); err == nil {
+			t.Errorf("Format %s: expected error writing over existing files, but err was nil", format)
+		}
+	}
+}
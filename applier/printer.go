@@ -11,9 +11,11 @@ import (
 // being called from multiple pushworker goroutines.
 type Printer struct {
 	briefOutput        bool
+	quiet              bool
 	lastStdoutInstance string
 	lastStdoutSchema   string
 	seenInstance       map[string]bool
+	migrations         *MigrationWriter
 	*sync.Mutex
 }
 
@@ -29,6 +31,18 @@ func NewPrinter(briefMode bool) *Printer {
 	}
 }
 
+// SetMigrationWriter configures the printer to also write the DDL of each
+// target to migration files using mw.
+func (p *Printer) SetMigrationWriter(mw *MigrationWriter) {
+	p.migrations = mw
+}
+
+// SetQuiet configures whether the printer omits the comment line identifying
+// each instance. USE commands are still output as needed.
+func (p *Printer) SetQuiet(quiet bool) {
+	p.quiet = quiet
+}
+
 // printDDL outputs DDLStatement values to STDOUT in a way that prevents
 // interleaving of output from multiple workers.
 // TODO: buffer output from external commands and also prevent interleaving there
@@ -48,7 +62,9 @@ func (p *Printer) printDDL(ddl *DDLStatement) {
 	}
 
 	if instString != p.lastStdoutInstance {
-		fmt.Printf("-- instance: %s\n", instString)
+		if !p.quiet {
+			fmt.Printf("-- instance: %s\n", instString)
+		}
 		p.lastStdoutInstance = instString
 		p.lastStdoutSchema = ""
 	}
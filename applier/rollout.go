@@ -0,0 +1,83 @@
+package applier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// PlanRollout splits groups into an ordered series of stages, for use in
+// performing a staged rollout across many instances. If canaryCount is
+// positive, the first stage consists of that many TargetGroups (i.e. that many
+// instances). The remaining TargetGroups are then split into stages of
+// batchSize groups each; a batchSize of 0 places all remaining groups into a
+// single stage. Empty stages are never returned.
+func PlanRollout(groups []TargetGroup, canaryCount, batchSize int) (stages [][]TargetGroup) {
+	if canaryCount > 0 && len(groups) > 0 {
+		if canaryCount > len(groups) {
+			canaryCount = len(groups)
+		}
+		stages = append(stages, groups[0:canaryCount])
+		groups = groups[canaryCount:]
+	}
+	if batchSize < 1 {
+		batchSize = len(groups)
+	}
+	for len(groups) > 0 {
+		if batchSize > len(groups) {
+			batchSize = len(groups)
+		}
+		stages = append(stages, groups[0:batchSize])
+		groups = groups[batchSize:]
+	}
+	return stages
+}
+
+// CountTargets returns the total number of Targets in the supplied stages.
+func CountTargets(stages [][]TargetGroup) (count int) {
+	for _, stage := range stages {
+		for _, tg := range stage {
+			count += len(tg)
+		}
+	}
+	return count
+}
+
+// VerifyPushed re-introspects each target's schema on its instance, and
+// confirms that the instance now matches the desired schema from the target's
+// dir. This is intended for use after a push completes, to confirm that a
+// canary stage of a staged rollout behaved as expected before proceeding to
+// other instances. Objects with unsupported diffs are ignored, since push skips
+// them as well. An error is returned describing the first target that does not
+// match, or if introspection fails.
+func VerifyPushed(tg TargetGroup) error {
+	for _, t := range tg {
+		schemaFromInstance, err := t.SchemaFromInstance()
+		if err != nil {
+			return fmt.Errorf("Unable to verify %s %s: %s", t.Instance, t.SchemaName, err)
+		}
+		schemaFromDir := t.SchemaFromDir()
+		mods, err := statementModifiersForTarget(t, schemaFromDir)
+		if err != nil {
+			return err
+		}
+		mods.AllowUnsafe = true // we only care whether any statement is generated
+		diff := tengo.NewSchemaDiff(schemaFromInstance, schemaFromDir)
+		var mismatches []string
+		for _, objDiff := range diff.ObjectDiffs() {
+			stmt, err := objDiff.Statement(mods)
+			if _, unsupported := err.(*tengo.UnsupportedDiffError); unsupported {
+				continue // push skips these too, so they cannot be verified
+			}
+			if stmt != "" || (err != nil && !tengo.IsForbiddenDiff(err)) {
+				mismatches = append(mismatches, objDiff.ObjectKey().String())
+			}
+		}
+		if len(mismatches) > 0 {
+			return fmt.Errorf("Post-push verification failure on %s %s: %s still not matching %s (%s)", t.Instance, t.SchemaName, util.CountAndNoun(len(mismatches), "object"), t.Dir, strings.Join(mismatches, ", "))
+		}
+	}
+	return nil
+}
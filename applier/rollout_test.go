@@ -0,0 +1,38 @@
+package applier
+
+import (
+	"testing"
+)
+
+func TestPlanRollout(t *testing.T) {
+	groups := make([]TargetGroup, 7)
+	for n := range groups {
+		groups[n] = TargetGroup{&Target{}, &Target{}}
+	}
+	assertStageSizes := func(canaryCount, batchSize int, expected ...int) {
+		t.Helper()
+		stages := PlanRollout(groups, canaryCount, batchSize)
+		if len(stages) != len(expected) {
+			t.Errorf("PlanRollout(%d, %d): expected %d stages, found %d", canaryCount, batchSize, len(expected), len(stages))
+			return
+		}
+		for n := range stages {
+			if len(stages[n]) != expected[n] {
+				t.Errorf("PlanRollout(%d, %d): expected stage %d to have %d groups, found %d", canaryCount, batchSize, n, expected[n], len(stages[n]))
+			}
+		}
+		if count := CountTargets(stages); count != 2*len(groups) {
+			t.Errorf("PlanRollout(%d, %d): expected %d targets total, found %d", canaryCount, batchSize, 2*len(groups), count)
+		}
+	}
+	assertStageSizes(0, 0, 7)
+	assertStageSizes(1, 0, 1, 6)
+	assertStageSizes(2, 2, 2, 2, 2, 1)
+	assertStageSizes(0, 3, 3, 3, 1)
+	assertStageSizes(10, 3, 7)
+	assertStageSizes(0, 10, 7)
+
+	if stages := PlanRollout([]TargetGroup{}, 1, 1); len(stages) != 0 {
+		t.Errorf("Expected no stages for empty input, instead found %d", len(stages))
+	}
+}
@@ -0,0 +1,115 @@
+package applier
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// SchemaOptions represents schema-level settings beyond the default character
+// set and collation, which tengo does not introspect or diff. Each field is
+// blank if the setting is unsupported by the flavor, or not managed.
+type SchemaOptions struct {
+	DefaultEncryption string // "Y" or "N"; requires MySQL 8.0.16+
+	ReadOnly          string // "1" or "0"; requires MySQL 8.0.22+
+}
+
+// SchemaOptionsForLogicalSchema returns the SchemaOptions configured for the
+// supplied logical schema.
+func SchemaOptionsForLogicalSchema(logicalSchema *fs.LogicalSchema) SchemaOptions {
+	return SchemaOptions{
+		DefaultEncryption: logicalSchema.DefaultEncryption,
+		ReadOnly:          logicalSchema.ReadOnly,
+	}
+}
+
+// SchemaOptionsFromInstance introspects the SchemaOptions of an existing
+// schema. Settings which the instance's flavor does not support are left
+// blank.
+func SchemaOptionsFromInstance(instance *tengo.Instance, schemaName string) (opts SchemaOptions, err error) {
+	flavor := instance.Flavor()
+	if !flavor.MySQLishMinVersion(8, 0, 16) {
+		return opts, nil
+	}
+	db, err := instance.Connect("information_schema", "")
+	if err != nil {
+		return opts, err
+	}
+	query := "SELECT default_encryption FROM schemata WHERE schema_name = ?"
+	if err = db.QueryRow(query, schemaName).Scan(&opts.DefaultEncryption); err != nil {
+		return opts, err
+	}
+	if !flavor.MySQLishMinVersion(8, 0, 22) {
+		return opts, nil
+	}
+	var options sql.NullString
+	query = "SELECT options FROM schemata_extensions WHERE schema_name = ?"
+	if err = db.QueryRow(query, schemaName).Scan(&options); err != nil {
+		return opts, err
+	}
+	opts.ReadOnly = "0"
+	if strings.Contains(options.String, "READ ONLY=1") {
+		opts.ReadOnly = "1"
+	}
+	return opts, nil
+}
+
+// schemaOptionStatements returns ALTER DATABASE statements needed to change
+// the schema-level options of the target's live schema to match its desired
+// options. Changes which must be made before other DDL (including making a
+// schema writable) are returned in pre; making a schema read-only is returned
+// in post, since it must occur after all other changes. If the schema should
+// remain read-only but hasChanges is true, it is made writable in pre and then
+// read-only again in post. If the live schema does not exist yet, all managed
+// options are set explicitly. An UnsupportedSchemaOptionError is returned if
+// the instance's flavor does not support a managed option.
+func schemaOptionStatements(t *Target, schemaExists, hasChanges bool) (pre, post []*DDLStatement, err error) {
+	desired := SchemaOptionsForLogicalSchema(t.DesiredSchema.LogicalSchema)
+	if desired == (SchemaOptions{}) {
+		return nil, nil, nil
+	}
+	flavor := t.Instance.Flavor()
+	if desired.DefaultEncryption != "" && !flavor.MySQLishMinVersion(8, 0, 16) {
+		return nil, nil, UnsupportedSchemaOptionError{Option: "default-encryption", Flavor: flavor}
+	} else if desired.ReadOnly != "" && !flavor.MySQLishMinVersion(8, 0, 22) {
+		return nil, nil, UnsupportedSchemaOptionError{Option: "schema-read-only", Flavor: flavor}
+	}
+	var live SchemaOptions
+	if schemaExists {
+		if live, err = SchemaOptionsFromInstance(t.Instance, t.SchemaName); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	newDDL := func(clause string) *DDLStatement {
+		return &DDLStatement{
+			stmt:     fmt.Sprintf("ALTER DATABASE %s %s", tengo.EscapeIdentifier(t.SchemaName), clause),
+			instance: t.Instance,
+		}
+	}
+	if (desired.ReadOnly == "0" && live.ReadOnly != "0") || (desired.ReadOnly == "1" && live.ReadOnly == "1" && hasChanges) {
+		pre = append(pre, newDDL("READ ONLY = 0"))
+	}
+	if desired.DefaultEncryption != "" && desired.DefaultEncryption != live.DefaultEncryption {
+		pre = append(pre, newDDL(fmt.Sprintf("DEFAULT ENCRYPTION = '%s'", desired.DefaultEncryption)))
+	}
+	if desired.ReadOnly == "1" && (live.ReadOnly != "1" || hasChanges) {
+		post = append(post, newDDL("READ ONLY = 1"))
+	}
+	return pre, post, nil
+}
+
+// UnsupportedSchemaOptionError is returned when a schema-level option is
+// configured, but the database server's flavor does not support it.
+type UnsupportedSchemaOptionError struct {
+	Option string
+	Flavor tengo.Flavor
+}
+
+// Error satisfies the builtin error interface.
+func (err UnsupportedSchemaOptionError) Error() string {
+	return fmt.Sprintf("Option %s is not supported by database server flavor %s", err.Option, err.Flavor)
+}
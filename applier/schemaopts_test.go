@@ -0,0 +1,71 @@
+package applier
+
+import (
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+func TestSchemaOptionStatements(t *testing.T) {
+	inst, err := tengo.NewInstance("mysql", "root:@tcp(127.0.0.1:3306)/")
+	if err != nil {
+		t.Fatalf("Unexpected error from NewInstance: %s", err)
+	}
+	inst.ForceFlavor(tengo.NewFlavor("mysql", 8, 0, 23))
+	logicalSchema := &fs.LogicalSchema{}
+	target := &Target{
+		Instance:      inst,
+		SchemaName:    "product",
+		DesiredSchema: &workspace.Schema{LogicalSchema: logicalSchema},
+	}
+	statements := func(ddls []*DDLStatement) (result []string) {
+		for _, ddl := range ddls {
+			result = append(result, ddl.stmt)
+		}
+		return result
+	}
+
+	// No options managed: no statements
+	if pre, post, err := schemaOptionStatements(target, false, true); len(pre)+len(post) > 0 || err != nil {
+		t.Errorf("Expected no statements or errors, instead found %v, %v, %v", statements(pre), statements(post), err)
+	}
+
+	// New schema: all managed options set explicitly, with read-only last
+	logicalSchema.DefaultEncryption = "Y"
+	logicalSchema.ReadOnly = "1"
+	pre, post, err := schemaOptionStatements(target, false, true)
+	if err != nil {
+		t.Fatalf("Unexpected error from schemaOptionStatements: %s", err)
+	}
+	if actual := statements(pre); len(actual) != 1 || actual[0] != "ALTER DATABASE `product` DEFAULT ENCRYPTION = 'Y'" {
+		t.Errorf("Unexpected pre statements: %v", actual)
+	}
+	if actual := statements(post); len(actual) != 1 || actual[0] != "ALTER DATABASE `product` READ ONLY = 1" {
+		t.Errorf("Unexpected post statements: %v", actual)
+	}
+
+	logicalSchema.DefaultEncryption = ""
+	logicalSchema.ReadOnly = "0"
+	pre, post, _ = schemaOptionStatements(target, false, false)
+	if actual := statements(pre); len(actual) != 1 || actual[0] != "ALTER DATABASE `product` READ ONLY = 0" || len(post) > 0 {
+		t.Errorf("Unexpected statements: %v, %v", actual, statements(post))
+	}
+
+	// Flavors lacking support for a managed option should return an error
+	for flavor, readOnly := range map[tengo.Flavor]string{
+		tengo.NewFlavor("mysql", 8, 0, 20): "1",
+		tengo.NewFlavor("mysql", 5, 7, 30): "",
+		tengo.NewFlavor("mariadb", 10, 5):  "",
+	} {
+		inst.ForceFlavor(flavor)
+		logicalSchema.DefaultEncryption = "N"
+		logicalSchema.ReadOnly = readOnly
+		if _, _, err := schemaOptionStatements(target, true, false); err == nil {
+			t.Errorf("Expected error for flavor %s, but err was nil", flavor)
+		} else if _, ok := err.(UnsupportedSchemaOptionError); !ok {
+			t.Errorf("Expected error for flavor %s to be UnsupportedSchemaOptionError, instead found %T", flavor, err)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package applier
 
 import (
 	"database/sql"
+	"sort"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/skeema/fs"
@@ -19,6 +21,7 @@ type Target struct {
 	Dir           *fs.Dir
 	SchemaName    string
 	DesiredSchema *workspace.Schema
+	started       time.Time // set by logApplyStart
 }
 
 // SchemaFromInstance introspects and returns the instance's version of the
@@ -50,26 +53,38 @@ func (t *Target) briefOutput() bool {
 	return t.Dir.Config.GetBool("brief") && t.dryRun()
 }
 
+// logger returns a log entry with fields identifying t, for use in structured
+// log output.
+func (t *Target) logger() *log.Entry {
+	return log.WithFields(log.Fields{
+		"instance": t.Instance.String(),
+		"schema":   t.SchemaName,
+		"dir":      t.Dir.RelPath(),
+	})
+}
+
 func (t *Target) logApplyStart() {
+	t.started = time.Now()
 	if t.dryRun() {
-		log.Infof("Generating diff of %s %s vs %s/*.sql", t.Instance, t.SchemaName, t.Dir)
+		t.logger().Infof("Generating diff of %s %s vs %s/*.sql", t.Instance, t.SchemaName, t.Dir)
 	} else {
-		log.Infof("Pushing changes from %s/*.sql to %s %s", t.Dir, t.Instance, t.SchemaName)
+		t.logger().Infof("Pushing changes from %s/*.sql to %s %s", t.Dir, t.Instance, t.SchemaName)
 	}
 	if len(t.Dir.IgnoredStatements) > 0 {
-		log.Warnf("Ignoring %d unsupported or unparseable statements found in this directory's *.sql files; run `skeema lint` for more info", len(t.Dir.IgnoredStatements))
+		t.logger().Warnf("Ignoring %d unsupported or unparseable statements found in this directory's *.sql files; run `skeema lint` for more info", len(t.Dir.IgnoredStatements))
 	}
 }
 
 func (t *Target) logApplyEnd(result Result) {
+	entry := t.logger().WithField("duration", time.Since(t.started).Seconds())
 	if result.Differences {
 		verb := "push"
 		if t.dryRun() {
 			verb = "diff"
 		}
-		log.Infof("%s %s: %s complete\n", t.Instance, t.SchemaName, verb)
+		entry.Infof("%s %s: %s complete\n", t.Instance, t.SchemaName, verb)
 	} else {
-		log.Infof("%s %s: No differences found\n", t.Instance, t.SchemaName)
+		entry.Infof("%s %s: No differences found\n", t.Instance, t.SchemaName)
 	}
 }
 
@@ -78,11 +93,11 @@ func (t *Target) processDDL(ddls []*DDLStatement, printer *Printer) (skipCount i
 		printer.printDDL(ddl)
 		if !t.dryRun() {
 			if err := ddl.Execute(); err != nil {
-				log.Errorf("Error running DDL on %s %s: %s", t.Instance, t.SchemaName, err)
+				t.logger().Errorf("Error running DDL on %s %s: %s", t.Instance, t.SchemaName, err)
 				skipped := len(ddls) - i
 				skipCount += skipped
 				if skipped > 1 {
-					log.Warnf("Skipping %d remaining operations for %s %s due to previous error", skipped-1, t.Instance, t.SchemaName)
+					t.logger().Warnf("Skipping %d remaining operations for %s %s due to previous error", skipped-1, t.Instance, t.SchemaName)
 				}
 				return
 			}
@@ -266,24 +281,48 @@ func targetsForLogicalSchema(logicalSchema *fs.LogicalSchema, dir *fs.Dir, insta
 	return
 }
 
+// TargetGroupsForDir returns TargetGroups for this dir and its subdirs, and
+// count of directories that were skipped due to non-fatal errors. The groups
+// are sorted by instance, so that callers requiring a deterministic ordering
+// (for example, staged rollouts) always see the same sequence.
+func TargetGroupsForDir(dir *fs.Dir) ([]TargetGroup, int) {
+	targets, skipCount := TargetsForDir(dir, 5)
+	byInst := make(map[string]TargetGroup)
+	instNames := []string{}
+	for _, t := range targets {
+		key := t.Instance.String()
+		if _, already := byInst[key]; !already {
+			instNames = append(instNames, key)
+		}
+		byInst[key] = append(byInst[key], t)
+	}
+	sort.Strings(instNames)
+	groups := make([]TargetGroup, len(instNames))
+	for n, key := range instNames {
+		groups[n] = byInst[key]
+	}
+	return groups, skipCount
+}
+
 // TargetGroupChanForDir returns a channel for obtaining TargetGroups for this
 // dir and its subdirs, and count of directories that were skipped due to non-
 // fatal errors.
 func TargetGroupChanForDir(dir *fs.Dir) (<-chan TargetGroup, int) {
-	targets, skipCount := TargetsForDir(dir, 5)
-	groups := make(chan TargetGroup)
+	groups, skipCount := TargetGroupsForDir(dir)
+	return TargetGroupChan(groups), skipCount
+}
+
+// TargetGroupChan returns a channel which will yield each of the supplied
+// TargetGroups, and then be closed.
+func TargetGroupChan(groups []TargetGroup) <-chan TargetGroup {
+	ch := make(chan TargetGroup)
 	go func() {
-		byInst := make(map[string]TargetGroup)
-		for _, t := range targets {
-			key := t.Instance.String()
-			byInst[key] = append(byInst[key], t)
-		}
-		for _, tg := range byInst {
-			groups <- tg
+		for _, tg := range groups {
+			ch <- tg
 		}
-		close(groups)
+		close(ch)
 	}()
-	return groups, skipCount
+	return ch
 }
 
 func isStrictModeError(err error) bool {
@@ -0,0 +1,5 @@
+CREATE TABLE pageviews (
+  id bigint unsigned NOT NULL,
+  url varchar(200) NOT NULL,
+  PRIMARY KEY (id)
+);
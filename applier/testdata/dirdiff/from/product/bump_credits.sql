@@ -0,0 +1,7 @@
+DELIMITER //
+CREATE PROCEDURE bump_credits(uid int unsigned)
+BEGIN
+  UPDATE users SET name = name WHERE id = uid;
+  SELECT uid;
+END//
+DELIMITER ;
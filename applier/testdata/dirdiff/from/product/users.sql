@@ -0,0 +1,5 @@
+CREATE TABLE users (
+  id int unsigned NOT NULL AUTO_INCREMENT,
+  name varchar(30) NOT NULL,
+  PRIMARY KEY (id)
+);
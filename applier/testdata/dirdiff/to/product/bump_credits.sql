@@ -0,0 +1,7 @@
+DELIMITER //
+CREATE PROCEDURE bump_credits(uid int unsigned)
+BEGIN
+  UPDATE users SET credits = credits + 1 WHERE id = uid;
+  SELECT uid;
+END//
+DELIMITER ;
@@ -0,0 +1,5 @@
+CREATE TABLE comments (
+  id bigint unsigned NOT NULL,
+  post_id bigint unsigned NOT NULL,
+  PRIMARY KEY (id)
+);
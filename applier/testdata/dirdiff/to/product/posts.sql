@@ -0,0 +1,5 @@
+CREATE TABLE posts (
+  id bigint unsigned NOT NULL,
+  user_id int unsigned NOT NULL,
+  PRIMARY KEY (id)
+);
@@ -0,0 +1,6 @@
+CREATE TABLE users (
+  id int unsigned NOT NULL AUTO_INCREMENT,
+  name varchar(30) NOT NULL,
+  credits decimal(9,2) DEFAULT '10.00',
+  PRIMARY KEY (id)
+);
@@ -0,0 +1,11 @@
+-- Roles and users managed on this host
+CREATE ROLE app_read, 'app_write'@'%';
+CREATE USER 'app'@'10.%';
+CREATE USER IF NOT EXISTS `admin`@`localhost`;
+
+GRANT SELECT ON product.* TO app_read;
+GRANT insert, Update, DELETE ON `product`.* TO 'app_write'@'%';
+GRANT app_read, app_write TO 'app'@'10.%';
+GRANT SELECT (id, `name`) ON product.users TO 'app'@'10.%';
+GRANT EXECUTE ON PROCEDURE product.cleanup TO 'app'@'10.%';
+GRANT ALL ON *.* TO admin@localhost WITH GRANT OPTION;
@@ -0,0 +1 @@
+CREATE USER app IDENTIFIED BY 'hunter2';
@@ -0,0 +1,2 @@
+CREATE USER app;
+DROP USER olduser;
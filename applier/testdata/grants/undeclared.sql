@@ -0,0 +1,2 @@
+CREATE USER app;
+GRANT SELECT ON product.* TO 'app'@'localhost';
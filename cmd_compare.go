@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+)
+
+func init() {
+	summary := "Compare the live schemas of two environments to each other"
+	desc := `Compares the schemas on the database instance(s) of one environment directly to
+the schemas on the database instance(s) of another environment, without
+involving the *.sql files in the filesystem. The output is a series of DDL
+commands that, if run on the first environment's instances, would cause their
+schemas to match the second environment's.
+
+Both environment names must be supplied as CLI args. For example, running
+` + "`" + `skeema compare production staging` + "`" + ` will show how to transform each production schema
+into its staging counterpart. Only the first reachable instance per directory
+is examined in each environment.
+
+Alternatively, two DSNs may be supplied instead of environment names, to
+compare two arbitrary schemas without any .skeema configuration of hosts or
+schema names. Each DSN must be of format user:password@tcp(host:port)/schema
+as used by the Go MySQL driver. Connection parameters are not permitted in the
+DSNs; use the connect-options option instead. For example, running
+` + "`" + `skeema compare 'root@tcp(db1:3306)/product' 'root@tcp(db2:3306)/product'` + "`" + ` will
+show how to transform the product schema on db1 into the one on db2.
+
+The ignore-table and ignore-schema options, as well as any options affecting
+how DDL is generated, are obtained from the configuration of the second
+environment, or the current directory's sectionless configuration if DSNs are
+supplied.
+
+An exit code of 0 will be returned if no differences were found, 1 if some
+differences were found, or 2+ if an error occurred.`
+
+	cmd := mybase.NewCommand("compare", summary, desc, CompareHandler)
+	cmd.AddOption(mybase.BoolOption("allow-unsafe", 0, false, "Permit generating ALTER or DROP operations that are potentially destructive"))
+	cmd.AddOption(mybase.BoolOption("exact-match", 0, false, "Report differences in table definitions even if they have no functional impact"))
+	cmd.AddOption(mybase.BoolOption("compare-metadata", 0, false, "For stored programs, detect changes to creation-time sql_mode or DB collation"))
+	cmd.AddOption(mybase.BoolOption("alter-validate-virtual", 0, false, "Apply a WITH VALIDATION clause to ALTER TABLEs affecting virtual columns"))
+	cmd.AddOption(mybase.StringOption("alter-lock", 0, "", `Apply a LOCK clause to all ALTER TABLEs (valid values: "none", "shared", "exclusive")`))
+	cmd.AddOption(mybase.StringOption("alter-algorithm", 0, "", `Apply an ALGORITHM clause to all ALTER TABLEs (valid values: "inplace", "copy", "instant")`))
+	cmd.AddOption(mybase.StringOption("partitioning", 0, "keep", `Specify handling of partitioning status on the database side (valid values: "keep", "remove", "modify")`))
+	cmd.AddOption(mybase.BoolOption("brief", 'q', false, "<not supported by compare command>").Hidden())
+	cmd.AddOption(mybase.BoolOption("dry-run", 0, true, "<always enabled for compare command>").Hidden())
+	cmd.AddArg("environment", "", true)
+	cmd.AddArg("to-environment", "", true)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// CompareHandler is the handler method for `skeema compare`
+func CompareHandler(cfg *mybase.Config) error {
+	fromEnv, toEnv := cfg.Get("environment"), cfg.Get("to-environment")
+	if fromEnv == toEnv {
+		return NewExitValue(CodeBadUsage, "Two different environment names or DSNs must be supplied")
+	}
+	fromDir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+
+	var pairs []*applier.SchemaPair
+	var skipCount int
+	if isDSN(fromEnv) != isDSN(toEnv) {
+		return NewExitValue(CodeBadUsage, "Either two environment names or two DSNs must be supplied, not one of each")
+	} else if isDSN(fromEnv) {
+		pair, err := applier.SchemaPairForDSNs(fromEnv, toEnv, fromDir)
+		if _, ok := err.(applier.ConfigError); ok {
+			return NewExitValue(CodeBadConfig, err.Error())
+		} else if err != nil {
+			return NewExitValue(CodeBadInput, err.Error())
+		}
+		pairs = []*applier.SchemaPair{pair}
+	} else {
+		toDir, err := fs.ParseDir(".", configForEnvironment(cfg, toEnv))
+		if err != nil {
+			return err
+		}
+		pairs, skipCount = applier.SchemaPairsForDirs(fromDir, toDir, 5)
+	}
+
+	results := make([]applier.Result, 0, len(pairs))
+	for _, pair := range pairs {
+		log.Infof("Comparing %s", pair)
+		stmts, result, err := pair.Statements()
+		if _, ok := err.(applier.ConfigError); ok {
+			return NewExitValue(CodeBadConfig, err.Error())
+		} else if err != nil {
+			log.Errorf("Skipping %s: %s", pair, err)
+			skipCount++
+			continue
+		}
+		results = append(results, result)
+		if len(stmts) == 0 {
+			if !result.Differences {
+				log.Infof("%s: No differences found\n", pair)
+			}
+			continue
+		}
+		if !cfg.GetBool("quiet") {
+			fmt.Printf("-- from instance: %s, to instance: %s\n", pair.FromInstance, pair.ToInstance)
+		}
+		for _, stmt := range stmts {
+			fmt.Print(fs.AddDelimiter(stmt))
+		}
+		log.Infof("%s: compare complete\n", pair)
+	}
+	sum := applier.SumResults(results)
+	sum.SkipCount += skipCount
+
+	if sum.SkipCount+sum.UnsupportedCount == 0 {
+		if sum.Differences {
+			return NewExitValue(CodeDifferencesFound, "")
+		}
+		return nil
+	}
+	code := CodeFatalError
+	if sum.SkipCount == 0 {
+		code = CodePartialError
+	}
+	return NewExitValue(code, sum.Summary())
+}
+
+// isDSN returns true if the supplied compare arg is a DSN rather than an
+// environment name. All DSNs contain a slash before the schema name, whereas
+// environment names never do.
+func isDSN(arg string) bool {
+	return strings.Contains(arg, "/")
+}
+
+// configForEnvironment returns a new config based on cfg, which uses the
+// supplied environment name in place of the one supplied on the command-line.
+// Global option files are re-parsed, so that the section for the environment is
+// used in both global option files and the option files of dirs subsequently
+// passed the new config in fs.ParseDir. Any sources of cfg which are not option
+// files, such as those supplied by test logic, have their values carried over.
+func configForEnvironment(cfg *mybase.Config, environment string) *mybase.Config {
+	cliCopy := *cfg.CLI
+	cliCopy.ArgValues = make([]string, len(cfg.CLI.ArgValues))
+	copy(cliCopy.ArgValues, cfg.CLI.ArgValues)
+	cliCopy.ArgValues[0] = environment
+
+	otherValues := make(map[string]string)
+	for name := range cfg.CLI.Command.Options() {
+		switch cfg.Source(name).(type) {
+		case *mybase.File, *mybase.Command, *mybase.CommandLine:
+		default:
+			otherValues[name] = cfg.GetRaw(name)
+		}
+	}
+	envCfg := mybase.NewConfig(&cliCopy, mybase.SimpleSource(otherValues))
+	envCfg.IsTest = cfg.IsTest
+	envCfg.LooseFileOptions = cfg.LooseFileOptions
+	util.AddGlobalConfigFiles(envCfg)
+	return envCfg
+}
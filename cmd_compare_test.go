@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/util"
+)
+
+func TestConfigForEnvironment(t *testing.T) {
+	os.MkdirAll("fake-etc", 0777)
+	defer os.RemoveAll("fake-etc")
+	contents := "user=base\nport=3307\n\n[production]\npassword=prodpw\nconnect-options='wait_timeout=5'\n\n[staging]\nuser=stager\n"
+	if err := ioutil.WriteFile("fake-etc/skeema", []byte(contents), 0777); err != nil {
+		t.Fatalf("Unable to write fake global option file: %v", err)
+	}
+
+	fakeSource := mybase.SimpleSource(map[string]string{"temp-schema": "_compare_test"})
+	cfg := mybase.ParseFakeCLI(t, CommandSuite, "skeema compare production staging --allow-unsafe", fakeSource)
+	util.AddGlobalConfigFiles(cfg)
+	envCfg := configForEnvironment(cfg, "staging")
+
+	// The original config should still use the production section, while the
+	// new one should use the staging section, without any leftover values from
+	// the production section of the global option file
+	if cfg.Get("environment") != "production" || cfg.Get("password") != "prodpw" || cfg.Get("user") != "base" {
+		t.Errorf("Original config unexpectedly modified: environment=%s password=%s user=%s", cfg.Get("environment"), cfg.Get("password"), cfg.Get("user"))
+	}
+	if envCfg.Get("environment") != "staging" || envCfg.Get("user") != "stager" || envCfg.Get("port") != "3307" {
+		t.Errorf("Unexpected values in new config: environment=%s user=%s port=%s", envCfg.Get("environment"), envCfg.Get("user"), envCfg.Get("port"))
+	}
+	if envCfg.Changed("password") || envCfg.Changed("connect-options") {
+		t.Errorf("Options from production section leaked into new config: password=%s connect-options=%s", envCfg.Get("password"), envCfg.Get("connect-options"))
+	}
+
+	// Values from the command-line and from other non-file sources should be
+	// carried over
+	if envCfg.Get("to-environment") != "staging" || !envCfg.GetBool("allow-unsafe") || envCfg.Get("temp-schema") != "_compare_test" {
+		t.Errorf("Unexpected values in new config: to-environment=%s allow-unsafe=%t temp-schema=%s", envCfg.Get("to-environment"), envCfg.GetBool("allow-unsafe"), envCfg.Get("temp-schema"))
+	}
+}
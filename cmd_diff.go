@@ -1,7 +1,17 @@
 package main
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
 )
 
 func init() {
@@ -20,10 +30,24 @@ top of the file. If no environment name is supplied, the default is
 
 The ` + "`" + `skeema diff` + "`" + ` command is equivalent to ` + "`" + `skeema push --dry-run` + "`" + `.
 
+With --from-git-ref, no database instance is used at all. Instead, the *.sql
+files as of the supplied git revision are compared to the current *.sql files
+in the working tree, and the output shows the DDL that would transform the
+former into the latter. This requires workspace=docker, workspace=offline, or
+workspace=dedicated-host, as well as the flavor option.
+
+With --emit-migration, the DDL for each schema is also written to a new
+versioned migration file in the supplied directory, along with a corresponding
+down migration which reverts it. The file naming convention is controlled by
+the migration-format option.
+
 An exit code of 0 will be returned if no differences were found, 1 if some
 differences were found, or 2+ if an error occurred.`
 
 	cmd := mybase.NewCommand("diff", summary, desc, DiffHandler)
+	cmd.AddOption(mybase.StringOption("from-git-ref", 0, "", "Compare *.sql files to their state at this git revision, instead of to DB instances"))
+	cmd.AddOption(mybase.StringOption("emit-migration", 0, "", "Also write DDL to versioned migration files in this directory"))
+	cmd.AddOption(mybase.StringOption("migration-format", 0, "golang-migrate", `File naming convention for emit-migration (valid values: "flyway", "goose", "golang-migrate")`))
 	cmd.AddArg("environment", "production", false)
 	CommandSuite.AddSubCommand(cmd)
 	clonePushOptionsToDiff()
@@ -34,7 +58,81 @@ func DiffHandler(cfg *mybase.Config) error {
 	// We just delegate to PushHandler, forcing dry-run to be enabled
 	cfg.CLI.OptionValues["dry-run"] = "1"
 	cfg.MarkDirty()
-	return PushHandler(cfg)
+	if cfg.Changed("from-git-ref") {
+		if cfg.Changed("emit-migration") {
+			return NewExitValue(CodeBadUsage, "Options from-git-ref and emit-migration cannot be used together")
+		}
+		return diffFromGitRef(cfg)
+	}
+	var mw *applier.MigrationWriter
+	if cfg.Changed("emit-migration") {
+		format, err := cfg.GetEnum("migration-format", "flyway", "goose", "golang-migrate")
+		if err != nil {
+			return NewExitValue(CodeBadConfig, err.Error())
+		}
+		mw = applier.NewMigrationWriter(cfg.Get("emit-migration"), applier.MigrationFormat(format), time.Now())
+	}
+	return push(cfg, mw)
+}
+
+// diffFromGitRef handles `skeema diff --from-git-ref`, comparing the dir tree at
+// another git revision to the working tree, without involving any database
+// instances.
+func diffFromGitRef(cfg *mybase.Config) error {
+	ref := cfg.Get("from-git-ref")
+	tmpDir, err := ioutil.TempDir("", "skeema-git-ref")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	refPath, err := util.GitExportTree(".", ref, tmpDir)
+	if err != nil {
+		return NewExitValue(CodeBadInput, err.Error())
+	}
+	// If the current dir did not exist yet at ref, treat it as empty
+	if err := os.MkdirAll(refPath, 0755); err != nil {
+		return err
+	}
+
+	fromDir, err := fs.ParseDir(refPath, cfg)
+	if err != nil {
+		return err
+	}
+	toDir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	log.Infof("Generating diff of *.sql files at git ref %s vs working tree", ref)
+	diffs, result, err := applier.DiffDirTrees(fromDir, toDir, 5)
+	if err != nil {
+		if _, ok := err.(applier.ConfigError); ok {
+			return NewExitValue(CodeBadConfig, err.Error())
+		}
+		return err
+	}
+	for _, dd := range diffs {
+		if !cfg.GetBool("quiet") {
+			fmt.Printf("-- dir: %s\n", dd.Path)
+		}
+		if dd.SchemaName != "" {
+			fmt.Printf("USE %s;\n", tengo.EscapeIdentifier(dd.SchemaName))
+		}
+		for _, stmt := range dd.Statements {
+			fmt.Print(fs.AddDelimiter(stmt))
+		}
+	}
+
+	if result.SkipCount+result.UnsupportedCount == 0 {
+		if result.Differences {
+			return NewExitValue(CodeDifferencesFound, "")
+		}
+		return nil
+	}
+	code := CodeFatalError
+	if result.SkipCount == 0 {
+		code = CodePartialError
+	}
+	return NewExitValue(code, result.Summary())
 }
 
 // clonePushOptionsToDiff copies options from `skeema push` into `skeema diff`
@@ -57,6 +155,10 @@ func clonePushOptionsToDiff() {
 		"brief":              false,
 		"dry-run":            true,
 		"foreign-key-checks": true,
+		"canary-instances":   true,
+		"canary-wait":        true,
+		"canary-confirm":     true,
+		"rollout-batch-size": true,
 	}
 
 	diffOptions := diff.Options()
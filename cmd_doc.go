@@ -0,0 +1,150 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/schemadoc"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/skeema/workspace"
+)
+
+func init() {
+	summary := "Generate documentation of database objects from the filesystem"
+	desc := `Generates human-readable documentation of the tables and routines defined in
+each directory's *.sql files, in Markdown or HTML format. Each schema receives
+an index page listing its tables and routines, along with a page per table
+describing its columns, indexes, foreign keys, and comments. Foreign keys are
+cross-linked to the pages of the tables they reference, including tables in
+other documented schemas.
+
+Documentation is generated from the *.sql files, not from live databases. This
+command relies on accessing database instances to test the SQL DDL in a
+temporary location. See the workspace option for more information.
+
+Pages are written to subdirectories of the output-dir, mirroring the layout of
+the schema directories. Any previously generated pages for the same format in
+those subdirectories are removed. For this reason, the output-dir cannot be the
+current directory or one of its ancestors.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for workspace selection. For
+example, running ` + "`" + `skeema doc staging` + "`" + ` will apply config directives
+from the [staging] section of config files, as well as any sectionless
+directives at the top of the file. If no environment name is supplied, the
+default is "production".
+
+An exit code of 0 will be returned if documentation was generated for all
+directories, or 2+ if any errors occurred.`
+
+	cmd := mybase.NewCommand("doc", summary, desc, DocHandler)
+	cmd.AddOption(mybase.StringOption("output-dir", 0, "schemadoc", "Directory to write documentation files to"))
+	cmd.AddOption(mybase.StringOption("doc-format", 0, "markdown", `Format of documentation files (valid values: "markdown", "html")`))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// DocHandler is the handler method for `skeema doc`
+func DocHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	format, err := dir.Config.GetEnum("doc-format", "markdown", "html")
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	outputDir := dir.Config.Get("output-dir")
+	if outputDir == "" {
+		return NewExitValue(CodeBadConfig, "Option output-dir cannot be blank")
+	}
+	if outputDir, err = filepath.Abs(outputDir); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	// Previously generated pages are removed from subdirs of the output dir, so
+	// it must not contain the schema dirs themselves
+	if rel, err := filepath.Rel(outputDir, dir.Path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return NewExitValue(CodeBadConfig, "Option output-dir cannot be the current directory or one of its ancestors")
+	}
+
+	// Statements which fail to execute are omitted from the documentation, but
+	// still cause a fatal exit code. The site's output dir is not walked.
+	site := schemadoc.NewSite(outputDir, schemadoc.Format(format))
+	var failures int
+	err = walkDirs(dir, 5, func(sub *fs.Dir) error {
+		if sub.Path == site.OutputDir {
+			return errSkipDir
+		}
+		subFailures, err := docDir(sub, dir, site)
+		failures += subFailures
+		return err
+	})
+	if failures > 0 && ExitCode(err) < CodeFatalError {
+		err = NewExitValue(CodeFatalError, "")
+	}
+	count, writeErr := site.Write()
+	if writeErr != nil {
+		return NewExitValue(CodeCantCreate, "Unable to write documentation in %s: %s", outputDir, writeErr)
+	}
+	log.Infof("Wrote %s to %s", util.CountAndNoun(count, "documentation file", "documentation files"), outputDir)
+	return NewExitValue(ExitCode(err), "")
+}
+
+// docDir executes all logical schemas in dir in a workspace, and adds the
+// resulting schemas to site. Statements which fail to execute are logged as
+// errors, and omitted from the documentation; the number of such failures is
+// returned. This function does not recurse into subdirs.
+func docDir(dir, rootDir *fs.Dir, site *schemadoc.Site) (failures int, err error) {
+	if len(dir.LogicalSchemas) == 0 {
+		return 0, nil
+	}
+	log.WithField("dir", dir.RelPath()).Infof("Generating documentation for %s", dir)
+
+	wsOpts, err := workspaceOptionsForDir(dir, nil)
+	if err != nil {
+		return 0, NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	relPath, err := filepath.Rel(rootDir.Path, dir.Path)
+	if err != nil || relPath == "." {
+		relPath = ""
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	for _, logicalSchema := range dir.LogicalSchemas {
+		wsSchema, err := workspace.ExecLogicalSchema(logicalSchema, wsOpts)
+		if err != nil {
+			return failures, err
+		}
+		logStatementErrors(wsSchema.Failures)
+		failures += len(wsSchema.Failures)
+		name := docSchemaName(dir, logicalSchema)
+		subdir := relPath
+		if len(dir.LogicalSchemas) > 1 {
+			subdir = path.Join(subdir, name)
+		}
+		site.AddSchema(name, subdir, dir.RelPath(), wsSchema.Schema)
+	}
+	return failures, nil
+}
+
+// docSchemaName returns the schema name to use in documentation for a logical
+// schema in dir: the name from a USE or CREATE DATABASE statement if present,
+// otherwise the dir's schema option if it refers to a single literal schema
+// name, otherwise the dir's base name.
+func docSchemaName(dir *fs.Dir, logicalSchema *fs.LogicalSchema) string {
+	if logicalSchema.Name != "" {
+		return logicalSchema.Name
+	}
+	if dir.Config.Changed("schema") {
+		raw := dir.Config.GetRaw("schema")
+		if name := dir.Config.Get("schema"); name != "" && name != "*" && !strings.ContainsAny(name, ",`") && raw[0] != '`' {
+			return name
+		}
+	}
+	return dir.BaseName()
+}
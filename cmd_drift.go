@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+)
+
+func init() {
+	summary := "Report schema drift between DB instances and the filesystem"
+	desc := `Compares the schemas on every database instance mapped to each directory against
+the corresponding filesystem representation, and outputs a report of objects
+that are missing from an instance, extra objects that exist only on an instance,
+and objects whose definitions differ, including the schema's own default
+character set and collation. For differing objects, a unified diff of the
+instance's CREATE statement vs the filesystem's is included. The report
+also summarizes which instances disagree with each other, for directories that
+map to multiple instances.
+
+Unlike ` + "`" + `skeema diff` + "`" + `, this command does not output DDL, and always examines every
+instance returned by the host or host-wrapper options. It is intended for use in
+scheduled jobs.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for processing. For example,
+running ` + "`" + `skeema drift staging` + "`" + ` will apply config directives from the
+[staging] section of config files, as well as any sectionless directives at the
+top of the file. If no environment name is supplied, the default is
+"production".
+
+An exit code of 0 will be returned if no drift was found, 1 if some drift was
+found, or 2+ if an error occurred for any instance, even if drift was also
+found elsewhere.`
+
+	cmd := mybase.NewCommand("drift", summary, desc, DriftHandler)
+	cmd.AddOption(mybase.StringOption("report-format", 0, "text", `Format of drift report written to STDOUT (valid values: "text", "json")`))
+	cmd.AddOption(mybase.BoolOption("exact-match", 0, false, "Report differences in *.sql table definitions even if they have no functional impact"))
+	cmd.AddOption(mybase.BoolOption("compare-metadata", 0, false, "For stored programs, detect changes to creation-time sql_mode or DB collation"))
+	cmd.AddOption(mybase.StringOption("partitioning", 0, "keep", `Specify handling of partitioning status on the database side (valid values: "keep", "remove", "modify")`))
+	cmd.AddOption(mybase.BoolOption("first-only", 0, false, "<not supported by drift command>").Hidden())
+	cmd.AddOption(mybase.BoolOption("allow-unsafe", 0, true, "<always enabled for drift command>").Hidden())
+	cmd.AddOption(mybase.BoolOption("alter-validate-virtual", 0, false, "<not supported by drift command>").Hidden())
+	cmd.AddOption(mybase.StringOption("alter-lock", 0, "", "<not supported by drift command>").Hidden())
+	cmd.AddOption(mybase.StringOption("alter-algorithm", 0, "", "<not supported by drift command>").Hidden())
+	cmd.AddOption(mybase.BoolOption("brief", 'q', false, "<not supported by drift command>").Hidden())
+	cmd.AddOption(mybase.BoolOption("dry-run", 0, true, "<always enabled for drift command>").Hidden())
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// DriftHandler is the handler method for `skeema drift`
+func DriftHandler(cfg *mybase.Config) error {
+	// Every instance is always examined, even if an option file sets first-only
+	// for use by diff and push
+	cfg.CLI.OptionValues["first-only"] = "0"
+	cfg.MarkDirty()
+
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	reportFormat, err := dir.Config.GetEnum("report-format", "text", "json")
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	targets, skipCount := applier.TargetsForDir(dir, 5)
+	reports := make([]*applier.DriftReport, 0, len(targets))
+	for _, t := range targets {
+		log.WithFields(log.Fields{"instance": t.Instance.String(), "schema": t.SchemaName, "dir": t.Dir.RelPath()}).Infof("Checking %s %s for drift from %s", t.Instance, t.SchemaName, t.Dir)
+		report, err := applier.DriftForTarget(t)
+		if _, ok := err.(applier.ConfigError); ok {
+			return NewExitValue(CodeBadConfig, err.Error())
+		} else if err != nil {
+			log.Errorf("Skipping %s %s for %s: %s", t.Instance, t.SchemaName, t.Dir, err)
+			skipCount++
+			continue
+		}
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Dir != reports[j].Dir {
+			return reports[i].Dir < reports[j].Dir
+		} else if reports[i].Instance != reports[j].Instance {
+			return reports[i].Instance < reports[j].Instance
+		}
+		return reports[i].Schema < reports[j].Schema
+	})
+	disagreements := applier.FindDisagreements(reports)
+
+	var driftCount int
+	for _, report := range reports {
+		if report.HasDrift() {
+			driftCount++
+		}
+	}
+	if reportFormat == "json" {
+		err = writeDriftJSON(reports, disagreements)
+	} else {
+		writeDriftText(reports, disagreements)
+	}
+	if err != nil {
+		return err
+	}
+
+	if skipCount > 0 {
+		return NewExitValue(CodeFatalError, "Skipped %s due to errors", util.CountAndNoun(skipCount, "operation", "operations"))
+	} else if driftCount > 0 {
+		return NewExitValue(CodeDifferencesFound, "Found drift in %s", util.CountAndNoun(driftCount, "schema", "schemas"))
+	}
+	return nil
+}
+
+func writeDriftJSON(reports []*applier.DriftReport, disagreements []applier.Disagreement) error {
+	output := struct {
+		Reports       []*applier.DriftReport `json:"reports"`
+		Disagreements []applier.Disagreement `json:"disagreements"`
+	}{
+		Reports:       reports,
+		Disagreements: disagreements,
+	}
+	if output.Disagreements == nil {
+		output.Disagreements = []applier.Disagreement{}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(output)
+}
+
+func writeDriftText(reports []*applier.DriftReport, disagreements []applier.Disagreement) {
+	for _, report := range reports {
+		fmt.Printf("-- instance: %s, schema: %s, dir: %s\n", report.Instance, report.Schema, report.Dir)
+		if !report.HasDrift() {
+			fmt.Print("-- no drift\n\n")
+			continue
+		}
+		for _, obj := range report.Missing {
+			fmt.Printf("-- missing from instance: %s\n", obj)
+		}
+		for _, obj := range report.Extra {
+			fmt.Printf("-- extra on instance: %s\n", obj)
+		}
+		for _, obj := range report.Differing {
+			fmt.Printf("-- differing: %s\n", obj.Object)
+		}
+		for _, obj := range report.Differing {
+			fmt.Print(obj.Diff)
+		}
+		fmt.Println()
+	}
+	for _, d := range disagreements {
+		fmt.Printf("-- instances disagree on schema %s for dir %s:\n", d.Schema, d.Dir)
+		for n, instances := range d.Variants {
+			fmt.Printf("--   variant %d: %s\n", n+1, strings.Join(instances, ", "))
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/erd"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Output an entity-relationship diagram of the filesystem schema"
+	desc := `Outputs an entity-relationship diagram of the tables defined in the current
+directory's *.sql files to STDOUT, showing each table's columns along with the
+foreign keys between tables. The diagram may be written in Graphviz DOT,
+Mermaid, or PlantUML syntax, as selected by the erd-format option.
+
+The diagram is generated from the *.sql files, not from a live database. This
+command relies on accessing database instances to test the SQL DDL in a
+temporary location. See the workspace option for more information.
+
+Only the current directory is examined; subdirectories are not walked. If the
+directory defines multiple schemas, only the first one is diagrammed.
+
+With the highlight-changes option, tables which would be created or altered by
+` + "`" + `skeema push` + "`" + ` are highlighted, by comparing the filesystem against the schema on
+the directory's first host.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for processing. For example,
+running ` + "`" + `skeema erd staging` + "`" + ` will apply config directives from the
+[staging] section of config files, as well as any sectionless directives at the
+top of the file. If no environment name is supplied, the default is
+"production".
+
+An exit code of 0 will be returned if the diagram was generated, or 2+ if any
+errors occurred.`
+
+	cmd := mybase.NewCommand("erd", summary, desc, ERDHandler)
+	cmd.AddOption(mybase.StringOption("erd-format", 0, "dot", `Diagram syntax (valid values: "dot", "mermaid", "plantuml")`))
+	cmd.AddOption(mybase.StringOption("table-filter", 0, "", "Only include tables with names matching regex"))
+	cmd.AddOption(mybase.BoolOption("collapse-columns", 0, false, "Only show table names and relationships, omitting columns"))
+	cmd.AddOption(mybase.BoolOption("highlight-changes", 0, false, "Highlight tables which differ between the filesystem and the first host"))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// ERDHandler is the handler method for `skeema erd`
+func ERDHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	} else if dir.ParseError != nil {
+		return NewExitValue(CodeBadConfig, dir.ParseError.Error())
+	} else if len(dir.LogicalSchemas) == 0 {
+		return NewExitValue(CodeBadConfig, "No schema is defined in %s", dir)
+	} else if len(dir.LogicalSchemas) > 1 {
+		log.Warnf("%s defines multiple schemas; only the first will be diagrammed", dir)
+	}
+
+	var opts erd.Options
+	format, err := dir.Config.GetEnum("erd-format", "dot", "mermaid", "plantuml")
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	opts.Format = erd.Format(format)
+	opts.CollapseColumns = dir.Config.GetBool("collapse-columns")
+	if opts.OnlyTable, err = dir.Config.GetRegexp("table-filter"); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	if opts.IgnoreTable, err = dir.Config.GetRegexp("ignore-table"); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	// Highlighting changes requires the first defined instance, which may then
+	// also be used for the workspace
+	var inst *tengo.Instance
+	highlight := dir.Config.GetBool("highlight-changes")
+	if highlight {
+		if inst, err = dir.FirstInstance(); err != nil {
+			return NewExitValue(CodeBadConfig, err.Error())
+		} else if inst == nil {
+			return NewExitValue(CodeBadConfig, "Option highlight-changes requires a host to be configured for %s", dir)
+		}
+	}
+	wsOpts, err := workspaceOptionsForDir(dir, inst)
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	logicalSchema := dir.LogicalSchemas[0]
+	wsSchema, err := workspace.ExecLogicalSchema(logicalSchema, wsOpts)
+	if err != nil {
+		return err
+	}
+	logStatementErrors(wsSchema.Failures)
+	if len(wsSchema.Failures) > 0 {
+		return NewExitValue(CodeFatalError, "Unable to generate diagram due to %s", util.CountAndNoun(len(wsSchema.Failures), "SQL error", "SQL errors"))
+	}
+
+	if highlight {
+		if opts.Highlight, err = erdChanges(dir, inst, wsSchema.Schema); err != nil {
+			return err
+		}
+	}
+	fmt.Print(erd.Render(docSchemaName(dir, logicalSchema), wsSchema.Schema, opts))
+	return nil
+}
+
+// erdChanges returns a map of table name to diff type, for tables in dirSchema
+// which would be created or altered in order to bring the corresponding schema
+// on inst in line with the dir.
+func erdChanges(dir *fs.Dir, inst *tengo.Instance, dirSchema *tengo.Schema) (map[string]tengo.DiffType, error) {
+	schemaNames, err := dir.SchemaNames(inst)
+	if err != nil {
+		return nil, NewExitValue(CodeBadConfig, err.Error())
+	} else if len(schemaNames) == 0 {
+		return nil, NewExitValue(CodeBadConfig, "No schema name for %s on %s", dir, inst)
+	}
+	instSchema, err := inst.Schema(schemaNames[0])
+	if err == sql.ErrNoRows {
+		instSchema = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	mods := tengo.StatementModifiers{
+		NextAutoInc:  tengo.NextAutoIncIgnore,
+		AllowUnsafe:  true,
+		Partitioning: tengo.PartitioningKeep,
+		Flavor:       inst.Flavor(),
+	}
+	changes := make(map[string]tengo.DiffType)
+	for _, objDiff := range tengo.NewSchemaDiff(instSchema, dirSchema).ObjectDiffs() {
+		key := objDiff.ObjectKey()
+		if key.Type != tengo.ObjectTypeTable || objDiff.DiffType() == tengo.DiffTypeDrop {
+			continue
+		}
+		if stmt, err := objDiff.Statement(mods); stmt != "" || err != nil {
+			changes[key.Name] = objDiff.DiffType()
+		}
+	}
+	return changes, nil
+}
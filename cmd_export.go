@@ -0,0 +1,125 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/schemaexport"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/skeema/workspace"
+)
+
+func init() {
+	summary := "Export schemas as structured JSON or YAML"
+	desc := `Writes a structured representation of the tables and routines of each
+directory's schemas to STDOUT, in JSON or YAML format. This is intended for
+consumption by other tools, such as code generators or data catalogs.
+
+By default, schemas are obtained from the *.sql files of each directory. This
+relies on accessing database instances to test the SQL DDL in a temporary
+location; see the workspace option for more information. Alternatively, with
+--export-source=instance, schemas are introspected from the first host of each
+directory.
+
+The output is a single document containing all exported schemas. Its structure
+is described in the documentation for the export-format option, and includes a
+formatVersion field which is incremented whenever a backwards-incompatible
+change is made to the structure.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for processing. For example,
+running ` + "`" + `skeema export staging` + "`" + ` will apply config directives from the
+[staging] section of config files, as well as any sectionless directives at the
+top of the file. If no environment name is supplied, the default is
+"production".
+
+An exit code of 0 will be returned if all schemas were exported, or 2+ if any
+errors occurred.`
+
+	cmd := mybase.NewCommand("export", summary, desc, ExportHandler)
+	cmd.AddOption(mybase.StringOption("export-format", 0, "json", `Format of output (valid values: "json", "yaml")`))
+	cmd.AddOption(mybase.StringOption("export-source", 0, "filesystem", `Where to obtain schemas from (valid values: "filesystem", "instance")`))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// ExportHandler is the handler method for `skeema export`
+func ExportHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+	format, err := dir.Config.GetEnum("export-format", "json", "yaml")
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	source, err := dir.Config.GetEnum("export-source", "filesystem", "instance")
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	doc := schemaexport.NewDocument(source)
+	err = walkDirs(dir, 5, func(dir *fs.Dir) error {
+		return exportDir(dir, doc)
+	})
+	if writeErr := doc.Write(os.Stdout, schemaexport.Format(format)); writeErr != nil {
+		return writeErr
+	}
+	log.Infof("Exported %s", util.CountAndNoun(len(doc.Schemas), "schema", "schemas"))
+	return NewExitValue(ExitCode(err), "")
+}
+
+// exportDir adds the schemas of dir to doc, obtaining them from either the
+// dir's *.sql files or its first instance, depending on configuration. This
+// function does not recurse into subdirs.
+func exportDir(dir *fs.Dir, doc *schemaexport.Document) error {
+	if len(dir.LogicalSchemas) == 0 {
+		return nil
+	}
+
+	if doc.Source == "instance" {
+		inst, err := dir.FirstInstance()
+		if err != nil {
+			return NewExitValue(CodeBadConfig, err.Error())
+		} else if inst == nil {
+			return NewExitValue(CodeBadConfig, "No host defined for environment %q", dir.Config.Get("environment"))
+		}
+		schemaNames, err := dir.SchemaNames(inst)
+		if err != nil {
+			return NewExitValue(CodeBadConfig, err.Error())
+		}
+		for _, name := range schemaNames {
+			schema, err := inst.Schema(name)
+			if err == sql.ErrNoRows {
+				log.Warnf("Skipping schema %s on %s: schema does not exist", name, inst)
+				continue
+			} else if err != nil {
+				return err
+			}
+			log.WithFields(log.Fields{"instance": inst.String(), "schema": name, "dir": dir.RelPath()}).Infof("Exporting %s %s", inst, name)
+			doc.AddSchema(name, dir.RelPath(), inst.String(), schema)
+		}
+		return nil
+	}
+
+	wsOpts, err := workspaceOptionsForDir(dir, nil)
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	for _, logicalSchema := range dir.LogicalSchemas {
+		wsSchema, err := workspace.ExecLogicalSchema(logicalSchema, wsOpts)
+		if err != nil {
+			return err
+		}
+		logStatementErrors(wsSchema.Failures)
+		if len(wsSchema.Failures) > 0 {
+			return NewExitValue(CodeFatalError, "%s prevented export", util.CountAndNoun(len(wsSchema.Failures), "SQL error", "SQL errors"))
+		}
+		log.WithField("dir", dir.RelPath()).Infof("Exporting %s", dir)
+		doc.AddSchema(docSchemaName(dir, logicalSchema), dir.RelPath(), "", wsSchema.Schema)
+	}
+	return nil
+}
@@ -1,14 +1,11 @@
 package main
 
 import (
-	"strings"
-
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/dumper"
 	"github.com/skeema/skeema/fs"
 	"github.com/skeema/skeema/workspace"
-	"github.com/skeema/tengo"
 )
 
 func init() {
@@ -43,47 +40,20 @@ func FormatHandler(cfg *mybase.Config) error {
 		return err
 	}
 
-	// formatWalker returns the "worst" (highest) exit code it encounters. We care
+	// walkDirs returns the "worst" (highest) exit code it encounters. We care
 	// about the exit code, but not the error message, since any error will already
 	// have been logged. (Multiple errors may have been encountered along the way,
 	// and it's simpler to log them when they occur, rather than needlessly
 	// collecting them.)
-	err = formatWalker(dir, 5)
-	return NewExitValue(ExitCode(err), "")
-}
-
-func formatWalker(dir *fs.Dir, maxDepth int) error {
-	if dir.ParseError != nil {
-		log.Warnf("Skipping %s: %s", dir.Path, dir.ParseError)
-		return NewExitValue(CodeBadConfig, "")
-	}
-
-	if dir.Config.GetBool("write") {
-		log.Infof("Reformatting %s", dir)
-	} else {
-		log.Infof("Checking format of %s", dir)
-	}
-	result := formatDir(dir)
-	if ExitCode(result) > CodeDifferencesFound {
-		log.Errorf("Skipping %s: %s", dir, result)
-		return result // don't walk subdirs if something fatal happened here
-	}
-
-	subdirs, err := dir.Subdirs()
-	if err != nil {
-		log.Errorf("Cannot list subdirs of %s: %s", dir, err)
-		return err
-	} else if len(subdirs) > 0 && maxDepth <= 0 {
-		log.Errorf("Not walking subdirs of %s: max depth reached", dir)
-		return result
-	}
-	for _, sub := range subdirs {
-		err := formatWalker(sub, maxDepth-1)
-		if ExitCode(err) > ExitCode(result) {
-			result = err
+	err = walkDirs(dir, 5, func(dir *fs.Dir) error {
+		if dir.Config.GetBool("write") {
+			log.WithField("dir", dir.RelPath()).Infof("Reformatting %s", dir)
+		} else {
+			log.WithField("dir", dir.RelPath()).Infof("Checking format of %s", dir)
 		}
-	}
-	return result
+		return formatDir(dir)
+	})
+	return NewExitValue(ExitCode(err), "")
 }
 
 // formatDir reformats SQL statements in all logical schemas in dir. This
@@ -93,18 +63,14 @@ func formatDir(dir *fs.Dir) error {
 	if err != nil {
 		return NewExitValue(CodeBadConfig, err.Error())
 	}
+	style, err := dumper.ParseStyle(dir.Config.Get("format-style"))
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
 
-	// Get workspace options for dir. This involves connecting to the first
-	// defined instance, unless configured to use local Docker.
 	var wsOpts workspace.Options
 	if len(dir.LogicalSchemas) > 0 {
-		var inst *tengo.Instance
-		if wsType, _ := dir.Config.GetEnum("workspace", "temp-schema", "docker"); wsType != "docker" || !dir.Config.Changed("flavor") {
-			if inst, err = dir.FirstInstance(); err != nil {
-				return NewExitValue(CodeBadConfig, err.Error())
-			}
-		}
-		if wsOpts, err = workspace.OptionsForDir(dir, inst); err != nil {
+		if wsOpts, err = workspaceOptionsForDir(dir, nil); err != nil {
 			return NewExitValue(CodeBadConfig, err.Error())
 		}
 	}
@@ -115,16 +81,14 @@ func formatDir(dir *fs.Dir) error {
 		if err != nil {
 			return err
 		}
-		for _, stmtErr := range wsSchema.Failures {
-			message := strings.Replace(stmtErr.Err.Error(), "Error executing DDL in workspace: ", "", 1)
-			log.Errorf("%s: %s", stmtErr.Location(), message)
-			totalReformatCount++
-		}
+		logStatementErrors(wsSchema.Failures)
+		totalReformatCount += len(wsSchema.Failures)
 
 		dumpOpts := dumper.Options{
 			IncludeAutoInc: true,
 			IgnoreTable:    ignoreTable,
 			CountOnly:      !dir.Config.GetBool("write"),
+			Style:          style,
 		}
 		dumpOpts.IgnoreKeys(wsSchema.FailedKeys())
 		reformatCount, err := dumper.DumpSchema(wsSchema.Schema, dir, dumpOpts)
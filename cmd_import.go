@@ -0,0 +1,148 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/dumper"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/importer"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Convert migration files from another tool into *.sql files"
+	desc := `Replays a directory of migration files from another schema management tool,
+such as Flyway, goose, or golang-migrate, and writes the resulting schema to
+the current directory as *.sql files. This command should be run from a
+directory which defines a schema, for example one created by ` + "`" + `skeema init` + "`" + `.
+Any existing *.sql files in the directory are updated to match the result of
+the migrations.
+
+Migrations are applied in order of the version number at the start of each file
+name, such as "V1_2__add_users.sql" or "20200101120000_add_users.up.sql".
+Files without a version number are applied afterwards in order of file name,
+followed by Flyway repeatable migrations. Down migrations, Flyway undo
+migrations, and the down sections of goose migrations are skipped.
+
+Only DDL statements (CREATE, ALTER, DROP, and RENAME) are applied; other
+statements, such as DML, are skipped. Migrations are applied in a temporary
+location; see the workspace option for more information. A workspace of
+"offline" cannot be used with this command. DDL affecting anything other than
+tables, indexes, procedures, and functions, such as databases, users, or views,
+is not supported, and neither are statements referring to objects in other
+schemas.
+
+If a statement fails, the rest of its migration file is skipped, and the
+failure is reported. Subsequent migrations are still attempted.
+
+You may optionally pass an environment name as a CLI option, after the
+migration directory. This will affect which section of .skeema config files is
+used for workspace selection. If no environment name is supplied, the default
+is "production".
+
+An exit code of 0 will be returned if all migrations were applied, 1 if some
+migrations could not be fully applied, or 2+ if a fatal error occurred.`
+
+	cmd := mybase.NewCommand("import", summary, desc, ImportHandler)
+	cmd.AddArg("migration-dir", "", true)
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// ImportHandler is the handler method for `skeema import`
+func ImportHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	} else if dir.ParseError != nil {
+		return NewExitValue(CodeBadConfig, dir.ParseError.Error())
+	} else if !dir.HasSchema() {
+		return NewExitValue(CodeBadConfig, "The import command must be run from a directory which defines a schema")
+	}
+	migrationDir, err := filepath.Abs(cfg.Get("migration-dir"))
+	if err != nil {
+		return NewExitValue(CodeBadUsage, err.Error())
+	} else if migrationDir == dir.Path {
+		return NewExitValue(CodeBadUsage, "The migration directory must differ from the current directory")
+	}
+	migrations, err := importer.Load(migrationDir)
+	if err != nil {
+		return NewExitValue(CodeNoInput, "Unable to read migrations: %s", err)
+	} else if len(migrations) == 0 {
+		return NewExitValue(CodeNoInput, "No migration files found in %s", migrationDir)
+	}
+
+	dumpOpts := dumper.Options{
+		Relocate: dir.Config.Changed("layout"),
+	}
+	if dumpOpts.IgnoreTable, err = dir.Config.GetRegexp("ignore-table"); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	if dumpOpts.Layout, err = dir.FileLayout(); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	if dumpOpts.Style, err = dumper.ParseStyle(dir.Config.Get("format-style")); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	wsOpts, err := workspaceOptionsForDir(dir, nil)
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	} else if wsOpts.Type == workspace.TypeOffline {
+		return NewExitValue(CodeBadConfig, "The import command cannot be used with workspace=offline")
+	}
+
+	log.Infof("Applying %s from %s", util.CountAndNoun(len(migrations), "migration", "migrations"), migrationDir)
+	schema, failures, err := importMigrations(migrations, wsOpts)
+	if err != nil {
+		return err
+	}
+	for _, failure := range failures {
+		log.Errorf("Could not apply migration %s: %s", failure.Migration, failure)
+	}
+
+	count, err := dumper.DumpSchema(schema, dir, dumpOpts)
+	if err != nil {
+		return NewExitValue(CodeCantCreate, "Unable to write in %s: %s", dir, err)
+	}
+	log.Infof("Updated %s in %s", util.CountAndNoun(count, "object", "objects"), dir)
+	writeLogSpacer()
+	if len(failures) > 0 {
+		return NewExitValue(CodePartialError, "%s could not be fully applied", util.CountAndNoun(len(failures), "migration", "migrations"))
+	}
+	return nil
+}
+
+// importMigrations applies migrations in a new workspace, and returns the
+// resulting schema along with any migrations which could not be applied.
+func importMigrations(migrations []*importer.Migration, wsOpts workspace.Options) (schema *tengo.Schema, failures []*importer.Failure, err error) {
+	ws, err := workspace.New(wsOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if cleanupErr := ws.Cleanup(); err == nil {
+			err = cleanupErr
+		}
+	}()
+
+	// Disable FK checks, since migrations may have done so in statements which
+	// are not applied
+	params := []string{"foreign_key_checks=0"}
+	if wsOpts.SkipBinlog {
+		params = append(params, "sql_log_bin=0")
+	}
+	if wsOpts.SessionParams != "" {
+		params = append(params, wsOpts.SessionParams)
+	}
+	if failures, err = importer.Apply(migrations, ws, strings.Join(params, "&")); err != nil {
+		return nil, nil, err
+	}
+	schema, err = ws.IntrospectSchema()
+	return schema, failures, err
+}
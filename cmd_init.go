@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"path"
 	"strconv"
 	"strings"
@@ -27,7 +26,10 @@ which section of .skeema config files the host and schema names are written to.
 For example, running ` + "`" + `skeema init staging` + "`" + ` will add config directives to the
 [staging] section of config files. If no environment name is supplied, the
 default is "production", so directives will be written to the [production]
-section of the file.`
+section of the file.
+
+With --object-filter or --object-types, only the matching objects are written
+to *.sql files.`
 
 	cmd := mybase.NewCommand("init", summary, desc, InitHandler)
 	cmd.AddOption(mybase.StringOption("host", 'h', "", "Database hostname or IP address"))
@@ -38,6 +40,8 @@ section of the file.`
 	cmd.AddOption(mybase.BoolOption("include-auto-inc", 0, false, "Include starting auto-inc values in table files"))
 	cmd.AddOption(mybase.StringOption("ignore-schema", 0, "", "Ignore schemas that match regex"))
 	cmd.AddOption(mybase.StringOption("ignore-table", 0, "", "Ignore tables that match regex"))
+	cmd.AddOption(mybase.StringOption("object-filter", 0, "", "Only write objects with these names (comma-separated list; * wildcards permitted)"))
+	cmd.AddOption(mybase.StringOption("object-types", 0, "", "Only write objects of these types (comma-separated list of: table, procedure, function)"))
 	cmd.AddArg("environment", "production", false)
 	CommandSuite.AddSubCommand(cmd)
 }
@@ -58,6 +62,16 @@ func InitHandler(cfg *mybase.Config) error {
 		return NewExitValue(CodeBadConfig, "Environment name \"%s\" is invalid", environment)
 	}
 
+	if _, err := fs.ParseFileLayout(cfg.Get("layout")); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	if _, err := dumper.ParseStyle(cfg.Get("format-style")); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	if _, err := objectFilterForConfig(cfg); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
 	hostDir, err := createHostDir(cfg)
 	if err != nil {
 		return err
@@ -157,6 +171,14 @@ func createHostOptionFile(cfg *mybase.Config, hostDir *fs.Dir, inst *tengo.Insta
 		}
 	}
 
+	// The file layout and format style are placed outside of any named
+	// section/environment, since the dir's files are shared between environments
+	for _, persistOpt := range []string{"layout", "format-style"} {
+		if cfg.OnCLI(persistOpt) {
+			hostOptionFile.SetOptionValue("", persistOpt, cfg.Get(persistOpt))
+		}
+	}
+
 	// If a schema name was supplied, a "flat" dir is created that represents both
 	// the host and the schema. The schema name is placed outside of any named
 	// section/environment since the default assumption is that schema names match
@@ -230,7 +252,7 @@ func PopulateSchemaDir(s *tengo.Schema, parentDir *fs.Dir, makeSubdir bool) erro
 	} else {
 		dir = parentDir
 	}
-	log.Infof("Populating %s", dir)
+	log.WithFields(log.Fields{"schema": s.Name, "dir": dir.RelPath()}).Infof("Populating %s", dir)
 
 	dumpOpts := dumper.Options{
 		IncludeAutoInc: dir.Config.GetBool("include-auto-inc"),
@@ -239,10 +261,21 @@ func PopulateSchemaDir(s *tengo.Schema, parentDir *fs.Dir, makeSubdir bool) erro
 	if err != nil {
 		return NewExitValue(CodeBadConfig, err.Error())
 	}
+	if dumpOpts.Layout, err = dir.FileLayout(); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	if dumpOpts.Style, err = dumper.ParseStyle(dir.Config.Get("format-style")); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	if filter, err := objectFilterForConfig(dir.Config); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	} else if filter != nil {
+		dumpOpts.OnlyKeys(filter.filterKeys(allObjectKeys(s, nil)))
+	}
 
 	if _, err = dumper.DumpSchema(s, dir, dumpOpts); err != nil {
 		return NewExitValue(CodeCantCreate, "Unable to write in %s: %s", dir, err)
 	}
-	os.Stderr.WriteString("\n")
+	writeLogSpacer()
 	return nil
 }
@@ -8,8 +8,8 @@ import (
 	"github.com/skeema/skeema/dumper"
 	"github.com/skeema/skeema/fs"
 	"github.com/skeema/skeema/linter"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/skeema/workspace"
-	"github.com/skeema/tengo"
 )
 
 func init() {
@@ -61,20 +61,20 @@ func LintHandler(cfg *mybase.Config) error {
 			}
 		}
 		return NewExitValue(exitCode, "Skipped %s due to fatal errors",
-			countAndNoun(len(result.Exceptions), "operation", "operations"),
+			util.CountAndNoun(len(result.Exceptions), "operation", "operations"),
 		)
 	case result.ErrorCount > 0 && result.WarningCount > 0:
 		return NewExitValue(CodeFatalError, "Found %s and %s",
-			countAndNoun(result.ErrorCount, "error", "errors"),
-			countAndNoun(result.WarningCount, "warning", "warnings"),
+			util.CountAndNoun(result.ErrorCount, "error", "errors"),
+			util.CountAndNoun(result.WarningCount, "warning", "warnings"),
 		)
 	case result.ErrorCount > 0:
 		return NewExitValue(CodeFatalError, "Found %s",
-			countAndNoun(result.ErrorCount, "error", "errors"),
+			util.CountAndNoun(result.ErrorCount, "error", "errors"),
 		)
 	case result.WarningCount > 0:
 		return NewExitValue(CodePartialError, "Found %s",
-			countAndNoun(result.WarningCount, "warning", "warnings"),
+			util.CountAndNoun(result.WarningCount, "warning", "warnings"),
 		)
 	case result.ReformatCount > 0:
 		return NewExitValue(CodeDifferencesFound, "")
@@ -82,43 +82,102 @@ func LintHandler(cfg *mybase.Config) error {
 	return nil
 }
 
+// lintNode tracks the linting of a single dir, as part of a walk of a dir
+// tree in which multiple dirs may be linted concurrently.
+type lintNode struct {
+	dir       *fs.Dir
+	parent    *lintNode
+	subdirErr error
+	result    *linter.Result
+	skipped   bool          // true if an ancestor had a fatal error
+	done      chan struct{} // closed once result or skipped is set
+}
+
+// lintWalker lints dir and its subdirs, up to maxDepth levels deep. With
+// workspace=docker and docker-containers above 1, multiple dirs are linted
+// concurrently, but output is still logged in the order of the dir tree.
 func lintWalker(dir *fs.Dir, maxDepth int) *linter.Result {
-	if dir.ParseError != nil {
-		log.Error(fmt.Sprintf("Skipping directory %s due to error: %s", dir.RelPath(), dir.ParseError))
-		return linter.BadConfigResult(dir, dir.ParseError)
-	}
-	log.Infof("Linting %s", dir)
-	result := lintDir(dir)
-	for _, err := range result.Exceptions {
-		log.Error(fmt.Sprintf("Skipping directory %s due to error: %s", dir.RelPath(), err))
-	}
-	for _, annotation := range result.Annotations {
-		annotation.Log()
+	concurrency := 1
+	if wsType, _ := dir.Config.GetEnum("workspace", "temp-schema", "docker", "offline", "dedicated-host"); wsType == "docker" {
+		if containers, err := dir.Config.GetInt("docker-containers"); err == nil && containers > 1 {
+			concurrency = containers
+		}
 	}
-	for _, dl := range result.DebugLogs {
-		log.Debug(dl)
+	nodes := lintNodes(dir, nil, maxDepth)
+	sem := make(chan struct{}, concurrency)
+	for _, node := range nodes {
+		go func(node *lintNode) {
+			defer close(node.done)
+			// Don't lint subdirs if there was something fatally wrong with an ancestor
+			if node.parent != nil {
+				<-node.parent.done
+				if node.parent.skipped || len(node.parent.result.Exceptions) > 0 {
+					node.skipped = true
+					return
+				}
+			}
+			if node.dir.ParseError != nil {
+				node.result = linter.BadConfigResult(node.dir, node.dir.ParseError)
+				return
+			}
+			sem <- struct{}{}
+			node.result = lintDir(node.dir)
+			<-sem
+		}(node)
 	}
 
-	// Don't recurse into subdirs if there was something fatally wrong
-	if len(result.Exceptions) > 0 {
-		return result
+	result := &linter.Result{}
+	for _, node := range nodes {
+		<-node.done
+		if node.skipped {
+			continue
+		}
+		if node.dir.ParseError != nil {
+			log.Error(fmt.Sprintf("Skipping directory %s due to error: %s", node.dir.RelPath(), node.dir.ParseError))
+			result.Merge(node.result)
+			continue
+		}
+		log.WithField("dir", node.dir.RelPath()).Infof("Linting %s", node.dir)
+		for _, err := range node.result.Exceptions {
+			log.Error(fmt.Sprintf("Skipping directory %s due to error: %s", node.dir.RelPath(), err))
+		}
+		for _, annotation := range node.result.Annotations {
+			annotation.Log()
+		}
+		for _, dl := range node.result.DebugLogs {
+			log.Debug(dl)
+		}
+		result.Merge(node.result)
+		if node.subdirErr != nil && len(node.result.Exceptions) == 0 {
+			log.Error(node.subdirErr)
+			result.Fatal(node.subdirErr)
+		}
 	}
+	return result
+}
 
-	var subdirErr error
+// lintNodes returns a lintNode for dir and each of its subdirs, recursively up
+// to maxDepth levels deep, ordered such that each dir precedes its subdirs.
+func lintNodes(dir *fs.Dir, parent *lintNode, maxDepth int) []*lintNode {
+	node := &lintNode{
+		dir:    dir,
+		parent: parent,
+		done:   make(chan struct{}),
+	}
+	nodes := []*lintNode{node}
+	if dir.ParseError != nil {
+		return nodes
+	}
 	if subdirs, err := dir.Subdirs(); err != nil {
-		subdirErr = fmt.Errorf("Cannot list subdirs of %s: %s", dir, err)
+		node.subdirErr = fmt.Errorf("Cannot list subdirs of %s: %s", dir, err)
 	} else if len(subdirs) > 0 && maxDepth <= 0 {
-		subdirErr = fmt.Errorf("Not walking subdirs of %s: max depth reached", dir)
+		node.subdirErr = fmt.Errorf("Not walking subdirs of %s: max depth reached", dir)
 	} else {
 		for _, sub := range subdirs {
-			result.Merge(lintWalker(sub, maxDepth-1))
+			nodes = append(nodes, lintNodes(sub, node, maxDepth-1)...)
 		}
 	}
-	if subdirErr != nil {
-		log.Error(subdirErr)
-		result.Fatal(subdirErr)
-	}
-	return result
+	return nodes
 }
 
 // lintDir lints all logical schemas in dir, optionally also reformatting
@@ -130,20 +189,16 @@ func lintDir(dir *fs.Dir) *linter.Result {
 		return linter.BadConfigResult(dir, err)
 	}
 
-	// Get workspace options for dir. This involves connecting to the first
-	// defined instance, unless configured to use local Docker.
 	var wsOpts workspace.Options
 	if len(dir.LogicalSchemas) > 0 {
-		var inst *tengo.Instance
-		if wsType, _ := dir.Config.GetEnum("workspace", "temp-schema", "docker"); wsType != "docker" || !dir.Config.Changed("flavor") {
-			if inst, err = dir.FirstInstance(); err != nil {
-				return linter.BadConfigResult(dir, err)
-			}
-		}
-		if wsOpts, err = workspace.OptionsForDir(dir, inst); err != nil {
+		if wsOpts, err = workspaceOptionsForDir(dir, nil); err != nil {
 			return linter.BadConfigResult(dir, err)
 		}
 	}
+	style, err := dumper.ParseStyle(dir.Config.Get("format-style"))
+	if err != nil {
+		return linter.BadConfigResult(dir, err)
+	}
 
 	result := &linter.Result{}
 	for _, logicalSchema := range dir.LogicalSchemas {
@@ -162,6 +217,7 @@ func lintDir(dir *fs.Dir) *linter.Result {
 			dumpOpts := dumper.Options{
 				IncludeAutoInc: true,
 				IgnoreTable:    opts.IgnoreTable,
+				Style:          style,
 			}
 			dumpOpts.IgnoreKeys(wsSchema.FailedKeys())
 			result.ReformatCount, err = dumper.DumpSchema(wsSchema.Schema, dir, dumpOpts)
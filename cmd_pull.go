@@ -3,13 +3,17 @@ package main
 import (
 	"database/sql"
 	"fmt"
-	"os"
+	"path"
 	"regexp"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/applier"
 	"github.com/skeema/skeema/dumper"
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/skeema/workspace"
 	"github.com/skeema/tengo"
 )
@@ -26,13 +30,19 @@ which section of .skeema config files is used for processing. For example,
 running ` + "`" + `skeema pull staging` + "`" + ` will apply config directives from the
 [staging] section of config files, as well as any sectionless directives at the
 top of the file. If no environment name is supplied, the default is
-"production".`
+"production".
+
+With --object-filter or --object-types, only the matching objects are updated,
+and other objects' files are left as-is. Schema-level options are not updated,
+and new schemas are not detected, when either option is used.`
 
 	cmd := mybase.NewCommand("pull", summary, desc, PullHandler)
 	cmd.AddOption(mybase.BoolOption("include-auto-inc", 0, false, "Include starting auto-inc values in new table files, and update in existing files"))
 	cmd.AddOption(mybase.BoolOption("format", 0, true, "Reformat SQL statements to match canonical SHOW CREATE"))
 	cmd.AddOption(mybase.BoolOption("normalize", 0, true, "(deprecated alias for format)").Hidden())
 	cmd.AddOption(mybase.BoolOption("new-schemas", 0, true, "Detect any new schemas and populate new dirs for them"))
+	cmd.AddOption(mybase.StringOption("object-filter", 0, "", "Only update objects with these names (comma-separated list; * wildcards permitted)"))
+	cmd.AddOption(mybase.StringOption("object-types", 0, "", "Only update objects of these types (comma-separated list of: table, procedure, function)"))
 	cmd.AddOption(mybase.StringOption("partitioning", 0, "keep", "(slight pull impact of having partitioning=remove in .skeema file for diff/push)").Hidden())
 	cmd.AddArg("environment", "production", false)
 	CommandSuite.AddSubCommand(cmd)
@@ -44,6 +54,9 @@ func PullHandler(cfg *mybase.Config) error {
 	if err != nil {
 		return err
 	}
+	if _, err := objectFilterForConfig(dir.Config); err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
 
 	var skipCount int
 	if skipCount, err = pullWalker(dir, 5); err != nil {
@@ -90,6 +103,9 @@ func pullWalker(dir *fs.Dir, maxDepth int) (skipCount int, err error) {
 	}
 
 	wantNewSchemas := dir.Config.GetBool("new-schemas")
+	if filter, _ := objectFilterForConfig(dir.Config); filter != nil {
+		wantNewSchemas = false // pulling a subset of objects shouldn't populate new dirs
+	}
 	allSchemaNames := []string{}
 	for _, sub := range subdirs {
 		if sub.ParseError != nil {
@@ -153,6 +169,10 @@ func pullLogicalSchema(dir *fs.Dir, instance *tengo.Instance, logicalSchema *fs.
 		log.Warnf("Ignoring schema %s from directory %s -- multiple schemas per dir not supported yet", logicalSchema.Name, dir)
 		return []string{logicalSchema.Name}, nil
 	}
+	filter, err := objectFilterForConfig(dir.Config)
+	if err != nil {
+		return nil, NewExitValue(CodeBadConfig, err.Error())
+	}
 	if schemaNames, err = dir.SchemaNames(instance); err != nil {
 		return nil, fmt.Errorf("%s: Unable to fetch schema names mapped by this dir: %s", dir, err)
 	}
@@ -161,24 +181,35 @@ func pullLogicalSchema(dir *fs.Dir, instance *tengo.Instance, logicalSchema *fs.
 		return
 	}
 	instSchema, err := instance.Schema(schemaNames[0])
-	if err == sql.ErrNoRows {
+	if err == sql.ErrNoRows && filter != nil {
+		log.Warnf("Ignoring directory %s -- schema %s no longer exists, but only a subset of objects is being updated\n", dir, schemaNames[0])
+		return nil, nil
+	} else if err == sql.ErrNoRows {
 		log.Infof("Deleted directory %s -- schema %s no longer exists\n", dir, schemaNames[0])
 		return nil, dir.Delete()
 	} else if err != nil {
 		return nil, fmt.Errorf("%s: Unable to fetch schema %s from %s: %s", dir, schemaNames[0], instance, err)
 	}
 
-	log.Infof("Updating %s to reflect %s %s", dir, instance, instSchema.Name)
+	start := time.Now()
+	logger := log.WithFields(log.Fields{"instance": instance.String(), "schema": instSchema.Name, "dir": dir.RelPath()})
+	logger.Infof("Updating %s to reflect %s %s", dir, instance, instSchema.Name)
 
-	// Handle changes in schema's default character set and/or collation by
-	// persisting changes to the dir's option file.
-	if dir.Config.Get("default-character-set") != instSchema.CharSet || dir.Config.Get("default-collation") != instSchema.Collation {
-		dir.OptionFile.SetOptionValue("", "default-character-set", instSchema.CharSet)
-		dir.OptionFile.SetOptionValue("", "default-collation", instSchema.Collation)
-		if err := dir.OptionFile.Write(true); err != nil {
-			return nil, fmt.Errorf("Unable to update character set and collation for %s: %s", dir.OptionFile.Path(), err)
+	// Handle changes in schema's default character set and/or collation, as well
+	// as other schema-level options, by persisting changes to the dir's option
+	// file. This is skipped when only updating a subset of objects.
+	if filter == nil {
+		if dir.Config.Get("default-character-set") != instSchema.CharSet || dir.Config.Get("default-collation") != instSchema.Collation {
+			dir.OptionFile.SetOptionValue("", "default-character-set", instSchema.CharSet)
+			dir.OptionFile.SetOptionValue("", "default-collation", instSchema.Collation)
+			if err := dir.OptionFile.Write(true); err != nil {
+				return nil, fmt.Errorf("Unable to update character set and collation for %s: %s", dir.OptionFile.Path(), err)
+			}
+			log.Infof("Wrote %s -- updated schema-level default-character-set and default-collation", dir.OptionFile.Path())
+		}
+		if err := pullSchemaOptions(dir, instance, instSchema.Name); err != nil {
+			return nil, err
 		}
-		log.Infof("Wrote %s -- updated schema-level default-character-set and default-collation", dir.OptionFile.Path())
 	}
 
 	dumpOpts := dumper.Options{
@@ -187,6 +218,15 @@ func pullLogicalSchema(dir *fs.Dir, instance *tengo.Instance, logicalSchema *fs.
 	if dumpOpts.IgnoreTable, err = dir.Config.GetRegexp("ignore-table"); err != nil {
 		return nil, NewExitValue(CodeBadConfig, err.Error())
 	}
+	if dumpOpts.Layout, err = dir.FileLayout(); err != nil {
+		return nil, NewExitValue(CodeBadConfig, err.Error())
+	}
+	if dumpOpts.Style, err = dumper.ParseStyle(dir.Config.Get("format-style")); err != nil {
+		return nil, NewExitValue(CodeBadConfig, err.Error())
+	}
+	// Only move existing files to match the layout if one was configured
+	// explicitly, since users may otherwise have arranged files manually
+	dumpOpts.Relocate = dir.Config.Changed("layout")
 	if partitioning, _ := dir.Config.GetEnum("partitioning", "keep", "remove", "modify"); partitioning == "remove" {
 		dumpOpts.RetainPartitioning = true
 	}
@@ -205,14 +245,94 @@ func pullLogicalSchema(dir *fs.Dir, instance *tengo.Instance, logicalSchema *fs.
 		if err != nil {
 			return nil, err
 		}
+		if filter != nil {
+			inDiff = filter.filterKeys(inDiff)
+		}
 		dumpOpts.OnlyKeys(inDiff)
+	} else if filter != nil {
+		dumpOpts.OnlyKeys(filter.filterKeys(allObjectKeys(instSchema, logicalSchema)))
+	}
+
+	// Data tables excluded by the filter keep their existing INSERTs, since
+	// DumpData does not apply OnlyKeys
+	if filter != nil {
+		for _, name := range dir.DataTables() {
+			if key := (tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: name}); !filter.matches(key) {
+				dumpOpts.IgnoreKeys([]tengo.ObjectKey{key})
+			}
+		}
 	}
 
-	_, err = dumper.DumpSchema(instSchema, dir, dumpOpts)
-	os.Stderr.WriteString("\n")
+	var count int
+	if count, err = dumper.DumpSchema(instSchema, dir, dumpOpts); err == nil && len(dir.DataTables()) > 0 {
+		err = pullData(dir, instance, instSchema, dumpOpts)
+	}
+	if err == nil {
+		logger.WithField("duration", time.Since(start).Seconds()).Debugf("Updated %s in %s", util.CountAndNoun(count, "object", "objects"), dir)
+	}
+	writeLogSpacer()
 	return
 }
 
+// pullSchemaOptions persists changes in schema-level options beyond charset
+// and collation to the dir's option file. Each option is only updated if the
+// dir already configures it, or if the live schema uses a non-default value.
+func pullSchemaOptions(dir *fs.Dir, instance *tengo.Instance, schemaName string) error {
+	live, err := applier.SchemaOptionsFromInstance(instance, schemaName)
+	if err != nil {
+		return fmt.Errorf("%s: Unable to fetch schema-level options for %s: %s", dir, schemaName, err)
+	}
+	liveValues := []struct {
+		name, value, nonDefault string
+	}{
+		{"default-encryption", live.DefaultEncryption, "Y"},
+		{"schema-read-only", live.ReadOnly, "1"},
+	}
+	var updated []string
+	for _, opt := range liveValues {
+		if opt.value == "" || strings.EqualFold(dir.Config.Get(opt.name), opt.value) {
+			continue
+		}
+		if dir.Config.Changed(opt.name) || opt.value == opt.nonDefault {
+			dir.OptionFile.SetOptionValue("", opt.name, opt.value)
+			updated = append(updated, opt.name)
+		}
+	}
+	if len(updated) == 0 {
+		return nil
+	}
+	if err := dir.OptionFile.Write(true); err != nil {
+		return fmt.Errorf("Unable to update schema-level options for %s: %s", dir.OptionFile.Path(), err)
+	}
+	log.Infof("Wrote %s -- updated schema-level %s", dir.OptionFile.Path(), strings.Join(updated, " and "))
+	return nil
+}
+
+// pullData updates the INSERT statements in dir to match the rows of each
+// table listed in the data-tables option. Tables whose rows cannot be fetched
+// are logged and skipped, leaving their INSERTs unchanged.
+func pullData(dir *fs.Dir, instance *tengo.Instance, instSchema *tengo.Schema, dumpOpts dumper.Options) error {
+	db, err := instance.Connect(instSchema.Name, "")
+	if err != nil {
+		return fmt.Errorf("%s: Unable to connect to %s: %s", dir, instance, err)
+	}
+	var data []*fs.TableData
+	for _, name := range dir.DataTables() {
+		table := instSchema.Table(name)
+		if table == nil {
+			continue
+		}
+		if td, err := workspace.QueryTableData(db, table); err != nil {
+			log.Warnf("Skipping data for %s: %s", dir, err)
+			dumpOpts.IgnoreKeys([]tengo.ObjectKey{{Type: tengo.ObjectTypeTable, Name: name}})
+		} else {
+			data = append(data, td)
+		}
+	}
+	_, err = dumper.DumpData(data, dir, dumpOpts)
+	return err
+}
+
 func statementModifiersForPull(config *mybase.Config, instance *tengo.Instance, ignoreTable *regexp.Regexp) tengo.StatementModifiers {
 	// We're permissive of unsafe operations here since we don't ever actually
 	// execute the generated statement! We just examine its type.
@@ -323,3 +443,80 @@ func findNewSchemas(dir *fs.Dir, instance *tengo.Instance, seenNames []string) e
 
 	return nil
 }
+
+// objectFilter restricts pull and init to a subset of objects, based on the
+// object-filter and object-types options.
+type objectFilter struct {
+	patterns []string
+	types    map[tengo.ObjectType]bool
+}
+
+// objectFilterForConfig returns an objectFilter based on config, or nil if
+// neither option restricts which objects are handled.
+func objectFilterForConfig(config *mybase.Config) (*objectFilter, error) {
+	if !config.Changed("object-filter") && !config.Changed("object-types") {
+		return nil, nil
+	}
+	f := &objectFilter{
+		patterns: config.GetSlice("object-filter", ',', true),
+	}
+	for _, pattern := range f.patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("Option object-filter: invalid pattern %q", pattern)
+		}
+	}
+	if typeNames := config.GetSlice("object-types", ',', true); len(typeNames) > 0 {
+		f.types = make(map[tengo.ObjectType]bool, len(typeNames))
+		for _, name := range typeNames {
+			switch ot := tengo.ObjectType(strings.ToLower(name)); ot {
+			case tengo.ObjectTypeTable, tengo.ObjectTypeProc, tengo.ObjectTypeFunc:
+				f.types[ot] = true
+			default:
+				return nil, fmt.Errorf("Option object-types: invalid value %q; valid values are table, procedure, function", name)
+			}
+		}
+	}
+	return f, nil
+}
+
+// matches returns true if key should be handled.
+func (f *objectFilter) matches(key tengo.ObjectKey) bool {
+	if f.types != nil && !f.types[key.Type] {
+		return false
+	} else if len(f.patterns) == 0 {
+		return true
+	}
+	for _, pattern := range f.patterns {
+		if matched, _ := path.Match(pattern, key.Name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// filterKeys returns the subset of keys which should be handled.
+func (f *objectFilter) filterKeys(keys []tengo.ObjectKey) []tengo.ObjectKey {
+	result := make([]tengo.ObjectKey, 0, len(keys))
+	for _, key := range keys {
+		if f.matches(key) {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// allObjectKeys returns the keys of all objects in schema and logicalSchema.
+// Either argument may be nil.
+func allObjectKeys(schema *tengo.Schema, logicalSchema *fs.LogicalSchema) (keys []tengo.ObjectKey) {
+	if schema != nil {
+		for key := range schema.ObjectDefinitions() {
+			keys = append(keys, key)
+		}
+	}
+	if logicalSchema != nil {
+		for key := range logicalSchema.Creates {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
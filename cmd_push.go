@@ -2,12 +2,17 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
 
 	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/applier"
 	"github.com/skeema/skeema/fs"
 	"github.com/skeema/skeema/linter"
+	"github.com/skeema/skeema/util"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -44,6 +49,11 @@ top of the file. If no environment name is supplied, the default is
 	cmd.AddOption(mybase.StringOption("safe-below-size", 0, "0", "Always permit destructive operations for tables below this size in bytes"))
 	cmd.AddOption(mybase.StringOption("concurrent-instances", 'c', "1", "Perform operations on this number of instances concurrently"))
 	cmd.AddOption(mybase.StringOption("partitioning", 0, "keep", `Specify handling of partitioning status on the database side (valid values: "keep", "remove", "modify")`))
+	cmd.AddOption(mybase.StringOption("canary-instances", 0, "0", "Push to this many instances first, and verify the result before continuing to others"))
+	cmd.AddOption(mybase.StringOption("canary-wait", 0, "0s", "After a successful canary push, wait this long before continuing to others"))
+	cmd.AddOption(mybase.BoolOption("canary-confirm", 0, false, "After a successful canary push, prompt for confirmation before continuing to others"))
+	cmd.AddOption(mybase.StringOption("rollout-batch-size", 0, "0", "After any canary instances, push to at most this many instances per batch"))
+	cmd.AddOption(mybase.StringOption("user-password-command", 0, "", "Shell command to obtain password for each new user in grants-file; see manual for template vars"))
 	linter.AddCommandOptions(cmd)
 	cmd.AddArg("environment", "production", false)
 	CommandSuite.AddSubCommand(cmd)
@@ -52,6 +62,12 @@ top of the file. If no environment name is supplied, the default is
 
 // PushHandler is the handler method for `skeema push`
 func PushHandler(cfg *mybase.Config) error {
+	return push(cfg, nil)
+}
+
+// push implements both `skeema push` and `skeema diff`. If mw is non-nil, the
+// DDL for each target is also written to migration files.
+func push(cfg *mybase.Config, mw *applier.MigrationWriter) error {
 	dir, err := fs.ParseDir(".", cfg)
 	if err != nil {
 		return err
@@ -59,9 +75,8 @@ func PushHandler(cfg *mybase.Config) error {
 
 	briefMode := dir.Config.GetBool("dry-run") && dir.Config.GetBool("brief")
 	printer := applier.NewPrinter(briefMode)
-	g, ctx := errgroup.WithContext(context.Background())
-	tgchan, skipCount := applier.TargetGroupChanForDir(dir)
-	results := make(chan applier.Result)
+	printer.SetMigrationWriter(mw)
+	printer.SetQuiet(dir.Config.GetBool("quiet"))
 
 	workerCount, err := dir.Config.GetInt("concurrent-instances")
 	if err == nil && workerCount < 1 {
@@ -70,6 +85,95 @@ func PushHandler(cfg *mybase.Config) error {
 	if err != nil {
 		return NewExitValue(CodeBadConfig, err.Error())
 	}
+	rollout, err := rolloutOptions(dir.Config)
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	groups, skipCount := applier.TargetGroupsForDir(dir)
+	grantTargets, grantSkipCount := applier.GrantTargetsForDir(dir, 5)
+	skipCount += grantSkipCount
+	for _, gt := range grantTargets {
+		if err := gt.CheckFlavor(); err != nil {
+			return NewExitValue(CodeBadConfig, err.Error())
+		}
+	}
+	stages := [][]applier.TargetGroup{groups}
+	if !dir.Config.GetBool("dry-run") {
+		stages = applier.PlanRollout(groups, rollout.canaryCount, rollout.batchSize)
+	}
+
+	allResults := make([]applier.Result, 0, len(stages)+len(grantTargets))
+	var aborted, declined bool
+	var declinedCount int
+	for n, stage := range stages {
+		if len(stages) > 1 {
+			log.Infof("Rollout stage %d of %d: %s", n+1, len(stages), util.CountAndNoun(len(stage), "instance"))
+		}
+		result, err := pushTargetGroups(stage, workerCount, printer)
+		if err != nil {
+			if _, ok := err.(applier.ConfigError); ok {
+				return NewExitValue(CodeBadConfig, err.Error())
+			}
+			return err
+		}
+		allResults = append(allResults, result)
+		if n == len(stages)-1 {
+			break
+		}
+		if err := rollout.proceed(stage, result, n == 0); err == errRolloutDeclined {
+			declinedCount = applier.CountTargets(stages[n+1:])
+			log.Warnf("Stopping rollout: not pushing to %s on remaining instances since continuing was declined", util.CountAndNoun(declinedCount, "target"))
+			aborted, declined = true, true
+			break
+		} else if err != nil {
+			remaining := applier.CountTargets(stages[n+1:])
+			log.Errorf("Aborting rollout: skipping %s on remaining instances due to previous failure", util.CountAndNoun(remaining, "target"))
+			allResults = append(allResults, applier.Result{SkipCount: remaining})
+			aborted = true
+			break
+		}
+	}
+
+	// Users, roles, and privileges are handled after all schema changes, and not
+	// at all if a staged rollout was aborted
+	for _, gt := range grantTargets {
+		if declined {
+			declinedCount++
+		} else if aborted {
+			allResults = append(allResults, applier.Result{SkipCount: 1})
+		} else {
+			allResults = append(allResults, applier.ApplyGrants(gt, printer))
+		}
+	}
+	sum := applier.SumResults(allResults)
+	sum.SkipCount += skipCount
+
+	if sum.SkipCount+sum.UnsupportedCount == 0 {
+		if declined {
+			// Declining at the canary-confirm prompt is intentional, so it is not
+			// treated as a fatal error, but the fleet is left only partially pushed
+			return NewExitValue(CodePartialError, "Rollout declined after canary stage; %s not pushed", util.CountAndNoun(declinedCount, "target"))
+		}
+		if dir.Config.GetBool("dry-run") && sum.Differences {
+			return NewExitValue(CodeDifferencesFound, "")
+		}
+		return nil
+	}
+	code := CodeFatalError
+	if sum.SkipCount == 0 {
+		code = CodePartialError
+	}
+	return NewExitValue(code, sum.Summary())
+}
+
+// pushTargetGroups runs diff/push operations on the supplied TargetGroups,
+// using workerCount concurrent workers. It returns the combined result of all
+// workers, or the first fatal error encountered.
+func pushTargetGroups(groups []applier.TargetGroup, workerCount int, printer *applier.Printer) (applier.Result, error) {
+	g, ctx := errgroup.WithContext(context.Background())
+	tgchan := applier.TargetGroupChan(groups)
+	results := make(chan applier.Result)
 	for n := 0; n < workerCount; n++ {
 		g.Go(func() error {
 			return applier.Worker(ctx, tgchan, results, printer)
@@ -85,23 +189,82 @@ func PushHandler(cfg *mybase.Config) error {
 		allResults = append(allResults, r)
 	}
 	if err := g.Wait(); err != nil {
-		if _, ok := err.(applier.ConfigError); ok {
-			return NewExitValue(CodeBadConfig, err.Error())
-		}
-		return err
+		return applier.Result{}, err
 	}
-	sum := applier.SumResults(allResults)
-	sum.SkipCount += skipCount
+	return applier.SumResults(allResults), nil
+}
 
-	if sum.SkipCount+sum.UnsupportedCount == 0 {
-		if dir.Config.GetBool("dry-run") && sum.Differences {
-			return NewExitValue(CodeDifferencesFound, "")
-		}
+// rolloutConfig stores settings for staged rollouts in `skeema push`.
+type rolloutConfig struct {
+	canaryCount int
+	batchSize   int
+	wait        time.Duration
+	confirm     bool
+}
+
+// rolloutOptions returns a rolloutConfig based on the supplied configuration,
+// or an error if any rollout-related option has an invalid value.
+func rolloutOptions(config *mybase.Config) (rc rolloutConfig, err error) {
+	if rc.canaryCount, err = config.GetInt("canary-instances"); err != nil {
+		return
+	} else if rc.canaryCount < 0 {
+		return rc, fmt.Errorf("canary-instances cannot be negative")
+	}
+	if rc.batchSize, err = config.GetInt("rollout-batch-size"); err != nil {
+		return
+	} else if rc.batchSize < 0 {
+		return rc, fmt.Errorf("rollout-batch-size cannot be negative")
+	}
+	if rc.wait, err = time.ParseDuration(config.Get("canary-wait")); err != nil {
+		return rc, fmt.Errorf("Invalid value for canary-wait: %s", err)
+	} else if rc.wait < 0 {
+		return rc, fmt.Errorf("canary-wait cannot be negative")
+	}
+	rc.confirm = config.GetBool("canary-confirm")
+	return
+}
+
+// promptConfirm is used by rolloutConfig.proceed to prompt for confirmation
+// with canary-confirm. Tests may replace it, since STDIN is not a TTY there.
+var promptConfirm = util.PromptConfirm
+
+// errRolloutDeclined is returned by rolloutConfig.proceed if the user answers
+// no at the canary-confirm prompt.
+var errRolloutDeclined = errors.New("rollout declined")
+
+// proceed returns nil if a staged rollout should continue to the next stage,
+// after completing the supplied stage with the supplied result. Operations
+// skipped due to errors halt the rollout, but unsupported tables do not, since
+// those are a non-fatal partial outcome. If isCanary is true, the stage's
+// instances are re-verified, and any configured wait or confirmation prompt
+// occurs before returning. If the rollout should not continue, the returned
+// error is errRolloutDeclined if the user declined at the prompt, or otherwise
+// describes the failure.
+func (rc rolloutConfig) proceed(stage []applier.TargetGroup, result applier.Result, isCanary bool) error {
+	if result.SkipCount > 0 {
+		return errors.New(result.Summary())
+	}
+	if !isCanary || rc.canaryCount == 0 {
 		return nil
 	}
-	code := CodeFatalError
-	if sum.SkipCount == 0 {
-		code = CodePartialError
+	for _, tg := range stage {
+		if err := applier.VerifyPushed(tg); err != nil {
+			log.Error(err.Error())
+			return err
+		}
 	}
-	return NewExitValue(code, sum.Summary())
+	log.Infof("Canary push verified successfully on %s", util.CountAndNoun(len(stage), "instance"))
+	if rc.wait > 0 {
+		log.Infof("Waiting %s before continuing rollout", rc.wait)
+		time.Sleep(rc.wait)
+	}
+	if rc.confirm {
+		if ok, err := promptConfirm("Continue rollout to remaining instances?"); err != nil {
+			log.Error(err.Error())
+			return err
+		} else if !ok {
+			return errRolloutDeclined
+		}
+	}
+	return nil
 }
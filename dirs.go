@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+// errSkipDir may be returned by a walkDirs handler to indicate that the dir and
+// its subdirs should be skipped, without affecting the overall result.
+var errSkipDir = errors.New("skip this directory")
+
+// walkDirs calls handler on dir and its subdirs, recursively up to maxDepth
+// levels deep. Dirs which could not be parsed are skipped with a warning. If
+// handler returns an error with an exit code above CodeDifferencesFound, the
+// error is logged and the dir's subdirs are not walked. The "worst" (highest
+// exit code) error encountered is returned. Callers generally only care about
+// its exit code, since all errors are logged as they occur.
+func walkDirs(dir *fs.Dir, maxDepth int, handler func(*fs.Dir) error) error {
+	if dir.ParseError != nil {
+		log.Warnf("Skipping %s: %s", dir.Path, dir.ParseError)
+		return NewExitValue(CodeBadConfig, "")
+	}
+	result := handler(dir)
+	if result == errSkipDir {
+		return nil
+	} else if ExitCode(result) > CodeDifferencesFound {
+		log.Errorf("Skipping %s: %s", dir, result)
+		return result // don't walk subdirs if something fatal happened here
+	}
+
+	subdirs, err := dir.Subdirs()
+	if err != nil {
+		log.Errorf("Cannot list subdirs of %s: %s", dir, err)
+		return err
+	} else if len(subdirs) > 0 && maxDepth <= 0 {
+		log.Errorf("Not walking subdirs of %s: max depth reached", dir)
+		return result
+	}
+	for _, sub := range subdirs {
+		err := walkDirs(sub, maxDepth-1, handler)
+		if ExitCode(err) > ExitCode(result) {
+			result = err
+		}
+	}
+	return result
+}
+
+// workspaceOptionsForDir returns workspace options for dir. If inst is nil,
+// this involves connecting to the dir's first defined instance, unless
+// configured to use local Docker or an offline workspace with an explicit
+// flavor. Callers which need an instance for other purposes may supply it
+// instead.
+func workspaceOptionsForDir(dir *fs.Dir, inst *tengo.Instance) (workspace.Options, error) {
+	if inst == nil {
+		if wsType, _ := dir.Config.GetEnum("workspace", "temp-schema", "docker", "offline", "dedicated-host"); wsType == "temp-schema" || !dir.Config.Changed("flavor") {
+			var err error
+			if inst, err = dir.FirstInstance(); err != nil {
+				return workspace.Options{}, err
+			}
+		}
+	}
+	return workspace.OptionsForDir(dir, inst)
+}
+
+// logStatementErrors logs each of the supplied workspace statement failures at
+// error level, along with its location.
+func logStatementErrors(failures []*workspace.StatementError) {
+	for _, stmtErr := range failures {
+		log.Errorf("%s: %s", stmtErr.Location(), stmtErr.Message())
+	}
+}
@@ -0,0 +1,341 @@
+package dumper
+
+import (
+	"strings"
+	"unicode"
+)
+
+// commentedLine represents one line of a CREATE TABLE statement, split into
+// its SQL code and any comments it contains.
+type commentedLine struct {
+	raw     string // original line
+	code    string // line with comments removed, trimmed of whitespace
+	comment string // comments from the line, separated by spaces
+	depth   int    // parenthesis nesting depth at start of line
+	closed  bool   // true if line ends outside of a version-gated /*! comment
+}
+
+// splitComments splits a statement into lines, separating the comments in
+// each line from its code. Version-gated comments (/*!...*/) are considered
+// code. Comment markers inside of quoted strings or identifiers are ignored.
+func splitComments(text string) []commentedLine {
+	var inQuote rune
+	var inBlock, inVersion bool
+	var depth int
+	lines := strings.Split(text, "\n")
+	result := make([]commentedLine, len(lines))
+	for n, line := range lines {
+		var code, comment strings.Builder
+		startComment := func() {
+			if comment.Len() > 0 {
+				comment.WriteRune(' ')
+			}
+		}
+		result[n].depth = depth
+		runes := []rune(line)
+		peek := func(i int) rune {
+			if i < len(runes) {
+				return runes[i]
+			}
+			return 0
+		}
+		for i := 0; i < len(runes); i++ {
+			c := runes[i]
+			if inBlock {
+				comment.WriteRune(c)
+				if c == '*' && peek(i+1) == '/' {
+					comment.WriteRune('/')
+					i++
+					inBlock = false
+				}
+				continue
+			} else if inQuote != 0 {
+				code.WriteRune(c)
+				if c == '\\' && inQuote != '`' && i+1 < len(runes) {
+					code.WriteRune(runes[i+1])
+					i++
+				} else if c == inQuote {
+					inQuote = 0
+				}
+				continue
+			}
+			switch {
+			case c == '\'' || c == '"' || c == '`':
+				inQuote = c
+				code.WriteRune(c)
+			case c == '/' && peek(i+1) == '*' && peek(i+2) == '!':
+				inVersion = true
+				code.WriteString("/*")
+				i++
+			case c == '*' && peek(i+1) == '/' && inVersion:
+				inVersion = false
+				code.WriteString("*/")
+				i++
+			case c == '/' && peek(i+1) == '*':
+				inBlock = true
+				startComment()
+				comment.WriteString("/*")
+				i++
+			case c == '#' || (c == '-' && peek(i+1) == '-' && (i+2 == len(runes) || unicode.IsSpace(runes[i+2]))):
+				startComment()
+				comment.WriteString(strings.TrimSpace(string(runes[i:])))
+				i = len(runes)
+			default:
+				if c == '(' {
+					depth++
+				} else if c == ')' {
+					depth--
+				}
+				code.WriteRune(c)
+			}
+		}
+		result[n].raw = line
+		result[n].code = strings.TrimSpace(code.String())
+		result[n].comment = strings.TrimSpace(comment.String())
+		result[n].closed = !inVersion
+	}
+	return result
+}
+
+// unanchored is the anchor key for lines beginning an element which cannot be
+// matched between statements, such as an unnamed index.
+const unanchored = "?"
+
+// anchorKeys returns a slice with the anchor key of each line: a string
+// identifying the column, index, constraint, or clause that begins on that
+// line. Lines which continue a previous element, or which have no code, have
+// a blank key.
+func anchorKeys(lines []commentedLine) []string {
+	keys := make([]string, len(lines))
+	var prevCode string
+	var seenCreate, bodyDone bool
+	for n, line := range lines {
+		if line.code == "" {
+			continue
+		}
+		switch {
+		case !seenCreate:
+			keys[n] = "create"
+			seenCreate = true
+		case !bodyDone && line.depth == 1 && strings.HasPrefix(line.code, ")"):
+			keys[n] = "options"
+			bodyDone = true
+		case !bodyDone && line.depth == 1 && (strings.HasSuffix(prevCode, ",") || strings.HasSuffix(prevCode, "(")):
+			if keys[n] = elementKey(line.code); keys[n] == "" {
+				keys[n] = unanchored
+			}
+		case bodyDone:
+			keys[n] = partitionKey(line.code)
+		}
+		prevCode = line.code
+	}
+	return keys
+}
+
+// elementKey returns an anchor key for a line beginning a column, index, or
+// constraint definition in the body of a CREATE TABLE. A blank string is
+// returned for unnamed indexes or constraints, since their names cannot be
+// matched.
+func elementKey(code string) string {
+	words := leadingWords(code, 4)
+	if len(words) == 0 {
+		return ""
+	}
+	name := func(pos int) string {
+		// Skip optional KEY or INDEX keyword
+		if pos < len(words) && (words[pos] == "key" || words[pos] == "index") {
+			pos++
+		}
+		if pos < len(words) && words[pos] != "(" {
+			return "key " + words[pos]
+		}
+		return ""
+	}
+	switch words[0] {
+	case "primary":
+		return "primary key"
+	case "unique", "fulltext", "spatial":
+		return name(1)
+	case "key", "index":
+		return name(1)
+	case "constraint":
+		if len(words) > 1 && words[1] != "foreign" && words[1] != "check" && words[1] != "unique" && words[1] != "primary" {
+			return "constraint " + words[1]
+		}
+		return ""
+	case "foreign", "check", "(":
+		return ""
+	}
+	return "column " + words[0]
+}
+
+// partitionKey returns an anchor key for a line in the partitioning clause
+// of a CREATE TABLE, or a blank string if the line does not begin a partition
+// definition or the partitioning clause itself.
+func partitionKey(code string) string {
+	code = strings.TrimLeft(strings.TrimPrefix(code, "/*!"), "0123456789 (")
+	words := leadingWords(code, 2)
+	if len(words) < 2 || words[0] != "partition" {
+		return ""
+	} else if words[1] == "by" {
+		return "partitioning"
+	}
+	return "partition " + words[1]
+}
+
+// leadingWords returns up to max lowercased words from the beginning of code.
+// Backtick-quoted identifiers are unquoted. Any other punctuation character is
+// returned as a single word.
+func leadingWords(code string, max int) (words []string) {
+	runes := []rune(code)
+	for i := 0; i < len(runes) && len(words) < max; {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '`':
+			var word strings.Builder
+			for i++; i < len(runes); i++ {
+				if runes[i] == '`' {
+					if i+1 < len(runes) && runes[i+1] == '`' {
+						i++
+					} else {
+						i++
+						break
+					}
+				}
+				word.WriteRune(runes[i])
+			}
+			words = append(words, strings.ToLower(word.String()))
+		case unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '$':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '$') {
+				i++
+			}
+			words = append(words, strings.ToLower(string(runes[start:i])))
+		default:
+			words = append(words, string(c))
+			i++
+		}
+	}
+	return words
+}
+
+// preserveComments returns canonicalCreate with the comments from fsCreate
+// carried over. Each comment is anchored to the column, index, constraint, or
+// clause it annotated in fsCreate: a comment on its own line(s) is placed
+// before the anchor's line in the result, and a comment following code on the
+// same line is placed at the end of the anchor's line. Comments whose anchor
+// no longer exists in canonicalCreate are dropped. Both statements should be
+// CREATE TABLE statements without a trailing delimiter.
+func preserveComments(fsCreate, canonicalCreate string) string {
+	fsLines := splitComments(fsCreate)
+	fsKeys := anchorKeys(fsLines)
+	leading := make(map[string][]string)
+	trailing := make(map[string]string)
+	var pending []string
+	var lastKey string
+	for n, line := range fsLines {
+		key := fsKeys[n]
+		if key != "" {
+			lastKey = key
+			leading[key] = append(leading[key], pending...)
+			pending = nil
+		}
+		if line.comment == "" {
+			continue
+		} else if line.code == "" {
+			if isCommentStart(line.comment) {
+				pending = append(pending, line.comment)
+			} else {
+				// Continuation line of a multi-line block comment: retain its indentation
+				pending = append(pending, strings.TrimRightFunc(line.raw, unicode.IsSpace))
+			}
+		} else if lastKey != "" && lastKey != unanchored {
+			// A comment after code on a continuation line is anchored to the element
+			// being continued
+			if trailing[lastKey] != "" {
+				trailing[lastKey] += " "
+			}
+			trailing[lastKey] += line.comment
+		}
+	}
+	if len(leading) == 0 && len(trailing) == 0 && len(pending) == 0 {
+		return canonicalCreate
+	}
+
+	canonLines := splitComments(canonicalCreate)
+	canonKeys := anchorKeys(canonLines)
+	result := make([]string, 0, len(canonLines))
+	for n, line := range canonLines {
+		key := canonKeys[n]
+		text := line.raw
+		if key != "" && key != unanchored {
+			indent := text[:len(text)-len(strings.TrimLeftFunc(text, unicode.IsSpace))]
+			inVersion := n > 0 && !canonLines[n-1].closed
+			for _, comment := range leading[key] {
+				if inVersion {
+					comment = asLineComment(comment)
+				}
+				if isCommentStart(comment) {
+					comment = indent + comment
+				}
+				result = append(result, comment)
+			}
+			if comment := trailing[key]; comment != "" {
+				text = appendComment(text, comment, !line.closed, n == len(canonLines)-1)
+			}
+		}
+		if n == len(canonLines)-1 {
+			for _, comment := range pending {
+				text = appendComment(text, comment, !line.closed, true)
+			}
+		}
+		result = append(result, text)
+	}
+	return strings.Join(result, "\n")
+}
+
+// isCommentStart returns true if text begins with a comment marker.
+func isCommentStart(text string) bool {
+	return strings.HasPrefix(text, "/*") || strings.HasPrefix(text, "--") || strings.HasPrefix(text, "#")
+}
+
+// appendComment appends comment to the end of line. If the line ends inside
+// of a version-gated comment, the comment is converted to a line comment, to
+// avoid nesting comments. If isLast is true, the comment is converted to a
+// block comment, since a line comment would swallow the statement delimiter.
+func appendComment(line, comment string, inVersion, isLast bool) string {
+	if inVersion {
+		comment = asLineComment(comment)
+	} else if isLast {
+		comment = asBlockComment(comment)
+	}
+	return line + " " + comment
+}
+
+// asLineComment converts a block comment into a line comment.
+func asLineComment(comment string) string {
+	comment = strings.TrimSpace(comment)
+	if !strings.HasPrefix(comment, "--") && !strings.HasPrefix(comment, "#") {
+		comment = strings.TrimPrefix(comment, "/*")
+		comment = strings.TrimSuffix(comment, "*/")
+		comment = "-- " + strings.TrimSpace(comment)
+	}
+	return comment
+}
+
+// asBlockComment converts a line comment into a block comment. Any block
+// comment terminator in the line comment is broken up.
+func asBlockComment(comment string) string {
+	var body string
+	if strings.HasPrefix(comment, "--") {
+		body = strings.TrimSpace(comment[2:])
+	} else if strings.HasPrefix(comment, "#") {
+		body = strings.TrimSpace(comment[1:])
+	} else {
+		return comment
+	}
+	body = strings.Replace(body, "*/", "* /", -1)
+	return "/* " + body + " */"
+}
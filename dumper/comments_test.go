@@ -0,0 +1,134 @@
+package dumper
+
+import (
+	"strings"
+	"testing"
+)
+
+const canonicalCommentsTable = "CREATE TABLE `posts` (\n" +
+	"  `id` bigint(20) unsigned NOT NULL,\n" +
+	"  `author_id` int(10) unsigned NOT NULL DEFAULT '0',\n" +
+	"  `body` text,\n" +
+	"  PRIMARY KEY (`id`),\n" +
+	"  KEY `author` (`author_id`)\n" +
+	") ENGINE=InnoDB DEFAULT CHARSET=latin1"
+
+func TestPreserveComments(t *testing.T) {
+	fsCreate := "create table posts ( -- blog posts\n" +
+		"  -- surrogate key\n" +
+		"  id bigint unsigned not null,\n" +
+		"  author_id int unsigned not null default 0, # references users.id\n" +
+		"  body text, /* markdown */\n" +
+		"  /* this column was\n" +
+		"     removed */\n" +
+		"  legacy char(1) -- dropped along with column\n" +
+		"  , primary key (id),\n" +
+		"  key author (author_id) -- for profile pages\n" +
+		") engine=InnoDB -- storage engine"
+	expected := "CREATE TABLE `posts` ( -- blog posts\n" +
+		"  -- surrogate key\n" +
+		"  `id` bigint(20) unsigned NOT NULL,\n" +
+		"  `author_id` int(10) unsigned NOT NULL DEFAULT '0', # references users.id\n" +
+		"  `body` text, /* markdown */\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `author` (`author_id`) -- for profile pages\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1 /* storage engine */"
+	if actual := preserveComments(fsCreate, canonicalCommentsTable); actual != expected {
+		t.Errorf("Unexpected result from preserveComments.\nExpected:\n%s\nActual:\n%s", expected, actual)
+	}
+
+	// Result should be stable if used as the filesystem create
+	if again := preserveComments(expected, canonicalCommentsTable); again != expected {
+		t.Errorf("preserveComments not idempotent.\nExpected:\n%s\nActual:\n%s", expected, again)
+	}
+
+	// Without any comments, canonical create should be returned unchanged
+	if actual := preserveComments(strings.ToLower(canonicalCommentsTable), canonicalCommentsTable); actual != canonicalCommentsTable {
+		t.Errorf("Expected canonical create to be unchanged, instead found:\n%s", actual)
+	}
+}
+
+func TestPreserveCommentsQuoted(t *testing.T) {
+	fsCreate := "CREATE TABLE `posts` (\n" +
+		"  `id` bigint(20) unsigned NOT NULL,\n" +
+		"  `author_id` int(10) unsigned NOT NULL DEFAULT '0',\n" +
+		"  `body` text COMMENT 'not -- a comment # or /* this */',\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `author` (`author_id`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1"
+	if actual := preserveComments(fsCreate, canonicalCommentsTable); actual != canonicalCommentsTable {
+		t.Errorf("Expected comment markers in quoted strings to be ignored, instead found:\n%s", actual)
+	}
+}
+
+func TestPreserveCommentsVersioned(t *testing.T) {
+	canonical := "CREATE TABLE `logs` (\n" +
+		"  `id` int(10) unsigned NOT NULL,\n" +
+		"  `created_at` datetime NOT NULL,\n" +
+		"  PRIMARY KEY (`id`,`created_at`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1\n" +
+		"/*!50500 PARTITION BY RANGE  COLUMNS(created_at)\n" +
+		"(PARTITION p2019 VALUES LESS THAN ('2020-01-01') ENGINE = InnoDB,\n" +
+		" PARTITION pmax VALUES LESS THAN (MAXVALUE) ENGINE = InnoDB) */"
+	fsCreate := "CREATE TABLE `logs` (\n" +
+		"  `id` int(10) unsigned NOT NULL,\n" +
+		"  `created_at` datetime NOT NULL,\n" +
+		"  PRIMARY KEY (`id`,`created_at`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1\n" +
+		"/*!50500 PARTITION BY RANGE  COLUMNS(created_at)\n" +
+		"/* archived yearly */\n" +
+		"(PARTITION p2019 VALUES LESS THAN ('2020-01-01') ENGINE = InnoDB, -- old rows\n" +
+		" PARTITION pmax VALUES LESS THAN (MAXVALUE) ENGINE = InnoDB) */ -- catch-all"
+	expected := "CREATE TABLE `logs` (\n" +
+		"  `id` int(10) unsigned NOT NULL,\n" +
+		"  `created_at` datetime NOT NULL,\n" +
+		"  PRIMARY KEY (`id`,`created_at`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1\n" +
+		"/*!50500 PARTITION BY RANGE  COLUMNS(created_at)\n" +
+		"-- archived yearly\n" +
+		"(PARTITION p2019 VALUES LESS THAN ('2020-01-01') ENGINE = InnoDB, -- old rows\n" +
+		" PARTITION pmax VALUES LESS THAN (MAXVALUE) ENGINE = InnoDB) */ /* catch-all */"
+	if actual := preserveComments(fsCreate, canonical); actual != expected {
+		t.Errorf("Unexpected result from preserveComments.\nExpected:\n%s\nActual:\n%s", expected, actual)
+	}
+	if again := preserveComments(expected, canonical); again != expected {
+		t.Errorf("preserveComments not idempotent.\nExpected:\n%s\nActual:\n%s", expected, again)
+	}
+}
+
+func TestPreserveCommentsUnanchored(t *testing.T) {
+	canonical := "CREATE TABLE `posts` (\n" +
+		"  `id` bigint(20) unsigned NOT NULL,\n" +
+		"  `author_id` int(10) unsigned NOT NULL,\n" +
+		"  KEY `author_id` (`author_id`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1"
+	fsCreate := "CREATE TABLE `posts` (\n" +
+		"  `id` bigint(20) unsigned NOT NULL,\n" +
+		"  `author_id` int(10) unsigned NOT NULL,\n" +
+		"  -- unnamed index\n" +
+		"  KEY (`author_id`) -- name is generated\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1"
+	if actual := preserveComments(fsCreate, canonical); actual != canonical {
+		t.Errorf("Expected comments on unnamed index to be dropped, instead found:\n%s", actual)
+	}
+}
+
+func TestElementKey(t *testing.T) {
+	cases := map[string]string{
+		"`id` int unsigned NOT NULL,":                   "column id",
+		"ID INT,":                                       "column id",
+		"PRIMARY KEY (`id`),":                           "primary key",
+		"UNIQUE KEY `email` (`email`),":                 "key email",
+		"unique email (email),":                         "key email",
+		"FULLTEXT INDEX ft_body (body),":                "key ft_body",
+		"KEY (`author_id`),":                            "",
+		"CONSTRAINT `fk_author` FOREIGN KEY (`a`) ...":  "constraint fk_author",
+		"FOREIGN KEY (author_id) REFERENCES users (id)": "",
+		"CHECK (id > 0)":                                "",
+	}
+	for input, expected := range cases {
+		if actual := elementKey(input); actual != expected {
+			t.Errorf("Expected elementKey(%q) to return %q, instead found %q", input, expected, actual)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package dumper
 import (
 	"regexp"
 
+	"github.com/skeema/skeema/fs"
 	"github.com/skeema/tengo"
 )
 
@@ -12,6 +13,9 @@ type Options struct {
 	RetainPartitioning bool                     // if true, and fs stmt has partitioning, but db doesn't, retain fs partitioning clause
 	CountOnly          bool                     // if true, skip writing files, just report count of rewrites
 	IgnoreTable        *regexp.Regexp           // skip tables with names matching this regex
+	Layout             fs.FileLayout            // determines file paths for new objects; zero value places each in <name>.sql
+	Relocate           bool                     // if true, move existing statements to match Layout
+	Style              *Style                   // if non-nil, render CREATEs in this style instead of server's SHOW CREATE format
 	skipKeys           map[tengo.ObjectKey]bool // skip objects with true values
 	onlyKeys           map[tengo.ObjectKey]bool // if map is non-nil, only format objects with true values
 }
@@ -6,6 +6,12 @@ package dumper
 
 import (
 	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/skeema/fs"
@@ -21,14 +27,43 @@ type statement struct {
 
 // DumpSchema updates the *.sql files in dir to match the creation statements
 // in schema. Any preexisting creation statements in the dir will be updated to
-// match the canonical format from the live schema. Objects that no longer exist
-// in the live schema will have their statements removed. A count of modified
-// statements is returned, along with any fatal write error. If opts.CountOnly
-// is true, no actual filesystem writes occur, but a count is still returned.
+// match the canonical format from the live schema, except for statements using
+// template placeholders, which are left as-is with a warning. Objects that no
+// longer exist in the live schema will have their statements removed. New
+// objects are written to files determined by opts.Layout; if opts.Relocate is
+// true, existing statements located elsewhere in the dir are moved there as
+// well. Statements located in subdirs not used by opts.Layout are always moved.
+// Statements located in included files outside of the dir are never removed,
+// and an included file is only rewritten if no other dir has already claimed
+// it for writing during this run.
+// A count of modified statements is returned, along with any fatal write error.
+// If opts.CountOnly is true, no actual filesystem writes occur, but a count is
+// still returned.
 func DumpSchema(schema *tengo.Schema, dir *fs.Dir, opts Options) (count int, err error) {
 	filesToRewrite := make(map[*fs.TokenizedSQLFile]bool)
+	var appends []fileAppend
 	for key, s := range getStatementMap(schema, dir, opts) {
-		if opts.shouldIgnore(key) || s.canonicalCreate == s.filesystemCreate {
+		if opts.shouldIgnore(key) {
+			continue
+		}
+		layoutPath := opts.Layout.PathForObject(dir.Path, key)
+		relocate := s.fsStatement != nil && s.canonicalCreate != "" && s.fsStatement.File != layoutPath && isWithinDir(s.fsStatement.File, dir.Path) && (opts.Relocate || !inLayoutDir(s.fsStatement.File, dir.Path, opts.Layout))
+		if s.canonicalCreate == s.filesystemCreate && !relocate {
+			continue
+		}
+		if s.canonicalCreate == "" && !isWithinDir(s.fsStatement.File, dir.Path) {
+			// Removing a statement from an included file would also remove it from
+			// every other dir including that file
+			log.Warnf("%s: Leaving %s unchanged, since it is located in an included file outside of %s. It does not exist in the live schema for this dir, but may still be needed by other dirs including the file.", s.fsStatement.Location(), key, dir.Path)
+			continue
+		}
+		if s.fsStatement != nil && !claimIncludedStatements([]*fs.Statement{s.fsStatement}, dir.Path, opts) {
+			log.Warnf("%s: Leaving %s unchanged, since its included file was already updated by another dir in this run", s.fsStatement.Location(), key)
+			continue
+		}
+		if s.canonicalCreate != "" && s.fsStatement != nil && s.fsStatement.Template != "" {
+			// Rewriting or moving a templated statement would lose its placeholders
+			log.Warnf("%s: Leaving %s unchanged, since it uses template variables. Its expanded form differs from the canonical form or its location differs from the layout, so it may need to be updated manually.", s.fsStatement.Location(), key)
 			continue
 		}
 
@@ -41,30 +76,210 @@ func DumpSchema(schema *tengo.Schema, dir *fs.Dir, opts Options) (count int, err
 		}
 
 		if s.fsStatement == nil { // exists in live db schema but not yet in filesystem
-			contents := fs.AddDelimiter(s.canonicalCreate)
-			filePath := fs.PathForObject(dir.Path, key.Name)
-			if err := appendToFile(filePath, contents); err != nil {
-				return count, err
-			}
+			appends = append(appends, fileAppend{filePath: layoutPath, contents: fs.AddDelimiter(s.canonicalCreate)})
 		} else if s.canonicalCreate == "" { // already exists in filesystem, but does not exist in live db schema
 			s.fsStatement.Remove()
+		} else if relocate { // exists in both, but is in the wrong location for the layout
+			s.fsStatement.Remove()
+			appends = append(appends, fileAppend{filePath: layoutPath, contents: fs.AddDelimiter(s.canonicalCreate), moved: true})
 		} else { // exists in live db schema AND filesystem, but needs reformat/update
 			s.fsStatement.Text = fmt.Sprintf("%s%s", s.canonicalCreate, s.filesystemDelim)
 		}
 	}
+	if opts.CountOnly {
+		for file := range filesToRewrite {
+			log.Infof("File %s requires formatting changes", file)
+		}
+		return count, nil
+	}
+	return count, writeChanges(filesToRewrite, appends)
+}
 
-	// Do the appropriate rewrites of files tracked above, if requested
+// fileAppend represents a statement to be appended to a file.
+type fileAppend struct {
+	filePath string
+	contents string
+	moved    bool // true if the statement was moved from another file
+}
+
+// writeChanges appends statements to files, and then rewrites any files whose
+// statements were modified. If an append targets a file that also needs to be
+// rewritten, the statement is added to that file's statements instead, to
+// avoid the rewrite clobbering it. Files gaining statements are written before
+// any others, so that a statement being moved between files is never absent
+// from both.
+func writeChanges(filesToRewrite map[*fs.TokenizedSQLFile]bool, appends []fileAppend) error {
+	rewriteByPath := make(map[string]*fs.TokenizedSQLFile, len(filesToRewrite))
 	for file := range filesToRewrite {
+		rewriteByPath[file.Path()] = file
+	}
+	receiving := make(map[*fs.TokenizedSQLFile]bool)
+	for _, fa := range appends {
+		if file := rewriteByPath[fa.filePath]; file != nil {
+			file.AppendStatementText(fa.contents)
+			receiving[file] = true
+		} else if err := appendToFile(fa.filePath, fa.contents, fa.moved); err != nil {
+			return err
+		}
+	}
+	for _, writeReceiving := range []bool{true, false} {
+		for file := range filesToRewrite {
+			if receiving[file] != writeReceiving {
+				continue
+			}
+			if err := rewriteSQLFile(file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// includedFiles tracks which dir has claimed each included file for writing.
+// Several dirs may include the same file, and each dir has its own parsed copy
+// of it, which may be processed concurrently. Only the first dir to modify the
+// file may rewrite it; otherwise dirs would clobber each other's changes.
+var includedFiles struct {
+	sync.Mutex
+	owner map[string]string // file path -> dir path
+}
+
+// claimIncludedFile returns true if the included file at filePath may be
+// rewritten on behalf of the dir at dirPath, or false if another dir has
+// already claimed it during this run.
+func claimIncludedFile(filePath, dirPath string) bool {
+	includedFiles.Lock()
+	defer includedFiles.Unlock()
+	if includedFiles.owner == nil {
+		includedFiles.owner = make(map[string]string)
+	}
+	if owner, ok := includedFiles.owner[filePath]; ok {
+		return owner == dirPath
+	}
+	includedFiles.owner[filePath] = dirPath
+	return true
+}
+
+// isWithinDir returns true if filePath is located in dirPath or one of its
+// subdirs, or false if it is located elsewhere, for example if it was merged
+// into the dir by way of the include option.
+func isWithinDir(filePath, dirPath string) bool {
+	return strings.HasPrefix(filePath, dirPath+"/")
+}
+
+// inLayoutDir returns true if filePath is located directly in dirPath, or in
+// one of layout's subdirs of dirPath. Files located in any other subdir must
+// remain from a previous layout, so they are always relocated.
+func inLayoutDir(filePath, dirPath string, layout fs.FileLayout) bool {
+	fileDir := path.Dir(filePath)
+	if fileDir == dirPath {
+		return true
+	}
+	for _, layoutDir := range layout.Subdirs() {
+		if fileDir == path.Join(dirPath, layoutDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// DumpData updates the INSERT statements in dir's *.sql files to match the
+// rows in data. Each table's rows are expressed as a single INSERT statement,
+// which is placed in the table's usual file if the dir did not already contain
+// any INSERTs for that table. INSERTs for any of the dir's data tables that
+// lack an entry in data are removed, since the table no longer exists. A count
+// of modified tables is returned, along with any fatal write error. Options
+// restricting the dump to specific keys are not applied here. An error is
+// returned if the dir has INSERTs for any schema other than its own, since data
+// only reflects the dir's schema.
+func DumpData(data []*fs.TableData, dir *fs.Dir, opts Options) (count int, err error) {
+	insertsByTable := make(map[string][]*fs.Statement)
+	for n, logicalSchema := range dir.LogicalSchemas {
+		if n > 0 && len(logicalSchema.Inserts) > 0 {
+			return 0, fmt.Errorf("%s: INSERT statements are only supported for the dir's own schema, not schema %s", logicalSchema.Inserts[0].Location(), tengo.EscapeIdentifier(logicalSchema.Inserts[0].Schema()))
+		}
+		for _, stmt := range logicalSchema.Inserts {
+			insertsByTable[stmt.ObjectName] = append(insertsByTable[stmt.ObjectName], stmt)
+		}
+	}
+	canonicalInserts := make(map[string]string, len(data))
+	for _, td := range data {
+		canonicalInserts[td.TableName] = td.InsertStatement()
+		if _, ok := insertsByTable[td.TableName]; !ok {
+			insertsByTable[td.TableName] = nil
+		}
+	}
+
+	filesToRewrite := make(map[*fs.TokenizedSQLFile]bool)
+	for name, stmts := range insertsByTable {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: name}
+		if opts.skipKeys[key] || (opts.IgnoreTable != nil && opts.IgnoreTable.MatchString(name)) {
+			continue
+		}
+		canonical := canonicalInserts[name]
+		if (len(stmts) == 0 && canonical == "") || (len(stmts) == 1 && stmts[0].Body() == canonical) {
+			continue
+		}
+		if claimed := claimIncludedStatements(stmts, dir.Path, opts); !claimed {
+			log.Warnf("%s: Leaving INSERT for %s unchanged, since its included file was already updated by another dir in this run", stmts[0].Location(), key)
+			continue
+		}
+		if len(stmts) == 1 && canonical != "" && stmts[0].Template != "" {
+			log.Warnf("%s: Leaving INSERT for %s unchanged, since it uses template variables. Its expanded form differs from the table's rows, so it may need to be updated manually.", stmts[0].Location(), key)
+			continue
+		}
+
+		count++
+		for _, stmt := range stmts {
+			filesToRewrite[stmt.FromFile] = true
+		}
 		if opts.CountOnly {
-			log.Infof("File %s requires formatting changes", file)
+			continue
+		}
+
+		if len(stmts) == 0 { // table has rows but no INSERTs in filesystem yet
+			if err := appendToFile(opts.Layout.PathForObject(dir.Path, key), fs.AddDelimiter(canonical), false); err != nil {
+				return count, err
+			}
+			continue
+		}
+		// Replace the first INSERT with the canonical one, and remove the rest
+		if canonical == "" {
+			stmts[0].Remove()
+		} else {
+			_, delim := stmts[0].SplitTextBody()
+			stmts[0].Text = fmt.Sprintf("%s%s", canonical, delim)
+		}
+		for _, stmt := range stmts[1:] {
+			stmt.Remove()
+		}
+	}
+
+	for file := range filesToRewrite {
+		if opts.CountOnly {
+			log.Infof("File %s requires data changes", file)
 		} else if err := rewriteSQLFile(file); err != nil {
 			return count, err
 		}
 	}
-
 	return count, nil
 }
 
+// claimIncludedStatements returns false if any of stmts are located in an
+// included file which another dir has already claimed for writing. Claims are
+// not made if opts.CountOnly is true, since no writes occur.
+func claimIncludedStatements(stmts []*fs.Statement, dirPath string, opts Options) bool {
+	if opts.CountOnly {
+		return true
+	}
+	for _, stmt := range stmts {
+		if !isWithinDir(stmt.File, dirPath) && !claimIncludedFile(stmt.File, dirPath) {
+			return false
+		}
+	}
+	return true
+}
+
 // getStatementMap builds a mapping of all object keys relevant to this dir,
 // regardless of whether they're only in filesystem, only in the live db schema,
 // or both.
@@ -92,6 +307,14 @@ func getStatementMap(schema *tengo.Schema, dir *fs.Dir, opts Options) map[tengo.
 		s := statementMap[key] // not a pointer, zero value fine
 		s.canonicalCreate = canonicalCreate
 
+		// Render the create in a flavor-independent style, if one was configured.
+		// The next auto_increment value is included under the same conditions as
+		// below.
+		if opts.Style != nil {
+			includeAutoInc := opts.IncludeAutoInc || fsAutoIncrement(s.filesystemCreate) > 1
+			s.canonicalCreate = opts.Style.CreateStatement(schema, key, includeAutoInc)
+		}
+
 		// Include or strip auto_increment clause. (Note that if fs representation
 		// already exists and explicitly had an autoinc value > 1, we keep and update
 		// it regardless.)
@@ -101,6 +324,12 @@ func getStatementMap(schema *tengo.Schema, dir *fs.Dir, opts Options) map[tengo.
 			}
 		}
 
+		// Carry over any comments inside the filesystem create, anchored to the
+		// columns, indexes, and clauses they annotated.
+		if key.Type == tengo.ObjectTypeTable && s.fsStatement != nil {
+			s.canonicalCreate = preserveComments(s.filesystemCreate, s.canonicalCreate)
+		}
+
 		// If requested, adjust the canonical create to add the partitioning clause
 		// from the filesystem create.
 		if opts.RetainPartitioning && key.Type == tengo.ObjectTypeTable && s.fsStatement != nil {
@@ -123,12 +352,33 @@ func getStatementMap(schema *tengo.Schema, dir *fs.Dir, opts Options) map[tengo.
 	return statementMap
 }
 
-// appendToFile appends contents to filePath.
-func appendToFile(filePath, contents string) error {
+// reFindAutoInc matches a table-level AUTO_INCREMENT clause in a CREATE TABLE
+// of any keyword case.
+var reFindAutoInc = regexp.MustCompile(`(?i)\)[^()]* AUTO_INCREMENT\s*=\s*(\d+)`)
+
+// fsAutoIncrement returns the table-level next AUTO_INCREMENT value from a
+// filesystem CREATE TABLE, or 0 if none is present.
+func fsAutoIncrement(create string) uint64 {
+	matches := reFindAutoInc.FindStringSubmatch(create)
+	if matches == nil {
+		return 0
+	}
+	value, _ := strconv.ParseUint(matches[1], 10, 64)
+	return value
+}
+
+// appendToFile appends contents to filePath, creating its parent dir if
+// necessary. If moved is true, the contents were moved from another file.
+func appendToFile(filePath, contents string, moved bool) error {
+	if err := os.MkdirAll(path.Dir(filePath), 0777); err != nil {
+		return err
+	}
 	if bytesWritten, wasNew, err := fs.AppendToFile(filePath, contents); err != nil {
 		return err
 	} else if wasNew {
 		log.Infof("Created %s (%d bytes)", filePath, bytesWritten)
+	} else if moved {
+		log.Infof("Wrote %s (%d bytes) -- moved object from another file", filePath, bytesWritten)
 	} else {
 		log.Infof("Wrote %s (%d bytes) -- appended new object", filePath, bytesWritten)
 	}
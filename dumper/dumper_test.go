@@ -1,6 +1,7 @@
 package dumper
 
 import (
+	"database/sql"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -52,6 +53,132 @@ type IntegrationSuite struct {
 // TestFormatSimple tests simple reformatting, where the filesystem and schema
 // match aside from formatting differences and statement errors. This is similar
 // to the usage pattern of `skeema format` or `skeema lint --format`.
+func TestDumpData(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "skeema-test-dumpdata")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dirPath)
+	fs.WriteTestFile(t, filepath.Join(dirPath, ".skeema"), "schema=product\ndata-tables=colors,sizes,shapes\n")
+	fs.WriteTestFile(t, filepath.Join(dirPath, "colors.sql"), "CREATE TABLE colors (id int PRIMARY KEY, name varchar(10));\nINSERT INTO colors VALUES (1, 'red');\nINSERT INTO colors VALUES (2, 'blue');\n")
+	fs.WriteTestFile(t, filepath.Join(dirPath, "shapes.sql"), "INSERT INTO shapes VALUES (1);\n")
+	cmd := mybase.NewCommand("dumpertest", "", "", nil)
+	util.AddGlobalOptions(cmd)
+	cmd.AddArg("environment", "production", false)
+	cfg := mybase.ParseFakeCLI(t, cmd, "dumpertest")
+	dir, err := fs.ParseDir(dirPath, cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseDir: %s", err)
+	}
+
+	str := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+	data := []*fs.TableData{
+		{
+			TableName:  "colors",
+			Columns:    []string{"id", "name"},
+			Types:      []string{"int", "varchar(10)"},
+			PrimaryKey: []int{0},
+			Rows:       [][]sql.NullString{{str("1"), str("red")}, {str("2"), str("green")}},
+		},
+		{
+			TableName:  "sizes",
+			Columns:    []string{"id"},
+			Types:      []string{"int"},
+			PrimaryKey: []int{0},
+			Rows:       [][]sql.NullString{{str("7")}},
+		},
+	}
+	count, err := DumpData(data, dir, Options{CountOnly: true})
+	if count != 3 || err != nil {
+		t.Errorf("Expected DumpData with CountOnly to return 3, nil; instead found %d, %v", count, err)
+	}
+	if count, err = DumpData(data, dir, Options{}); count != 3 || err != nil {
+		t.Errorf("Expected DumpData to return 3, nil; instead found %d, %v", count, err)
+	}
+
+	expectColors := "CREATE TABLE colors (id int PRIMARY KEY, name varchar(10));\n" + data[0].InsertStatement() + ";\n"
+	if actual := fs.ReadTestFile(t, filepath.Join(dirPath, "colors.sql")); actual != expectColors {
+		t.Errorf("Unexpected contents of colors.sql:\n%s", actual)
+	}
+	if actual := fs.ReadTestFile(t, filepath.Join(dirPath, "sizes.sql")); actual != data[1].InsertStatement()+";\n" {
+		t.Errorf("Unexpected contents of sizes.sql:\n%s", actual)
+	}
+	if _, err := os.Stat(filepath.Join(dirPath, "shapes.sql")); !os.IsNotExist(err) {
+		t.Errorf("Expected shapes.sql to be deleted, but Stat returned %v", err)
+	}
+
+	// Dumping again should be a no-op
+	if dir, err = fs.ParseDir(dirPath, cfg); err != nil {
+		t.Fatalf("Unexpected error from ParseDir: %s", err)
+	}
+	if count, err = DumpData(data, dir, Options{}); count != 0 || err != nil {
+		t.Errorf("Expected repeated DumpData to return 0, nil; instead found %d, %v", count, err)
+	}
+
+	// INSERTs for a different schema cannot be handled
+	fs.WriteTestFile(t, filepath.Join(dirPath, "other.sql"), "USE other;\nINSERT INTO colors VALUES (3, 'black');\n")
+	if dir, err = fs.ParseDir(dirPath, cfg); err != nil {
+		t.Fatalf("Unexpected error from ParseDir: %s", err)
+	}
+	if _, err = DumpData(data, dir, Options{}); err == nil {
+		t.Error("Expected DumpData to return an error for INSERTs in another schema, but it did not")
+	}
+}
+
+func TestDumpDataSharedInclude(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "skeema-test-dumpshared")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(basePath)
+	fs.WriteTestFile(t, filepath.Join(basePath, ".skeema"), "flavor=mysql:5.7\n")
+	fs.WriteTestFile(t, filepath.Join(basePath, "shared", "colors.sql"), "CREATE TABLE colors (id int PRIMARY KEY, name varchar(10));\nINSERT INTO colors VALUES (1, 'red');\n")
+	for _, shard := range []string{"shard1", "shard2"} {
+		fs.WriteTestFile(t, filepath.Join(basePath, shard, ".skeema"), "schema="+shard+"\ndata-tables=colors\ninclude=../shared/colors.sql\n")
+	}
+	cmd := mybase.NewCommand("dumpertest", "", "", nil)
+	util.AddGlobalOptions(cmd)
+	cmd.AddArg("environment", "production", false)
+	cfg := mybase.ParseFakeCLI(t, cmd, "dumpertest")
+
+	// Both dirs are parsed up-front, as with lint, so each has its own copy of
+	// the shared file. Only the first dir to write the file may do so.
+	dirs := make([]*fs.Dir, 2)
+	for n, shard := range []string{"shard1", "shard2"} {
+		if dirs[n], err = fs.ParseDir(filepath.Join(basePath, shard), cfg); err != nil {
+			t.Fatalf("Unexpected error from ParseDir: %s", err)
+		}
+	}
+	str := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+	newData := func(name string) []*fs.TableData {
+		return []*fs.TableData{{
+			TableName:  "colors",
+			Columns:    []string{"id", "name"},
+			Types:      []string{"int", "varchar(10)"},
+			PrimaryKey: []int{0},
+			Rows:       [][]sql.NullString{{str("1"), str(name)}},
+		}}
+	}
+	if count, err := DumpData(newData("green"), dirs[0], Options{}); count != 1 || err != nil {
+		t.Errorf("Expected first DumpData to return 1, nil; instead found %d, %v", count, err)
+	}
+	if count, err := DumpData(newData("blue"), dirs[1], Options{}); count != 0 || err != nil {
+		t.Errorf("Expected second DumpData to return 0, nil; instead found %d, %v", count, err)
+	}
+	expected := "CREATE TABLE colors (id int PRIMARY KEY, name varchar(10));\n" + newData("green")[0].InsertStatement() + ";\n"
+	if actual := fs.ReadTestFile(t, filepath.Join(basePath, "shared", "colors.sql")); actual != expected {
+		t.Errorf("Unexpected contents of shared colors.sql:\n%s", actual)
+	}
+
+	// The dir which claimed the file may continue to write it
+	if dirs[0], err = fs.ParseDir(filepath.Join(basePath, "shard1"), cfg); err != nil {
+		t.Fatalf("Unexpected error from ParseDir: %s", err)
+	}
+	if count, err := DumpData(newData("black"), dirs[0], Options{}); count != 1 || err != nil {
+		t.Errorf("Expected repeated DumpData to return 1, nil; instead found %d, %v", count, err)
+	}
+}
+
 func (s IntegrationSuite) TestFormatSimple(t *testing.T) {
 	opts := Options{
 		IncludeAutoInc: true,
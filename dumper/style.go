@@ -0,0 +1,368 @@
+package dumper
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// Style controls rendering of CREATE statements in a format that does not
+// depend on the database server's flavor or version. A nil *Style indicates
+// that statements should exactly match the server's SHOW CREATE output.
+type Style struct {
+	LowerKeywords         bool   // if true, SQL keywords are lowercase instead of uppercase
+	Indent                string // indentation for each line within a statement's body
+	ShowDefaultCollations bool   // if true, COLLATE clauses are included even when the collation is the charset's default
+}
+
+// ParseStyle returns a *Style for the supplied value of the format-style
+// option. A blank value, or "server", returns nil, indicating statements should
+// match the server's SHOW CREATE output. Otherwise, the value should be a
+// comma-separated list of settings, any of which may be omitted to use its
+// default: "keyword-case=upper|lower", "indent=N|tab" where N is a number of
+// spaces from 1 to 8, and "default-collations=hide|show". The value "portable"
+// uses the default for all settings.
+func ParseStyle(value string) (*Style, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "server") {
+		return nil, nil
+	}
+	style := &Style{Indent: "  "}
+	for _, setting := range strings.Split(value, ",") {
+		setting = strings.ToLower(strings.TrimSpace(setting))
+		if setting == "portable" {
+			continue
+		}
+		tokens := strings.SplitN(setting, "=", 2)
+		var name, arg string
+		name = strings.TrimSpace(tokens[0])
+		if len(tokens) > 1 {
+			arg = strings.TrimSpace(tokens[1])
+		}
+		switch {
+		case name == "keyword-case" && (arg == "upper" || arg == "lower"):
+			style.LowerKeywords = (arg == "lower")
+		case name == "indent" && arg == "tab":
+			style.Indent = "\t"
+		case name == "indent":
+			spaces, err := strconv.Atoi(arg)
+			if err != nil || spaces < 1 || spaces > 8 {
+				return nil, fmt.Errorf("Invalid format-style %q: indent must be a number of spaces from 1 to 8, or tab", value)
+			}
+			style.Indent = strings.Repeat(" ", spaces)
+		case name == "default-collations" && (arg == "hide" || arg == "show"):
+			style.ShowDefaultCollations = (arg == "show")
+		default:
+			return nil, fmt.Errorf("Invalid format-style %q: unknown setting %q", value, setting)
+		}
+	}
+	return style, nil
+}
+
+// kw returns the supplied uppercase keyword(s) in the style's keyword case.
+func (style *Style) kw(keywords string) string {
+	if style.LowerKeywords {
+		return strings.ToLower(keywords)
+	}
+	return keywords
+}
+
+// CreateStatement returns the CREATE statement for the object with the
+// supplied key in schema, rendered in the style. If the object uses features
+// that cannot be rendered, or does not exist in schema, the server's SHOW
+// CREATE output is returned instead. For tables, includeAutoInc determines
+// whether a next AUTO_INCREMENT value greater than 1 is included.
+func (style *Style) CreateStatement(schema *tengo.Schema, key tengo.ObjectKey, includeAutoInc bool) string {
+	switch key.Type {
+	case tengo.ObjectTypeTable:
+		if t := schema.Table(key.Name); t != nil {
+			if t.UnsupportedDDL || (t.Partitioning != nil && t.Partitioning.SubMethod != "") {
+				return t.CreateStatement
+			}
+			return style.tableCreate(t, includeAutoInc)
+		}
+	case tengo.ObjectTypeProc, tengo.ObjectTypeFunc:
+		for _, r := range schema.Routines {
+			if r.Type == key.Type && r.Name == key.Name {
+				return style.routineCreate(r)
+			}
+		}
+	}
+	return schema.ObjectDefinitions()[key]
+}
+
+// tableCreate renders a CREATE TABLE statement for t.
+func (style *Style) tableCreate(t *tengo.Table, includeAutoInc bool) string {
+	defs := make([]string, 0, len(t.Columns)+len(t.SecondaryIndexes)+len(t.ForeignKeys)+1)
+	for _, c := range t.Columns {
+		defs = append(defs, style.columnDefinition(c, t))
+	}
+	if t.PrimaryKey != nil {
+		defs = append(defs, style.indexDefinition(t.PrimaryKey))
+	}
+	for _, idx := range t.SecondaryIndexes {
+		defs = append(defs, style.indexDefinition(idx))
+	}
+	for _, fk := range t.ForeignKeys {
+		defs = append(defs, style.foreignKeyDefinition(fk))
+	}
+
+	options := []string{style.kw("ENGINE=") + t.Engine}
+	if includeAutoInc && t.NextAutoIncrement > 1 {
+		options = append(options, fmt.Sprintf("%s%d", style.kw("AUTO_INCREMENT="), t.NextAutoIncrement))
+	}
+	options = append(options, style.kw("DEFAULT CHARSET=")+t.CharSet)
+	if t.Collation != "" && (!t.CollationIsDefault || style.ShowDefaultCollations) {
+		options = append(options, style.kw("COLLATE=")+t.Collation)
+	}
+	if t.CreateOptions != "" {
+		options = append(options, style.createOptions(t.CreateOptions))
+	}
+	if t.Comment != "" {
+		options = append(options, fmt.Sprintf("%s'%s'", style.kw("COMMENT="), tengo.EscapeValueForCreateTable(t.Comment)))
+	}
+
+	return fmt.Sprintf("%s %s (\n%s%s\n) %s%s",
+		style.kw("CREATE TABLE"),
+		tengo.EscapeIdentifier(t.Name),
+		style.Indent,
+		strings.Join(defs, ",\n"+style.Indent),
+		strings.Join(options, " "),
+		style.partitioning(t.Partitioning, t.Engine),
+	)
+}
+
+// reIntDisplayWidth matches int family column types which include a display
+// width, or year types with the deprecated display width of 4.
+var reIntDisplayWidth = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint|year)\((\d+)\)`)
+
+// columnType returns the column's type, with the int display width removed
+// unless it affects behavior (zerofill) or conventionally indicates a boolean
+// (tinyint(1)), since newer servers omit it.
+func (style *Style) columnType(c *tengo.Column) string {
+	colType := c.TypeInDB
+	if matches := reIntDisplayWidth.FindStringSubmatch(colType); matches != nil {
+		if !strings.Contains(colType, "zerofill") && (matches[1] != "tinyint" || matches[2] != "1") {
+			colType = matches[1] + colType[len(matches[0]):]
+		}
+	}
+	return colType
+}
+
+// columnDefinition renders a column's definition clause.
+func (style *Style) columnDefinition(c *tengo.Column, t *tengo.Table) string {
+	clauses := []string{tengo.EscapeIdentifier(c.Name), style.columnType(c)}
+	var showCharSet bool
+	if c.CharSet != "" && (c.Collation != t.Collation || c.CharSet != t.CharSet) {
+		showCharSet = true
+		clauses = append(clauses, style.kw("CHARACTER SET "+c.CharSet))
+	}
+	if c.Collation != "" && (!c.CollationIsDefault || (showCharSet && style.ShowDefaultCollations)) {
+		clauses = append(clauses, style.kw("COLLATE ")+c.Collation)
+	}
+	if c.GenerationExpr != "" {
+		genKind := "STORED"
+		if c.Virtual {
+			genKind = "VIRTUAL"
+		}
+		clauses = append(clauses, fmt.Sprintf("%s (%s) %s", style.kw("GENERATED ALWAYS AS"), c.GenerationExpr, style.kw(genKind)))
+	}
+	if !c.Nullable {
+		clauses = append(clauses, style.kw("NOT NULL"))
+	} else if strings.HasPrefix(c.TypeInDB, "timestamp") {
+		// Nullability of timestamps is displayed explicitly, since the implicit
+		// default varies by server configuration
+		clauses = append(clauses, style.kw("NULL"))
+	}
+	if c.Invisible {
+		clauses = append(clauses, style.kw("INVISIBLE"))
+	}
+	if c.AutoIncrement {
+		clauses = append(clauses, style.kw("AUTO_INCREMENT"))
+	}
+	if c.Default != "" {
+		clauses = append(clauses, style.kw("DEFAULT ")+c.Default)
+	}
+	if c.OnUpdate != "" {
+		clauses = append(clauses, style.kw("ON UPDATE ")+c.OnUpdate)
+	}
+	if c.ColumnFormat != "" {
+		clauses = append(clauses, fmt.Sprintf("/*!50633 %s */", style.kw("COLUMN_FORMAT "+c.ColumnFormat)))
+	}
+	if c.Comment != "" {
+		clauses = append(clauses, fmt.Sprintf("%s '%s'", style.kw("COMMENT"), tengo.EscapeValueForCreateTable(c.Comment)))
+	}
+	return strings.Join(clauses, " ")
+}
+
+// indexDefinition renders an index's definition clause.
+func (style *Style) indexDefinition(idx *tengo.Index) string {
+	parts := make([]string, len(idx.Parts))
+	for n, part := range idx.Parts {
+		parts[n] = part.Definition(tengo.FlavorUnknown)
+		if part.Descending {
+			parts[n] = strings.TrimSuffix(parts[n], " DESC") + style.kw(" DESC")
+		}
+	}
+	var typeAndName string
+	if idx.PrimaryKey {
+		typeAndName = style.kw("PRIMARY KEY")
+	} else if idx.Unique {
+		typeAndName = style.kw("UNIQUE KEY ") + tengo.EscapeIdentifier(idx.Name)
+	} else if idx.Type != "BTREE" && idx.Type != "" {
+		typeAndName = style.kw(idx.Type+" KEY ") + tengo.EscapeIdentifier(idx.Name)
+	} else {
+		typeAndName = style.kw("KEY ") + tengo.EscapeIdentifier(idx.Name)
+	}
+	def := fmt.Sprintf("%s (%s)", typeAndName, strings.Join(parts, ","))
+	if idx.Comment != "" {
+		def += fmt.Sprintf(" %s '%s'", style.kw("COMMENT"), tengo.EscapeValueForCreateTable(idx.Comment))
+	}
+	if idx.Invisible {
+		def += fmt.Sprintf(" /*!80000 %s */", style.kw("INVISIBLE"))
+	}
+	return def
+}
+
+// foreignKeyDefinition renders a foreign key's definition clause. RESTRICT and
+// NO ACTION rules are equivalent and are always omitted, since display of NO
+// ACTION varies by server version.
+func (style *Style) foreignKeyDefinition(fk *tengo.ForeignKey) string {
+	escapeList := func(names []string) string {
+		escaped := make([]string, len(names))
+		for n, name := range names {
+			escaped[n] = tengo.EscapeIdentifier(name)
+		}
+		return strings.Join(escaped, ", ")
+	}
+	referencedTable := tengo.EscapeIdentifier(fk.ReferencedTableName)
+	if fk.ReferencedSchemaName != "" {
+		referencedTable = fmt.Sprintf("%s.%s", tengo.EscapeIdentifier(fk.ReferencedSchemaName), referencedTable)
+	}
+	def := fmt.Sprintf("%s %s %s (%s) %s %s (%s)",
+		style.kw("CONSTRAINT"),
+		tengo.EscapeIdentifier(fk.Name),
+		style.kw("FOREIGN KEY"),
+		escapeList(fk.ColumnNames),
+		style.kw("REFERENCES"),
+		referencedTable,
+		escapeList(fk.ReferencedColumnNames),
+	)
+	if fk.DeleteRule != "RESTRICT" && fk.DeleteRule != "NO ACTION" {
+		def += style.kw(" ON DELETE " + fk.DeleteRule)
+	}
+	if fk.UpdateRule != "RESTRICT" && fk.UpdateRule != "NO ACTION" {
+		def += style.kw(" ON UPDATE " + fk.UpdateRule)
+	}
+	return def
+}
+
+// createOptions renders a table's create options, such as ROW_FORMAT, in the
+// style's keyword case. Options containing quoted values are left as-is.
+func (style *Style) createOptions(createOptions string) string {
+	options := strings.Split(createOptions, " ")
+	for n, option := range options {
+		if !strings.ContainsAny(option, "`'\"") {
+			options[n] = style.kw(option)
+		}
+	}
+	return strings.Join(options, " ")
+}
+
+// partitioning renders a table's partitioning clause, or a blank string if tp
+// is nil. Unlike SHOW CREATE TABLE, the clause is never wrapped in a version-
+// gated comment, and each partition's storage engine is only included if it
+// differs from the table's.
+func (style *Style) partitioning(tp *tengo.TablePartitioning, tableEngine string) string {
+	if tp == nil {
+		return ""
+	}
+	clause := fmt.Sprintf("\n%s %s%s(%s)", style.kw("PARTITION BY"), style.kw(tp.Method+" "), tp.AlgoClause, tp.Expression)
+
+	listMode := tp.ForcePartitionList
+	if listMode == tengo.PartitionListDefault {
+		listMode = tengo.PartitionListCount
+		for n, p := range tp.Partitions {
+			if p.Values != "" || p.Comment != "" || p.DataDir != "" || p.Name != fmt.Sprintf("p%d", n) {
+				listMode = tengo.PartitionListExplicit
+				break
+			}
+		}
+	}
+	if listMode == tengo.PartitionListCount {
+		return fmt.Sprintf("%s\n%s %d", clause, style.kw("PARTITIONS"), len(tp.Partitions))
+	} else if listMode != tengo.PartitionListExplicit {
+		return clause
+	}
+
+	defs := make([]string, len(tp.Partitions))
+	for n, p := range tp.Partitions {
+		def := style.kw("PARTITION ") + tengo.EscapeIdentifier(p.Name)
+		if tp.Method == "RANGE" && p.Values == "MAXVALUE" {
+			def += style.kw(" VALUES LESS THAN MAXVALUE")
+		} else if strings.HasPrefix(tp.Method, "RANGE") {
+			def += fmt.Sprintf("%s (%s)", style.kw(" VALUES LESS THAN"), p.Values)
+		} else if strings.HasPrefix(tp.Method, "LIST") {
+			def += fmt.Sprintf("%s (%s)", style.kw(" VALUES IN"), p.Values)
+		}
+		if p.DataDir != "" {
+			def += fmt.Sprintf("%s '%s'", style.kw(" DATA DIRECTORY ="), p.DataDir)
+		}
+		if p.Comment != "" {
+			def += fmt.Sprintf("%s '%s'", style.kw(" COMMENT ="), tengo.EscapeValueForCreateTable(p.Comment))
+		}
+		if p.Engine != "" && p.Engine != tableEngine {
+			def += style.kw(" ENGINE = ") + p.Engine
+		}
+		defs[n] = def
+	}
+	return fmt.Sprintf("%s\n(\n%s%s\n)", clause, style.Indent, strings.Join(defs, ",\n"+style.Indent))
+}
+
+// routineCreate renders a CREATE PROCEDURE or CREATE FUNCTION statement for r.
+// The routine body is taken verbatim from the server's SHOW CREATE output, so
+// if the portion of that output preceding the body cannot be identified, the
+// SHOW CREATE output is returned as-is.
+func (style *Style) routineCreate(r *tengo.Routine) string {
+	server := &Style{Indent: "    "}
+	serverHead := server.routineHead(r)
+	if !strings.HasPrefix(r.CreateStatement, serverHead) {
+		return r.CreateStatement
+	}
+	return style.routineHead(r) + r.CreateStatement[len(serverHead):]
+}
+
+// routineHead renders the portion of a routine's CREATE statement preceding
+// its body. Characteristics are each placed on a separate indented line. With
+// an indent of 4 spaces and uppercase keywords, this matches SHOW CREATE.
+func (style *Style) routineHead(r *tengo.Routine) string {
+	var definer, returnClause string
+	if atPos := strings.LastIndex(r.Definer, "@"); atPos >= 0 {
+		definer = fmt.Sprintf("%s@%s", tengo.EscapeIdentifier(r.Definer[0:atPos]), tengo.EscapeIdentifier(r.Definer[atPos+1:]))
+	}
+	if r.Type == tengo.ObjectTypeFunc {
+		returnClause = fmt.Sprintf(" %s %s", style.kw("RETURNS"), r.ReturnDataType)
+	}
+	var characteristics []string
+	if r.SQLDataAccess != "CONTAINS SQL" {
+		characteristics = append(characteristics, style.kw(r.SQLDataAccess))
+	}
+	if r.Deterministic {
+		characteristics = append(characteristics, style.kw("DETERMINISTIC"))
+	}
+	if r.SecurityType != "DEFINER" {
+		characteristics = append(characteristics, style.kw("SQL SECURITY "+r.SecurityType))
+	}
+	if r.Comment != "" {
+		characteristics = append(characteristics, fmt.Sprintf("%s '%s'", style.kw("COMMENT"), tengo.EscapeValueForCreateTable(r.Comment)))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%s %s %s(%s)%s\n", style.kw("CREATE DEFINER="), definer, style.kw(r.Type.Caps()), tengo.EscapeIdentifier(r.Name), r.ParamString, returnClause)
+	for _, characteristic := range characteristics {
+		fmt.Fprintf(&b, "%s%s\n", style.Indent, characteristic)
+	}
+	return b.String()
+}
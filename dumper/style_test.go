@@ -0,0 +1,169 @@
+package dumper
+
+import (
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestParseStyle(t *testing.T) {
+	for _, value := range []string{"", "server", " Server "} {
+		if style, err := ParseStyle(value); style != nil || err != nil {
+			t.Errorf("Expected ParseStyle(%q) to return nil, nil; instead found %+v, %v", value, style, err)
+		}
+	}
+
+	cases := map[string]Style{
+		"portable":                                    {Indent: "  "},
+		"keyword-case=lower":                          {LowerKeywords: true, Indent: "  "},
+		"portable, indent=4":                          {Indent: "    "},
+		"indent=tab,default-collations=show":          {Indent: "\t", ShowDefaultCollations: true},
+		"Keyword-Case=UPPER, default-collations=hide": {Indent: "  "},
+	}
+	for value, expected := range cases {
+		if style, err := ParseStyle(value); err != nil || style == nil || *style != expected {
+			t.Errorf("Unexpected result from ParseStyle(%q): %+v, %v", value, style, err)
+		}
+	}
+
+	for _, value := range []string{"portable,indent=0", "indent=9", "indent=x", "keyword-case=title", "tabs", "portable,"} {
+		if style, err := ParseStyle(value); err == nil {
+			t.Errorf("Expected ParseStyle(%q) to return an error, instead found %+v", value, style)
+		}
+	}
+}
+
+func styleTestSchema() *tengo.Schema {
+	idCol := &tengo.Column{Name: "id", TypeInDB: "int(10) unsigned", AutoIncrement: true}
+	posts := &tengo.Table{
+		Name:               "posts",
+		Engine:             "InnoDB",
+		CharSet:            "utf8mb4",
+		Collation:          "utf8mb4_general_ci",
+		CollationIsDefault: true,
+		CreateOptions:      "ROW_FORMAT=DYNAMIC",
+		NextAutoIncrement:  123,
+		Columns: []*tengo.Column{
+			idCol,
+			{Name: "author_id", TypeInDB: "int(10) unsigned zerofill", Default: "'0'"},
+			{Name: "flag", TypeInDB: "tinyint(1)", Nullable: true, Default: "NULL"},
+			{Name: "title", TypeInDB: "varchar(80)", CharSet: "latin1", Collation: "latin1_swedish_ci", CollationIsDefault: true, Comment: "it's"},
+			{Name: "body", TypeInDB: "text", Nullable: true, CharSet: "utf8mb4", Collation: "utf8mb4_general_ci", CollationIsDefault: true},
+		},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", PrimaryKey: true, Unique: true, Parts: []tengo.IndexPart{{ColumnName: "id"}}},
+		SecondaryIndexes: []*tengo.Index{
+			{Name: "author", Parts: []tengo.IndexPart{{ColumnName: "author_id"}, {ColumnName: "title", PrefixLength: 10}}},
+		},
+		ForeignKeys: []*tengo.ForeignKey{
+			{Name: "fk_author", ColumnNames: []string{"author_id"}, ReferencedTableName: "users", ReferencedColumnNames: []string{"id"}, UpdateRule: "NO ACTION", DeleteRule: "CASCADE"},
+		},
+	}
+	logs := &tengo.Table{
+		Name:               "logs",
+		Engine:             "InnoDB",
+		CharSet:            "latin1",
+		Collation:          "latin1_swedish_ci",
+		CollationIsDefault: true,
+		Columns:            []*tengo.Column{{Name: "year", TypeInDB: "year(4)"}},
+		Partitioning: &tengo.TablePartitioning{
+			Method:     "RANGE",
+			Expression: "`year`",
+			Partitions: []*tengo.Partition{
+				{Name: "p2019", Values: "2020", Engine: "InnoDB"},
+				{Name: "pmax", Values: "MAXVALUE", Engine: "InnoDB"},
+			},
+		},
+	}
+	unsupported := &tengo.Table{
+		Name:            "unsupported",
+		UnsupportedDDL:  true,
+		CreateStatement: "CREATE TABLE `unsupported` (\n  `id` int(11) DEFAULT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=latin1",
+	}
+	proc := &tengo.Routine{
+		Name:            "doit",
+		Type:            tengo.ObjectTypeProc,
+		Definer:         "root@%",
+		ParamString:     "IN x int",
+		SQLDataAccess:   "READS SQL DATA",
+		SecurityType:    "INVOKER",
+		CreateStatement: "CREATE DEFINER=`root`@`%` PROCEDURE `doit`(IN x int)\n    READS SQL DATA\n    SQL SECURITY INVOKER\nSELECT x",
+	}
+	return &tengo.Schema{
+		Name:     "product",
+		Tables:   []*tengo.Table{posts, logs, unsupported},
+		Routines: []*tengo.Routine{proc},
+	}
+}
+
+func TestStyleCreateStatement(t *testing.T) {
+	schema := styleTestSchema()
+	style, _ := ParseStyle("portable")
+	postsKey := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "posts"}
+	expected := "CREATE TABLE `posts` (\n" +
+		"  `id` int unsigned NOT NULL AUTO_INCREMENT,\n" +
+		"  `author_id` int(10) unsigned zerofill NOT NULL DEFAULT '0',\n" +
+		"  `flag` tinyint(1) DEFAULT NULL,\n" +
+		"  `title` varchar(80) CHARACTER SET latin1 NOT NULL COMMENT 'it''s',\n" +
+		"  `body` text,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `author` (`author_id`,`title`(10)),\n" +
+		"  CONSTRAINT `fk_author` FOREIGN KEY (`author_id`) REFERENCES `users` (`id`) ON DELETE CASCADE\n" +
+		") ENGINE=InnoDB AUTO_INCREMENT=123 DEFAULT CHARSET=utf8mb4 ROW_FORMAT=DYNAMIC"
+	if actual := style.CreateStatement(schema, postsKey, true); actual != expected {
+		t.Errorf("Unexpected result from CreateStatement.\nExpected:\n%s\nActual:\n%s", expected, actual)
+	}
+
+	style, _ = ParseStyle("keyword-case=lower,indent=tab,default-collations=show")
+	expected = "create table `posts` (\n" +
+		"\t`id` int unsigned not null auto_increment,\n" +
+		"\t`author_id` int(10) unsigned zerofill not null default '0',\n" +
+		"\t`flag` tinyint(1) default NULL,\n" +
+		"\t`title` varchar(80) character set latin1 collate latin1_swedish_ci not null comment 'it''s',\n" +
+		"\t`body` text,\n" +
+		"\tprimary key (`id`),\n" +
+		"\tkey `author` (`author_id`,`title`(10)),\n" +
+		"\tconstraint `fk_author` foreign key (`author_id`) references `users` (`id`) on delete cascade\n" +
+		") engine=InnoDB default charset=utf8mb4 collate=utf8mb4_general_ci row_format=dynamic"
+	if actual := style.CreateStatement(schema, postsKey, false); actual != expected {
+		t.Errorf("Unexpected result from CreateStatement.\nExpected:\n%s\nActual:\n%s", expected, actual)
+	}
+
+	style, _ = ParseStyle("portable")
+	logsKey := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "logs"}
+	expected = "CREATE TABLE `logs` (\n" +
+		"  `year` year NOT NULL\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=latin1\n" +
+		"PARTITION BY RANGE (`year`)\n" +
+		"(\n" +
+		"  PARTITION `p2019` VALUES LESS THAN (2020),\n" +
+		"  PARTITION `pmax` VALUES LESS THAN MAXVALUE\n" +
+		")"
+	if actual := style.CreateStatement(schema, logsKey, true); actual != expected {
+		t.Errorf("Unexpected result from CreateStatement.\nExpected:\n%s\nActual:\n%s", expected, actual)
+	}
+
+	unsupportedKey := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "unsupported"}
+	if actual := style.CreateStatement(schema, unsupportedKey, true); actual != schema.Tables[2].CreateStatement {
+		t.Errorf("Expected unsupported table to use SHOW CREATE output, instead found:\n%s", actual)
+	}
+
+	style, _ = ParseStyle("keyword-case=lower,indent=2")
+	procKey := tengo.ObjectKey{Type: tengo.ObjectTypeProc, Name: "doit"}
+	expected = "create definer=`root`@`%` procedure `doit`(IN x int)\n  reads sql data\n  sql security invoker\nSELECT x"
+	if actual := style.CreateStatement(schema, procKey, true); actual != expected {
+		t.Errorf("Unexpected result from CreateStatement.\nExpected:\n%s\nActual:\n%s", expected, actual)
+	}
+}
+
+func TestFSAutoIncrement(t *testing.T) {
+	cases := map[string]uint64{
+		"CREATE TABLE t (id int AUTO_INCREMENT PRIMARY KEY) ENGINE=InnoDB AUTO_INCREMENT=5 DEFAULT CHARSET=latin1": 5,
+		"create table t (id int auto_increment primary key) engine=InnoDB auto_increment = 12":                     12,
+		"CREATE TABLE t (id int AUTO_INCREMENT PRIMARY KEY) ENGINE=InnoDB":                                         0,
+	}
+	for input, expected := range cases {
+		if actual := fsAutoIncrement(input); actual != expected {
+			t.Errorf("Expected fsAutoIncrement to return %d, instead found %d, for input %s", expected, actual, input)
+		}
+	}
+}
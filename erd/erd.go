@@ -0,0 +1,285 @@
+// Package erd renders entity-relationship diagrams of a schema's tables and
+// foreign keys, in formats understood by common diagramming tools.
+package erd
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// Format represents an output format for diagrams.
+type Format string
+
+// Constants enumerating valid Format values.
+const (
+	FormatDOT      Format = "dot"      // Graphviz
+	FormatMermaid  Format = "mermaid"  // Mermaid erDiagram
+	FormatPlantUML Format = "plantuml" // PlantUML entity diagram
+)
+
+// Options controls diagram rendering.
+type Options struct {
+	Format          Format
+	OnlyTable       *regexp.Regexp            // if non-nil, only include tables with names matching this regex
+	IgnoreTable     *regexp.Regexp            // if non-nil, exclude tables with names matching this regex
+	CollapseColumns bool                      // if true, show only table names, without columns
+	Highlight       map[string]tengo.DiffType // table name -> DiffTypeCreate or DiffTypeAlter, for tables to highlight
+}
+
+// relationship is a foreign key between two tables which are both included in
+// a diagram.
+type relationship struct {
+	from, to *tengo.Table
+	fk       *tengo.ForeignKey
+	optional bool // true if any of the FK's columns are nullable
+}
+
+// Render returns a diagram of the tables in schema, along with the
+// relationships between them. Foreign keys referencing tables in other schemas,
+// or tables excluded by opts, are not shown. The schema's name is supplied
+// separately, since schema may have been introspected from a workspace.
+func Render(schemaName string, schema *tengo.Schema, opts Options) string {
+	var tables []*tengo.Table
+	included := make(map[string]*tengo.Table)
+	for _, t := range schema.Tables {
+		if (opts.OnlyTable != nil && !opts.OnlyTable.MatchString(t.Name)) || (opts.IgnoreTable != nil && opts.IgnoreTable.MatchString(t.Name)) {
+			continue
+		}
+		tables = append(tables, t)
+		included[t.Name] = t
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+
+	var rels []relationship
+	for _, t := range tables {
+		cols := t.ColumnsByName()
+		for _, fk := range t.ForeignKeys {
+			parent := included[fk.ReferencedTableName]
+			if parent == nil || (fk.ReferencedSchemaName != "" && fk.ReferencedSchemaName != schemaName) {
+				continue
+			}
+			rel := relationship{from: t, to: parent, fk: fk}
+			for _, colName := range fk.ColumnNames {
+				if col := cols[colName]; col != nil && col.Nullable {
+					rel.optional = true
+				}
+			}
+			rels = append(rels, rel)
+		}
+	}
+
+	switch opts.Format {
+	case FormatMermaid:
+		return renderMermaid(tables, rels, opts)
+	case FormatPlantUML:
+		return renderPlantUML(schemaName, tables, rels, opts)
+	default:
+		return renderDOT(schemaName, tables, rels, opts)
+	}
+}
+
+// keyColumns returns sets of the names of columns in the table's primary key
+// and in any of its foreign keys.
+func keyColumns(t *tengo.Table) (pk, fk map[string]bool) {
+	pk, fk = make(map[string]bool), make(map[string]bool)
+	if t.PrimaryKey != nil {
+		for _, part := range t.PrimaryKey.Parts {
+			pk[part.ColumnName] = true
+		}
+	}
+	for _, foreignKey := range t.ForeignKeys {
+		for _, colName := range foreignKey.ColumnNames {
+			fk[colName] = true
+		}
+	}
+	return pk, fk
+}
+
+// identifier returns name with any characters other than letters, digits, and
+// underscores replaced, for use in formats which do not permit quoting names.
+func identifier(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// highlightColors maps diff types to the background colors of highlighted
+// tables.
+var highlightColors = map[tengo.DiffType]string{
+	tengo.DiffTypeCreate: "palegreen",
+	tengo.DiffTypeAlter:  "khaki",
+}
+
+func renderDOT(schemaName string, tables []*tengo.Table, rels []relationship, opts Options) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(schemaName))
+	b.WriteString("  graph [rankdir=LR];\n")
+	if opts.CollapseColumns {
+		b.WriteString("  node [shape=box];\n")
+	} else {
+		b.WriteString("  node [shape=plaintext];\n")
+	}
+	b.WriteString("  edge [arrowhead=none, arrowtail=crow, dir=both];\n")
+	ports := make(map[*tengo.Table]map[string]string)
+	for _, t := range tables {
+		color := highlightColors[opts.Highlight[t.Name]]
+		if opts.CollapseColumns {
+			var style string
+			if color != "" {
+				style = fmt.Sprintf(", style=filled, fillcolor=%s", color)
+			}
+			fmt.Fprintf(&b, "  %s [label=%s%s];\n", dotQuote(t.Name), dotQuote(t.Name), style)
+			continue
+		}
+		if color == "" {
+			color = "lightgrey"
+		}
+		pk, fk := keyColumns(t)
+		ports[t] = make(map[string]string, len(t.Columns))
+		var label strings.Builder
+		fmt.Fprintf(&label, `<table border="0" cellborder="1" cellspacing="0"><tr><td bgcolor="%s"><b>%s</b></td></tr>`, color, html.EscapeString(t.Name))
+		for n, col := range t.Columns {
+			port := fmt.Sprintf("c%d", n)
+			ports[t][col.Name] = port
+			name := html.EscapeString(col.Name)
+			if pk[col.Name] {
+				name = "<u>" + name + "</u>"
+			}
+			if fk[col.Name] {
+				name = "<i>" + name + "</i>"
+			}
+			fmt.Fprintf(&label, `<tr><td align="left" port="%s">%s %s</td></tr>`, port, name, html.EscapeString(col.TypeInDB))
+		}
+		label.WriteString("</table>")
+		fmt.Fprintf(&b, "  %s [label=<%s>];\n", dotQuote(t.Name), label.String())
+	}
+	for _, rel := range rels {
+		from, to := dotQuote(rel.from.Name), dotQuote(rel.to.Name)
+		if !opts.CollapseColumns {
+			from += ":" + ports[rel.from][rel.fk.ColumnNames[0]]
+			to += ":" + ports[rel.to][rel.fk.ReferencedColumnNames[0]]
+		}
+		var style string
+		if rel.optional {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [label=%s%s];\n", from, to, dotQuote(rel.fk.Name), style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote returns s as a double-quoted DOT ID.
+func dotQuote(s string) string {
+	return `"` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+}
+
+func renderMermaid(tables []*tengo.Table, rels []relationship, opts Options) string {
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, t := range tables {
+		if opts.CollapseColumns {
+			fmt.Fprintf(&b, "  %s\n", identifier(t.Name))
+			continue
+		}
+		pk, fk := keyColumns(t)
+		fmt.Fprintf(&b, "  %s {\n", identifier(t.Name))
+		for _, col := range t.Columns {
+			colType := col.TypeInDB
+			if pos := strings.IndexAny(colType, "( "); pos > 0 {
+				colType = colType[:pos]
+			}
+			var keys []string
+			if pk[col.Name] {
+				keys = append(keys, "PK")
+			}
+			if fk[col.Name] {
+				keys = append(keys, "FK")
+			}
+			line := fmt.Sprintf("    %s %s", identifier(colType), identifier(col.Name))
+			if len(keys) > 0 {
+				line += " " + strings.Join(keys, ",")
+			}
+			fmt.Fprintf(&b, "%s\n", line)
+		}
+		b.WriteString("  }\n")
+	}
+	for _, rel := range rels {
+		parentCardinality := "||"
+		if rel.optional {
+			parentCardinality = "|o"
+		}
+		fmt.Fprintf(&b, "  %s }o--%s %s : %q\n", identifier(rel.from.Name), parentCardinality, identifier(rel.to.Name), strings.Replace(rel.fk.Name, `"`, "'", -1))
+	}
+	if len(opts.Highlight) > 0 {
+		for _, diffType := range []tengo.DiffType{tengo.DiffTypeCreate, tengo.DiffTypeAlter} {
+			var names []string
+			for _, t := range tables {
+				if opts.Highlight[t.Name] == diffType {
+					names = append(names, identifier(t.Name))
+				}
+			}
+			if len(names) > 0 {
+				className := strings.ToLower(diffType.String())
+				fmt.Fprintf(&b, "  classDef %s fill:%s\n", className, highlightColors[diffType])
+				fmt.Fprintf(&b, "  class %s %s\n", strings.Join(names, ","), className)
+			}
+		}
+	}
+	return b.String()
+}
+
+func renderPlantUML(schemaName string, tables []*tengo.Table, rels []relationship, opts Options) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "@startuml %s\n", identifier(schemaName))
+	b.WriteString("hide circle\nskinparam linetype ortho\n")
+	for _, t := range tables {
+		var color string
+		if c := highlightColors[opts.Highlight[t.Name]]; c != "" {
+			color = " #" + c
+		}
+		fmt.Fprintf(&b, "entity %q as %s%s", t.Name, identifier(t.Name), color)
+		if opts.CollapseColumns {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(" {\n")
+		pk, fk := keyColumns(t)
+		var pastPK bool
+		for _, col := range t.Columns {
+			if !pk[col.Name] && !pastPK && len(pk) > 0 {
+				b.WriteString("  --\n")
+				pastPK = true
+			}
+			var mandatory, stereotype string
+			if !col.Nullable {
+				mandatory = "* "
+			}
+			if pk[col.Name] {
+				stereotype = " <<PK>>"
+			}
+			if fk[col.Name] {
+				stereotype += " <<FK>>"
+			}
+			fmt.Fprintf(&b, "  %s%s : %s%s\n", mandatory, col.Name, col.TypeInDB, stereotype)
+		}
+		b.WriteString("}\n")
+	}
+	for _, rel := range rels {
+		parentCardinality := "||"
+		if rel.optional {
+			parentCardinality = "|o"
+		}
+		fmt.Fprintf(&b, "%s }o..%s %s : %s\n", identifier(rel.from.Name), parentCardinality, identifier(rel.to.Name), rel.fk.Name)
+	}
+	b.WriteString("@enduml\n")
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package erd
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func testSchema() *tengo.Schema {
+	users := &tengo.Table{
+		Name: "users",
+		Columns: []*tengo.Column{
+			{Name: "id", TypeInDB: "int(10) unsigned"},
+			{Name: "name", TypeInDB: "varchar(30)"},
+		},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", PrimaryKey: true, Unique: true, Parts: []tengo.IndexPart{{ColumnName: "id"}}},
+	}
+	posts := &tengo.Table{
+		Name: "posts",
+		Columns: []*tengo.Column{
+			{Name: "id", TypeInDB: "int(10) unsigned"},
+			{Name: "user_id", TypeInDB: "int(10) unsigned"},
+			{Name: "editor_id", TypeInDB: "int(10) unsigned", Nullable: true},
+		},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", PrimaryKey: true, Unique: true, Parts: []tengo.IndexPart{{ColumnName: "id"}}},
+		ForeignKeys: []*tengo.ForeignKey{
+			{Name: "posts_user", ColumnNames: []string{"user_id"}, ReferencedTableName: "users", ReferencedColumnNames: []string{"id"}},
+			{Name: "posts_editor", ColumnNames: []string{"editor_id"}, ReferencedTableName: "users", ReferencedColumnNames: []string{"id"}},
+			{Name: "posts_other", ColumnNames: []string{"user_id"}, ReferencedSchemaName: "other", ReferencedTableName: "users", ReferencedColumnNames: []string{"id"}},
+		},
+	}
+	logs := &tengo.Table{
+		Name:    "audit-log",
+		Columns: []*tengo.Column{{Name: "msg", TypeInDB: "text", Nullable: true}},
+	}
+	return &tengo.Schema{Name: "_skeema_tmp", Tables: []*tengo.Table{users, posts, logs}}
+}
+
+func expectContains(t *testing.T, output string, expected ...string) {
+	t.Helper()
+	for _, substring := range expected {
+		if !strings.Contains(output, substring) {
+			t.Errorf("Expected output to contain %q, but it did not. Output:\n%s", substring, output)
+		}
+	}
+}
+
+func expectNotContains(t *testing.T, output string, unexpected ...string) {
+	t.Helper()
+	for _, substring := range unexpected {
+		if strings.Contains(output, substring) {
+			t.Errorf("Expected output not to contain %q, but it did. Output:\n%s", substring, output)
+		}
+	}
+}
+
+func TestRenderDOT(t *testing.T) {
+	schema := testSchema()
+	output := Render("product", schema, Options{Format: FormatDOT})
+	expectContains(t, output,
+		`digraph "product" {`,
+		`"posts" [label=<<table border="0" cellborder="1" cellspacing="0"><tr><td bgcolor="lightgrey"><b>posts</b></td></tr><tr><td align="left" port="c0"><u>id</u> int(10) unsigned</td></tr><tr><td align="left" port="c1"><i>user_id</i> int(10) unsigned</td></tr>`,
+		`"posts":c1 -> "users":c0 [label="posts_user"];`,
+		`"posts":c2 -> "users":c0 [label="posts_editor", style=dashed];`,
+		`"audit-log" [label=<`,
+	)
+	expectNotContains(t, output, "posts_other")
+
+	opts := Options{
+		Format:          FormatDOT,
+		CollapseColumns: true,
+		Highlight:       map[string]tengo.DiffType{"posts": tengo.DiffTypeCreate},
+	}
+	output = Render("product", schema, opts)
+	expectContains(t, output,
+		`"posts" [label="posts", style=filled, fillcolor=palegreen];`,
+		`"users" [label="users"];`,
+		`"posts" -> "users" [label="posts_user"];`,
+	)
+	expectNotContains(t, output, "user_id", "<table")
+}
+
+func TestRenderMermaid(t *testing.T) {
+	opts := Options{
+		Format:    FormatMermaid,
+		Highlight: map[string]tengo.DiffType{"posts": tengo.DiffTypeAlter, "audit-log": tengo.DiffTypeCreate},
+	}
+	output := Render("product", testSchema(), opts)
+	expectContains(t, output,
+		"erDiagram\n",
+		"  posts {\n    int id PK\n    int user_id FK\n    int editor_id FK\n  }\n",
+		"  audit_log {\n    text msg\n  }\n",
+		`  posts }o--|| users : "posts_user"`,
+		`  posts }o--|o users : "posts_editor"`,
+		"  classDef create fill:palegreen\n  class audit_log create\n",
+		"  classDef alter fill:khaki\n  class posts alter\n",
+	)
+}
+
+func TestRenderPlantUML(t *testing.T) {
+	opts := Options{
+		Format:      FormatPlantUML,
+		OnlyTable:   regexp.MustCompile("^(posts|users)$"),
+		IgnoreTable: regexp.MustCompile("^users$"),
+		Highlight:   map[string]tengo.DiffType{"posts": tengo.DiffTypeAlter},
+	}
+	output := Render("product", testSchema(), opts)
+	expectContains(t, output,
+		"@startuml product\n",
+		"entity \"posts\" as posts #khaki {\n  * id : int(10) unsigned <<PK>>\n  --\n  * user_id : int(10) unsigned <<FK>>\n  editor_id : int(10) unsigned <<FK>>\n}\n",
+		"@enduml\n",
+	)
+	expectNotContains(t, output, "users {", "audit", "}o..")
+
+	opts.IgnoreTable = nil
+	opts.CollapseColumns = true
+	output = Render("product", testSchema(), opts)
+	expectContains(t, output,
+		"entity \"users\" as users\n",
+		"posts }o..|| users : posts_user\n",
+		"posts }o..|o users : posts_editor\n",
+	)
+	expectNotContains(t, output, "<<PK>>")
+}
@@ -69,10 +69,11 @@ func Exit(err error) {
 	} else {
 		message := err.Error()
 		if message != "" {
+			entry := log.WithField("exitCode", exitCode)
 			if exitCode >= CodeFatalError {
-				log.Error(message)
+				entry.Error(message)
 			} else {
-				log.Warn(message)
+				entry.Warn(message)
 			}
 		}
 		log.Debugf("Exit code %d", exitCode)
@@ -0,0 +1,112 @@
+package fs
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// TableData represents the rows of a table listed in the data-tables option.
+// Values are stored exactly as returned by the server, with NULLs represented
+// by an invalid sql.NullString. Generated columns are excluded, since their
+// values cannot be inserted.
+type TableData struct {
+	TableName  string             `json:"table"`
+	Columns    []string           `json:"columns"`
+	Types      []string           `json:"types"`                // column types, in same order as Columns
+	Collations []string           `json:"collations,omitempty"` // column collations, blank if not textual
+	PrimaryKey []int              `json:"primaryKey"`           // positions in Columns of the primary key
+	Rows       [][]sql.NullString `json:"rows"`                 // ordered by primary key
+}
+
+// RowKey returns a string uniquely identifying the supplied row by its primary
+// key values, for use in comparing rows between two TableData values. Textual
+// primary key values are normalized according to their column's collation, so
+// that rows which the server considers to have equal primary keys also have
+// equal RowKeys: values are lowercased for case-insensitive collations, and
+// trailing spaces are removed for collations with PAD SPACE semantics. Other
+// collation rules, such as accent insensitivity, are not taken into account.
+func (td *TableData) RowKey(row []sql.NullString) string {
+	parts := make([]string, len(td.PrimaryKey))
+	for n, pos := range td.PrimaryKey {
+		parts[n] = row[pos].String
+		if pos < len(td.Collations) && td.Collations[pos] != "" {
+			parts[n] = normalizeForCollation(parts[n], td.Collations[pos])
+		}
+	}
+	return strings.Join(parts, "\000")
+}
+
+// normalizeForCollation returns value in a form suitable for equality
+// comparisons under the named collation.
+func normalizeForCollation(value, collation string) string {
+	// MySQL 8's utf8mb4_0900 collations and MariaDB's nopad collations do not
+	// ignore trailing spaces; all others do
+	if !strings.Contains(collation, "_0900_") && !strings.Contains(collation, "_nopad_") {
+		value = strings.TrimRight(value, " ")
+	}
+	if strings.HasSuffix(collation, "_ci") {
+		value = strings.ToLower(value)
+	}
+	return value
+}
+
+// ColumnIndex returns the position of the named column in td.Columns, or -1
+// if there is no such column.
+func (td *TableData) ColumnIndex(name string) int {
+	for n, col := range td.Columns {
+		if col == name {
+			return n
+		}
+	}
+	return -1
+}
+
+var numericValue = regexp.MustCompile(`^[-+]?[0-9]*\.?[0-9]+([eE][-+]?[0-9]+)?$`)
+
+// Literal returns value, obtained from the column at position col, formatted
+// as a SQL literal. Numeric values are unquoted, binary values are expressed
+// in hex, and all other values are quoted strings.
+func (td *TableData) Literal(col int, value sql.NullString) string {
+	if !value.Valid {
+		return "NULL"
+	}
+	colType := strings.ToLower(td.Types[col])
+	if paren := strings.IndexAny(colType, "( "); paren > -1 {
+		colType = colType[:paren]
+	}
+	switch colType {
+	case "tinyint", "smallint", "mediumint", "int", "bigint", "decimal", "float", "double":
+		if numericValue.MatchString(value.String) {
+			return value.String
+		}
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bit":
+		return fmt.Sprintf("X'%s'", hex.EncodeToString([]byte(value.String)))
+	}
+	return "'" + tengo.EscapeValueForCreateTable(value.String) + "'"
+}
+
+// InsertStatement returns a single multi-row INSERT statement for all rows in
+// td, with one row per line. If td has no rows, an empty string is returned.
+func (td *TableData) InsertStatement() string {
+	if len(td.Rows) == 0 {
+		return ""
+	}
+	cols := make([]string, len(td.Columns))
+	for n, col := range td.Columns {
+		cols[n] = tengo.EscapeIdentifier(col)
+	}
+	rows := make([]string, len(td.Rows))
+	for n, row := range td.Rows {
+		values := make([]string, len(row))
+		for col, value := range row {
+			values[col] = td.Literal(col, value)
+		}
+		rows[n] = "  (" + strings.Join(values, ", ") + ")"
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES\n%s", tengo.EscapeIdentifier(td.TableName), strings.Join(cols, ", "), strings.Join(rows, ",\n"))
+}
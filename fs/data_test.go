@@ -0,0 +1,58 @@
+package fs
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestTableDataInsertStatement(t *testing.T) {
+	str := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+	td := &TableData{
+		TableName:  "statuses",
+		Columns:    []string{"id", "name", "flags", "rate"},
+		Types:      []string{"int(10) unsigned", "varchar(30)", "varbinary(2)", "decimal(5,2)"},
+		PrimaryKey: []int{0},
+		Rows: [][]sql.NullString{
+			{str("1"), str("it's\nactive"), str("\x01\xff"), str("1.50")},
+			{str("2"), {}, str(""), str("-3")},
+		},
+	}
+	expected := "INSERT INTO `statuses` (`id`, `name`, `flags`, `rate`) VALUES\n" +
+		"  (1, 'it''s\\nactive', X'01ff', 1.50),\n" +
+		"  (2, NULL, X'', -3)"
+	if actual := td.InsertStatement(); actual != expected {
+		t.Errorf("Unexpected InsertStatement():\n%s\nExpected:\n%s", actual, expected)
+	}
+	if key1, key2 := td.RowKey(td.Rows[0]), td.RowKey(td.Rows[1]); key1 == key2 || key1 != "1" {
+		t.Errorf("Unexpected RowKey results: %q, %q", key1, key2)
+	}
+	// RowKey should normalize textual primary key values by collation
+	td.PrimaryKey = []int{1}
+	td.Collations = []string{"", "latin1_swedish_ci", "", ""}
+	if key1, key2 := td.RowKey([]sql.NullString{{}, str("Abc  ")}), td.RowKey([]sql.NullString{{}, str("abc")}); key1 != key2 {
+		t.Errorf("Expected RowKeys to be equal under case-insensitive PAD SPACE collation, instead found %q vs %q", key1, key2)
+	}
+	td.Collations[1] = "utf8mb4_0900_ai_ci"
+	if key1, key2 := td.RowKey([]sql.NullString{{}, str("Abc  ")}), td.RowKey([]sql.NullString{{}, str("abc")}); key1 == key2 {
+		t.Errorf("Expected RowKeys to differ under NO PAD collation, but both were %q", key1)
+	}
+	td.Collations[1] = "latin1_bin"
+	if key1, key2 := td.RowKey([]sql.NullString{{}, str("Abc")}), td.RowKey([]sql.NullString{{}, str("abc")}); key1 == key2 {
+		t.Errorf("Expected RowKeys to differ under binary collation, but both were %q", key1)
+	}
+	td.PrimaryKey, td.Collations = []int{0}, nil
+
+	if td.ColumnIndex("flags") != 2 || td.ColumnIndex("nope") != -1 {
+		t.Error("Unexpected result from ColumnIndex")
+	}
+
+	// Non-numeric values in numeric columns should still be quoted
+	if actual := td.Literal(0, str("1; DROP TABLE x")); actual != "'1; DROP TABLE x'" {
+		t.Errorf("Unexpected Literal result: %s", actual)
+	}
+
+	td.Rows = nil
+	if actual := td.InsertStatement(); actual != "" {
+		t.Errorf("Expected empty InsertStatement for table without rows, instead found %s", actual)
+	}
+}
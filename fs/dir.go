@@ -37,11 +37,16 @@ type Dir struct {
 // statement before them". This "nameless" LogicalSchema is mapped to schema
 // names based on the "schema" option in the dir's OptionFile.
 type LogicalSchema struct {
-	Name      string
-	CharSet   string
-	Collation string
-	Creates   map[tengo.ObjectKey]*Statement
-	Alters    []*Statement // Alterations that are run after the Creates
+	Name              string
+	CharSet           string
+	Collation         string
+	DefaultEncryption string // "Y", "N", or blank if not managed
+	ReadOnly          string // "1", "0", or blank if not managed
+	OptionFilePath    string // path of the option file configuring this schema, if any
+	Creates           map[tengo.ObjectKey]*Statement
+	Alters            []*Statement // Alterations that are run after the Creates
+	Inserts           []*Statement // Row data for DataTables, inserted after the Alters
+	DataTables        []string     // Tables whose rows are managed by Inserts
 }
 
 // AddStatement adds the supplied statement into the appropriate data structure
@@ -65,6 +70,9 @@ func (logicalSchema *LogicalSchema) AddStatement(stmt *Statement) error {
 	case StatementTypeAlter:
 		logicalSchema.Alters = append(logicalSchema.Alters, stmt)
 		return nil
+	case StatementTypeInsert:
+		logicalSchema.Inserts = append(logicalSchema.Inserts, stmt)
+		return nil
 	default:
 		return nil
 	}
@@ -149,9 +157,22 @@ func (dir *Dir) Subdirs() ([]*Dir, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Subdirs holding a schema dir's *.sql files under its layout are part of the
+	// schema dir itself, rather than separate dirs
+	layoutDirs := make(map[string]bool)
+	if dir.HasSchema() {
+		if layout, err := dir.FileLayout(); err == nil {
+			for _, layoutDir := range layout.Subdirs() {
+				layoutDirs[strings.SplitN(layoutDir, "/", 2)[0]] = true
+			}
+			for _, layoutDir := range dir.staleLayoutDirs(layout) {
+				layoutDirs[layoutDir] = true
+			}
+		}
+	}
 	result := make([]*Dir, 0, len(fileInfos))
 	for _, fi := range fileInfos {
-		if fi.IsDir() && fi.Name()[0] != '.' {
+		if fi.IsDir() && fi.Name()[0] != '.' && !layoutDirs[fi.Name()] {
 			sub := &Dir{
 				Path:     path.Join(dir.Path, fi.Name()),
 				Config:   dir.Config.Clone(),
@@ -164,6 +185,32 @@ func (dir *Dir) Subdirs() ([]*Dir, error) {
 	return result, nil
 }
 
+// staleLayoutDirs returns the names of direct subdirs of dir which are named
+// like a layout's type subdirs, but are not used by the supplied layout. These
+// typically remain after the layout option is changed or removed. As long as
+// they lack a .skeema file, their *.sql files are still treated as part of dir,
+// so that they may be relocated to match the current layout.
+func (dir *Dir) staleLayoutDirs(layout FileLayout) []string {
+	fileInfos, err := ioutil.ReadDir(dir.Path)
+	if err != nil {
+		return nil
+	}
+	current := make(map[string]bool)
+	for _, layoutDir := range layout.Subdirs() {
+		current[strings.SplitN(layoutDir, "/", 2)[0]] = true
+	}
+	var result []string
+	for _, fi := range fileInfos {
+		if !fi.IsDir() || current[fi.Name()] || !isLayoutDirName(fi.Name()) {
+			continue
+		}
+		if _, err := os.Stat(path.Join(dir.Path, fi.Name(), ".skeema")); os.IsNotExist(err) {
+			result = append(result, fi.Name())
+		}
+	}
+	return result
+}
+
 // CreateSubdir creates a subdirectory with the supplied name and optional
 // config file. If the directory already exists, it is an error if it already
 // contains any *.sql files or a .skeema file.
@@ -347,6 +394,41 @@ func (dir *Dir) FirstInstance() (*tengo.Instance, error) {
 	return nil, fmt.Errorf("Unable to connect to any of %d instances for %s; last error %s", len(instances), dir, lastErr)
 }
 
+// WorkspaceInstance returns a tengo.Instance for the dedicated workspace host
+// configured by the dir's "workspace-host" option, or nil if that option is not
+// set. The value may be a hostname or IP, optionally followed by a colon and
+// port number; the port defaults to 3306 rather than the dir's "port" option.
+// The user, password, and connect-options are the same as for the dir's other
+// instances. The instance is not checked for connectivity.
+func (dir *Dir) WorkspaceInstance() (*tengo.Instance, error) {
+	host := dir.Config.Get("workspace-host")
+	if host == "" {
+		return nil, nil
+	}
+	var userAndPass string
+	if !dir.Config.Changed("password") {
+		userAndPass = dir.Config.Get("user")
+	} else {
+		userAndPass = fmt.Sprintf("%s:%s", dir.Config.Get("user"), dir.Config.Get("password"))
+	}
+	params, err := dir.InstanceDefaultParams()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid connection options: %s", err)
+	}
+	host, port, err := tengo.SplitHostOptionalPort(host)
+	if err != nil {
+		return nil, err
+	} else if port == 0 {
+		port = 3306
+	}
+	dsn := fmt.Sprintf("%s@tcp(%s:%d)/?%s", userAndPass, host, port, params)
+	instance, err := util.NewInstance("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid connection information for workspace-host %s: %s", dir.Config.Get("workspace-host"), err)
+	}
+	return instance, nil
+}
+
 // SchemaNames interprets the value of the dir's "schema" option, returning one
 // or more schema names that the statements in dir's *.sql files will be applied
 // to, in cases where no schema name is explicitly specified in SQL statements.
@@ -458,6 +540,89 @@ func (dir *Dir) HasSchema() bool {
 	return false
 }
 
+// DataTables returns the names of tables whose rows, in addition to their
+// definitions, are managed by *.sql files in this dir. These are configured by
+// the data-tables option. Rows are expressed as INSERT statements; INSERTs for
+// any other table are ignored.
+func (dir *Dir) DataTables() []string {
+	return dir.Config.GetSlice("data-tables", ',', true)
+}
+
+// FileLayout returns the layout used for writing CREATE statements of new
+// objects to this dir's *.sql files, as configured by the layout option.
+func (dir *Dir) FileLayout() (FileLayout, error) {
+	return ParseFileLayout(dir.Config.Get("layout"))
+}
+
+// Includes returns the absolute paths of other dirs or *.sql files whose
+// statements are merged into this dir's logical schemas, as configured by the
+// include option. Like the schema option, include is only honored if this
+// dir's own option file sets it for the current environment; subdirs do not
+// inherit it. Relative paths are interpreted relative to this dir.
+func (dir *Dir) Includes() []string {
+	if dir.OptionFile == nil {
+		return nil
+	}
+	if val, _ := dir.OptionFile.OptionValue("include"); val == "" {
+		return nil
+	}
+	var result []string
+	for _, includePath := range dir.Config.GetSlice("include", ',', true) {
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(dir.Path, includePath)
+		}
+		result = append(result, filepath.Clean(includePath))
+	}
+	return result
+}
+
+// TemplateVars returns the variables to substitute into placeholders of format
+// {{name}} in this dir's *.sql files, as configured by the template-vars option.
+// Since option files may set template-vars differently in each environment's
+// section, this permits statements to vary slightly by environment. A nil map
+// is returned if template-vars is not set, in which case templating is not
+// performed.
+func (dir *Dir) TemplateVars() (map[string]string, error) {
+	values := dir.Config.GetSlice("template-vars", ',', true)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	vars := make(map[string]string, len(values))
+	for _, entry := range values {
+		eq := strings.IndexByte(entry, '=')
+		var name string
+		if eq > -1 {
+			name = strings.TrimSpace(entry[:eq])
+		}
+		if name == "" || !templateVarName.MatchString(name) {
+			return nil, fmt.Errorf("Option template-vars must be a comma-separated list of name=value pairs, but found %q", entry)
+		}
+		vars[name] = stripAnyQuote(strings.TrimSpace(entry[eq+1:]))
+	}
+	return vars, nil
+}
+
+var templateVarName = regexp.MustCompile(`^[\w-]+$`)
+
+// GrantsFile returns the absolute path to the file declaring users, roles, and
+// privileges for this dir's host, or an empty string if none is configured.
+// Like the schema option, grants-file is only honored if this dir's own option
+// file sets it for the current environment; subdirs do not inherit it. A
+// relative path is interpreted relative to this dir.
+func (dir *Dir) GrantsFile() string {
+	if dir.OptionFile == nil {
+		return ""
+	}
+	val, _ := dir.OptionFile.OptionValue("grants-file")
+	if val == "" {
+		return ""
+	}
+	if !filepath.IsAbs(val) {
+		val = filepath.Join(dir.Path, val)
+	}
+	return filepath.Clean(val)
+}
+
 // InstanceDefaultParams returns a param string for use in constructing a
 // DSN. Any overrides specified in the config for this dir will be taken into
 // account. The returned string will already be in the correct format (HTTP
@@ -544,10 +709,69 @@ func (dir *Dir) parseContents() {
 	if dir.SQLFiles, dir.ParseError = sqlFiles(dir.Path, dir.repoBase); dir.ParseError != nil {
 		return
 	}
-	logicalSchemasByName := make(map[string]*LogicalSchema)
+	if dir.HasSchema() {
+		var layout FileLayout
+		if layout, dir.ParseError = dir.FileLayout(); dir.ParseError != nil {
+			return
+		}
+		for _, layoutDir := range append(layout.Subdirs(), dir.staleLayoutDirs(layout)...) {
+			layoutPath := path.Join(dir.Path, layoutDir)
+			if fi, err := os.Stat(layoutPath); err != nil || !fi.IsDir() {
+				continue
+			}
+			var files []SQLFile
+			if files, dir.ParseError = sqlFiles(layoutPath, dir.repoBase); dir.ParseError != nil {
+				return
+			}
+			dir.SQLFiles = append(dir.SQLFiles, files...)
+		}
+	}
+	includedBy := make(map[string]string) // included file path -> include path
 	for _, sf := range dir.SQLFiles {
-		tokenizedFile, err := sf.Tokenize()
-		if err != nil {
+		includedBy[sf.Path()] = ""
+	}
+	for _, includePath := range dir.Includes() {
+		var files []SQLFile
+		if files, dir.ParseError = includedSQLFiles(includePath, dir.repoBase); dir.ParseError != nil {
+			return
+		}
+		for _, sf := range files {
+			if _, already := includedBy[sf.Path()]; !already {
+				includedBy[sf.Path()] = includePath
+				dir.SQLFiles = append(dir.SQLFiles, sf)
+			}
+		}
+	}
+	if grantsFile := dir.GrantsFile(); grantsFile != "" {
+		// The grants file doesn't contain schema objects, so exclude it here if it
+		// happens to be a *.sql file in this dir
+		keepFiles := make([]SQLFile, 0, len(dir.SQLFiles))
+		for _, sf := range dir.SQLFiles {
+			if sf.Path() != grantsFile {
+				keepFiles = append(keepFiles, sf)
+			}
+		}
+		dir.SQLFiles = keepFiles
+	}
+	dataTables := dir.DataTables()
+	isDataTable := make(map[string]bool, len(dataTables))
+	for _, name := range dataTables {
+		isDataTable[name] = true
+	}
+	var templateVars map[string]string
+	if templateVars, dir.ParseError = dir.TemplateVars(); dir.ParseError != nil {
+		return
+	}
+	logicalSchemasByName := make(map[string]*LogicalSchema)
+	for n := range dir.SQLFiles {
+		dir.SQLFiles[n].Vars = templateVars
+		tokenizedFile, err := dir.SQLFiles[n].Tokenize()
+		if _, ok := err.(UndefinedTemplateVarError); ok {
+			// Ignoring the file's statements here could cause its objects to be
+			// dropped, so treat this as a fatal error instead
+			dir.ParseError = err
+			return
+		} else if err != nil {
 			log.Warnf(err.Error())
 			dir.IgnoredStatements = append(dir.IgnoredStatements, tokenizedFile.Statements...)
 			continue
@@ -558,13 +782,21 @@ func (dir *Dir) parseContents() {
 					Creates: make(map[tengo.ObjectKey]*Statement),
 				}
 			}
+			// INSERTs are only supported for tables listed in the data-tables option;
+			// otherwise they're ignored, just like any other unsupported statement
+			if stmt.Type == StatementTypeUnknown || (stmt.Type == StatementTypeInsert && !isDataTable[stmt.ObjectName]) {
+				dir.IgnoredStatements = append(dir.IgnoredStatements, stmt)
+				continue
+			}
 			dir.ParseError = logicalSchemasByName[stmt.Schema()].AddStatement(stmt)
+			if dde, ok := dir.ParseError.(DuplicateDefinitionError); ok {
+				dde.FirstInclude = includedBy[dde.FirstFile]
+				dde.DupeInclude = includedBy[dde.DupeFile]
+				dir.ParseError = dde
+			}
 			if dir.ParseError != nil {
 				return
 			}
-			if stmt.Type == StatementTypeUnknown {
-				dir.IgnoredStatements = append(dir.IgnoredStatements, stmt)
-			}
 		}
 	}
 
@@ -581,12 +813,24 @@ func (dir *Dir) parseContents() {
 	if ls, ok := logicalSchemasByName[""]; ok {
 		ls.CharSet = dir.Config.Get("default-character-set")
 		ls.Collation = dir.Config.Get("default-collation")
+		if ls.DefaultEncryption, dir.ParseError = dir.Config.GetEnum("default-encryption", "Y", "N"); dir.ParseError != nil {
+			return
+		}
+		if ls.ReadOnly, dir.ParseError = dir.Config.GetEnum("schema-read-only", "1", "0"); dir.ParseError != nil {
+			return
+		}
 		dir.LogicalSchemas = append([]*LogicalSchema{ls}, dir.LogicalSchemas...)
 		delete(logicalSchemasByName, "")
 	}
 	for _, ls := range logicalSchemasByName {
 		dir.LogicalSchemas = append(dir.LogicalSchemas, ls)
 	}
+	for _, ls := range dir.LogicalSchemas {
+		ls.DataTables = dataTables
+		if dir.OptionFile != nil {
+			ls.OptionFilePath = dir.OptionFile.Path()
+		}
+	}
 }
 
 // ParentOptionFiles returns a slice of *mybase.File, corresponding to the
@@ -741,21 +985,66 @@ func sqlFiles(dirPath, repoBase string) ([]SQLFile, error) {
 	return result, nil
 }
 
+// includedSQLFiles returns a slice of SQLFile for the supplied include path,
+// which may refer to either a directory or a single *.sql file. The path must
+// be located within repoBase.
+func includedSQLFiles(includePath, repoBase string) ([]SQLFile, error) {
+	dest, err := filepath.EvalSymlinks(includePath)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to process include %s: %s", includePath, err)
+	}
+	realBase := repoBase
+	if evaluated, err := filepath.EvalSymlinks(repoBase); err == nil {
+		realBase = evaluated
+	}
+	if rel, err := filepath.Rel(realBase, dest); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return nil, fmt.Errorf("Unable to process include %s: path is outside of %s", includePath, repoBase)
+	}
+	fi, err := os.Stat(dest)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to process include %s: %s", includePath, err)
+	}
+	if fi.IsDir() {
+		files, err := sqlFiles(includePath, repoBase)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to process include %s: %s", includePath, err)
+		}
+		return files, nil
+	} else if !fi.Mode().IsRegular() || !strings.HasSuffix(includePath, ".sql") {
+		return nil, fmt.Errorf("Unable to process include %s: only directories and *.sql files may be included", includePath)
+	}
+	sf := SQLFile{
+		Dir:      filepath.Dir(includePath),
+		FileName: filepath.Base(includePath),
+	}
+	return []SQLFile{sf}, nil
+}
+
 // DuplicateDefinitionError is an error returned when Dir.parseContents()
-// encounters multiple CREATE statements for the same exact object.
+// encounters multiple CREATE statements for the same exact object. If either
+// statement came from a file merged into the dir via the include option,
+// FirstInclude or DupeInclude indicates the corresponding include path.
 type DuplicateDefinitionError struct {
-	ObjectKey tengo.ObjectKey
-	FirstFile string
-	FirstLine int
-	DupeFile  string
-	DupeLine  int
+	ObjectKey    tengo.ObjectKey
+	FirstFile    string
+	FirstLine    int
+	FirstInclude string
+	DupeFile     string
+	DupeLine     int
+	DupeInclude  string
 }
 
 // Error satisfies the builtin error interface.
 func (dde DuplicateDefinitionError) Error() string {
-	return fmt.Sprintf("%s defined multiple times in same directory: %s line %d and %s line %d",
+	location := func(file string, line int, include string) string {
+		if include == "" {
+			return fmt.Sprintf("%s line %d", file, line)
+		}
+		return fmt.Sprintf("%s line %d (from include %s)", file, line, include)
+	}
+	return fmt.Sprintf("%s defined multiple times in same directory: %s and %s",
 		dde.ObjectKey,
-		dde.FirstFile, dde.FirstLine,
-		dde.DupeFile, dde.DupeLine,
+		location(dde.FirstFile, dde.FirstLine, dde.FirstInclude),
+		location(dde.DupeFile, dde.DupeLine, dde.DupeInclude),
 	)
 }
@@ -78,6 +78,276 @@ func TestParseDirErrors(t *testing.T) {
 	}
 }
 
+func TestParseDirSchemaOptions(t *testing.T) {
+	MakeTestDirectory(t, "testdata/schemaopts")
+	defer RemoveTestDirectory(t, "testdata/schemaopts")
+	WriteTestFile(t, "testdata/schemaopts/.skeema", "schema=product\ndefault-encryption=y\nschema-read-only=0\n")
+	WriteTestFile(t, "testdata/schemaopts/users.sql", "CREATE TABLE users (id int PRIMARY KEY);\n")
+
+	dir := getDir(t, "testdata/schemaopts")
+	logicalSchema := dir.LogicalSchemas[0]
+	if logicalSchema.DefaultEncryption != "Y" || logicalSchema.ReadOnly != "0" {
+		t.Errorf("LogicalSchema not correctly populated with schema-level options: DefaultEncryption=%q ReadOnly=%q", logicalSchema.DefaultEncryption, logicalSchema.ReadOnly)
+	}
+	if expected := filepath.Join(dir.Path, ".skeema"); logicalSchema.OptionFilePath != expected {
+		t.Errorf("Expected OptionFilePath %q, instead found %q", expected, logicalSchema.OptionFilePath)
+	}
+
+	// Unmanaged options should be blank
+	dir = getDir(t, "../testdata/golden/init/mydb/product")
+	if logicalSchema := dir.LogicalSchemas[0]; logicalSchema.DefaultEncryption != "" || logicalSchema.ReadOnly != "" {
+		t.Errorf("Expected blank schema-level options, instead found DefaultEncryption=%q ReadOnly=%q", logicalSchema.DefaultEncryption, logicalSchema.ReadOnly)
+	}
+
+	// Invalid values should cause a parse error
+	WriteTestFile(t, "testdata/schemaopts/.skeema", "schema=product\nschema-read-only=yes\n")
+	if dir, err := ParseDir("testdata/schemaopts", getValidConfig(t)); err == nil && dir.ParseError == nil {
+		t.Error("Expected invalid schema-read-only value to cause a parse error, but it did not")
+	}
+}
+
+func TestParseDirDataTables(t *testing.T) {
+	MakeTestDirectory(t, "testdata/datatables")
+	defer RemoveTestDirectory(t, "testdata/datatables")
+	WriteTestFile(t, "testdata/datatables/.skeema", "schema=product\ndata-tables=statuses, colors\n")
+	WriteTestFile(t, "testdata/datatables/statuses.sql", "CREATE TABLE statuses (id int PRIMARY KEY, name varchar(20));\nINSERT INTO statuses VALUES (1, 'active; really'), (2, 'inactive');\n")
+	WriteTestFile(t, "testdata/datatables/users.sql", "CREATE TABLE users (id int PRIMARY KEY);\nINSERT INTO users VALUES (1);\n")
+	WriteTestFile(t, "testdata/datatables/zcolors.sql", "INSERT IGNORE colors VALUES (1, 'red');\n")
+
+	dir := getDir(t, "testdata/datatables")
+	if expected := []string{"statuses", "colors"}; !reflect.DeepEqual(dir.DataTables(), expected) {
+		t.Errorf("Expected DataTables() to return %v, instead found %v", expected, dir.DataTables())
+	}
+	if len(dir.LogicalSchemas) != 1 {
+		t.Fatalf("Expected 1 LogicalSchema; instead found %d", len(dir.LogicalSchemas))
+	}
+	logicalSchema := dir.LogicalSchemas[0]
+	if len(logicalSchema.Creates) != 2 {
+		t.Errorf("Expected 2 Creates, instead found %d", len(logicalSchema.Creates))
+	}
+	if len(logicalSchema.Inserts) != 2 {
+		t.Fatalf("Expected 2 Inserts, instead found %d", len(logicalSchema.Inserts))
+	}
+	if stmt := logicalSchema.Inserts[0]; stmt.ObjectName != "statuses" || stmt.LineNo != 2 {
+		t.Errorf("Unexpected Insert: %+v", *stmt)
+	}
+	if stmt := logicalSchema.Inserts[1]; stmt.ObjectName != "colors" {
+		t.Errorf("Unexpected Insert: %+v", *stmt)
+	}
+	if !reflect.DeepEqual(logicalSchema.DataTables, dir.DataTables()) {
+		t.Errorf("Expected LogicalSchema.DataTables to be %v, instead found %v", dir.DataTables(), logicalSchema.DataTables)
+	}
+
+	// INSERTs for tables not listed in data-tables are ignored
+	if len(dir.IgnoredStatements) != 1 || dir.IgnoredStatements[0].ObjectName != "users" {
+		t.Errorf("Expected 1 IgnoredStatement for table users, instead found %v", dir.IgnoredStatements)
+	}
+}
+
+func TestParseDirTemplateVars(t *testing.T) {
+	MakeTestDirectory(t, "testdata/templatevars")
+	defer RemoveTestDirectory(t, "testdata/templatevars")
+	WriteTestFile(t, "testdata/templatevars/.skeema", "schema=product\n[production]\ntemplate-vars=offset=1000, env='prod, eu'\n")
+	contents := "# {{undefined}} in a comment is fine\nCREATE TABLE users (\n  id int PRIMARY KEY\n) AUTO_INCREMENT={{offset}} COMMENT='{{ env }} users';\n"
+	WriteTestFile(t, "testdata/templatevars/users.sql", contents)
+
+	dir := getDir(t, "testdata/templatevars")
+	if expected := map[string]string{"offset": "1000", "env": "prod, eu"}; !reflect.DeepEqual(dir.SQLFiles[0].Vars, expected) {
+		t.Errorf("Expected template vars %v, instead found %v", expected, dir.SQLFiles[0].Vars)
+	}
+	if len(dir.LogicalSchemas) != 1 || len(dir.LogicalSchemas[0].Creates) != 1 {
+		t.Fatalf("Unexpected LogicalSchemas: %+v", dir.LogicalSchemas)
+	}
+	stmt := dir.LogicalSchemas[0].Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "users"}]
+	if stmt == nil {
+		t.Fatal("Expected CREATE TABLE users to be found, but it was not")
+	}
+	if expected := "CREATE TABLE users (\n  id int PRIMARY KEY\n) AUTO_INCREMENT=1000 COMMENT='prod, eu users'"; stmt.Body() != expected {
+		t.Errorf("Unexpected statement body: %s", stmt.Body())
+	}
+
+	// Rewriting the file must preserve the placeholders
+	if _, err := stmt.FromFile.Rewrite(); err != nil {
+		t.Fatalf("Unexpected error from Rewrite: %s", err)
+	}
+	if actual := ReadTestFile(t, "testdata/templatevars/users.sql"); actual != contents {
+		t.Errorf("Expected Rewrite to preserve template placeholders, instead file contains:\n%s", actual)
+	}
+
+	// Without template-vars, no templating occurs
+	WriteTestFile(t, "testdata/templatevars/.skeema", "schema=product\n[staging]\ntemplate-vars=offset=1000\n")
+	dir = getDir(t, "testdata/templatevars")
+	if dir.ParseError != nil || dir.SQLFiles[0].Vars != nil || len(dir.IgnoredStatements) != 1 {
+		t.Errorf("Unexpected ParseError %v, Vars %v, or IgnoredStatements %v", dir.ParseError, dir.SQLFiles[0].Vars, dir.IgnoredStatements)
+	}
+	for _, stmt := range dir.IgnoredStatements {
+		if stmt.Template != "" || !strings.Contains(stmt.Text, "{{offset}}") {
+			t.Errorf("Expected no templating to occur without template-vars, instead found %+v", stmt)
+		}
+	}
+
+	// Undefined variables and malformed template-vars should cause a parse error
+	WriteTestFile(t, "testdata/templatevars/.skeema", "schema=product\n[production]\ntemplate-vars=offset=1000\n")
+	if dir, err := ParseDir("testdata/templatevars", getValidConfig(t)); err == nil && dir.ParseError == nil {
+		t.Error("Expected undefined template variable to cause a parse error, but it did not")
+	} else if _, ok := dir.ParseError.(UndefinedTemplateVarError); !ok {
+		t.Errorf("Expected parse error to be UndefinedTemplateVarError, instead found %T", dir.ParseError)
+	}
+	for _, value := range []string{"offset", "=1000", "a b=1"} {
+		WriteTestFile(t, "testdata/templatevars/.skeema", "schema=product\ntemplate-vars="+value+"\n")
+		if dir, err := ParseDir("testdata/templatevars", getValidConfig(t)); err == nil && dir.ParseError == nil {
+			t.Errorf("Expected template-vars=%s to cause a parse error, but it did not", value)
+		}
+	}
+}
+
+func TestParseDirIncludes(t *testing.T) {
+	MakeTestDirectory(t, "testdata/includes")
+	defer RemoveTestDirectory(t, "testdata/includes")
+	WriteTestFile(t, "testdata/includes/shared/users.sql", "CREATE TABLE users (id int PRIMARY KEY);\n")
+	WriteTestFile(t, "testdata/includes/shared/posts.sql", "CREATE TABLE posts (id int PRIMARY KEY);\n")
+	WriteTestFile(t, "testdata/includes/common.sql", "CREATE TABLE settings (name varchar(20) PRIMARY KEY);\n")
+	WriteTestFile(t, "testdata/includes/shard1/.skeema", "schema=shard1\ninclude=../shared, ../common.sql, ../shared/users.sql\n")
+	WriteTestFile(t, "testdata/includes/shard1/local.sql", "CREATE TABLE local (id int PRIMARY KEY);\n")
+	WriteTestFile(t, "testdata/includes/shard1/sub/.skeema", "schema=sub\n")
+
+	dir := getDir(t, "testdata/includes/shard1")
+	if dir.ParseError != nil {
+		t.Fatalf("Unexpected ParseError: %s", dir.ParseError)
+	}
+	if len(dir.SQLFiles) != 4 {
+		t.Errorf("Expected 4 SQLFiles, instead found %d: %v", len(dir.SQLFiles), dir.SQLFiles)
+	}
+	if len(dir.LogicalSchemas) != 1 || len(dir.LogicalSchemas[0].Creates) != 4 {
+		t.Fatalf("Unexpected LogicalSchemas: %+v", dir.LogicalSchemas)
+	}
+	stmt := dir.LogicalSchemas[0].Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "users"}]
+	if expected, _ := filepath.Abs("testdata/includes/shared/users.sql"); stmt == nil || stmt.File != expected || stmt.FromFile.Path() != expected {
+		t.Errorf("Expected statement to be tracked back to its source file, instead found %+v", stmt)
+	}
+
+	// Subdirs do not inherit the include option
+	subdir := getDir(t, "testdata/includes/shard1/sub")
+	if len(subdir.Includes()) > 0 || len(subdir.SQLFiles) > 0 {
+		t.Errorf("Expected subdir to not inherit includes, instead found %v", subdir.Includes())
+	}
+
+	// Conflicts should indicate which include introduced them
+	WriteTestFile(t, "testdata/includes/shard1/posts.sql", "CREATE TABLE posts (id int PRIMARY KEY);\n")
+	if _, err := ParseDir("testdata/includes/shard1", getValidConfig(t)); err == nil {
+		t.Error("Expected DuplicateDefinitionError, but err was nil")
+	} else if dde, ok := err.(DuplicateDefinitionError); !ok {
+		t.Errorf("Expected DuplicateDefinitionError, instead found %v", err)
+	} else if expected, _ := filepath.Abs("testdata/includes/shared"); dde.FirstInclude+dde.DupeInclude != expected || !strings.Contains(dde.Error(), expected) {
+		t.Errorf("Expected DuplicateDefinitionError to reference include %s, instead found %+v", expected, dde)
+	}
+
+	// Nonexistent paths, paths outside of the repo, and non-SQL files are errors
+	for _, include := range []string{"../doesnt-exist", "/tmp", "../shard1/.skeema"} {
+		WriteTestFile(t, "testdata/includes/shard1/.skeema", "schema=shard1\ninclude="+include+"\n")
+		if _, err := ParseDir("testdata/includes/shard1", getValidConfig(t)); err == nil {
+			t.Errorf("Expected include=%s to cause a parse error, but it did not", include)
+		}
+	}
+
+	// A sibling path sharing a name prefix with the repo base is still outside
+	// of the repo
+	WriteTestFile(t, "testdata/includes/shard10/users.sql", "CREATE TABLE users (id int PRIMARY KEY);\n")
+	repoBase, _ := filepath.Abs("testdata/includes/shard1")
+	if _, err := includedSQLFiles(repoBase+"0", repoBase); err == nil {
+		t.Error("Expected include of sibling dir to return an error, but it did not")
+	}
+}
+
+func TestParseDirLayout(t *testing.T) {
+	MakeTestDirectory(t, "testdata/layout")
+	defer RemoveTestDirectory(t, "testdata/layout")
+	WriteTestFile(t, "testdata/layout/.skeema", "schema=product\nlayout=type-dirs\n")
+	WriteTestFile(t, "testdata/layout/tables/users.sql", "CREATE TABLE users (id int PRIMARY KEY);\n")
+	WriteTestFile(t, "testdata/layout/procs/users.sql", "CREATE PROCEDURE users() SELECT 1;\n")
+	WriteTestFile(t, "testdata/layout/posts.sql", "CREATE TABLE posts (id int PRIMARY KEY);\n")
+	WriteTestFile(t, "testdata/layout/other/.skeema", "schema=other\n")
+
+	dir := getDir(t, "testdata/layout")
+	if len(dir.SQLFiles) != 3 {
+		t.Errorf("Expected 3 SQLFiles, instead found %d: %v", len(dir.SQLFiles), dir.SQLFiles)
+	}
+	if len(dir.LogicalSchemas) != 1 || len(dir.LogicalSchemas[0].Creates) != 3 {
+		t.Fatalf("Unexpected LogicalSchemas: %+v", dir.LogicalSchemas)
+	}
+	subdirs, err := dir.Subdirs()
+	if err != nil || len(subdirs) != 1 || subdirs[0].BaseName() != "other" {
+		t.Errorf("Expected layout subdirs to be excluded from Subdirs(), instead found %v, %v", subdirs, err)
+	}
+
+	// Removing the layout option should not cause its subdirs to be treated as
+	// separate dirs
+	WriteTestFile(t, "testdata/layout/.skeema", "schema=product\n")
+	dir = getDir(t, "testdata/layout")
+	if len(dir.SQLFiles) != 3 || len(dir.LogicalSchemas) != 1 || len(dir.LogicalSchemas[0].Creates) != 3 {
+		t.Errorf("Expected stale layout subdirs to remain part of dir, instead found SQLFiles %v", dir.SQLFiles)
+	}
+	if subdirs, err := dir.Subdirs(); err != nil || len(subdirs) != 1 || subdirs[0].BaseName() != "other" {
+		t.Errorf("Expected stale layout subdirs to be excluded from Subdirs(), instead found %v, %v", subdirs, err)
+	}
+
+	WriteTestFile(t, "testdata/layout/.skeema", "schema=product\nlayout={name}.txt\n")
+	if _, err := ParseDir("testdata/layout", getValidConfig(t)); err == nil {
+		t.Error("Expected invalid layout to cause a parse error, but it did not")
+	}
+}
+
+func TestParseDirGrantsFile(t *testing.T) {
+	MakeTestDirectory(t, "testdata/grantsfile")
+	defer RemoveTestDirectory(t, "testdata/grantsfile")
+	WriteTestFile(t, "testdata/grantsfile/.skeema", "host=127.0.0.1\n[staging]\ngrants-file=grants.sql\n")
+	WriteTestFile(t, "testdata/grantsfile/grants.sql", "CREATE USER app;\nGRANT SELECT ON product.* TO app;\n")
+	WriteTestFile(t, "testdata/grantsfile/product/.skeema", "schema=product\n")
+	WriteTestFile(t, "testdata/grantsfile/product/users.sql", "CREATE TABLE users (id int PRIMARY KEY);\n")
+
+	// grants-file is only set in the staging environment
+	dir := getDir(t, "testdata/grantsfile")
+	if grantsFile := dir.GrantsFile(); grantsFile != "" {
+		t.Errorf("Expected GrantsFile() to return empty string, instead found %q", grantsFile)
+	}
+	if len(dir.SQLFiles) != 1 {
+		t.Errorf("Expected 1 SQLFile, instead found %d", len(dir.SQLFiles))
+	}
+
+	cmd := mybase.NewCommand("fstest", "", "", nil)
+	cmd.AddOption(mybase.StringOption("grants-file", 0, "", "").Hidden())
+	cmd.AddOption(mybase.StringOption("host", 0, "", "").Hidden())
+	cmd.AddOption(mybase.StringOption("schema", 0, "", "").Hidden())
+	cmd.AddOption(mybase.StringOption("default-character-set", 0, "", "").Hidden())
+	cmd.AddOption(mybase.StringOption("default-collation", 0, "", "").Hidden())
+	cmd.AddOption(mybase.StringOption("default-encryption", 0, "", "").Hidden())
+	cmd.AddOption(mybase.StringOption("schema-read-only", 0, "", "").Hidden())
+	cmd.AddOption(mybase.StringOption("data-tables", 0, "", "").Hidden())
+	cmd.AddOption(mybase.StringOption("template-vars", 0, "", "").Hidden())
+	cmd.AddOption(mybase.StringOption("include", 0, "", "").Hidden())
+	cmd.AddOption(mybase.StringOption("layout", 0, "", "").Hidden())
+	cmd.AddArg("environment", "production", false)
+	dir, err := ParseDir("testdata/grantsfile", mybase.ParseFakeCLI(t, cmd, "fstest staging"))
+	if err != nil {
+		t.Fatalf("Unexpected error from ParseDir: %s", err)
+	}
+	if expected := filepath.Join(dir.Path, "grants.sql"); dir.GrantsFile() != expected {
+		t.Errorf("Expected GrantsFile() to return %q, instead found %q", expected, dir.GrantsFile())
+	}
+	if len(dir.SQLFiles) != 0 || len(dir.LogicalSchemas) != 0 || len(dir.IgnoredStatements) != 0 {
+		t.Errorf("Expected grants file to be excluded from dir contents, instead found %d SQLFiles, %d LogicalSchemas, %d IgnoredStatements", len(dir.SQLFiles), len(dir.LogicalSchemas), len(dir.IgnoredStatements))
+	}
+
+	// Subdirs do not inherit grants-file
+	subdirs, err := dir.Subdirs()
+	if err != nil || len(subdirs) != 1 {
+		t.Fatalf("Unexpected result from Subdirs(): %v, %v", subdirs, err)
+	}
+	if grantsFile := subdirs[0].GrantsFile(); grantsFile != "" {
+		t.Errorf("Expected subdir GrantsFile() to return empty string, instead found %q", grantsFile)
+	}
+}
+
 func TestParseDirSymlinks(t *testing.T) {
 	dir := getDir(t, "testdata/sqlsymlinks")
 
@@ -232,6 +502,39 @@ func TestDirInstances(t *testing.T) {
 	assertInstances(map[string]string{"host-wrapper": "/bin/echo -n", "host": "ignored"}, false)
 }
 
+func TestDirWorkspaceInstance(t *testing.T) {
+	assertWorkspaceInstance := func(optionValues map[string]string, expectError bool, expected string) {
+		t.Helper()
+		cmd := mybase.NewCommand("test", "1.0", "this is for testing", nil)
+		util.AddGlobalOptions(cmd)
+		cfg := mybase.NewConfig(&mybase.CommandLine{Command: cmd}, mybase.SimpleSource(optionValues))
+		dir := &Dir{
+			Path:   "/tmp/dummydir",
+			Config: cfg,
+		}
+		inst, err := dir.WorkspaceInstance()
+		if expectError {
+			if err == nil {
+				t.Errorf("With option values %v, expected error to be returned, but it was nil", optionValues)
+			}
+			return
+		} else if err != nil {
+			t.Errorf("With option values %v, expected nil error, but found %s", optionValues, err)
+		} else if expected == "" && inst != nil {
+			t.Errorf("With option values %v, expected nil instance, but found %s", optionValues, inst)
+		} else if expected != "" && (inst == nil || inst.String() != expected) {
+			t.Errorf("With option values %v, expected instance %s, but found %v", optionValues, expected, inst)
+		}
+	}
+	assertWorkspaceInstance(nil, false, "")
+	assertWorkspaceInstance(map[string]string{"host": "some.db.host"}, false, "")
+	assertWorkspaceInstance(map[string]string{"workspace-host": "ci.db.host"}, false, "ci.db.host:3306")
+	assertWorkspaceInstance(map[string]string{"workspace-host": "ci.db.host:3307", "port": "3308"}, false, "ci.db.host:3307")
+	assertWorkspaceInstance(map[string]string{"workspace-host": "ci.db.host", "port": "3308"}, false, "ci.db.host:3306")
+	assertWorkspaceInstance(map[string]string{"workspace-host": "ci.db.host:abc"}, true, "")
+	assertWorkspaceInstance(map[string]string{"workspace-host": "ci.db.host", "connect-options": ","}, true, "")
+}
+
 func TestDirInstanceDefaultParams(t *testing.T) {
 	getDir := func(connectOptions, flavor string) *Dir {
 		return &Dir{
@@ -292,9 +595,16 @@ func getValidConfig(t *testing.T) *mybase.Config {
 	cmd.AddOption(mybase.StringOption("schema", 0, "", "Database schema name").Hidden())
 	cmd.AddOption(mybase.StringOption("default-character-set", 0, "", "Schema-level default character set").Hidden())
 	cmd.AddOption(mybase.StringOption("default-collation", 0, "", "Schema-level default collation").Hidden())
+	cmd.AddOption(mybase.StringOption("default-encryption", 0, "", `Schema-level default encryption (valid values: "y", "n")`).Hidden())
+	cmd.AddOption(mybase.StringOption("schema-read-only", 0, "", `Schema-level read-only status (valid values: "1", "0")`).Hidden())
 	cmd.AddOption(mybase.StringOption("host", 0, "", "Database hostname or IP address").Hidden())
 	cmd.AddOption(mybase.StringOption("port", 0, "3306", "Port to use for database host").Hidden())
 	cmd.AddOption(mybase.StringOption("flavor", 0, "", "Database server expressed in format vendor:major.minor, for use in vendor/version specific syntax").Hidden())
+	cmd.AddOption(mybase.StringOption("data-tables", 0, "", "Comma-separated list of tables whose rows are managed by INSERT statements").Hidden())
+	cmd.AddOption(mybase.StringOption("grants-file", 0, "", "File declaring users, roles, and privileges to manage on this dir's host").Hidden())
+	cmd.AddOption(mybase.StringOption("template-vars", 0, "", "Comma-separated list of name=value variables to substitute into {{name}} placeholders in *.sql files").Hidden())
+	cmd.AddOption(mybase.StringOption("include", 0, "", "Comma-separated list of other dirs or *.sql files whose statements are merged into this dir").Hidden())
+	cmd.AddOption(mybase.StringOption("layout", 0, "", `File layout for object definitions: "flat", "type-dirs", "type-suffix", "single-file", or a template such as "{types}/{name}.sql"`).Hidden())
 	cmd.AddArg("environment", "production", false)
 	return mybase.ParseFakeCLI(t, cmd, "fstest")
 }
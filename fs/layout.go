@@ -0,0 +1,143 @@
+package fs
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// FileLayout determines which file, relative to a dir, should contain the
+// CREATE statement for each object. It is configured by the layout option,
+// which may be either the name of a predefined layout, or a template using
+// placeholders {name}, {type}, and {types}. The zero value is equivalent to the
+// "flat" layout.
+type FileLayout struct {
+	template string
+}
+
+// namedLayouts maps names of predefined layouts to their templates.
+var namedLayouts = map[string]string{
+	"flat":        "{name}.sql",
+	"type-dirs":   "{types}/{name}.sql",
+	"type-suffix": "{name}.{type}.sql",
+	"single-file": "schema.sql",
+}
+
+// layoutTypeNames maps object types to values of the {type} and {types}
+// placeholders.
+var layoutTypeNames = map[tengo.ObjectType][2]string{
+	tengo.ObjectTypeTable: {"table", "tables"},
+	tengo.ObjectTypeProc:  {"proc", "procs"},
+	tengo.ObjectTypeFunc:  {"func", "funcs"},
+}
+
+// layoutPlaceholder is a regexp for detecting placeholders of format "{name}"
+// in layout templates
+var layoutPlaceholder = regexp.MustCompile(`{([^}]*)}`)
+
+// ParseFileLayout returns a FileLayout for the supplied value of the layout
+// option. A blank value is equivalent to "flat", which places each object in
+// a file named after the object directly in the dir. An error is returned if
+// the value is not a valid layout. Placeholders for the object name are only
+// permitted in the file name portion of a template, so that the set of subdirs
+// used by a layout is always known.
+func ParseFileLayout(value string) (FileLayout, error) {
+	template := strings.TrimSpace(value)
+	if template == "" {
+		template = namedLayouts["flat"]
+	} else if named, ok := namedLayouts[strings.ToLower(template)]; ok {
+		template = named
+	}
+	template = layoutPlaceholder.ReplaceAllStringFunc(template, strings.ToLower)
+
+	if !strings.HasSuffix(template, ".sql") {
+		return FileLayout{}, fmt.Errorf("Invalid layout %q: files must have a .sql extension", value)
+	}
+	parts := strings.Split(template, "/")
+	for n, part := range parts {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, "\\") {
+			return FileLayout{}, fmt.Errorf("Invalid layout %q: must be a relative path, without any empty, . or .. components", value)
+		}
+		for _, match := range layoutPlaceholder.FindAllStringSubmatch(part, -1) {
+			switch match[1] {
+			case "type", "types":
+			case "name":
+				if n < len(parts)-1 {
+					return FileLayout{}, fmt.Errorf("Invalid layout %q: placeholder {name} may only be used in the file name", value)
+				}
+			default:
+				return FileLayout{}, fmt.Errorf("Invalid layout %q: unknown placeholder %s", value, match[0])
+			}
+		}
+	}
+	return FileLayout{template: template}, nil
+}
+
+// String returns the layout's template.
+func (fl FileLayout) String() string {
+	if fl.template == "" {
+		return namedLayouts["flat"]
+	}
+	return fl.template
+}
+
+// PathForObject returns the path to use for the file containing the CREATE
+// statement for the supplied object in dirPath. As with the package-level
+// PathForObject function, special characters in the object name are removed.
+func (fl FileLayout) PathForObject(dirPath string, key tengo.ObjectKey) string {
+	name := strings.Map(removeSpecialChars, key.Name)
+	if name == "" {
+		name = "symbols"
+	}
+	return path.Join(dirPath, fl.expand(key.Type, name))
+}
+
+// Subdirs returns the relative paths of all subdirs which may contain files
+// under this layout, sorted by name. If the layout places all files directly
+// in the dir, nil is returned.
+func (fl FileLayout) Subdirs() []string {
+	if !strings.Contains(fl.String(), "/") {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for objType := range layoutTypeNames {
+		seen[path.Dir(fl.expand(objType, ""))] = true
+	}
+	result := make([]string, 0, len(seen))
+	for layoutDir := range seen {
+		result = append(result, layoutDir)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// isLayoutDirName returns true if name matches a value of the {type} or
+// {types} placeholders, meaning a subdir with this name may have been created
+// by some layout.
+func isLayoutDirName(name string) bool {
+	for _, typeNames := range layoutTypeNames {
+		if name == typeNames[0] || name == typeNames[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// expand returns the template with its placeholders replaced by values for
+// the supplied object type and name.
+func (fl FileLayout) expand(objType tengo.ObjectType, name string) string {
+	typeNames, ok := layoutTypeNames[objType]
+	if !ok {
+		typeNames = [2]string{string(objType), string(objType) + "s"}
+	}
+	template := fl.template
+	if template == "" {
+		template = namedLayouts["flat"]
+	}
+	replacer := strings.NewReplacer("{name}", name, "{type}", typeNames[0], "{types}", typeNames[1])
+	return replacer.Replace(template)
+}
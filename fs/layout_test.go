@@ -0,0 +1,56 @@
+package fs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestParseFileLayout(t *testing.T) {
+	table := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "users"}
+	proc := tengo.ObjectKey{Type: tengo.ObjectTypeProc, Name: "users"}
+	cases := []struct {
+		Value         string
+		ExpectTable   string
+		ExpectProc    string
+		ExpectSubdirs []string
+	}{
+		{"", "/db/users.sql", "/db/users.sql", nil},
+		{"flat", "/db/users.sql", "/db/users.sql", nil},
+		{"Type-Dirs", "/db/tables/users.sql", "/db/procs/users.sql", []string{"funcs", "procs", "tables"}},
+		{"type-suffix", "/db/users.table.sql", "/db/users.proc.sql", nil},
+		{"single-file", "/db/schema.sql", "/db/schema.sql", nil},
+		{"{TYPE}/{Name}.sql", "/db/table/users.sql", "/db/proc/users.sql", []string{"func", "proc", "table"}},
+		{"sql/{types}/{type}_{name}.sql", "/db/sql/tables/table_users.sql", "/db/sql/procs/proc_users.sql", []string{"sql/funcs", "sql/procs", "sql/tables"}},
+		{"defs/all.sql", "/db/defs/all.sql", "/db/defs/all.sql", []string{"defs"}},
+	}
+	for _, c := range cases {
+		layout, err := ParseFileLayout(c.Value)
+		if err != nil {
+			t.Errorf("Unexpected error from ParseFileLayout(%q): %s", c.Value, err)
+			continue
+		}
+		if actual := layout.PathForObject("/db", table); actual != c.ExpectTable {
+			t.Errorf("Layout %q: expected table path %q, instead found %q", c.Value, c.ExpectTable, actual)
+		}
+		if actual := layout.PathForObject("/db", proc); actual != c.ExpectProc {
+			t.Errorf("Layout %q: expected proc path %q, instead found %q", c.Value, c.ExpectProc, actual)
+		}
+		if actual := layout.Subdirs(); !reflect.DeepEqual(actual, c.ExpectSubdirs) {
+			t.Errorf("Layout %q: expected subdirs %v, instead found %v", c.Value, c.ExpectSubdirs, actual)
+		}
+	}
+
+	// Zero value should behave like the flat layout
+	var zero FileLayout
+	if actual := zero.PathForObject("/db", proc); actual != "/db/users.sql" || zero.String() != "{name}.sql" || zero.Subdirs() != nil {
+		t.Errorf("Unexpected behavior from zero value FileLayout: %q, %q, %v", actual, zero.String(), zero.Subdirs())
+	}
+
+	for _, value := range []string{"{name}.txt", "/abs/{name}.sql", "../{name}.sql", "a//{name}.sql", "{name}/x.sql", "{schema}.sql", "./{name}.sql"} {
+		if _, err := ParseFileLayout(value); err == nil {
+			t.Errorf("Expected error from ParseFileLayout(%q), but err was nil", value)
+		}
+	}
+}
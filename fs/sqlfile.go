@@ -8,12 +8,15 @@ import (
 	"regexp"
 	"strings"
 	"unicode"
+
+	"github.com/skeema/tengo"
 )
 
 // SQLFile represents a file containing zero or more SQL statements.
 type SQLFile struct {
 	Dir      string
 	FileName string
+	Vars     map[string]string // template variables for Tokenize; nil if templating is not enabled
 }
 
 // TokenizedSQLFile represents a SQLFile that has been tokenized into
@@ -64,8 +67,16 @@ func (sf SQLFile) Delete() error {
 // entire file. Some of the returned "statements" may just be comments and/or
 // whitespace, since any comments and/or whitespace between SQL statements gets
 // split into separate Statement values.
+//
+// If sf.Vars is non-nil, any placeholders of format {{name}} in each statement
+// are replaced with the corresponding variable's value. The resulting Text is
+// used for all other purposes, but the original text is retained in the
+// statement's Template field, so that rewriting the file preserves the
+// placeholders. An UndefinedTemplateVarError is returned if a placeholder
+// refers to a variable not present in sf.Vars.
 func (sf SQLFile) Tokenize() (*TokenizedSQLFile, error) {
 	tokenizer := newStatementTokenizer(sf.Path(), ";")
+	tokenizer.vars = sf.Vars
 	statements, err := tokenizer.statements()
 
 	// As a special case, if a file contains a single routine but no DELIMITER
@@ -83,7 +94,9 @@ func (sf SQLFile) Tokenize() (*TokenizedSQLFile, error) {
 			} else {
 				tryReparse = false
 			}
-		case StatementTypeUnknown:
+		case StatementTypeUnknown, StatementTypeInsert:
+			// INSERTs are treated like unknown statements here, since a routine body
+			// may contain them
 			if seenRoutine {
 				unknownAfterRoutine = true
 			}
@@ -96,6 +109,7 @@ func (sf SQLFile) Tokenize() (*TokenizedSQLFile, error) {
 	}
 	if seenRoutine && unknownAfterRoutine && tryReparse {
 		tokenizer := newStatementTokenizer(sf.Path(), "\000")
+		tokenizer.vars = sf.Vars
 		if statements2, err2 := tokenizer.statements(); err2 == nil {
 			statements = statements2
 			err = nil
@@ -105,11 +119,16 @@ func (sf SQLFile) Tokenize() (*TokenizedSQLFile, error) {
 }
 
 // WriteStatements writes (or re-writes) the file using the contents of the
-// supplied statements. The number of bytes written is returned.
+// supplied statements. The number of bytes written is returned. Statements
+// with template placeholders are written using their original, unexpanded text.
 func (sf SQLFile) WriteStatements(statements []*Statement) (int, error) {
 	lines := make([]string, len(statements))
 	for n := range statements {
-		lines[n] = string(statements[n].Text)
+		if statements[n].Template != "" {
+			lines[n] = statements[n].Template
+		} else {
+			lines[n] = statements[n].Text
+		}
 	}
 	value := strings.Join(lines, "")
 	err := ioutil.WriteFile(sf.Path(), []byte(value), 0666)
@@ -133,6 +152,20 @@ func NewTokenizedSQLFile(sf SQLFile, statements []*Statement) *TokenizedSQLFile
 	return result
 }
 
+// AppendStatementText adds a statement with the supplied text to the end of
+// the file's statements, without rewriting the file. If the file's current
+// final statement is not newline-terminated, a newline is added first.
+func (tsf *TokenizedSQLFile) AppendStatementText(text string) {
+	if n := len(tsf.Statements); n > 0 && !strings.HasSuffix(tsf.Statements[n-1].Text, "\n") {
+		text = "\n" + text
+	}
+	tsf.Statements = append(tsf.Statements, &Statement{
+		File:     tsf.Path(),
+		Text:     text,
+		FromFile: tsf,
+	})
+}
+
 // Rewrite rewrites the SQLFile with the current statements, returning the
 // number of bytes written. If the file's statements now only consist of
 // comments, whitespace, and commands (e.g. USE, DELIMITER) then the file will
@@ -154,13 +187,10 @@ func (tsf *TokenizedSQLFile) Rewrite() (int, error) {
 // PathForObject returns a string containing a path to use for the SQLFile
 // representing the supplied object name. Special characters in the objectName
 // will be removed; however, there is no risk of "conflicts" since a single
-// SQLFile can store definitions for multiple objects.
+// SQLFile can store definitions for multiple objects. This is equivalent to
+// using the path from the default "flat" FileLayout.
 func PathForObject(dirPath, objectName string) string {
-	objectName = strings.Map(removeSpecialChars, objectName)
-	if objectName == "" {
-		objectName = "symbols"
-	}
-	return path.Join(dirPath, fmt.Sprintf("%s.sql", objectName))
+	return FileLayout{}.PathForObject(dirPath, tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: objectName})
 }
 
 func removeSpecialChars(r rune) rune {
@@ -201,6 +201,15 @@ func TestTokenizedSQLFileRewrite(t *testing.T) {
 			stmt.Remove()
 		}
 	}
+	// Appending a statement should prevent the deletion
+	tokenizedFile.AppendStatementText("CREATE TABLE foo (id int);\n")
+	if bytesWritten, err = tokenizedFile.Rewrite(); bytesWritten == 0 || err != nil {
+		t.Errorf("Unexpected return values from Rewrite: %d / %v", bytesWritten, err)
+	} else if contents2 = ReadTestFile(t, sf2.Path()); !strings.HasSuffix(contents2, "\nCREATE TABLE foo (id int);\n") {
+		t.Errorf("Unexpected file contents after AppendStatementText: %q", contents2)
+	}
+	tokenizedFile.Statements[len(tokenizedFile.Statements)-1].Remove()
+
 	bytesWritten, err = tokenizedFile.Rewrite()
 	if bytesWritten != 0 || err != nil {
 		t.Errorf("Unexpected return values from Rewrite: %d / %v", bytesWritten, err)
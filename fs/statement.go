@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -27,6 +28,7 @@ const (
 	StatementTypeCommand               // currently just USE or DELIMITER
 	StatementTypeCreate
 	StatementTypeAlter
+	StatementTypeInsert // only used for tables listed in the data-tables option
 	// Other types will be added once they are supported by the package
 )
 
@@ -38,6 +40,7 @@ type Statement struct {
 	LineNo          int
 	CharNo          int
 	Text            string
+	Template        string // original text, only populated if Text had template placeholders expanded
 	DefaultDatabase string // only populated if an explicit USE command was encountered
 	Type            StatementType
 	ObjectType      tengo.ObjectType
@@ -134,7 +137,9 @@ func CanParse(input string) (bool, error) {
 // reduce the amount of code.
 type statementTokenizer struct {
 	filePath  string
-	delimiter string // statement delimiter, typically ";" or sometimes "//" for routines
+	delimiter string            // statement delimiter, typically ";" or sometimes "//" for routines
+	vars      map[string]string // template variables, or nil if templating is not enabled
+	varErr    error             // first error encountered when expanding template placeholders
 
 	result []*Statement // completed statements
 	stmt   *Statement   // tracking current (not yet completely tokenized) statement
@@ -184,7 +189,7 @@ func (st *statementTokenizer) statements() ([]*Statement, error) {
 	} else if st.inCComment {
 		err = fmt.Errorf("File %s has unterminated C-style comment", st.filePath)
 	} else {
-		err = nil
+		err = st.varErr
 	}
 	return st.result, err
 }
@@ -343,6 +348,9 @@ func (ls *lineState) doneStatement(omitEndBytes int) {
 		return
 	}
 	ls.stmt.Text = fmt.Sprintf("%s", ls.buf.Next(bufLen-omitEndBytes))
+	if ls.vars != nil && ls.inRelevant {
+		ls.expandTemplate()
+	}
 	ls.parseStatement()
 	ls.result = append(ls.result, ls.stmt)
 	ls.stmt = nil
@@ -354,6 +362,42 @@ func (ls *lineState) doneStatement(omitEndBytes int) {
 	}
 }
 
+// templatePlaceholder is a regexp for detecting template variable placeholders
+// of format "{{name}}" in statement text
+var templatePlaceholder = regexp.MustCompile(`{{\s*([\w-]+)\s*}}`)
+
+// expandTemplate replaces any template placeholders in the current statement's
+// text with the corresponding variable values. If any replacements were made,
+// the original text is retained in the statement's Template field.
+func (ls *lineState) expandTemplate() {
+	expanded := templatePlaceholder.ReplaceAllStringFunc(ls.stmt.Text, func(placeholder string) string {
+		name := templatePlaceholder.FindStringSubmatch(placeholder)[1]
+		if value, ok := ls.vars[name]; ok {
+			return value
+		}
+		if ls.varErr == nil {
+			ls.varErr = UndefinedTemplateVarError{File: ls.filePath, LineNo: ls.stmt.LineNo, Name: name}
+		}
+		return placeholder
+	})
+	if expanded != ls.stmt.Text {
+		ls.stmt.Template, ls.stmt.Text = ls.stmt.Text, expanded
+	}
+}
+
+// UndefinedTemplateVarError is returned by SQLFile.Tokenize when a statement
+// contains a template placeholder for a variable that has not been defined.
+type UndefinedTemplateVarError struct {
+	File   string
+	LineNo int
+	Name   string
+}
+
+// Error satisfies the builtin error interface.
+func (err UndefinedTemplateVarError) Error() string {
+	return fmt.Sprintf("%s:%d: template variable %s is not defined by option template-vars", err.File, err.LineNo, err.Name)
+}
+
 func (ls *lineState) parseStatement() {
 	txt, _ := ls.stmt.SplitTextBody()
 	if !ls.inRelevant || txt == "" {
@@ -380,6 +424,10 @@ func (ls *lineState) parseStatement() {
 			ls.stmt.Type = StatementTypeCreate
 			ls.stmt.ObjectType = tengo.ObjectTypeFunc
 			ls.stmt.ObjectQualifier, ls.stmt.ObjectName = sqlStmt.CreateFunc.Name.schemaAndTable()
+		} else if sqlStmt.InsertInto != nil {
+			ls.stmt.Type = StatementTypeInsert
+			ls.stmt.ObjectType = tengo.ObjectTypeTable
+			ls.stmt.ObjectQualifier, ls.stmt.ObjectName = sqlStmt.InsertInto.Name.schemaAndTable()
 		}
 	}
 }
@@ -435,6 +483,7 @@ type sqlStatement struct {
 	CreateTable      *createTable      `parser:"@@"`
 	CreateProc       *createProc       `parser:"| @@"`
 	CreateFunc       *createFunc       `parser:"| @@"`
+	InsertInto       *insertInto       `parser:"| @@"`
 	UseCommand       *useCommand       `parser:"| @@"`
 	DelimiterCommand *delimiterCommand `parser:"| @@"`
 }
@@ -508,6 +557,12 @@ type createFunc struct {
 	Body    body       `parser:"@@"`
 }
 
+// insertInto represents an INSERT statement.
+type insertInto struct {
+	Name objectName `parser:"'INSERT' ('LOW_PRIORITY' | 'DELAYED' | 'HIGH_PRIORITY')? 'IGNORE'? 'INTO'? @@"`
+	Body body       `parser:"@@"`
+}
+
 // useCommand represents a USE command.
 type useCommand struct {
 	DefaultDatabase string `parser:"'USE' @Word"`
@@ -93,10 +93,13 @@ func TestCanParse(t *testing.T) {
 	cases := map[string]bool{
 		"CREATE TABLE foo (\n\t`id` int unsigned DEFAULT '0'\n) ;\n": true,
 		"CREATE TABLE   IF  not EXISTS  foo (\n\tid int\n) ;\n":      true,
-		"USE some_db\n\n":              true,
-		"INSERT INTO foo VALUES (';')": false,
-		"bork bork bork":               false,
-		"# hello":                      false,
+		"USE some_db\n\n":                                 true,
+		"INSERT INTO foo VALUES (';')":                    true,
+		"INSERT foo VALUES (';')":                         true,
+		"INSERT IGNORE INTO foo VALUES (1)":               true,
+		"DELETE FROM foo":                                 false,
+		"bork bork bork":                                  false,
+		"# hello":                                         false,
 		"CREATE TEMPORARY TABLE foo (\n\tid int\n) ;\n":   false,
 		"CREATE TABLE foo LIKE bar":                       false,
 		"CREATE TABLE foo (like bar)":                     false,
@@ -0,0 +1,278 @@
+// Package importer replays migration files from other schema management
+// tools, such as Flyway, goose, or golang-migrate, in order to obtain the
+// resulting schema.
+package importer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/workspace"
+)
+
+// Migration represents a single migration file.
+type Migration struct {
+	FileName   string
+	Version    []string // numeric parts of version from file name; nil if unversioned
+	Repeatable bool     // true for Flyway repeatable migrations, which run after all versioned ones
+	Statements []*fs.Statement
+}
+
+func (m *Migration) String() string {
+	return m.FileName
+}
+
+// Failure describes a migration which could not be fully applied. Once a
+// statement in a migration fails, the rest of the migration is skipped.
+type Failure struct {
+	Migration *Migration
+	Statement *fs.Statement
+	Err       error
+}
+
+func (f *Failure) Error() string {
+	return fmt.Sprintf("%s: %s", f.Statement.Location(), f.Err)
+}
+
+// File name patterns used to determine migration ordering. Down or undo
+// migrations are excluded entirely.
+var (
+	reFlywayVersioned  = regexp.MustCompile(`^[Vv](\d+(?:[._]\d+)*)__`)
+	reFlywayRepeatable = regexp.MustCompile(`^R__`)
+	reFlywayUndo       = regexp.MustCompile(`^[Uu]\d+(?:[._]\d+)*__`)
+	reLeadingVersion   = regexp.MustCompile(`^(\d+)`)
+	reDownMigration    = regexp.MustCompile(`(?i)[._]down\.sql$`)
+)
+
+// Load reads all *.sql migration files in dirPath, and returns them in the
+// order they should be applied. Versioned migrations are ordered numerically
+// by version, followed by unversioned migrations and then Flyway repeatable
+// migrations, each in order of file name. Down migrations and Flyway undo
+// migrations are skipped, as are the down sections of goose migrations.
+func Load(dirPath string) ([]*Migration, error) {
+	fileInfos, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	var migrations []*Migration
+	for _, fi := range fileInfos {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(strings.ToLower(name), ".sql") {
+			continue
+		} else if reDownMigration.MatchString(name) || reFlywayUndo.MatchString(name) {
+			log.Debugf("Skipping down migration %s", name)
+			continue
+		}
+		m := &Migration{FileName: name}
+		if matches := reFlywayVersioned.FindStringSubmatch(name); matches != nil {
+			m.Version = strings.FieldsFunc(matches[1], func(r rune) bool { return r == '.' || r == '_' })
+		} else if reFlywayRepeatable.MatchString(name) {
+			m.Repeatable = true
+		} else if matches := reLeadingVersion.FindStringSubmatch(name); matches != nil {
+			m.Version = matches[1:]
+		}
+		sqlFile := fs.SQLFile{Dir: dirPath, FileName: name}
+		tokenizedFile, err := sqlFile.Tokenize()
+		if err != nil {
+			return nil, err
+		}
+		m.Statements = gooseUpStatements(tokenizedFile.Statements)
+		migrations = append(migrations, m)
+	}
+	sort.SliceStable(migrations, func(i, j int) bool {
+		return migrations[i].less(migrations[j])
+	})
+	return migrations, nil
+}
+
+// less returns true if m should be applied before other.
+func (m *Migration) less(other *Migration) bool {
+	if m.Repeatable != other.Repeatable {
+		return other.Repeatable
+	} else if (m.Version == nil) != (other.Version == nil) {
+		return m.Version != nil
+	}
+	for n := 0; n < len(m.Version) && n < len(other.Version); n++ {
+		if cmp := compareNumeric(m.Version[n], other.Version[n]); cmp != 0 {
+			return cmp < 0
+		}
+	}
+	if len(m.Version) != len(other.Version) {
+		return len(m.Version) < len(other.Version)
+	}
+	return m.FileName < other.FileName
+}
+
+// compareNumeric compares two strings of decimal digits by numeric value,
+// without any limit on their length.
+func compareNumeric(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// gooseUpStatements filters statements to those in the up section of a goose
+// migration, combining statements between StatementBegin and StatementEnd
+// annotations into one. If no goose annotations are present, all statements
+// are returned.
+func gooseUpStatements(statements []*fs.Statement) (result []*fs.Statement) {
+	var inDown, inBlock bool
+	var combined *fs.Statement
+	for _, stmt := range statements {
+		var annotations []string
+		if stmt.Type == fs.StatementTypeNoop {
+			annotations = gooseAnnotations(stmt.Text)
+		}
+		for _, annotation := range annotations {
+			switch annotation {
+			case "up":
+				inDown = false
+			case "down":
+				inDown = true
+			case "statementbegin":
+				inBlock = !inDown
+			case "statementend":
+				if inBlock && combined != nil {
+					combined.Text = strings.TrimSuffix(strings.TrimSpace(combined.Text), ";")
+					result = append(result, combined)
+				}
+				inBlock, combined = false, nil
+			}
+		}
+		if inDown || annotations != nil {
+			continue
+		} else if !inBlock {
+			result = append(result, stmt)
+		} else if combined != nil {
+			combined.Text += stmt.Text
+		} else if stmt.Type != fs.StatementTypeNoop {
+			// The combined statement retains the location and object type of the
+			// first statement in the block
+			copied := *stmt
+			combined = &copied
+		}
+	}
+	return result
+}
+
+// gooseAnnotations returns the lowercased names of any goose annotations in
+// comment text, such as "up" for "-- +goose Up".
+func gooseAnnotations(text string) (annotations []string) {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "--") {
+			if fields := strings.Fields(strings.TrimPrefix(line, "--")); len(fields) >= 2 && fields[0] == "+goose" {
+				annotations = append(annotations, strings.ToLower(fields[1]))
+			}
+		}
+	}
+	return annotations
+}
+
+// ddlKeywords lists the leading keywords of DDL statements. Other statements,
+// such as DML, are skipped, since data is not imported and workspaces must not
+// contain rows.
+var ddlKeywords = map[string]bool{
+	"CREATE": true,
+	"ALTER":  true,
+	"DROP":   true,
+	"RENAME": true,
+}
+
+// objectKeywords maps each DDL keyword to the object types which may be
+// affected by an applied statement. DDL affecting any other type of object,
+// such as a database, user, or view, is never executed, since it could affect
+// the server beyond the workspace's schema.
+var objectKeywords = map[string]map[string]bool{
+	"CREATE": {"INDEX": true},
+	"ALTER":  {"TABLE": true},
+	"DROP":   {"TABLE": true, "INDEX": true, "PROCEDURE": true, "FUNCTION": true},
+	"RENAME": {"TABLE": true},
+}
+
+// modifierKeywords lists optional keywords which may appear between the DDL
+// keyword and the object type.
+var modifierKeywords = map[string]bool{
+	"TEMPORARY": true,
+	"UNIQUE":    true,
+	"FULLTEXT":  true,
+	"SPATIAL":   true,
+}
+
+var (
+	reQuotedString    = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.|"")*"`)
+	reQualifiedName   = regexp.MustCompile("(?:`[^`]+`|[A-Za-z_$][0-9A-Za-z_$]*)\\s*\\.\\s*(?:`|[A-Za-z_$])")
+	reLeadingComments = regexp.MustCompile(`^(?:\s+|#[^\n]*\n|--\s[^\n]*\n|/\*(?s:.)*?\*/)*`)
+)
+
+// Apply executes the DDL statements of each migration in ws, in order. Any
+// other statements are skipped. DDL affecting anything other than tables,
+// indexes, procedures, or functions, such as databases or users, is not
+// executed, and neither are statements referring to objects in other schemas;
+// these are reported as failures, since they would otherwise affect the server
+// beyond the workspace. Failures are returned
+// for each migration which could not be fully applied; a non-nil error is only
+// returned if a fatal problem occurs.
+func Apply(migrations []*Migration, ws workspace.Workspace, params string) (failures []*Failure, err error) {
+	db, err := ws.ConnectionPool(params)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot connect to workspace: %s", err)
+	}
+	for _, m := range migrations {
+		log.Debugf("Applying migration %s", m)
+		for _, stmt := range m.Statements {
+			body := stmt.Body()
+			if stmt.Type == fs.StatementTypeNoop || stmt.Type == fs.StatementTypeCommand {
+				continue
+			}
+			words := strings.Fields(reLeadingComments.ReplaceAllString(body, ""))
+			if len(words) == 0 {
+				continue
+			} else if !ddlKeywords[strings.ToUpper(words[0])] {
+				log.Debugf("%s: Skipping non-DDL statement", stmt.Location())
+				continue
+			}
+			if err := checkStatement(stmt, words); err != nil {
+				failures = append(failures, &Failure{Migration: m, Statement: stmt, Err: err})
+				break
+			}
+			if _, execErr := db.Exec(body); execErr != nil {
+				failures = append(failures, &Failure{Migration: m, Statement: stmt, Err: execErr})
+				break
+			}
+		}
+	}
+	return failures, nil
+}
+
+// checkStatement returns an error if the DDL statement stmt, consisting of
+// words, should not be executed in a workspace. Only CREATE TABLE, PROCEDURE,
+// and FUNCTION statements, and DDL affecting tables, indexes, procedures, or
+// functions, are permitted. Statements referring to objects in other schemas
+// are not permitted either.
+func checkStatement(stmt *fs.Statement, words []string) error {
+	if stmt.Type != fs.StatementTypeCreate {
+		keyword := strings.ToUpper(words[0])
+		var objectType string
+		for _, word := range words[1:] {
+			if word = strings.ToUpper(word); !modifierKeywords[word] {
+				objectType = word
+				break
+			}
+		}
+		if !objectKeywords[keyword][objectType] {
+			return fmt.Errorf("%s %s statements are not supported", keyword, objectType)
+		}
+	}
+	if stmt.ObjectQualifier != "" || (stmt.ObjectType == "" && reQualifiedName.MatchString(reQuotedString.ReplaceAllString(stmt.Body(), ""))) {
+		return fmt.Errorf("Statements referring to objects in other schemas are not supported")
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package importer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeMigrations(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dirPath, err := ioutil.TempDir("", "importer")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dirPath, name), []byte(contents), 0666); err != nil {
+			t.Fatalf("Unable to write %s: %v", name, err)
+		}
+	}
+	return dirPath
+}
+
+func TestLoadOrdering(t *testing.T) {
+	dirPath := writeMigrations(t, map[string]string{
+		"V1__init.sql":           "CREATE TABLE a (id int);\n",
+		"V1.10__later.sql":       "CREATE TABLE b (id int);\n",
+		"V1_2__earlier.sql":      "CREATE TABLE c (id int);\n",
+		"V2__next.sql":           "CREATE TABLE d (id int);\n",
+		"U2__next.sql":           "DROP TABLE d;\n",
+		"R__views.sql":           "CREATE TABLE e (id int);\n",
+		"README.md":              "not a migration",
+		"000010_x.up.sql":        "CREATE TABLE f (id int);\n",
+		"000010_x.down.sql":      "DROP TABLE f;\n",
+		"9_y.up.sql":             "CREATE TABLE g (id int);\n",
+		"changelog.sql":          "CREATE TABLE h (id int);\n",
+		"20200101000000_big.sql": "CREATE TABLE i (id int);\n",
+	})
+	defer os.RemoveAll(dirPath)
+	migrations, err := Load(dirPath)
+	if err != nil {
+		t.Fatalf("Unexpected error from Load: %v", err)
+	}
+	expected := []string{
+		"V1__init.sql", "V1_2__earlier.sql", "V1.10__later.sql", "V2__next.sql",
+		"9_y.up.sql", "000010_x.up.sql", "20200101000000_big.sql",
+		"changelog.sql", "R__views.sql",
+	}
+	var actual []string
+	for _, m := range migrations {
+		actual = append(actual, m.String())
+	}
+	if strings.Join(actual, " ") != strings.Join(expected, " ") {
+		t.Errorf("Unexpected migration order.\nExpected: %v\nActual:   %v", expected, actual)
+	}
+}
+
+func TestLoadGoose(t *testing.T) {
+	contents := `-- +goose Up
+CREATE TABLE posts (id int);
+
+-- +goose StatementBegin
+CREATE PROCEDURE cleanup()
+BEGIN
+  DELETE FROM posts;
+  DELETE FROM posts;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE posts;
+`
+	dirPath := writeMigrations(t, map[string]string{"001_posts.sql": contents})
+	defer os.RemoveAll(dirPath)
+	migrations, err := Load(dirPath)
+	if err != nil || len(migrations) != 1 {
+		t.Fatalf("Unexpected result from Load: %v, %v", migrations, err)
+	}
+	var bodies []string
+	for _, stmt := range migrations[0].Statements {
+		if body := strings.TrimSpace(stmt.Body()); body != "" && !strings.HasPrefix(body, "--") {
+			bodies = append(bodies, body)
+		}
+	}
+	expected := []string{
+		"CREATE TABLE posts (id int)",
+		"CREATE PROCEDURE cleanup()\nBEGIN\n  DELETE FROM posts;\n  DELETE FROM posts;\nEND",
+	}
+	if len(bodies) != len(expected) {
+		t.Fatalf("Expected %d statements, instead found %d: %q", len(expected), len(bodies), bodies)
+	}
+	for n := range expected {
+		if bodies[n] != expected[n] {
+			t.Errorf("Statement %d: expected %q, found %q", n, expected[n], bodies[n])
+		}
+	}
+	if stmt := migrations[0].Statements[len(migrations[0].Statements)-1]; stmt.ObjectName != "cleanup" || stmt.LineNo != 5 {
+		t.Errorf("Expected combined statement to retain name and location of first statement, instead found %q at line %d", stmt.ObjectName, stmt.LineNo)
+	}
+}
+
+func TestQualifiedNamePattern(t *testing.T) {
+	cases := map[string]bool{
+		"DROP TABLE foo":                             false,
+		"DROP TABLE other.foo":                       true,
+		"RENAME TABLE `a`.`b` TO c":                  true,
+		"ALTER TABLE foo ADD COLUMN x int":           false,
+		"ALTER TABLE foo ALTER x SET DEFAULT 1.5":    false,
+		"ALTER TABLE foo COMMENT 'see docs.example'": false,
+		"ALTER TABLE other . foo DROP COLUMN x":      true,
+	}
+	for input, expected := range cases {
+		if actual := reQualifiedName.MatchString(reQuotedString.ReplaceAllString(input, "")); actual != expected {
+			t.Errorf("Expected qualified-name detection for %q to return %t, instead found %t", input, expected, actual)
+		}
+	}
+}
+
+func TestCheckStatement(t *testing.T) {
+	contents := `CREATE TABLE posts (id int);
+CREATE PROCEDURE cleanup() DELETE FROM posts;
+ALTER TABLE posts ADD COLUMN x int;
+CREATE UNIQUE INDEX idx_x ON posts (x);
+DROP INDEX idx_x ON posts;
+RENAME TABLE posts TO articles;
+DROP TEMPORARY TABLE IF EXISTS scratch;
+DROP PROCEDURE IF EXISTS cleanup;
+CREATE TABLE other.posts (id int);
+ALTER TABLE other.posts ADD COLUMN x int;
+DROP DATABASE legacy;
+ALTER DATABASE legacy CHARACTER SET utf8mb4;
+CREATE DATABASE legacy;
+CREATE USER 'bob'@'%' IDENTIFIED BY 'secret';
+DROP USER bob;
+CREATE ROLE app;
+CREATE TABLESPACE ts ADD DATAFILE 'ts.ibd';
+ALTER INSTANCE ROTATE INNODB MASTER KEY;
+CREATE SERVER s FOREIGN DATA WRAPPER mysql OPTIONS (HOST 'x');
+CREATE EVENT e ON SCHEDULE EVERY 1 DAY DO DELETE FROM posts;
+CREATE VIEW v AS SELECT 1;
+CREATE TRIGGER trg BEFORE INSERT ON posts FOR EACH ROW SET @x = 1;
+`
+	dirPath := writeMigrations(t, map[string]string{"V1__check.sql": contents})
+	defer os.RemoveAll(dirPath)
+	migrations, err := Load(dirPath)
+	if err != nil || len(migrations) != 1 {
+		t.Fatalf("Unexpected result from Load: %v, %v", migrations, err)
+	}
+	const allowedCount = 8
+	var n int
+	for _, stmt := range migrations[0].Statements {
+		words := strings.Fields(stmt.Body())
+		if len(words) == 0 {
+			continue
+		}
+		err := checkStatement(stmt, words)
+		if n < allowedCount && err != nil {
+			t.Errorf("Expected statement %q to be permitted, instead found error %v", stmt.Body(), err)
+		} else if n >= allowedCount && err == nil {
+			t.Errorf("Expected statement %q to be rejected, but it was permitted", stmt.Body())
+		}
+		n++
+	}
+	if n != 22 {
+		t.Errorf("Expected 22 statements, instead found %d", n)
+	}
+}
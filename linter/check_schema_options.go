@@ -0,0 +1,85 @@
+package linter
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+)
+
+func init() {
+	// This rule uses a customized RelatedOption and ConfigFunc, rather than using
+	// Rule.RelatedListOption, because each list entry may optionally specify a
+	// required value, and entries must be validated against the known
+	// schema-level options
+	RegisterRule(Rule{
+		CheckerFunc:     SchemaChecker(schemaOptionsChecker),
+		Name:            "schema-options",
+		Description:     "Require schema-level options listed in --require-schema-options to be configured",
+		DefaultSeverity: SeverityIgnore,
+		RelatedOption:   mybase.StringOption("require-schema-options", 0, "default-encryption=y", "List of schema-level options, optionally with required values, for --lint-schema-options"),
+		ConfigFunc:      RuleConfigFunc(schemaOptionsConfiger),
+	})
+}
+
+func schemaOptionsChecker(logicalSchema *fs.LogicalSchema, opts Options) (notes []Note) {
+	required, _ := opts.RuleConfig["schema-options"].(map[string]string)
+	names := make([]string, 0, len(required))
+	for name := range required {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	actualValues := map[string]string{
+		"default-encryption": logicalSchema.DefaultEncryption,
+		"schema-read-only":   logicalSchema.ReadOnly,
+	}
+	for _, name := range names {
+		actual, expected := actualValues[name], required[name]
+		if actual == "" {
+			notes = append(notes, Note{
+				Summary: "Schema-level option not configured",
+				Message: fmt.Sprintf("Option %s is not configured for this schema, but it is listed in option require-schema-options.", name),
+			})
+		} else if expected != "" && actual != expected {
+			notes = append(notes, Note{
+				Summary: "Schema-level option has wrong value",
+				Message: fmt.Sprintf("Option %s is configured as %s for this schema, but option require-schema-options requires a value of %s.", name, actual, expected),
+			})
+		}
+	}
+	return notes
+}
+
+// schemaOptionsConfiger parses and validates the require-schema-options
+// option, returning a map of option name to required value. The required value
+// is blank for entries which only require the option to be configured.
+func schemaOptionsConfiger(config *mybase.Config) interface{} {
+	values := config.GetSlice("require-schema-options", ',', true)
+	if len(values) == 0 {
+		return errors.New("Option require-schema-options must be non-empty")
+	}
+	allowedValues := map[string][]string{
+		"default-encryption": {"Y", "N"},
+		"schema-read-only":   {"1", "0"},
+	}
+	required := make(map[string]string, len(values))
+	for _, entry := range values {
+		name, value := entry, ""
+		if eq := strings.IndexByte(entry, '='); eq > -1 {
+			name, value = strings.TrimSpace(entry[:eq]), strings.ToUpper(strings.TrimSpace(entry[eq+1:]))
+		}
+		name = strings.ToLower(name)
+		allowed, ok := allowedValues[name]
+		if !ok {
+			return fmt.Errorf("Option require-schema-options cannot include %s: only default-encryption and schema-read-only are supported", name)
+		}
+		if value != "" && value != allowed[0] && value != allowed[1] {
+			return fmt.Errorf("Option require-schema-options cannot require %s=%s: valid values are %s", name, value, strings.Join(allowed, ", "))
+		}
+		required[name] = value
+	}
+	return required
+}
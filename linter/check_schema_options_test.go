@@ -0,0 +1,41 @@
+package linter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+)
+
+func TestSchemaOptionsChecker(t *testing.T) {
+	dir := getDir(t, "testdata/validcfg", "--lint-schema-options=error --require-schema-options='default-encryption=y, schema-read-only'")
+	opts, err := OptionsForDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error from OptionsForDir: %s", err)
+	}
+
+	logicalSchema := &fs.LogicalSchema{}
+	if notes := schemaOptionsChecker(logicalSchema, opts); len(notes) != 2 {
+		t.Errorf("Expected 2 notes for schema without options, instead found %d: %+v", len(notes), notes)
+	}
+	logicalSchema.DefaultEncryption = "N"
+	logicalSchema.ReadOnly = "0"
+	if notes := schemaOptionsChecker(logicalSchema, opts); len(notes) != 1 || !strings.Contains(notes[0].Message, "default-encryption") {
+		t.Errorf("Expected 1 note regarding default-encryption, instead found %+v", notes)
+	}
+	logicalSchema.DefaultEncryption = "Y"
+	if notes := schemaOptionsChecker(logicalSchema, opts); len(notes) != 0 {
+		t.Errorf("Expected no notes, instead found %+v", notes)
+	}
+
+	// Rule is ignored by default, in which case it should not have any config
+	// and should not return any notes
+	dir = getDir(t, "testdata/validcfg")
+	if opts, err = OptionsForDir(dir); err != nil {
+		t.Fatalf("Unexpected error from OptionsForDir: %s", err)
+	}
+	forceRulesWarning(opts)
+	if notes := schemaOptionsChecker(&fs.LogicalSchema{}, opts); len(notes) != 0 {
+		t.Errorf("Expected no notes, instead found %+v", notes)
+	}
+}
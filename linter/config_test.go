@@ -64,6 +64,9 @@ func TestOptionsForDir(t *testing.T) {
 		"--allow-engine=''",
 		"--lint-engine=gentle-nudge",
 		"--allow-definer=''",
+		"--lint-schema-options=warning --require-schema-options=''",
+		"--lint-schema-options=warning --require-schema-options=default-collation",
+		"--lint-schema-options=warning --require-schema-options=default-encryption=maybe",
 	}
 	confirmError := func(cliArgs string) {
 		t.Helper()
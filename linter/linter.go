@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
 	"github.com/skeema/skeema/workspace"
 	"github.com/skeema/tengo"
 )
@@ -47,6 +48,28 @@ func CheckSchema(wsSchema *workspace.Schema, opts Options) *Result {
 			}
 		}
 	}
+
+	// Schema-level rules run once per logical schema, rather than per object.
+	// Their annotations refer to the option file which configures the schema.
+	if !opts.shouldIgnore(tengo.ObjectKey{Type: tengo.ObjectTypeDatabase}) {
+		stmt := &fs.Statement{
+			File:       wsSchema.LogicalSchema.OptionFilePath,
+			ObjectType: tengo.ObjectTypeDatabase,
+			ObjectName: wsSchema.LogicalSchema.Name,
+		}
+		if stmt.File != "" {
+			stmt.LineNo, stmt.CharNo = 1, 1
+		}
+		for ruleName, severity := range opts.RuleSeverity {
+			checker, ok := rulesByName[ruleName].CheckerFunc.(SchemaChecker)
+			if severity == SeverityIgnore || !ok {
+				continue
+			}
+			for _, lo := range checker(wsSchema.LogicalSchema, opts) {
+				result.Annotate(stmt, severity, ruleName, lo)
+			}
+		}
+	}
 	return result
 }
 
@@ -100,6 +123,17 @@ func (rc RoutineChecker) CheckObject(object interface{}, createStatement string,
 	return nil
 }
 
+// SchemaChecker is a function that looks for problems in the schema-level
+// configuration of a logical schema, rather than in any individual object.
+type SchemaChecker func(logicalSchema *fs.LogicalSchema, opts Options) []Note
+
+// CheckObject allows SchemaChecker functions to satisfy the ObjectChecker
+// interface. It always returns nil, since schema-level checks are run
+// separately by CheckSchema rather than once per object.
+func (sc SchemaChecker) CheckObject(object interface{}, createStatement string, schema *tengo.Schema, opts Options) []Note {
+	return nil
+}
+
 // RuleConfigFunc is a function that performs supplemental configuration for
 // a Rule. The function can return any arbitrary value. If the return value
 // isn't an error or an untyped nil, it will be indexed in Config.
@@ -64,13 +64,23 @@ func (a *Annotation) Location() string {
 // Log logs the annotation, with a log level based on the annotation's severity.
 func (a *Annotation) Log() {
 	message := a.MessageWithLocation()
+	entry := log.WithFields(log.Fields{
+		"rule":   a.RuleName,
+		"object": a.Statement.ObjectKey().String(),
+	})
+	if a.Statement.File != "" && a.Statement.LineNo > 0 {
+		entry = entry.WithFields(log.Fields{
+			"file": a.Statement.File,
+			"line": a.LineNo(),
+		})
+	}
 	switch a.Severity {
 	case SeverityError:
-		log.Error(message)
+		entry.Error(message)
 	case SeverityWarning:
-		log.Warning(message)
+		entry.Warning(message)
 	default:
-		log.Info(message)
+		entry.Info(message)
 	}
 }
 
@@ -144,7 +154,7 @@ func (r *Result) AnnotateStatementErrors(statementErrors []*workspace.StatementE
 		}
 		note := Note{
 			Summary: "SQL statement returned an error",
-			Message: strings.Replace(stmtErr.Err.Error(), "Error executing DDL in workspace: ", "", 1),
+			Message: stmtErr.Message(),
 		}
 		// If the error was a syntax error, attempt to capture the correct line
 		if matches := reSyntaxErrorLine.FindStringSubmatch(note.Message); matches != nil {
@@ -75,11 +75,12 @@ func (f *customFormatter) Format(entry *log.Entry) ([]byte, error) {
 	return b.Bytes(), nil
 }
 
-func countAndNoun(n int, singular, plural string) string {
-	if n == 1 {
-		return fmt.Sprintf("1 %s", singular)
-	} else if n == 0 {
-		return fmt.Sprintf("no %s", plural)
+// writeLogSpacer writes a blank line to STDERR, to visually separate groups of
+// log lines. This is skipped with log-format=json, since each line of output
+// must be a JSON object; and with quiet, since the groups of info-level lines
+// are not logged.
+func writeLogSpacer() {
+	if _, ok := log.StandardLogger().Formatter.(*customFormatter); ok && log.IsLevelEnabled(log.InfoLevel) {
+		os.Stderr.WriteString("\n")
 	}
-	return fmt.Sprintf("%d %s", n, plural)
 }
@@ -0,0 +1,164 @@
+package schemadoc
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// span is a run of inline text in a document, optionally formatted as code
+// and/or linked to another page.
+type span struct {
+	text string
+	href string
+	code bool
+}
+
+// text returns a plain span.
+func text(s string) span {
+	return span{text: s}
+}
+
+// code returns a span formatted as code, such as an identifier.
+func code(s string) span {
+	return span{text: s, code: true}
+}
+
+// link returns a span linking to href. If href is blank, the span is unlinked.
+func link(s, href string, isCode bool) span {
+	return span{text: s, href: href, code: isCode}
+}
+
+// cell is the content of one table cell.
+type cell []span
+
+// page is a document being rendered in a particular format. Each method
+// appends a block of content to the page.
+type page interface {
+	heading(level int, content ...span)
+	paragraph(content ...span)
+	table(headers []string, rows [][]cell)
+	String() string
+}
+
+// newPage returns an empty page in the supplied format.
+func newPage(format Format, title string) page {
+	if format == FormatHTML {
+		return &htmlPage{title: title}
+	}
+	return &markdownPage{}
+}
+
+// markdownPage is a page rendered as Markdown, using table syntax supported by
+// GitHub and most other renderers.
+type markdownPage struct {
+	b strings.Builder
+}
+
+// markdownEscaper escapes characters with special meaning in Markdown text.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", "&lt;", ">", "&gt;", "#", `\#`, "|", `\|`,
+)
+
+func (mp *markdownPage) spans(content []span) string {
+	var b strings.Builder
+	for _, s := range content {
+		var rendered string
+		if s.code {
+			// A code span containing backticks must be delimited by a longer run of
+			// backticks. Pipes are still escaped, since they would otherwise end a
+			// table cell.
+			delim := "`"
+			for strings.Contains(s.text, delim) {
+				delim += "`"
+			}
+			inner := strings.Replace(s.text, "|", `\|`, -1)
+			if delim != "`" {
+				inner = " " + inner + " "
+			}
+			rendered = delim + inner + delim
+		} else {
+			rendered = markdownEscaper.Replace(s.text)
+		}
+		rendered = strings.Replace(rendered, "\n", "<br>", -1)
+		if s.href != "" {
+			rendered = fmt.Sprintf("[%s](%s)", rendered, s.href)
+		}
+		b.WriteString(rendered)
+	}
+	return b.String()
+}
+
+func (mp *markdownPage) heading(level int, content ...span) {
+	fmt.Fprintf(&mp.b, "%s %s\n\n", strings.Repeat("#", level), mp.spans(content))
+}
+
+func (mp *markdownPage) paragraph(content ...span) {
+	fmt.Fprintf(&mp.b, "%s\n\n", mp.spans(content))
+}
+
+func (mp *markdownPage) table(headers []string, rows [][]cell) {
+	fmt.Fprintf(&mp.b, "| %s |\n|%s\n", strings.Join(headers, " | "), strings.Repeat(" --- |", len(headers)))
+	for _, row := range rows {
+		rendered := make([]string, len(row))
+		for n, c := range row {
+			rendered[n] = mp.spans(c)
+		}
+		fmt.Fprintf(&mp.b, "| %s |\n", strings.Join(rendered, " | "))
+	}
+	mp.b.WriteString("\n")
+}
+
+func (mp *markdownPage) String() string {
+	return strings.TrimRight(mp.b.String(), "\n") + "\n"
+}
+
+// htmlPage is a page rendered as a standalone HTML document.
+type htmlPage struct {
+	title string
+	b     strings.Builder
+}
+
+func (hp *htmlPage) spans(content []span) string {
+	var b strings.Builder
+	for _, s := range content {
+		rendered := strings.Replace(html.EscapeString(s.text), "\n", "<br>", -1)
+		if s.code {
+			rendered = "<code>" + rendered + "</code>"
+		}
+		if s.href != "" {
+			rendered = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(s.href), rendered)
+		}
+		b.WriteString(rendered)
+	}
+	return b.String()
+}
+
+func (hp *htmlPage) heading(level int, content ...span) {
+	fmt.Fprintf(&hp.b, "<h%d>%s</h%d>\n", level, hp.spans(content), level)
+}
+
+func (hp *htmlPage) paragraph(content ...span) {
+	fmt.Fprintf(&hp.b, "<p>%s</p>\n", hp.spans(content))
+}
+
+func (hp *htmlPage) table(headers []string, rows [][]cell) {
+	hp.b.WriteString("<table>\n<tr>")
+	for _, header := range headers {
+		fmt.Fprintf(&hp.b, "<th>%s</th>", html.EscapeString(header))
+	}
+	hp.b.WriteString("</tr>\n")
+	for _, row := range rows {
+		hp.b.WriteString("<tr>")
+		for _, c := range row {
+			fmt.Fprintf(&hp.b, "<td>%s</td>", hp.spans(c))
+		}
+		hp.b.WriteString("</tr>\n")
+	}
+	hp.b.WriteString("</table>\n")
+}
+
+func (hp *htmlPage) String() string {
+	return fmt.Sprintf("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n%s</body>\n</html>\n", html.EscapeString(hp.title), hp.b.String())
+}
@@ -0,0 +1,409 @@
+// Package schemadoc generates human-readable documentation of schemas, as
+// Markdown or HTML files, from introspected tengo.Schema values.
+package schemadoc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// Format represents an output format for documentation files.
+type Format string
+
+// Constants enumerating valid Format values.
+const (
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+)
+
+// Extension returns the file extension, including the leading dot, used for
+// pages in the format.
+func (f Format) Extension() string {
+	if f == FormatHTML {
+		return ".html"
+	}
+	return ".md"
+}
+
+// indexPageName is the base name, without extension, of each schema's index
+// page.
+const indexPageName = "index"
+
+// Site is a set of schemas to document. Schemas are written to separate subdirs
+// of a single output dir, and foreign keys are cross-linked between any of the
+// site's schemas.
+type Site struct {
+	OutputDir string
+	Format    Format
+	schemas   []*siteSchema
+	byName    map[string]*siteSchema
+}
+
+// siteSchema is one schema in a Site.
+type siteSchema struct {
+	name      string
+	subdir    string
+	source    string
+	schema    *tengo.Schema
+	pageNames map[string]string // table name -> page file name, without extension
+}
+
+// NewSite returns a Site which will write files in the supplied format to
+// outputDir.
+func NewSite(outputDir string, format Format) *Site {
+	return &Site{
+		OutputDir: outputDir,
+		Format:    format,
+		byName:    make(map[string]*siteSchema),
+	}
+}
+
+// AddSchema adds a schema to the site. Its pages will be written to subdir,
+// a path relative to the site's output dir. The name is used in place of the
+// schema's own name, which may be a temporary workspace name. The source is a
+// description of where the schema was obtained from, such as a directory path,
+// and may be blank. If multiple schemas with the same name are added, foreign
+// keys referencing that name are linked to the first one.
+func (site *Site) AddSchema(name, subdir, source string, schema *tengo.Schema) {
+	ss := &siteSchema{
+		name:      name,
+		subdir:    subdir,
+		source:    source,
+		schema:    schema,
+		pageNames: make(map[string]string, len(schema.Tables)),
+	}
+	used := map[string]bool{indexPageName: true}
+	for _, table := range schema.Tables {
+		pageName := strings.Map(func(r rune) rune {
+			if r > 127 || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '-' || r == '$' {
+				return r
+			}
+			return '_'
+		}, table.Name)
+		for used[strings.ToLower(pageName)] {
+			pageName += "_"
+		}
+		used[strings.ToLower(pageName)] = true
+		ss.pageNames[table.Name] = pageName
+	}
+	site.schemas = append(site.schemas, ss)
+	if site.byName[name] == nil {
+		site.byName[name] = ss
+	}
+}
+
+// Write writes an index page for each schema in the site, along with a page
+// for each table. Any other files with the format's extension in each schema's
+// subdir are removed, so that pages for dropped tables do not linger. The
+// number of files written is returned.
+func (site *Site) Write() (count int, err error) {
+	for _, ss := range site.schemas {
+		dirPath := filepath.Join(site.OutputDir, ss.subdir)
+		if err := os.MkdirAll(dirPath, 0777); err != nil {
+			return count, err
+		}
+		if err := site.removeStalePages(dirPath); err != nil {
+			return count, err
+		}
+		pages := map[string]string{indexPageName: site.indexPage(ss)}
+		for _, table := range ss.schema.Tables {
+			pages[ss.pageNames[table.Name]] = site.tablePage(ss, table)
+		}
+		for pageName, contents := range pages {
+			if err := ioutil.WriteFile(filepath.Join(dirPath, pageName+site.Format.Extension()), []byte(contents), 0666); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// removeStalePages deletes files with the site format's extension directly in
+// dirPath. An error is returned if dirPath contains a .skeema file, since it is
+// then a schema dir rather than a dir of generated pages.
+func (site *Site) removeStalePages(dirPath string) error {
+	entries, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == ".skeema" {
+			return fmt.Errorf("%s contains a .skeema file, so its files cannot be replaced with generated pages", dirPath)
+		}
+	}
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() && strings.HasSuffix(entry.Name(), site.Format.Extension()) {
+			if err := os.Remove(filepath.Join(dirPath, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// tableHref returns a relative link from a page of schema from to the page for
+// the named table in the named schema, or a blank string if that table is not
+// documented by the site.
+func (site *Site) tableHref(from *siteSchema, schemaName, tableName string) string {
+	to := from
+	if schemaName != "" && schemaName != from.name {
+		to = site.byName[schemaName]
+	}
+	if to == nil || to.pageNames[tableName] == "" {
+		return ""
+	}
+	fileName := to.pageNames[tableName] + site.Format.Extension()
+	if to == from {
+		return fileName
+	}
+	rel, err := filepath.Rel(filepath.Join("/", from.subdir), filepath.Join("/", to.subdir, fileName))
+	if err != nil {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// indexPage renders the index page of a schema, listing its tables and
+// routines.
+func (site *Site) indexPage(ss *siteSchema) string {
+	p := newPage(site.Format, "Schema "+ss.name)
+	p.heading(1, text("Schema "), code(ss.name))
+	if ss.source != "" {
+		p.paragraph(text("Generated from "), code(ss.source), text("."))
+	}
+	if ss.schema.CharSet != "" {
+		p.paragraph(text("Default character set "), code(ss.schema.CharSet), text(", collation "), code(ss.schema.Collation), text("."))
+	}
+
+	p.heading(2, text("Tables"))
+	if len(ss.schema.Tables) == 0 {
+		p.paragraph(text("This schema does not have any tables."))
+	} else {
+		rows := make([][]cell, 0, len(ss.schema.Tables))
+		for _, table := range sortedTables(ss.schema.Tables) {
+			rows = append(rows, []cell{
+				{link(table.Name, ss.pageNames[table.Name]+site.Format.Extension(), true)},
+				{text(table.Engine)},
+				{text(table.Comment)},
+			})
+		}
+		p.table([]string{"Table", "Engine", "Comment"}, rows)
+	}
+
+	for _, objType := range []tengo.ObjectType{tengo.ObjectTypeProc, tengo.ObjectTypeFunc} {
+		var routines []*tengo.Routine
+		for _, r := range ss.schema.Routines {
+			if r.Type == objType {
+				routines = append(routines, r)
+			}
+		}
+		if len(routines) == 0 {
+			continue
+		}
+		sort.Slice(routines, func(i, j int) bool { return routines[i].Name < routines[j].Name })
+		if objType == tengo.ObjectTypeProc {
+			p.heading(2, text("Procedures"))
+		} else {
+			p.heading(2, text("Functions"))
+		}
+		for _, r := range routines {
+			p.heading(3, code(r.Name))
+			signature := fmt.Sprintf("%s(%s)", r.Name, r.ParamString)
+			if r.Type == tengo.ObjectTypeFunc {
+				signature += " RETURNS " + r.ReturnDataType
+			}
+			p.paragraph(code(signature))
+			if r.Comment != "" {
+				p.paragraph(text(r.Comment))
+			}
+			characteristics := []string{r.SQLDataAccess, "SQL SECURITY " + r.SecurityType}
+			if r.Deterministic {
+				characteristics = append(characteristics, "DETERMINISTIC")
+			}
+			p.paragraph(text("Characteristics: "), text(strings.Join(characteristics, ", ")), text("."))
+		}
+	}
+	return p.String()
+}
+
+// tablePage renders the page for a single table.
+func (site *Site) tablePage(ss *siteSchema, table *tengo.Table) string {
+	p := newPage(site.Format, fmt.Sprintf("Table %s.%s", ss.name, table.Name))
+	p.heading(1, text("Table "), code(table.Name))
+	p.paragraph(text("Schema "), link(ss.name, indexPageName+site.Format.Extension(), true), text("."))
+	if table.Comment != "" {
+		p.paragraph(text(table.Comment))
+	}
+	properties := fmt.Sprintf("Storage engine %s, default character set %s, collation %s.", table.Engine, table.CharSet, table.Collation)
+	if table.Partitioning != nil {
+		properties += fmt.Sprintf(" Partitioned by %s(%s) into %d partitions.", table.Partitioning.Method, table.Partitioning.Expression, len(table.Partitioning.Partitions))
+	}
+	p.paragraph(text(properties))
+
+	p.heading(2, text("Columns"))
+	rows := make([][]cell, len(table.Columns))
+	for n, col := range table.Columns {
+		rows[n] = columnRow(col, table)
+	}
+	p.table([]string{"Column", "Type", "Nullable", "Default", "Extra", "Comment"}, rows)
+
+	var indexes []*tengo.Index
+	if table.PrimaryKey != nil {
+		indexes = append(indexes, table.PrimaryKey)
+	}
+	indexes = append(indexes, table.SecondaryIndexes...)
+	if len(indexes) > 0 {
+		p.heading(2, text("Indexes"))
+		rows = make([][]cell, len(indexes))
+		for n, idx := range indexes {
+			rows[n] = indexRow(idx)
+		}
+		p.table([]string{"Index", "Type", "Columns", "Comment"}, rows)
+	}
+
+	if len(table.ForeignKeys) > 0 {
+		p.heading(2, text("Foreign keys"))
+		rows = make([][]cell, len(table.ForeignKeys))
+		for n, fk := range table.ForeignKeys {
+			refName := fk.ReferencedTableName
+			if fk.ReferencedSchemaName != "" {
+				refName = fk.ReferencedSchemaName + "." + refName
+			}
+			rows[n] = []cell{
+				{code(fk.Name)},
+				{text(strings.Join(fk.ColumnNames, ", "))},
+				{link(refName, site.tableHref(ss, fk.ReferencedSchemaName, fk.ReferencedTableName), true), text(" (" + strings.Join(fk.ReferencedColumnNames, ", ") + ")")},
+				{text(fk.DeleteRule)},
+				{text(fk.UpdateRule)},
+			}
+		}
+		p.table([]string{"Foreign key", "Columns", "References", "On delete", "On update"}, rows)
+	}
+
+	if rows = site.referencedByRows(ss, table); len(rows) > 0 {
+		p.heading(2, text("Referenced by"))
+		p.table([]string{"Table", "Foreign key", "Columns"}, rows)
+	}
+	return p.String()
+}
+
+// columnRow returns the cells describing a column.
+func columnRow(col *tengo.Column, table *tengo.Table) []cell {
+	colType := col.TypeInDB
+	if col.CharSet != "" && (col.CharSet != table.CharSet || col.Collation != table.Collation) {
+		colType += " CHARACTER SET " + col.CharSet
+		if !col.CollationIsDefault {
+			colType += " COLLATE " + col.Collation
+		}
+	}
+	nullable := "NO"
+	if col.Nullable {
+		nullable = "YES"
+	}
+	var extras []string
+	if col.AutoIncrement {
+		extras = append(extras, "AUTO_INCREMENT")
+	}
+	if col.GenerationExpr != "" {
+		kind := "STORED"
+		if col.Virtual {
+			kind = "VIRTUAL"
+		}
+		extras = append(extras, fmt.Sprintf("GENERATED ALWAYS AS (%s) %s", col.GenerationExpr, kind))
+	}
+	if col.OnUpdate != "" {
+		extras = append(extras, "ON UPDATE "+col.OnUpdate)
+	}
+	if col.Invisible {
+		extras = append(extras, "INVISIBLE")
+	}
+	defaultCell := cell{}
+	if col.Default != "" {
+		defaultCell = cell{code(col.Default)}
+	}
+	return []cell{
+		{code(col.Name)},
+		{text(colType)},
+		{text(nullable)},
+		defaultCell,
+		{text(strings.Join(extras, ", "))},
+		{text(col.Comment)},
+	}
+}
+
+// indexRow returns the cells describing an index.
+func indexRow(idx *tengo.Index) []cell {
+	kind := "INDEX"
+	if idx.PrimaryKey {
+		kind = "PRIMARY KEY"
+	} else if idx.Unique {
+		kind = "UNIQUE"
+	} else if idx.Type != "" && idx.Type != "BTREE" {
+		kind = idx.Type
+	}
+	parts := make([]string, len(idx.Parts))
+	for n, part := range idx.Parts {
+		parts[n] = part.ColumnName
+		if part.ColumnName == "" {
+			parts[n] = "(" + part.Expression + ")"
+		}
+		if part.PrefixLength > 0 {
+			parts[n] += fmt.Sprintf("(%d)", part.PrefixLength)
+		}
+		if part.Descending {
+			parts[n] += " DESC"
+		}
+	}
+	if idx.Invisible {
+		kind += ", invisible"
+	}
+	return []cell{
+		{code(idx.Name)},
+		{text(kind)},
+		{text(strings.Join(parts, ", "))},
+		{text(idx.Comment)},
+	}
+}
+
+// referencedByRows returns cells describing foreign keys, in any of the site's
+// schemas, which reference the supplied table.
+func (site *Site) referencedByRows(ss *siteSchema, table *tengo.Table) (rows [][]cell) {
+	for _, other := range site.schemas {
+		for _, t := range sortedTables(other.schema.Tables) {
+			for _, fk := range t.ForeignKeys {
+				refSchema := fk.ReferencedSchemaName
+				if refSchema == "" {
+					refSchema = other.name
+				}
+				if fk.ReferencedTableName != table.Name || site.byName[refSchema] != ss {
+					continue
+				}
+				name := t.Name
+				if other != ss {
+					name = other.name + "." + t.Name
+				}
+				rows = append(rows, []cell{
+					{link(name, site.tableHref(ss, other.name, t.Name), true)},
+					{code(fk.Name)},
+					{text(strings.Join(fk.ColumnNames, ", "))},
+				})
+			}
+		}
+	}
+	return rows
+}
+
+// sortedTables returns a copy of tables sorted by name.
+func sortedTables(tables []*tengo.Table) []*tengo.Table {
+	sorted := make([]*tengo.Table, len(tables))
+	copy(sorted, tables)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
@@ -0,0 +1,172 @@
+package schemadoc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func testSchemas() (product, analytics *tengo.Schema) {
+	users := &tengo.Table{
+		Name:      "users",
+		Engine:    "InnoDB",
+		CharSet:   "utf8mb4",
+		Collation: "utf8mb4_general_ci",
+		Comment:   "Registered | active users",
+		Columns: []*tengo.Column{
+			{Name: "id", TypeInDB: "int(10) unsigned", AutoIncrement: true},
+			{Name: "name", TypeInDB: "varchar(30)", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci", CollationIsDefault: true, Default: "''", Comment: "Display *name*"},
+		},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", PrimaryKey: true, Unique: true, Parts: []tengo.IndexPart{{ColumnName: "id"}}},
+		SecondaryIndexes: []*tengo.Index{
+			{Name: "name", Unique: true, Parts: []tengo.IndexPart{{ColumnName: "name", PrefixLength: 10}}},
+		},
+	}
+	posts := &tengo.Table{
+		Name:      "posts",
+		Engine:    "InnoDB",
+		CharSet:   "utf8mb4",
+		Collation: "utf8mb4_general_ci",
+		Columns: []*tengo.Column{
+			{Name: "id", TypeInDB: "int(10) unsigned"},
+			{Name: "user_id", TypeInDB: "int(10) unsigned"},
+		},
+		ForeignKeys: []*tengo.ForeignKey{
+			{Name: "posts_user", ColumnNames: []string{"user_id"}, ReferencedTableName: "users", ReferencedColumnNames: []string{"id"}, DeleteRule: "CASCADE", UpdateRule: "RESTRICT"},
+		},
+	}
+	index := &tengo.Table{Name: "index", Engine: "InnoDB", Columns: []*tengo.Column{{Name: "id", TypeInDB: "int(11)"}}}
+	proc := &tengo.Routine{Name: "cleanup", Type: tengo.ObjectTypeProc, ParamString: "IN days int", SQLDataAccess: "MODIFIES SQL DATA", SecurityType: "DEFINER", Comment: "Purges old rows"}
+	product = &tengo.Schema{Name: "_skeema_tmp", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci", Tables: []*tengo.Table{users, posts, index}, Routines: []*tengo.Routine{proc}}
+
+	pageviews := &tengo.Table{
+		Name:   "pageviews",
+		Engine: "InnoDB",
+		Columns: []*tengo.Column{
+			{Name: "user_id", TypeInDB: "int(10) unsigned"},
+		},
+		ForeignKeys: []*tengo.ForeignKey{
+			{Name: "pv_user", ColumnNames: []string{"user_id"}, ReferencedSchemaName: "product", ReferencedTableName: "users", ReferencedColumnNames: []string{"id"}, DeleteRule: "RESTRICT", UpdateRule: "RESTRICT"},
+		},
+	}
+	analytics = &tengo.Schema{Name: "_skeema_tmp", Tables: []*tengo.Table{pageviews}}
+	return product, analytics
+}
+
+func TestSiteWriteMarkdown(t *testing.T) {
+	outputDir, err := ioutil.TempDir("", "schemadoc")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(outputDir)
+	stalePath := filepath.Join(outputDir, "product", "dropped.md")
+	os.MkdirAll(filepath.Dir(stalePath), 0777)
+	ioutil.WriteFile(stalePath, []byte("old"), 0666)
+
+	product, analytics := testSchemas()
+	site := NewSite(outputDir, FormatMarkdown)
+	site.AddSchema("product", "product", "mydb/product", product)
+	site.AddSchema("analytics", "analytics", "mydb/analytics", analytics)
+	if count, err := site.Write(); count != 6 || err != nil {
+		t.Fatalf("Expected Write to return 6, nil; instead found %d, %v", count, err)
+	}
+	if _, err := os.Stat(stalePath); err == nil {
+		t.Error("Expected stale page to be removed, but it still exists")
+	}
+	readPage := func(relPath string) string {
+		t.Helper()
+		contents, err := ioutil.ReadFile(filepath.Join(outputDir, relPath))
+		if err != nil {
+			t.Fatalf("Unable to read %s: %v", relPath, err)
+		}
+		return string(contents)
+	}
+
+	expectContains := func(relPath string, substrings ...string) {
+		t.Helper()
+		contents := readPage(relPath)
+		for _, substring := range substrings {
+			if !strings.Contains(contents, substring) {
+				t.Errorf("Expected %s to contain %q, but it did not. Contents:\n%s", relPath, substring, contents)
+			}
+		}
+	}
+	expectContains("product/index.md",
+		"# Schema `product`",
+		"Generated from `mydb/product`.",
+		"| [`posts`](posts.md) | InnoDB |  |",
+		"| [`users`](users.md) | InnoDB | Registered \\| active users |",
+		"| [`index`](index_.md) |",
+		"## Procedures",
+		"`cleanup(IN days int)`",
+		"Purges old rows",
+	)
+	expectContains("product/users.md",
+		"# Table `users`",
+		"Schema [`product`](index.md).",
+		"| `id` | int(10) unsigned | NO |  | AUTO\\_INCREMENT |  |",
+		"| `name` | varchar(30) | NO | `''` |  | Display \\*name\\* |",
+		"| `PRIMARY` | PRIMARY KEY | id |  |",
+		"| `name` | UNIQUE | name(10) |  |",
+		"## Referenced by",
+		"| [`posts`](posts.md) | `posts_user` | user\\_id |",
+		"| [`analytics.pageviews`](../analytics/pageviews.md) | `pv_user` | user\\_id |",
+	)
+	expectContains("product/posts.md",
+		"| `posts_user` | user\\_id | [`users`](users.md) (id) | CASCADE | RESTRICT |",
+	)
+	expectContains("analytics/pageviews.md",
+		"| `pv_user` | user\\_id | [`product.users`](../product/users.md) (id) | RESTRICT | RESTRICT |",
+	)
+	if contents := readPage("product/posts.md"); strings.Contains(contents, "Referenced by") {
+		t.Errorf("Expected posts.md not to have a Referenced by section, but it did:\n%s", contents)
+	}
+
+	// Pages must not be written to a schema dir, since that would remove any
+	// other *.md files there
+	keepPath := filepath.Join(outputDir, "product", "README.md")
+	ioutil.WriteFile(filepath.Join(outputDir, "product", ".skeema"), []byte("schema=product\n"), 0666)
+	ioutil.WriteFile(keepPath, []byte("keep"), 0666)
+	if _, err := site.Write(); err == nil {
+		t.Error("Expected Write to return an error for a dir containing .skeema, but it did not")
+	}
+	if _, err := os.Stat(keepPath); err != nil {
+		t.Errorf("Expected README.md to be left alone, but Stat returned %v", err)
+	}
+}
+
+func TestSiteWriteHTML(t *testing.T) {
+	outputDir, err := ioutil.TempDir("", "schemadoc")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(outputDir)
+
+	product, _ := testSchemas()
+	site := NewSite(outputDir, FormatHTML)
+	site.AddSchema("product", "", "", product)
+	if count, err := site.Write(); count != 4 || err != nil {
+		t.Fatalf("Expected Write to return 4, nil; instead found %d, %v", count, err)
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(outputDir, "posts.html"))
+	if err != nil {
+		t.Fatalf("Unable to read posts.html: %v", err)
+	}
+	for _, substring := range []string{
+		"<title>Table product.posts</title>",
+		"<h1>Table <code>posts</code></h1>",
+		`<td><a href="users.html"><code>users</code></a> (id)</td>`,
+	} {
+		if !strings.Contains(string(contents), substring) {
+			t.Errorf("Expected posts.html to contain %q, but it did not. Contents:\n%s", substring, contents)
+		}
+	}
+	contents, _ = ioutil.ReadFile(filepath.Join(outputDir, "users.html"))
+	if !strings.Contains(string(contents), "<td>Display *name*</td>") || !strings.Contains(string(contents), "<td><code>&#39;&#39;</code></td>") {
+		t.Errorf("Unexpected escaping in users.html:\n%s", contents)
+	}
+}
@@ -0,0 +1,214 @@
+package schemaexport
+
+import (
+	"github.com/skeema/tengo"
+)
+
+// Table is the exported representation of a table.
+type Table struct {
+	Name              string        `json:"name"`
+	Engine            string        `json:"storageEngine"`
+	CharSet           string        `json:"defaultCharSet"`
+	Collation         string        `json:"defaultCollation"`
+	CreateOptions     string        `json:"createOptions,omitempty"` // row_format, stats_persistent, etc
+	Columns           []*Column     `json:"columns"`
+	PrimaryKey        *Index        `json:"primaryKey,omitempty"`
+	SecondaryIndexes  []*Index      `json:"secondaryIndexes,omitempty"`
+	ForeignKeys       []*ForeignKey `json:"foreignKeys,omitempty"`
+	Comment           string        `json:"comment,omitempty"`
+	NextAutoIncrement uint64        `json:"nextAutoIncrement,omitempty"`
+	Partitioning      *Partitioning `json:"partitioning,omitempty"` // nil if table isn't partitioned
+	CreateStatement   string        `json:"showCreateTable"`
+}
+
+// Column is the exported representation of a column in a table.
+type Column struct {
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	Nullable       bool   `json:"nullable,omitempty"`
+	AutoIncrement  bool   `json:"autoIncrement,omitempty"`
+	Default        string `json:"default,omitempty"` // SQL expression, i.e. quote-wrapped if string
+	OnUpdate       string `json:"onUpdate,omitempty"`
+	GenerationExpr string `json:"generationExpression,omitempty"` // only populated if generated column
+	Virtual        bool   `json:"virtual,omitempty"`
+	CharSet        string `json:"charSet,omitempty"`   // only populated if textual type
+	Collation      string `json:"collation,omitempty"` // only populated if textual type
+	ColumnFormat   string `json:"columnFormat,omitempty"`
+	Comment        string `json:"comment,omitempty"`
+	Invisible      bool   `json:"invisible,omitempty"`
+}
+
+// Index is the exported representation of a primary key or secondary index.
+type Index struct {
+	Name       string      `json:"name"`
+	Parts      []IndexPart `json:"parts"`
+	PrimaryKey bool        `json:"primaryKey,omitempty"`
+	Unique     bool        `json:"unique,omitempty"`
+	Invisible  bool        `json:"invisible,omitempty"`
+	Comment    string      `json:"comment,omitempty"`
+	Type       string      `json:"type"`
+}
+
+// IndexPart is the exported representation of one column or expression of an
+// index.
+type IndexPart struct {
+	ColumnName   string `json:"columnName,omitempty"`   // empty if expression
+	Expression   string `json:"expression,omitempty"`   // empty if column
+	PrefixLength uint16 `json:"prefixLength,omitempty"` // nonzero if only a prefix of column is indexed
+	Descending   bool   `json:"descending,omitempty"`
+}
+
+// ForeignKey is the exported representation of a foreign key constraint.
+type ForeignKey struct {
+	Name                  string   `json:"name"`
+	ColumnNames           []string `json:"columnNames"`
+	ReferencedSchemaName  string   `json:"referencedSchemaName,omitempty"` // empty if same schema
+	ReferencedTableName   string   `json:"referencedTableName"`
+	ReferencedColumnNames []string `json:"referencedColumnNames"`
+	UpdateRule            string   `json:"updateRule"`
+	DeleteRule            string   `json:"deleteRule"`
+}
+
+// Partitioning is the exported representation of a table's partitioning.
+type Partitioning struct {
+	Method        string       `json:"method"`
+	SubMethod     string       `json:"subMethod,omitempty"`
+	Expression    string       `json:"expression"`
+	SubExpression string       `json:"subExpression,omitempty"`
+	Partitions    []*Partition `json:"partitions"`
+}
+
+// Partition is the exported representation of one partition of a table.
+type Partition struct {
+	Name    string `json:"name"`
+	SubName string `json:"subName,omitempty"`
+	Values  string `json:"values,omitempty"` // only populated for RANGE or LIST
+	Comment string `json:"comment,omitempty"`
+	Engine  string `json:"engine"`
+	DataDir string `json:"dataDir,omitempty"`
+}
+
+// Routine is the exported representation of a stored procedure or function.
+type Routine struct {
+	Name              string `json:"name"`
+	Type              string `json:"type"` // "procedure" or "function"
+	Body              string `json:"body"`
+	ParamString       string `json:"paramString"`
+	ReturnDataType    string `json:"returnDataType,omitempty"` // only populated for functions
+	Definer           string `json:"definer"`
+	DatabaseCollation string `json:"dbCollation"`
+	Comment           string `json:"comment,omitempty"`
+	Deterministic     bool   `json:"deterministic,omitempty"`
+	SQLDataAccess     string `json:"sqlDataAccess,omitempty"`
+	SecurityType      string `json:"securityType"`
+	SQLMode           string `json:"sqlMode"`
+	CreateStatement   string `json:"showCreate"`
+}
+
+func newTable(t *tengo.Table) *Table {
+	table := &Table{
+		Name:              t.Name,
+		Engine:            t.Engine,
+		CharSet:           t.CharSet,
+		Collation:         t.Collation,
+		CreateOptions:     t.CreateOptions,
+		Columns:           make([]*Column, len(t.Columns)),
+		PrimaryKey:        newIndex(t.PrimaryKey),
+		Comment:           t.Comment,
+		NextAutoIncrement: t.NextAutoIncrement,
+		CreateStatement:   t.CreateStatement,
+	}
+	for n, col := range t.Columns {
+		table.Columns[n] = &Column{
+			Name:           col.Name,
+			Type:           col.TypeInDB,
+			Nullable:       col.Nullable,
+			AutoIncrement:  col.AutoIncrement,
+			Default:        col.Default,
+			OnUpdate:       col.OnUpdate,
+			GenerationExpr: col.GenerationExpr,
+			Virtual:        col.Virtual,
+			CharSet:        col.CharSet,
+			Collation:      col.Collation,
+			ColumnFormat:   col.ColumnFormat,
+			Comment:        col.Comment,
+			Invisible:      col.Invisible,
+		}
+	}
+	for _, idx := range t.SecondaryIndexes {
+		table.SecondaryIndexes = append(table.SecondaryIndexes, newIndex(idx))
+	}
+	for _, fk := range t.ForeignKeys {
+		table.ForeignKeys = append(table.ForeignKeys, &ForeignKey{
+			Name:                  fk.Name,
+			ColumnNames:           fk.ColumnNames,
+			ReferencedSchemaName:  fk.ReferencedSchemaName,
+			ReferencedTableName:   fk.ReferencedTableName,
+			ReferencedColumnNames: fk.ReferencedColumnNames,
+			UpdateRule:            fk.UpdateRule,
+			DeleteRule:            fk.DeleteRule,
+		})
+	}
+	if tp := t.Partitioning; tp != nil {
+		table.Partitioning = &Partitioning{
+			Method:        tp.Method,
+			SubMethod:     tp.SubMethod,
+			Expression:    tp.Expression,
+			SubExpression: tp.SubExpression,
+			Partitions:    make([]*Partition, len(tp.Partitions)),
+		}
+		for n, p := range tp.Partitions {
+			table.Partitioning.Partitions[n] = &Partition{
+				Name:    p.Name,
+				SubName: p.SubName,
+				Values:  p.Values,
+				Comment: p.Comment,
+				Engine:  p.Engine,
+				DataDir: p.DataDir,
+			}
+		}
+	}
+	return table
+}
+
+func newIndex(idx *tengo.Index) *Index {
+	if idx == nil {
+		return nil
+	}
+	index := &Index{
+		Name:       idx.Name,
+		Parts:      make([]IndexPart, len(idx.Parts)),
+		PrimaryKey: idx.PrimaryKey,
+		Unique:     idx.Unique,
+		Invisible:  idx.Invisible,
+		Comment:    idx.Comment,
+		Type:       idx.Type,
+	}
+	for n, part := range idx.Parts {
+		index.Parts[n] = IndexPart{
+			ColumnName:   part.ColumnName,
+			Expression:   part.Expression,
+			PrefixLength: part.PrefixLength,
+			Descending:   part.Descending,
+		}
+	}
+	return index
+}
+
+func newRoutine(r *tengo.Routine) *Routine {
+	return &Routine{
+		Name:              r.Name,
+		Type:              string(r.Type),
+		Body:              r.Body,
+		ParamString:       r.ParamString,
+		ReturnDataType:    r.ReturnDataType,
+		Definer:           r.Definer,
+		DatabaseCollation: r.DatabaseCollation,
+		Comment:           r.Comment,
+		Deterministic:     r.Deterministic,
+		SQLDataAccess:     r.SQLDataAccess,
+		SecurityType:      r.SecurityType,
+		SQLMode:           r.SQLMode,
+		CreateStatement:   r.CreateStatement,
+	}
+}
@@ -0,0 +1,99 @@
+// Package schemaexport converts schemas into a stable, structured document
+// format, for consumption by external tools such as code generators and data
+// catalogs.
+package schemaexport
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/skeema/tengo"
+)
+
+// FormatVersion is the version number of the document structure. It will be
+// incremented whenever a backwards-incompatible change is made to the
+// structure, such as removing or renaming a field.
+const FormatVersion = 1
+
+// Format represents an output format for documents.
+type Format string
+
+// Constants enumerating valid Format values.
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+// Document is the top-level structure of an export.
+type Document struct {
+	FormatVersion int       `json:"formatVersion"`
+	Source        string    `json:"source"` // "filesystem" or "instance"
+	Schemas       []*Schema `json:"schemas"`
+}
+
+// Schema is the exported representation of one schema.
+type Schema struct {
+	Name      string     `json:"name"`
+	Dir       string     `json:"dir,omitempty"`      // relative path of the dir defining the schema
+	Instance  string     `json:"instance,omitempty"` // only populated if introspected from a live instance
+	CharSet   string     `json:"defaultCharSet"`
+	Collation string     `json:"defaultCollation"`
+	Tables    []*Table   `json:"tables"`
+	Routines  []*Routine `json:"routines"`
+}
+
+// NewDocument returns an empty document for schemas obtained from source.
+func NewDocument(source string) *Document {
+	return &Document{
+		FormatVersion: FormatVersion,
+		Source:        source,
+		Schemas:       []*Schema{},
+	}
+}
+
+// AddSchema adds schema to the document under the supplied name, which may
+// differ from schema.Name if schema was introspected from a workspace. Tables
+// and routines are sorted by name, so that output is deterministic.
+func (doc *Document) AddSchema(name, dir, instance string, schema *tengo.Schema) {
+	s := &Schema{
+		Name:      name,
+		Dir:       dir,
+		Instance:  instance,
+		CharSet:   schema.CharSet,
+		Collation: schema.Collation,
+		Tables:    make([]*Table, len(schema.Tables)),
+		Routines:  make([]*Routine, len(schema.Routines)),
+	}
+	for n, table := range schema.Tables {
+		s.Tables[n] = newTable(table)
+	}
+	for n, routine := range schema.Routines {
+		s.Routines[n] = newRoutine(routine)
+	}
+	sort.Slice(s.Tables, func(i, j int) bool { return s.Tables[i].Name < s.Tables[j].Name })
+	sort.Slice(s.Routines, func(i, j int) bool {
+		if s.Routines[i].Name != s.Routines[j].Name {
+			return s.Routines[i].Name < s.Routines[j].Name
+		}
+		return s.Routines[i].Type < s.Routines[j].Type
+	})
+	doc.Schemas = append(doc.Schemas, s)
+}
+
+// Write encodes the document to w in the supplied format.
+func (doc *Document) Write(w io.Writer, format Format) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if format == FormatYAML {
+		if data, err = jsonToYAML(data); err != nil {
+			return err
+		}
+	} else {
+		data = append(data, '\n')
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,125 @@
+package schemaexport
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func testDocument() *Document {
+	users := &tengo.Table{
+		Name:               "users",
+		Engine:             "InnoDB",
+		CharSet:            "utf8mb4",
+		Collation:          "utf8mb4_general_ci",
+		CollationIsDefault: true,
+		Columns: []*tengo.Column{
+			{Name: "id", TypeInDB: "int(10) unsigned", AutoIncrement: true},
+			{Name: "name", TypeInDB: "varchar(30)", Default: "'yes'", Comment: "Display name: first, last"},
+		},
+		PrimaryKey:      &tengo.Index{Name: "PRIMARY", PrimaryKey: true, Unique: true, Parts: []tengo.IndexPart{{ColumnName: "id"}}, Type: "BTREE"},
+		CreateStatement: "CREATE TABLE `users` (\n  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB",
+	}
+	posts := &tengo.Table{Name: "posts", Engine: "InnoDB", Columns: []*tengo.Column{{Name: "id", TypeInDB: "int(10) unsigned"}}}
+	proc := &tengo.Routine{Name: "cleanup", Type: tengo.ObjectTypeProc, Body: "BEGIN\n  DELETE FROM posts;\nEND\n", SecurityType: "DEFINER"}
+	schema := &tengo.Schema{Name: "_skeema_tmp", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci", Tables: []*tengo.Table{users, posts}, Routines: []*tengo.Routine{proc}}
+
+	doc := NewDocument("filesystem")
+	doc.AddSchema("product", "mydb/product", "", schema)
+	return doc
+}
+
+func TestDocumentWriteJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := testDocument().Write(&b, FormatJSON); err != nil {
+		t.Fatalf("Unexpected error from Write: %v", err)
+	}
+	var decoded struct {
+		FormatVersion int    `json:"formatVersion"`
+		Source        string `json:"source"`
+		Schemas       []struct {
+			Name   string `json:"name"`
+			Dir    string `json:"dir"`
+			Tables []struct {
+				Name string `json:"name"`
+			} `json:"tables"`
+		} `json:"schemas"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unable to decode output: %v\n%s", err, b.String())
+	}
+	if decoded.FormatVersion != FormatVersion || decoded.Source != "filesystem" || len(decoded.Schemas) != 1 {
+		t.Fatalf("Unexpected decoded output: %+v", decoded)
+	}
+	s := decoded.Schemas[0]
+	if s.Name != "product" || s.Dir != "mydb/product" || len(s.Tables) != 2 || s.Tables[0].Name != "posts" || s.Tables[1].Name != "users" {
+		t.Errorf("Unexpected decoded schema: %+v", s)
+	}
+	if strings.Contains(b.String(), `"instance"`) {
+		t.Errorf("Expected blank instance field to be omitted, but it was present:\n%s", b.String())
+	}
+	if strings.Contains(b.String(), `"collationIsDefault"`) {
+		t.Errorf("Expected internal tengo fields to be omitted, but collationIsDefault was present:\n%s", b.String())
+	}
+}
+
+func TestDocumentWriteYAML(t *testing.T) {
+	var b bytes.Buffer
+	if err := testDocument().Write(&b, FormatYAML); err != nil {
+		t.Fatalf("Unexpected error from Write: %v", err)
+	}
+	expected := []string{
+		"formatVersion: 1\nsource: filesystem\nschemas:\n- name: product\n  dir: mydb/product\n  defaultCharSet: utf8mb4\n",
+		"  tables:\n  - name: posts\n",
+		"    columns:\n    - name: id\n      type: int(10) unsigned\n      autoIncrement: true\n",
+		"      default: \"'yes'\"\n      comment: \"Display name: first, last\"\n",
+		"    primaryKey:\n      name: PRIMARY\n      parts:\n      - columnName: id\n      primaryKey: true\n",
+		"    showCreateTable: |-\n      CREATE TABLE `users` (\n        `id` int(10) unsigned NOT NULL AUTO_INCREMENT,\n        PRIMARY KEY (`id`)\n      ) ENGINE=InnoDB\n",
+		"  routines:\n  - name: cleanup\n    type: procedure\n    body: |\n      BEGIN\n        DELETE FROM posts;\n      END\n    paramString: \"\"\n",
+	}
+	for _, substring := range expected {
+		if !strings.Contains(b.String(), substring) {
+			t.Errorf("Expected YAML output to contain %q, but it did not. Output:\n%s", substring, b.String())
+		}
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	cases := map[string]string{
+		"InnoDB":              "InnoDB",
+		"int(10) unsigned":    "int(10) unsigned",
+		"":                    `""`,
+		"yes":                 `"yes"`,
+		"Off":                 `"Off"`,
+		"123":                 `"123"`,
+		"a: b":                `"a: b"`,
+		"trailing ":           `"trailing "`,
+		"two\nlines":          "|-\n    two\n    lines",
+		"keep\n\n":            "|+\n    keep\n", // caller writes final newline
+		" leading\nspace":     `" leading\nspace"`,
+		"crlf\r\nline":        `"crlf\r\nline"`,
+		"blank\n   \nspaces":  `"blank\n   \nspaces"`,
+		"empty\n\nline\n":     "|\n    empty\n\n    line",
+		"\"quoted\" \\ slash": `"\"quoted\" \\ slash"`,
+	}
+	for input, expected := range cases {
+		if actual := yamlString(input, 2); actual != expected {
+			t.Errorf("Expected yamlString(%q) to return %q, instead found %q", input, expected, actual)
+		}
+	}
+}
+
+func TestJSONToYAMLNested(t *testing.T) {
+	input := `{"a": [], "b": {}, "c": [[1, 2], {"d": null}], "e": {"f": [true]}}`
+	expected := "a: []\nb: {}\nc:\n-\n  - 1\n  - 2\n- d: null\ne:\n  f:\n  - true\n"
+	actual, err := jsonToYAML([]byte(input))
+	if err != nil {
+		t.Fatalf("Unexpected error from jsonToYAML: %v", err)
+	}
+	if string(actual) != expected {
+		t.Errorf("Unexpected output from jsonToYAML.\nExpected:\n%s\nActual:\n%s", expected, actual)
+	}
+}
@@ -0,0 +1,204 @@
+package schemaexport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// node is a decoded JSON value which retains the order of object keys, so that
+// YAML output lists fields in the same order as JSON output.
+type node struct {
+	scalar interface{} // string, json.Number, bool, or nil; only used if keys and items are both nil
+	keys   []string    // non-nil if an object
+	values []*node     // values of object, in same order as keys
+	items  []*node     // non-nil if an array
+}
+
+// jsonToYAML converts a JSON document into an equivalent YAML document.
+func jsonToYAML(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	root, err := decodeNode(dec)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	writeYAML(&b, root, 0)
+	return b.Bytes(), nil
+}
+
+func decodeNode(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		n := &node{keys: []string{}}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.keys = append(n.keys, keyTok.(string))
+			n.values = append(n.values, value)
+		}
+		_, err = dec.Token() // closing delimiter
+		return n, err
+	case json.Delim('['):
+		n := &node{items: []*node{}}
+		for dec.More() {
+			item, err := decodeNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, item)
+		}
+		_, err = dec.Token() // closing delimiter
+		return n, err
+	default:
+		return &node{scalar: tok}, nil
+	}
+}
+
+// isCollection returns true if n is a non-empty object or array, which must be
+// written as a YAML block beginning on a new line.
+func (n *node) isCollection() bool {
+	return len(n.keys) > 0 || len(n.items) > 0
+}
+
+// writeYAML writes n as a YAML block collection, with each line indented by
+// indent spaces. The caller is responsible for having written any key or
+// sequence indicator preceding n.
+func writeYAML(b *bytes.Buffer, n *node, indent int) {
+	if !n.isCollection() {
+		b.WriteString(yamlScalar(n, indent))
+		b.WriteByte('\n')
+		return
+	}
+	prefix := strings.Repeat(" ", indent)
+	if n.keys != nil {
+		for i, key := range n.keys {
+			if i > 0 {
+				b.WriteString(prefix)
+			}
+			writeYAMLEntry(b, yamlString(key, indent)+":", n.values[i], indent)
+		}
+		return
+	}
+	for i, item := range n.items {
+		if i > 0 {
+			b.WriteString(prefix)
+		}
+		if len(item.keys) > 0 {
+			// A mapping in a sequence begins on the same line as the "-" indicator
+			b.WriteString("- ")
+			writeYAML(b, item, indent+2)
+		} else {
+			writeYAMLEntry(b, "-", item, indent)
+		}
+	}
+}
+
+// writeYAMLEntry writes a mapping key or sequence indicator, followed by value.
+func writeYAMLEntry(b *bytes.Buffer, label string, value *node, indent int) {
+	b.WriteString(label)
+	if value.isCollection() {
+		b.WriteByte('\n')
+		// Sequences nested in mappings are conventionally not indented further
+		childIndent := indent + 2
+		if value.items != nil && label != "-" {
+			childIndent = indent
+		}
+		b.WriteString(strings.Repeat(" ", childIndent))
+		writeYAML(b, value, childIndent)
+		return
+	}
+	b.WriteByte(' ')
+	b.WriteString(yamlScalar(value, indent))
+	b.WriteByte('\n')
+}
+
+// yamlScalar returns the YAML representation of a scalar or empty collection.
+func yamlScalar(n *node, indent int) string {
+	if n.keys != nil {
+		return "{}"
+	} else if n.items != nil {
+		return "[]"
+	}
+	switch v := n.scalar.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return fmt.Sprint(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlString(v, indent)
+	}
+	panic(fmt.Errorf("Unexpected JSON value %v", n.scalar))
+}
+
+var (
+	yamlPlainString  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_./()-]*( [A-Za-z0-9_./()-]+)*$`)
+	yamlReservedWord = regexp.MustCompile(`^(?i:y|n|yes|no|true|false|on|off|null)$`)
+	yamlUnsafeLine   = regexp.MustCompile(`^\s+$|[^\t\x20-\x7e\x{a0}-\x{d7ff}\x{e000}-\x{fffd}\x{10000}-\x{10ffff}]`)
+)
+
+// yamlString returns s as a YAML scalar. Simple strings are written unquoted,
+// multi-line strings as literal blocks, and all other strings double-quoted.
+// Double-quoted YAML strings use the same escape sequences as JSON.
+func yamlString(s string, indent int) string {
+	if yamlPlainString.MatchString(s) && !yamlReservedWord.MatchString(s) {
+		return s
+	}
+	if lines := strings.Split(s, "\n"); len(lines) > 1 && !strings.HasPrefix(s, " ") && !strings.HasPrefix(s, "\n") {
+		if literal, ok := yamlLiteral(lines, indent+2); ok {
+			return literal
+		}
+	}
+	encoded, _ := json.Marshal(s)
+	return string(encoded)
+}
+
+// yamlLiteral returns a literal block scalar consisting of lines, indented by
+// indent spaces. The chomping indicator is chosen based on the number of
+// trailing newlines. If any lines cannot be represented verbatim, ok will be
+// false.
+func yamlLiteral(lines []string, indent int) (literal string, ok bool) {
+	var trailing int
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	if len(lines) == 0 {
+		return "", false
+	}
+	chomp := map[int]string{0: "|-", 1: "|"}[trailing]
+	if chomp == "" {
+		chomp = "|+"
+	}
+	var b strings.Builder
+	b.WriteString(chomp)
+	prefix := strings.Repeat(" ", indent)
+	for _, line := range lines {
+		if yamlUnsafeLine.MatchString(line) {
+			return "", false
+		}
+		b.WriteByte('\n')
+		if line != "" {
+			b.WriteString(prefix + line)
+		}
+	}
+	for n := 1; n < trailing; n++ {
+		b.WriteByte('\n')
+	}
+	return b.String(), true
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -443,6 +445,71 @@ func (s SkeemaIntegrationSuite) TestDiffHandler(t *testing.T) {
 	}
 }
 
+func (s SkeemaIntegrationSuite) TestCompareHandler(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	s.handleCommand(t, CodeSuccess, ".", "skeema add-environment --host %s:%d --dir mydb staging", s.d.Instance.Host, s.d.Instance.Port)
+
+	// Both environments point to the same instance, so no differences
+	s.handleCommand(t, CodeSuccess, ".", "skeema compare production staging")
+
+	// Comparing an environment to itself is a usage error
+	s.handleCommand(t, CodeBadUsage, ".", "skeema compare production production")
+
+	// Map analytics to a different schema in staging, which is missing a table.
+	// This requires a DROP TABLE, which isn't permitted without allow-unsafe.
+	contents := fs.ReadTestFile(t, "mydb/analytics/.skeema")
+	fs.WriteTestFile(t, "mydb/analytics/.skeema", contents+"\n[staging]\nschema=analytics2\n")
+	s.dbExec(t, "", "CREATE DATABASE analytics2")
+	s.dbExec(t, "analytics2", "CREATE TABLE pageviews LIKE analytics.pageviews")
+	s.handleCommand(t, CodeFatalError, ".", "skeema compare production staging")
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema compare production staging --allow-unsafe")
+
+	// ignore-table should be honored
+	s.handleCommand(t, CodeSuccess, ".", "skeema compare production staging --ignore-table='^(activity|rollups)$'")
+
+	// The first environment's schema is missing the table, in the opposite direction
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema compare staging production")
+
+	compareOutput := func(expectedExitCode int, commandLine string, a ...interface{}) string {
+		t.Helper()
+		oldStdout := os.Stdout
+		outFile, err := os.Create("compare.out")
+		if err != nil {
+			t.Fatalf("Unable to redirect stdout to a file: %s", err)
+		}
+		os.Stdout = outFile
+		s.handleCommand(t, expectedExitCode, ".", commandLine, a...)
+		outFile.Close()
+		os.Stdout = oldStdout
+		contents := fs.ReadTestFile(t, "compare.out")
+		if err := os.Remove("compare.out"); err != nil {
+			t.Fatalf("Unable to delete compare.out: %s", err)
+		}
+		return contents
+	}
+
+	// A forbidden DROP TABLE only skips that table, not the rest of the schema
+	s.dbExec(t, "analytics2", "CREATE TABLE newtbl (id int)")
+	if out := compareOutput(CodeFatalError, "skeema compare production staging"); !strings.Contains(out, "CREATE TABLE `newtbl`") || strings.Contains(out, "DROP TABLE") {
+		t.Errorf("Unexpected output from compare with forbidden DROP TABLE:\n%s", out)
+	}
+
+	// DSNs may be supplied instead of environment names, but not a mix of both
+	dsnBase := fmt.Sprintf("root:fakepw@tcp(%s:%d)", s.d.Instance.Host, s.d.Instance.Port)
+	s.handleCommand(t, CodeSuccess, ".", "skeema compare '%s/analytics' '%s/analytics'", dsnBase, dsnBase)
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema compare '%s/analytics2' '%s/analytics' --allow-unsafe", dsnBase, dsnBase)
+	s.handleCommand(t, CodeBadUsage, ".", "skeema compare production '%s/analytics'", dsnBase)
+	s.handleCommand(t, CodeBadInput, ".", "skeema compare '%s/' '%s/analytics'", dsnBase, dsnBase)
+	s.handleCommand(t, CodeBadInput, ".", "skeema compare '%s/analytics?timeout=1s' '%s/analytics'", dsnBase, dsnBase)
+
+	// If the first schema does not exist, it must be created before it is used
+	out := compareOutput(CodeDifferencesFound, "skeema compare '%s/newschema' '%s/analytics2'", dsnBase, dsnBase)
+	createPos, usePos := strings.Index(out, "CREATE DATABASE `newschema`"), strings.Index(out, "USE `newschema`")
+	if createPos < 0 || usePos < createPos || strings.Count(out, "USE ") != 1 {
+		t.Errorf("Expected CREATE DATABASE to precede a single USE, instead output was:\n%s", out)
+	}
+}
+
 func (s SkeemaIntegrationSuite) TestPushHandler(t *testing.T) {
 	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
 
@@ -553,6 +620,64 @@ func (s SkeemaIntegrationSuite) TestPushHandler(t *testing.T) {
 	s.handleCommand(t, CodeSuccess, ".", "skeema diff --lint-pk=error")
 }
 
+func (s SkeemaIntegrationSuite) TestCanaryRollout(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+
+	// Point the analytics dir at the same server by a different hostname, so that
+	// it is treated as a separate instance. Instances are ordered by host, so the
+	// product dir's instance is always the canary.
+	contents := fs.ReadTestFile(t, "mydb/analytics/.skeema")
+	fs.WriteTestFile(t, "mydb/analytics/.skeema", contents+"host=localhost\n")
+	s.handleCommand(t, CodeBadConfig, ".", "skeema push --canary-instances=-1")
+	s.handleCommand(t, CodeBadConfig, ".", "skeema push --rollout-batch-size=foo")
+
+	// Successful canary stage proceeds to the other instance
+	fs.WriteTestFile(t, "mydb/product/canary1.sql", "CREATE TABLE canary1 (id int);\n")
+	fs.WriteTestFile(t, "mydb/analytics/canary1.sql", "CREATE TABLE canary1 (id int);\n")
+	s.handleCommand(t, CodeSuccess, ".", "skeema push --canary-instances=1")
+	s.assertTableExists(t, "product", "canary1", "")
+	s.assertTableExists(t, "analytics", "canary1", "")
+
+	// Unsafe change in the canary causes an error, which aborts the rollout
+	usersContents := fs.ReadTestFile(t, "mydb/product/users.sql")
+	if err := os.Remove("mydb/product/users.sql"); err != nil {
+		t.Fatalf("Unable to remove file: %s", err)
+	}
+	fs.WriteTestFile(t, "mydb/analytics/canary2.sql", "CREATE TABLE canary2 (id int);\n")
+	s.handleCommand(t, CodeFatalError, ".", "skeema push --canary-instances=1")
+	s.assertTableExists(t, "product", "users", "")
+	s.assertTableMissing(t, "analytics", "canary2", "")
+
+	// Same result with batches instead of a canary
+	s.handleCommand(t, CodeFatalError, ".", "skeema push --rollout-batch-size=1")
+	s.assertTableMissing(t, "analytics", "canary2", "")
+	fs.WriteTestFile(t, "mydb/product/users.sql", usersContents)
+	s.handleCommand(t, CodeSuccess, ".", "skeema push --rollout-batch-size=1")
+	s.assertTableExists(t, "analytics", "canary2", "")
+
+	// Declining at the canary-confirm prompt stops the rollout, but is not a
+	// fatal error
+	origPromptConfirm := promptConfirm
+	defer func() {
+		promptConfirm = origPromptConfirm
+	}()
+	promptConfirm = func(prompt string) (bool, error) {
+		return false, nil
+	}
+	fs.WriteTestFile(t, "mydb/product/canary3.sql", "CREATE TABLE canary3 (id int);\n")
+	fs.WriteTestFile(t, "mydb/analytics/canary3.sql", "CREATE TABLE canary3 (id int);\n")
+	s.handleCommand(t, CodePartialError, ".", "skeema push --canary-instances=1 --canary-confirm")
+	s.assertTableExists(t, "product", "canary3", "")
+	s.assertTableMissing(t, "analytics", "canary3", "")
+
+	// Confirming proceeds to the other instance
+	promptConfirm = func(prompt string) (bool, error) {
+		return true, nil
+	}
+	s.handleCommand(t, CodeSuccess, ".", "skeema push --canary-instances=1 --canary-confirm")
+	s.assertTableExists(t, "analytics", "canary3", "")
+}
+
 func (s SkeemaIntegrationSuite) TestHelpHandler(t *testing.T) {
 	// Simple tests just to confirm the commands don't error
 	fs.WriteTestFile(t, "fake-etc/skeema", "# hello world")
@@ -1432,6 +1557,8 @@ func (s SkeemaIntegrationSuite) TestPartitioning(t *testing.T) {
 	s.handleCommand(t, CodeDifferencesFound, "mydb/analytics", "skeema diff") // default is keep
 	s.handleCommand(t, CodeDifferencesFound, "mydb/analytics", "skeema diff --partitioning=modify")
 	s.handleCommand(t, CodeBadConfig, "mydb/analytics", "skeema diff --partitioning=invalid")
+	s.handleCommand(t, CodeSuccess, "mydb/analytics", "skeema drift --partitioning=remove")
+	s.handleCommand(t, CodeDifferencesFound, "mydb/analytics", "skeema drift --partitioning=keep")
 
 	// At this point we haven't pushed yet, but pull --partitioning=remove should
 	// leave the file unchanged, regardless of --format vs --skip-format. Here we're
@@ -1551,3 +1678,597 @@ func (s SkeemaIntegrationSuite) TestPartitioning(t *testing.T) {
 		s.handleCommand(t, CodeSuccess, "mydb/analytics", "skeema push --allow-unsafe --partitioning=%s", value)
 	}
 }
+
+func (s SkeemaIntegrationSuite) TestDataTables(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	s.dbExec(t, "product", "CREATE TABLE colors (id int unsigned NOT NULL PRIMARY KEY, name varchar(20) NOT NULL, hex char(6))")
+	s.dbExec(t, "product", "INSERT INTO colors VALUES (1, 'red', 'ff0000'), (2, 'green', NULL), (3, 'blue', '0000ff')")
+	contents := fs.ReadTestFile(t, "mydb/product/.skeema")
+	fs.WriteTestFile(t, "mydb/product/.skeema", contents+"data-tables=colors\n")
+
+	// pull should write the table's rows along with its definition
+	s.handleCommand(t, CodeSuccess, ".", "skeema pull")
+	contents = fs.ReadTestFile(t, "mydb/product/colors.sql")
+	expectInsert := "INSERT INTO `colors` (`id`, `name`, `hex`) VALUES\n  (1, 'red', 'ff0000'),\n  (2, 'green', NULL),\n  (3, 'blue', '0000ff');\n"
+	if !strings.HasSuffix(contents, expectInsert) {
+		t.Fatalf("Unexpected contents of colors.sql after pull:\n%s", contents)
+	}
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff")
+	s.handleCommand(t, CodeSuccess, ".", "skeema lint")
+
+	// Modifying and adding rows should be reflected by diff and push
+	newInsert := strings.Replace(expectInsert, "NULL)", "'00ff00')", 1)
+	newInsert = strings.Replace(newInsert, ");\n", "),\n  (4, 'black', '000000');\n", 1)
+	fs.WriteTestFile(t, "mydb/product/colors.sql", strings.Replace(contents, expectInsert, newInsert, 1))
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema diff")
+	s.handleCommand(t, CodeSuccess, ".", "skeema push")
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff")
+	db, err := s.d.Connect("product", "")
+	if err != nil {
+		t.Fatalf("Unable to connect to DockerizedInstance: %s", err)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM colors WHERE hex IS NOT NULL").Scan(&count); err != nil || count != 4 {
+		t.Errorf("Expected push to result in 4 rows with non-NULL hex; instead found %d, %v", count, err)
+	}
+
+	// Removing rows requires allow-unsafe
+	fs.WriteTestFile(t, "mydb/product/colors.sql", strings.Replace(contents, expectInsert, "", 1))
+	s.handleCommand(t, CodeFatalError, ".", "skeema push")
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema diff --allow-unsafe")
+	s.handleCommand(t, CodeSuccess, ".", "skeema push --allow-unsafe")
+	if err := db.QueryRow("SELECT COUNT(*) FROM colors").Scan(&count); err != nil || count != 0 {
+		t.Errorf("Expected push to delete all rows; instead found %d, %v", count, err)
+	}
+
+	// INSERTs for tables not listed in data-tables are ignored
+	fs.WriteTestFile(t, "mydb/product/posts.sql", fs.ReadTestFile(t, "mydb/product/posts.sql")+"INSERT INTO posts (id, user_id) VALUES (1, 1);\n")
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff")
+}
+
+func (s SkeemaIntegrationSuite) TestGrantsFile(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	contents := fs.ReadTestFile(t, "mydb/.skeema")
+	fs.WriteTestFile(t, "mydb/.skeema", contents+"grants-file=grants.sql\n")
+	fs.WriteTestFile(t, "mydb/grants.sql", "CREATE USER 'skeema_app'@'%';\nGRANT SELECT, INSERT ON product.* TO skeema_app;\nGRANT SELECT (id) ON analytics.pageviews TO skeema_app;\n")
+	defer s.dbExec(t, "", "DROP USER IF EXISTS 'skeema_app'@'%'")
+
+	// Creating a user requires a password, but diff does not
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema diff")
+	os.Unsetenv("SKEEMA_PASSWORD_SKEEMA_APP")
+	s.handleCommand(t, CodeFatalError, ".", "skeema push")
+	s.handleCommand(t, CodeSuccess, ".", "skeema push --user-password-command='echo hunter2'")
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff")
+
+	// Changing privileges should be reflected by diff and push; revoking requires
+	// allow-unsafe
+	fs.WriteTestFile(t, "mydb/grants.sql", "CREATE USER 'skeema_app'@'%';\nGRANT SELECT, UPDATE ON product.* TO skeema_app;\n")
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema diff --allow-unsafe")
+	s.handleCommand(t, CodeFatalError, ".", "skeema push")
+	s.handleCommand(t, CodeSuccess, ".", "skeema push --allow-unsafe")
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff")
+	db, err := s.d.Connect("", "")
+	if err != nil {
+		t.Fatalf("Unable to connect to DockerizedInstance: %s", err)
+	}
+	var grants []string
+	if err := db.Select(&grants, "SHOW GRANTS FOR 'skeema_app'@'%'"); err != nil {
+		t.Fatalf("Unexpected error from SHOW GRANTS: %s", err)
+	}
+	if len(grants) != 2 || !strings.Contains(grants[1], "GRANT SELECT, UPDATE ON `product`.*") {
+		t.Errorf("Unexpected grants after push: %v", grants)
+	}
+
+	// Grants file must not be treated as schema definitions, and accounts
+	// declared in it must exist in the file
+	s.handleCommand(t, CodeSuccess, ".", "skeema lint")
+	fs.WriteTestFile(t, "mydb/grants.sql", "GRANT SELECT ON product.* TO skeema_app;\n")
+	s.handleCommand(t, CodeFatalError, ".", "skeema diff")
+}
+
+func (s SkeemaIntegrationSuite) TestSchemaOptions(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	contents := fs.ReadTestFile(t, "mydb/product/.skeema")
+
+	// Flavors lacking support should skip the schema
+	if !s.d.Flavor().MySQLishMinVersion(8, 0, 22) {
+		fs.WriteTestFile(t, "mydb/product/.skeema", contents+"schema-read-only=1\n")
+		s.handleCommand(t, CodePartialError, ".", "skeema diff")
+		return
+	}
+	defer s.dbExec(t, "", "ALTER DATABASE product READ ONLY = 0")
+
+	// Making schema read-only should be the last statement, even when other
+	// changes are present
+	fs.WriteTestFile(t, "mydb/product/.skeema", contents+"default-encryption=n\nschema-read-only=1\n")
+	fs.WriteTestFile(t, "mydb/product/foo.sql", "CREATE TABLE foo (id int unsigned NOT NULL PRIMARY KEY);\n")
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema diff")
+	s.handleCommand(t, CodeSuccess, ".", "skeema push")
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff")
+
+	// Further changes to a read-only schema require temporarily making it
+	// writable
+	fs.WriteTestFile(t, "mydb/product/foo.sql", "CREATE TABLE foo (id int unsigned NOT NULL PRIMARY KEY, name varchar(30));\n")
+	s.handleCommand(t, CodeSuccess, ".", "skeema push")
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff")
+
+	// pull should persist changes made outside of Skeema
+	s.dbExec(t, "", "ALTER DATABASE product READ ONLY = 0")
+	s.handleCommand(t, CodeSuccess, ".", "skeema pull")
+	if cfg := fs.ReadTestFile(t, "mydb/product/.skeema"); !strings.Contains(cfg, "schema-read-only=0") {
+		t.Errorf("Expected pull to update schema-read-only, instead .skeema contains:\n%s", cfg)
+	}
+
+	// Linter rule should flag schemas lacking a required option
+	s.handleCommand(t, CodeSuccess, ".", "skeema lint --lint-schema-options=error --require-schema-options=default-encryption")
+	s.handleCommand(t, CodeFatalError, ".", "skeema lint --lint-schema-options=error --require-schema-options=default-encryption=y")
+}
+
+func (s SkeemaIntegrationSuite) TestTemplateVars(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	contents := fs.ReadTestFile(t, "mydb/.skeema")
+	fs.WriteTestFile(t, "mydb/.skeema", contents+"[production]\ntemplate-vars=env=production\n[staging]\ntemplate-vars=env=staging\n")
+	templated := "CREATE TABLE `widgets` (\n  `id` int(10) unsigned NOT NULL,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB DEFAULT CHARSET=latin1 COMMENT='{{env}} widgets';\n"
+	fs.WriteTestFile(t, "mydb/product/widgets.sql", templated)
+
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema diff")
+	s.handleCommand(t, CodeSuccess, ".", "skeema push")
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff")
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema diff staging")
+	if schema, err := s.d.Schema("product"); err != nil || schema.Table("widgets") == nil {
+		t.Fatalf("Unable to obtain table product.widgets: %v", err)
+	} else if comment := schema.Table("widgets").Comment; comment != "production widgets" {
+		t.Errorf("Expected table comment to be expanded from template, instead found %q", comment)
+	}
+
+	// pull and format must not overwrite template placeholders
+	s.dbExec(t, "product", "ALTER TABLE widgets ADD COLUMN name varchar(30)")
+	s.handleCommand(t, CodeSuccess, ".", "skeema pull")
+	s.handleCommand(t, CodeSuccess, ".", "skeema format")
+	if actual := fs.ReadTestFile(t, "mydb/product/widgets.sql"); actual != templated {
+		t.Errorf("Expected templated file to be unchanged, instead found:\n%s", actual)
+	}
+
+	// Undefined variables are a fatal error
+	fs.WriteTestFile(t, "mydb/product/widgets.sql", strings.Replace(templated, "{{env}}", "{{environment}}", 1))
+	s.handleCommand(t, CodeFatalError, ".", "skeema diff")
+}
+
+func (s SkeemaIntegrationSuite) TestIncludes(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+
+	// Move a table definition into a shared file included by the product dir
+	if err := os.MkdirAll("shared", 0777); err != nil {
+		t.Fatalf("Unable to create dir: %s", err)
+	}
+	if err := os.Rename("mydb/product/posts.sql", "shared/posts.sql"); err != nil {
+		t.Fatalf("Unable to move file: %s", err)
+	}
+	contents := fs.ReadTestFile(t, "mydb/product/.skeema")
+	fs.WriteTestFile(t, "mydb/product/.skeema", contents+"include=../../shared\n")
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff")
+
+	// pull should write changes back to the shared file, rather than creating a
+	// new file in the product dir
+	s.dbExec(t, "product", "ALTER TABLE posts ADD COLUMN summary varchar(100)")
+	s.handleCommand(t, CodeSuccess, ".", "skeema pull")
+	if _, err := os.Stat("mydb/product/posts.sql"); err == nil {
+		t.Error("Expected pull to not create mydb/product/posts.sql, but it exists")
+	}
+	if contents := fs.ReadTestFile(t, "shared/posts.sql"); !strings.Contains(contents, "summary") {
+		t.Errorf("Expected pull to update shared/posts.sql, instead found:\n%s", contents)
+	}
+
+	// pull should not remove a shared definition from the included file, just
+	// because another including dir's schema lacks that table
+	contents = fs.ReadTestFile(t, "mydb/analytics/.skeema")
+	fs.WriteTestFile(t, "mydb/analytics/.skeema", contents+"include=../../shared\n")
+	s.handleCommand(t, CodeSuccess, ".", "skeema pull")
+	if contents := fs.ReadTestFile(t, "shared/posts.sql"); !strings.Contains(contents, "CREATE TABLE `posts`") {
+		t.Errorf("Expected pull to leave shared/posts.sql unchanged, instead found:\n%s", contents)
+	}
+	fs.WriteTestFile(t, "mydb/analytics/.skeema", contents)
+
+	// Conflicting definitions prevent the dir from being processed
+	fs.WriteTestFile(t, "mydb/product/posts.sql", fs.ReadTestFile(t, "shared/posts.sql"))
+	s.handleCommand(t, CodeFatalError, ".", "skeema diff")
+}
+
+func (s SkeemaIntegrationSuite) TestFileLayout(t *testing.T) {
+	s.dbExec(t, "product", "CREATE PROCEDURE users() SELECT 1")
+	s.handleCommand(t, CodeBadConfig, ".", "skeema init --dir mydb -h %s -P %d --layout='{name}.txt'", s.d.Instance.Host, s.d.Instance.Port)
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d --layout=type-dirs", s.d.Instance.Host, s.d.Instance.Port)
+	for _, filePath := range []string{"mydb/product/tables/users.sql", "mydb/product/procs/users.sql", "mydb/analytics/tables/pageviews.sql"} {
+		if _, err := os.Stat(filePath); err != nil {
+			t.Errorf("Expected %s to exist, but Stat returned %v", filePath, err)
+		}
+	}
+	if contents := fs.ReadTestFile(t, "mydb/.skeema"); !strings.Contains(contents, "layout=type-dirs") {
+		t.Errorf("Expected init to persist layout option, instead .skeema contains:\n%s", contents)
+	}
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff")
+	s.handleCommand(t, CodeSuccess, ".", "skeema lint")
+
+	// Changing the layout should cause pull to move existing files
+	contents := fs.ReadTestFile(t, "mydb/.skeema")
+	fs.WriteTestFile(t, "mydb/.skeema", strings.Replace(contents, "layout=type-dirs", "layout=single-file", 1))
+	s.handleCommand(t, CodeSuccess, ".", "skeema pull")
+	for _, filePath := range []string{"mydb/product/tables/users.sql", "mydb/product/procs/users.sql"} {
+		if _, err := os.Stat(filePath); err == nil {
+			t.Errorf("Expected %s to be moved by pull, but it still exists", filePath)
+		}
+	}
+	schemaFile := fs.ReadTestFile(t, "mydb/product/schema.sql")
+	for _, name := range []string{"CREATE TABLE `users`", "CREATE TABLE `posts`", "PROCEDURE `users`"} {
+		if !strings.Contains(schemaFile, name) {
+			t.Errorf("Expected mydb/product/schema.sql to contain %s, but it did not", name)
+		}
+	}
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff")
+
+	// Removing the layout option should cause pull to move files out of the
+	// previous layout's subdirs, rather than treating them as separate dirs
+	fs.WriteTestFile(t, "mydb/.skeema", contents)
+	s.handleCommand(t, CodeSuccess, ".", "skeema pull")
+	fs.WriteTestFile(t, "mydb/.skeema", strings.Replace(contents, "layout=type-dirs\n", "", 1))
+	s.handleCommand(t, CodeSuccess, ".", "skeema pull")
+	for _, filePath := range []string{"mydb/product/tables/users.sql", "mydb/product/procs/users.sql"} {
+		if _, err := os.Stat(filePath); err == nil {
+			t.Errorf("Expected %s to be moved by pull, but it still exists", filePath)
+		}
+	}
+	if usersFile := fs.ReadTestFile(t, "mydb/product/users.sql"); !strings.Contains(usersFile, "CREATE TABLE `users`") || !strings.Contains(usersFile, "PROCEDURE `users`") {
+		t.Errorf("Expected mydb/product/users.sql to contain both users objects, instead found:\n%s", usersFile)
+	}
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff")
+}
+
+func (s SkeemaIntegrationSuite) TestFormatStyle(t *testing.T) {
+	s.handleCommand(t, CodeBadConfig, ".", "skeema init --dir mydb -h %s -P %d --format-style=indent=99", s.d.Instance.Host, s.d.Instance.Port)
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d --format-style='keyword-case=lower,indent=4'", s.d.Instance.Host, s.d.Instance.Port)
+	if contents := fs.ReadTestFile(t, "mydb/.skeema"); !strings.Contains(contents, "format-style=keyword-case=lower,indent=4") {
+		t.Errorf("Expected init to persist format-style option, instead .skeema contains:\n%s", contents)
+	}
+	contents := fs.ReadTestFile(t, "mydb/product/posts.sql")
+	if !strings.HasPrefix(contents, "create table `posts` (\n    `id` bigint unsigned not null auto_increment,") {
+		t.Errorf("Expected posts.sql to be written in configured style, instead found:\n%s", contents)
+	}
+
+	// Styled files should be stable across format, lint, and pull, and should
+	// still reflect the live schema
+	s.handleCommand(t, CodeSuccess, ".", "skeema format")
+	s.handleCommand(t, CodeSuccess, ".", "skeema lint")
+	s.handleCommand(t, CodeSuccess, ".", "skeema pull")
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff")
+	if contents2 := fs.ReadTestFile(t, "mydb/product/posts.sql"); contents2 != contents {
+		t.Errorf("Expected posts.sql to be unchanged, instead found:\n%s", contents2)
+	}
+
+	// Reverting to the server style should cause format to rewrite files
+	cfgContents := fs.ReadTestFile(t, "mydb/.skeema")
+	fs.WriteTestFile(t, "mydb/.skeema", strings.Replace(cfgContents, "format-style=keyword-case=lower,indent=4", "format-style=server", 1))
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema format")
+	if contents := fs.ReadTestFile(t, "mydb/product/posts.sql"); !strings.HasPrefix(contents, "CREATE TABLE `posts` (\n  `id`") {
+		t.Errorf("Expected posts.sql to be written in server style, instead found:\n%s", contents)
+	}
+}
+
+func (s SkeemaIntegrationSuite) TestDoc(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	s.handleCommand(t, CodeBadConfig, "mydb", "skeema doc --doc-format=pdf")
+	s.handleCommand(t, CodeBadConfig, "mydb", "skeema doc --output-dir=.")
+	s.handleCommand(t, CodeBadConfig, "mydb/product", "skeema doc --output-dir=..")
+
+	// Documentation should reflect the *.sql files, not the live database
+	fs.WriteTestFile(t, "mydb/product/widgets.sql", "CREATE TABLE widgets (id int unsigned NOT NULL PRIMARY KEY, posts_id bigint(20) unsigned COMMENT 'Owning post', CONSTRAINT widgets_post FOREIGN KEY (posts_id) REFERENCES posts (id)) COMMENT 'Things';\n")
+	s.handleCommand(t, CodeSuccess, "mydb", "skeema doc --output-dir=../docs")
+	for _, filePath := range []string{"docs/product/index.md", "docs/product/posts.md", "docs/product/widgets.md", "docs/analytics/index.md"} {
+		if _, err := os.Stat(filePath); err != nil {
+			t.Errorf("Expected %s to exist, but Stat returned %v", filePath, err)
+		}
+	}
+	contents := fs.ReadTestFile(t, "docs/product/widgets.md")
+	for _, expected := range []string{"Things", "Owning post", "[`posts`](posts.md)"} {
+		if !strings.Contains(contents, expected) {
+			t.Errorf("Expected widgets.md to contain %q, instead found:\n%s", expected, contents)
+		}
+	}
+	if contents := fs.ReadTestFile(t, "docs/product/posts.md"); !strings.Contains(contents, "[`widgets`](widgets.md)") {
+		t.Errorf("Expected posts.md to link to referencing table widgets, instead found:\n%s", contents)
+	}
+
+	// Removing a table should remove its page; html format should be supported
+	if err := os.Remove("mydb/product/widgets.sql"); err != nil {
+		t.Fatalf("Unable to remove widgets.sql: %v", err)
+	}
+	s.handleCommand(t, CodeSuccess, "mydb", "skeema doc --output-dir=../docs")
+	if _, err := os.Stat("docs/product/widgets.md"); err == nil {
+		t.Error("Expected widgets.md to be removed, but it still exists")
+	}
+	s.handleCommand(t, CodeSuccess, "mydb", "skeema doc --output-dir=../docs --doc-format=html")
+	if contents := fs.ReadTestFile(t, "docs/product/index.html"); !strings.Contains(contents, `<a href="posts.html">`) {
+		t.Errorf("Expected index.html to link to posts.html, instead found:\n%s", contents)
+	}
+
+	// Invalid SQL should be reported as an error, without preventing other
+	// objects from being documented
+	fs.WriteTestFile(t, "mydb/product/broken.sql", "CREATE TABLE broken (id int unsigned NOT NULL PRIMARY KEY, KEY (nonexistent));\n")
+	s.handleCommand(t, CodeFatalError, "mydb", "skeema doc --output-dir=../docs")
+	if _, err := os.Stat("docs/product/posts.md"); err != nil {
+		t.Errorf("Expected posts.md to exist, but Stat returned %v", err)
+	}
+}
+
+func (s SkeemaIntegrationSuite) TestERD(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	s.handleCommand(t, CodeBadConfig, "mydb", "skeema erd")
+	s.handleCommand(t, CodeBadConfig, "mydb/product", "skeema erd --erd-format=svg")
+
+	erdOutput := func(commandLine string) string {
+		t.Helper()
+		oldStdout := os.Stdout
+		outFile, err := os.Create("erd.out")
+		if err != nil {
+			t.Fatalf("Unable to redirect stdout to a file: %s", err)
+		}
+		os.Stdout = outFile
+		s.handleCommand(t, CodeSuccess, "mydb/product", commandLine)
+		outFile.Close()
+		os.Stdout = oldStdout
+		contents := fs.ReadTestFile(t, "erd.out")
+		if err := os.Remove("erd.out"); err != nil {
+			t.Fatalf("Unable to delete erd.out: %s", err)
+		}
+		return contents
+	}
+
+	// Diagram should reflect the *.sql files, not the live database; with
+	// highlight-changes, the new table should be highlighted
+	fs.WriteTestFile(t, "mydb/product/widgets.sql", "CREATE TABLE widgets (id int unsigned NOT NULL PRIMARY KEY, posts_id bigint(20) unsigned, CONSTRAINT widgets_post FOREIGN KEY (posts_id) REFERENCES posts (id));\n")
+	out := erdOutput("skeema erd --erd-format=mermaid --highlight-changes")
+	for _, expected := range []string{"erDiagram\n", "  widgets {\n", `widgets }o--|o posts : "widgets_post"`, "class widgets create\n"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %q, instead found:\n%s", expected, out)
+		}
+	}
+
+	// table-filter and collapse-columns should limit what is shown
+	out = erdOutput("skeema erd --table-filter=^widgets$ --collapse-columns")
+	if !strings.Contains(out, `"widgets" [label="widgets"];`) || strings.Contains(out, "posts") {
+		t.Errorf("Unexpected output from erd with table-filter and collapse-columns:\n%s", out)
+	}
+}
+
+func (s SkeemaIntegrationSuite) TestExport(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	s.handleCommand(t, CodeBadConfig, "mydb", "skeema export --export-format=xml")
+
+	exportOutput := func(commandLine string) string {
+		t.Helper()
+		oldStdout := os.Stdout
+		outFile, err := os.Create("export.out")
+		if err != nil {
+			t.Fatalf("Unable to redirect stdout to a file: %s", err)
+		}
+		os.Stdout = outFile
+		s.handleCommand(t, CodeSuccess, "mydb", commandLine)
+		outFile.Close()
+		os.Stdout = oldStdout
+		contents := fs.ReadTestFile(t, "export.out")
+		if err := os.Remove("export.out"); err != nil {
+			t.Fatalf("Unable to delete export.out: %s", err)
+		}
+		return contents
+	}
+
+	// Filesystem export should reflect the *.sql files, while instance export
+	// should reflect the live database
+	fs.WriteTestFile(t, "mydb/product/widgets.sql", "CREATE TABLE widgets (id int unsigned NOT NULL PRIMARY KEY);\n")
+	var doc struct {
+		Source  string `json:"source"`
+		Schemas []struct {
+			Name     string `json:"name"`
+			Instance string `json:"instance"`
+			Tables   []struct {
+				Name string `json:"name"`
+			} `json:"tables"`
+		} `json:"schemas"`
+	}
+	tableNames := func(schemaName string) (names []string) {
+		for _, schema := range doc.Schemas {
+			if schema.Name == schemaName {
+				for _, table := range schema.Tables {
+					names = append(names, table.Name)
+				}
+			}
+		}
+		return names
+	}
+	if err := json.Unmarshal([]byte(exportOutput("skeema export")), &doc); err != nil {
+		t.Fatalf("Unable to decode export output: %v", err)
+	}
+	if doc.Source != "filesystem" || len(doc.Schemas) != 2 || doc.Schemas[0].Instance != "" {
+		t.Errorf("Unexpected filesystem export: %+v", doc)
+	} else if names := tableNames("product"); len(names) != 5 || names[len(names)-1] != "widgets" {
+		t.Errorf("Unexpected tables in filesystem export of product: %v", names)
+	}
+	if err := json.Unmarshal([]byte(exportOutput("skeema export --export-source=instance")), &doc); err != nil {
+		t.Fatalf("Unable to decode export output: %v", err)
+	}
+	if doc.Source != "instance" || doc.Schemas[0].Instance != s.d.Instance.String() {
+		t.Errorf("Unexpected instance export: %+v", doc)
+	} else if names := tableNames("product"); len(names) != 4 {
+		t.Errorf("Unexpected tables in instance export of product: %v", names)
+	}
+
+	out := exportOutput("skeema export --export-format=yaml")
+	if !strings.HasPrefix(out, "formatVersion: 1\nsource: filesystem\nschemas:\n- name: ") || !strings.Contains(out, "  - name: widgets\n") {
+		t.Errorf("Unexpected YAML export:\n%s", out)
+	}
+}
+
+func (s SkeemaIntegrationSuite) TestImport(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	s.handleCommand(t, CodeBadConfig, "mydb", "skeema import ../migrations")
+
+	if err := os.Mkdir("migrations", 0777); err != nil {
+		t.Fatalf("Unable to create migrations dir: %v", err)
+	}
+	s.handleCommand(t, CodeNoInput, "mydb/product", "skeema import ../../migrations")
+
+	fs.WriteTestFile(t, "migrations/V1__create.sql", "CREATE TABLE widgets (id int unsigned NOT NULL PRIMARY KEY);\nINSERT INTO widgets VALUES (1);\n")
+	fs.WriteTestFile(t, "migrations/V2__alter.sql", "ALTER TABLE widgets ADD COLUMN name varchar(30);\nCREATE TABLE gadgets (id int);\n")
+	fs.WriteTestFile(t, "migrations/V10__drop.sql", "DROP TABLE gadgets;\n")
+	fs.WriteTestFile(t, "migrations/U10__drop.sql", "CREATE TABLE undone (id int);\n")
+	s.handleCommand(t, CodeSuccess, "mydb/product", "skeema import ../../migrations")
+	contents := fs.ReadTestFile(t, "mydb/product/widgets.sql")
+	if !strings.Contains(contents, "`name` varchar(30)") {
+		t.Errorf("Expected widgets.sql to reflect ALTER, instead found:\n%s", contents)
+	}
+	for _, name := range []string{"gadgets.sql", "undone.sql", "posts.sql"} {
+		if _, err := os.Stat("mydb/product/" + name); err == nil {
+			t.Errorf("Expected mydb/product/%s to not exist after import, but it does", name)
+		}
+	}
+
+	// A failing statement, or one referring to another schema, should skip the
+	// rest of its migration but not subsequent ones
+	fs.WriteTestFile(t, "migrations/V11__broken.sql", "ALTER TABLE nonexistent ADD COLUMN x int;\nCREATE TABLE skipped (id int);\n")
+	fs.WriteTestFile(t, "migrations/V12__other.sql", "DROP TABLE analytics.pageviews;\n")
+	fs.WriteTestFile(t, "migrations/V13__ok.sql", "CREATE TABLE final (id int);\n")
+	s.handleCommand(t, CodePartialError, "mydb/product", "skeema import ../../migrations")
+	if _, err := os.Stat("mydb/product/skipped.sql"); err == nil {
+		t.Error("Expected skipped.sql to not exist, but it does")
+	}
+	if _, err := os.Stat("mydb/product/final.sql"); err != nil {
+		t.Errorf("Expected final.sql to exist, but Stat returned %v", err)
+	}
+	if schema, err := s.d.Instance.Schema("analytics"); err != nil || !schema.HasTable("pageviews") {
+		t.Errorf("Expected analytics.pageviews to still exist, but it does not (err=%v)", err)
+	}
+}
+
+func (s SkeemaIntegrationSuite) TestDiffEmitMigration(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	s.handleCommand(t, CodeBadConfig, "mydb/product", "skeema diff --emit-migration=../../migrations --migration-format=liquibase")
+
+	// No differences: no migration files should be written
+	s.handleCommand(t, CodeSuccess, ".", "skeema diff --emit-migration=migrations")
+	if _, err := os.Stat("migrations"); err == nil {
+		t.Error("Expected migrations dir to not exist without any differences, but it does")
+	}
+
+	fs.WriteTestFile(t, "mydb/product/widgets.sql", "CREATE TABLE widgets (id int unsigned NOT NULL PRIMARY KEY);\n")
+	fs.RemoveTestFile(t, "mydb/analytics/pageviews.sql")
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema diff --allow-unsafe --emit-migration=migrations")
+	ups, _ := filepath.Glob("migrations/*.up.sql")
+	downs, _ := filepath.Glob("migrations/*.down.sql")
+	if len(ups) != 2 || len(downs) != 2 {
+		t.Fatalf("Expected 2 up and 2 down migrations, instead found %v and %v", ups, downs)
+	}
+	for n := range ups {
+		up, down := fs.ReadTestFile(t, ups[n]), fs.ReadTestFile(t, downs[n])
+		if strings.HasSuffix(ups[n], "_product.up.sql") {
+			if !strings.HasPrefix(up, "CREATE TABLE `widgets`") || down != "DROP TABLE `widgets`;\n" {
+				t.Errorf("Unexpected contents of product migration:\n%s\n%s", up, down)
+			}
+		} else if !strings.HasSuffix(ups[n], "_analytics.up.sql") || up != "DROP TABLE `pageviews`;\n" || !strings.HasPrefix(down, "CREATE TABLE `pageviews`") {
+			t.Errorf("Unexpected analytics migration %s:\n%s\n%s", ups[n], up, down)
+		}
+	}
+
+	// The generated migrations should not have affected the database
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema diff --allow-unsafe")
+
+	// Incompatible with from-git-ref
+	s.handleCommand(t, CodeBadUsage, ".", "skeema diff --emit-migration=migrations --from-git-ref=HEAD")
+}
+
+func (s SkeemaIntegrationSuite) TestPullObjectFilter(t *testing.T) {
+	s.handleCommand(t, CodeBadConfig, ".", "skeema init --dir mydb -h %s -P %d --object-types=view", s.d.Instance.Host, s.d.Instance.Port)
+	s.handleCommand(t, CodeBadConfig, ".", "skeema init --dir mydb -h %s -P %d --object-filter='[a'", s.d.Instance.Host, s.d.Instance.Port)
+
+	// init with a filter should only write matching objects
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir partial -h %s -P %d --object-filter='post*,users'", s.d.Instance.Host, s.d.Instance.Port)
+	for _, name := range []string{"partial/product/posts.sql", "partial/product/users.sql"} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("Expected %s to exist, but Stat returned %v", name, err)
+		}
+	}
+	for _, name := range []string{"partial/product/comments.sql", "partial/analytics/pageviews.sql"} {
+		if _, err := os.Stat(name); err == nil {
+			t.Errorf("Expected %s to not exist, but it does", name)
+		}
+	}
+
+	// pull with a filter should only update matching objects, and should not
+	// create dirs for new schemas or delete dirs for dropped schemas
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	s.dbExec(t, "product", "ALTER TABLE posts ADD COLUMN summary varchar(100)")
+	s.dbExec(t, "product", "ALTER TABLE users ADD COLUMN nickname varchar(30)")
+	s.dbExec(t, "", "CREATE DATABASE newschema")
+	s.handleCommand(t, CodeBadConfig, "mydb", "skeema pull --object-types=table,view")
+	s.handleCommand(t, CodeSuccess, "mydb", "skeema pull --object-filter=posts")
+	if contents := fs.ReadTestFile(t, "mydb/product/posts.sql"); !strings.Contains(contents, "summary") {
+		t.Errorf("Expected posts.sql to be updated, instead found:\n%s", contents)
+	}
+	if contents := fs.ReadTestFile(t, "mydb/product/users.sql"); strings.Contains(contents, "nickname") {
+		t.Errorf("Expected users.sql to be unchanged, instead found:\n%s", contents)
+	}
+	if _, err := os.Stat("mydb/newschema"); err == nil {
+		t.Error("Expected mydb/newschema to not exist, but it does")
+	}
+
+	// object-types alone should also restrict the pull; a dropped table matching
+	// the filter should have its file removed
+	s.dbExec(t, "product", "DROP TABLE posts")
+	s.handleCommand(t, CodeSuccess, "mydb", "skeema pull --object-types=procedure,function")
+	if _, err := os.Stat("mydb/product/posts.sql"); err != nil {
+		t.Errorf("Expected posts.sql to still exist, but Stat returned %v", err)
+	}
+	s.handleCommand(t, CodeSuccess, "mydb", "skeema pull --object-types=table --object-filter=p*")
+	if _, err := os.Stat("mydb/product/posts.sql"); err == nil {
+		t.Error("Expected posts.sql to be removed, but it still exists")
+	}
+	if contents := fs.ReadTestFile(t, "mydb/product/users.sql"); strings.Contains(contents, "nickname") {
+		t.Errorf("Expected users.sql to be unchanged, instead found:\n%s", contents)
+	}
+}
+
+func (s SkeemaIntegrationSuite) TestDedicatedHostWorkspace(t *testing.T) {
+	cfg := s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	workspaceFlags := fmt.Sprintf("--workspace=dedicated-host --workspace-host=%s:%d", s.d.Instance.Host, s.d.Instance.Port)
+
+	// lint and format should both work with a dedicated-host workspace, both with
+	// and without a flavor configured
+	s.handleCommand(t, CodeSuccess, ".", "skeema lint %s", workspaceFlags)
+	s.handleCommand(t, CodeSuccess, ".", "skeema format %s", workspaceFlags)
+	s.handleCommand(t, CodeSuccess, ".", "skeema format %s --flavor=%s", workspaceFlags, s.d.Flavor().Family())
+
+	// Reformatting should be performed via the workspace host
+	contents := fs.ReadTestFile(t, "mydb/product/posts.sql")
+	fs.WriteTestFile(t, "mydb/product/posts.sql", strings.ToLower(contents))
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema format %s", workspaceFlags)
+	s.verifyFiles(t, cfg, "../golden/init")
+	fs.WriteTestFile(t, "mydb/product/posts.sql", strings.ToLower(contents))
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema lint %s", workspaceFlags)
+	s.verifyFiles(t, cfg, "../golden/init")
+
+	// Without workspace-host, dedicated-host is a configuration error
+	s.handleCommand(t, CodeBadConfig, ".", "skeema lint --workspace=dedicated-host")
+	s.handleCommand(t, CodeBadConfig, ".", "skeema format --workspace=dedicated-host")
+}
+
+func (s SkeemaIntegrationSuite) TestDriftDatabaseOptions(t *testing.T) {
+	s.handleCommand(t, CodeSuccess, ".", "skeema init --dir mydb -h %s -P %d", s.d.Instance.Host, s.d.Instance.Port)
+	s.handleCommand(t, CodeSuccess, ".", "skeema drift")
+
+	// Changing the schema's default charset on the instance is drift, and
+	// first-only from an option file must not cause any instance to be skipped
+	s.dbExec(t, "", "ALTER DATABASE product CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci")
+	fs.WriteTestFile(t, "mydb/.skeema", fs.ReadTestFile(t, "mydb/.skeema")+"first-only\n")
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema drift")
+	s.handleCommand(t, CodeDifferencesFound, ".", "skeema drift --report-format=json")
+	s.dbExec(t, "", "ALTER DATABASE product CHARACTER SET latin1 COLLATE latin1_swedish_ci")
+	s.handleCommand(t, CodeSuccess, ".", "skeema drift")
+}
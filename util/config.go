@@ -1,6 +1,7 @@
 package util
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"os"
@@ -24,8 +25,16 @@ func AddGlobalOptions(cmd *mybase.Command) {
 	cmd.AddOption(mybase.StringOption("schema", 0, "", "Database schema name").Hidden())
 	cmd.AddOption(mybase.StringOption("ignore-schema", 0, "", "Ignore schemas that match regex").Hidden())
 	cmd.AddOption(mybase.StringOption("ignore-table", 0, "", "Ignore tables that match regex").Hidden())
+	cmd.AddOption(mybase.StringOption("layout", 0, "", `File layout for object definitions: "flat", "type-dirs", "type-suffix", "single-file", or a template such as "{types}/{name}.sql"`).Hidden())
+	cmd.AddOption(mybase.StringOption("format-style", 0, "", `Style for CREATE statements written by format, lint, pull, and init: blank for the server's SHOW CREATE output, or "portable" with optional settings`).Hidden())
+	cmd.AddOption(mybase.StringOption("include", 0, "", "Comma-separated list of other dirs or *.sql files whose statements are merged into this dir").Hidden())
+	cmd.AddOption(mybase.StringOption("grants-file", 0, "", "File declaring users, roles, and privileges to manage on this dir's host").Hidden())
+	cmd.AddOption(mybase.StringOption("template-vars", 0, "", "Comma-separated list of name=value variables to substitute into {{name}} placeholders in *.sql files").Hidden())
+	cmd.AddOption(mybase.StringOption("data-tables", 0, "", "Comma-separated list of tables whose rows are managed by INSERT statements").Hidden())
 	cmd.AddOption(mybase.StringOption("default-character-set", 0, "", "Schema-level default character set").Hidden())
 	cmd.AddOption(mybase.StringOption("default-collation", 0, "", "Schema-level default collation").Hidden())
+	cmd.AddOption(mybase.StringOption("default-encryption", 0, "", `Schema-level default encryption (valid values: "y", "n")`).Hidden())
+	cmd.AddOption(mybase.StringOption("schema-read-only", 0, "", `Schema-level read-only status (valid values: "1", "0")`).Hidden())
 	cmd.AddOption(mybase.StringOption("flavor", 0, "", "Database server expressed in format vendor:major.minor, for use in vendor/version specific syntax").Hidden())
 
 	// Deprecated options or deprecated aliases -- all hidden
@@ -39,9 +48,20 @@ func AddGlobalOptions(cmd *mybase.Command) {
 	cmd.AddOption(mybase.StringOption("temp-schema-binlog", 0, "auto", `Controls whether temp schema DDL operations are replicated (valid values: "on", "off", "auto")`))
 	cmd.AddOption(mybase.StringOption("temp-schema-threads", 0, "5", "Max number of concurrent CREATE/DROP with workspace=temp-schema"))
 	cmd.AddOption(mybase.StringOption("connect-options", 'o', "", "Comma-separated session options to set upon connecting to each database instance"))
-	cmd.AddOption(mybase.StringOption("workspace", 'w', "temp-schema", `Specifies where to run intermediate operations (valid values: "temp-schema", "docker")`))
+	cmd.AddOption(mybase.StringOption("workspace", 'w', "temp-schema", `Specifies where to run intermediate operations (valid values: "temp-schema", "docker", "offline", "dedicated-host")`))
+	cmd.AddOption(mybase.StringOption("workspace-host", 0, "", "With --workspace=dedicated-host, hostname and optional port of the workspace server"))
+	cmd.AddOption(mybase.StringOption("workspace-cleanup-age", 0, "1h", "With --workspace=dedicated-host, drop abandoned workspace schemas older than this duration"))
+	cmd.AddOption(mybase.StringOption("workspace-parity", 0, "off", `How to handle server configuration differences between workspace and target (valid values: "off", "warn", "error", "copy")`))
+	cmd.AddOption(mybase.BoolOption("workspace-cache", 0, false, "Cache workspace results on disk, skipping the workspace for unchanged dirs"))
+	cmd.AddOption(mybase.StringOption("docker-image", 0, "", "With --workspace=docker, image name template overriding the flavor-derived image"))
+	cmd.AddOption(mybase.StringOption("docker-containers", 0, "1", "With --workspace=docker, max number of containers per image, for processing dirs concurrently"))
+	cmd.AddOption(mybase.BoolOption("docker-warm-pool", 0, false, "With --workspace=docker, start all docker-containers containers upon first use"))
+	cmd.AddOption(mybase.StringOption("docker-args", 0, "", "With --workspace=docker, additional space-separated server args for new containers"))
+	cmd.AddOption(mybase.BoolOption("docker-tmpfs", 0, false, "With --workspace=docker, store container data dirs in memory using tmpfs"))
 	cmd.AddOption(mybase.StringOption("docker-cleanup", 0, "none", `With --workspace=docker, specifies how to clean up containers (valid values: "none", "stop", "destroy")`))
 	cmd.AddOption(mybase.BoolOption("debug", 0, false, "Enable debug logging"))
+	cmd.AddOption(mybase.StringOption("log-format", 0, "text", `Format of log output to STDERR (valid values: "text", "json")`))
+	cmd.AddOption(mybase.BoolOption("quiet", 0, false, "Suppress info-level logging and comment lines in STDOUT output"))
 	cmd.AddOption(mybase.BoolOption("my-cnf", 0, true, "Parse ~/.my.cnf for configuration"))
 }
 
@@ -95,7 +115,8 @@ func AddGlobalConfigFiles(cfg *mybase.Config) {
 
 // ProcessSpecialGlobalOptions performs special handling of global options with
 // unusual semantics -- handling restricted placement of host and schema;
-// obtaining a password from MYSQL_PWD or STDIN; enable debug logging.
+// obtaining a password from MYSQL_PWD or STDIN; enable debug logging, quiet
+// logging, and structured log output.
 func ProcessSpecialGlobalOptions(cfg *mybase.Config) error {
 	// The host and schema options are special -- most commands only expect
 	// to find them when recursively crawling directory configs. So if these
@@ -129,6 +150,13 @@ func ProcessSpecialGlobalOptions(cfg *mybase.Config) error {
 
 	if cfg.GetBool("debug") {
 		log.SetLevel(log.DebugLevel)
+	} else if cfg.GetBool("quiet") {
+		log.SetLevel(log.WarnLevel)
+	}
+	if logFormat, err := cfg.GetEnum("log-format", "text", "json"); err != nil {
+		return err
+	} else if logFormat == "json" {
+		log.SetFormatter(&JSONFormatter{})
 	}
 
 	return nil
@@ -149,6 +177,23 @@ func PromptPassword() (string, error) {
 	return string(bytePassword), nil
 }
 
+// PromptConfirm displays the supplied prompt and reads a yes/no response from
+// STDIN. Requires that STDIN is a TTY. Only a response beginning with "y" or
+// "Y" is treated as confirmation.
+func PromptConfirm(prompt string) (bool, error) {
+	stdin := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(stdin) {
+		return false, errors.New("STDIN must be a TTY to confirm")
+	}
+	fmt.Printf("%s [y/N] ", prompt)
+	response, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	response = strings.TrimSpace(response)
+	return len(response) > 0 && (response[0] == 'y' || response[0] == 'Y'), nil
+}
+
 // SplitConnectOptions takes a string containing a comma-separated list of
 // connection options (typically obtained from the "connect-options" option)
 // and splits them into a map of individual key: value strings. This function
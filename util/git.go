@@ -0,0 +1,128 @@
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitExportTree extracts the contents of the git repository containing workDir,
+// as of the supplied ref (commit, branch, tag, etc), into destDir. The returned
+// string is the path within destDir that corresponds to workDir. An empty .git
+// subdir is created in destDir, so that option file parsing treats destDir as
+// the repo base.
+func GitExportTree(workDir, ref, destDir string) (string, error) {
+	topLevel, err := runGit(workDir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	prefix, err := runGit(workDir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return "", err
+	}
+	if _, err := runGit(topLevel, "rev-parse", "--verify", "--quiet", ref+"^{tree}"); err != nil {
+		return "", fmt.Errorf("Unknown git ref %q", ref)
+	}
+
+	cmd := exec.Command("git", "archive", "--format=tar", ref)
+	cmd.Dir = topLevel
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+	extractErr := extractTar(stdout, destDir)
+	if err := cmd.Wait(); err != nil {
+		return "", fmt.Errorf("git archive failed: %s %s", err, strings.TrimSpace(stderr.String()))
+	} else if extractErr != nil {
+		return "", extractErr
+	}
+	if err := os.MkdirAll(filepath.Join(destDir, ".git"), 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(destDir, prefix), nil
+}
+
+// runGit runs a git subcommand in dir, returning its trimmed STDOUT.
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// extractTar writes the directories, regular files, and symlinks from a tar
+// stream into destDir. Symlinks must be relative, and must point to a location
+// within destDir; no other entries may be located beneath a symlink. This
+// prevents a crafted archive from causing reads or writes outside of destDir.
+func extractTar(r io.Reader, destDir string) error {
+	root := filepath.Clean(destDir) + string(os.PathSeparator)
+	var links []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		target := filepath.Join(destDir, hdr.Name)
+		if !strings.HasPrefix(target, root) {
+			return fmt.Errorf("Illegal path %q in archive", hdr.Name)
+		}
+		for _, link := range links {
+			if strings.HasPrefix(target, link+string(os.PathSeparator)) {
+				return fmt.Errorf("Illegal path %q beneath symlink in archive", hdr.Name)
+			}
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			err = writeTarFile(tr, target, os.FileMode(hdr.Mode).Perm())
+		case tar.TypeSymlink:
+			if filepath.IsAbs(hdr.Linkname) || !strings.HasPrefix(filepath.Join(filepath.Dir(target), hdr.Linkname)+string(os.PathSeparator), root) {
+				return fmt.Errorf("Illegal symlink %q -> %q in archive", hdr.Name, hdr.Linkname)
+			}
+			if err = os.MkdirAll(filepath.Dir(target), 0755); err == nil {
+				err = os.Symlink(hdr.Linkname, target)
+			}
+			links = append(links, target)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func writeTarFile(r io.Reader, target string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
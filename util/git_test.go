@@ -0,0 +1,131 @@
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitExportTree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("Skipping test since git is not available")
+	}
+	repoDir, err := ioutil.TempDir("", "skeema-git-src")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(repoDir)
+	destDir, err := ioutil.TempDir("", "skeema-git-dest")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(destDir)
+
+	subDir := filepath.Join(repoDir, "mydb", "product")
+	if err := os.MkdirAll(subDir, 0755); err != nil {
+		t.Fatalf("Unable to create dir: %s", err)
+	}
+	writeFile := func(contents string) {
+		t.Helper()
+		if err := ioutil.WriteFile(filepath.Join(subDir, "foo.sql"), []byte(contents), 0644); err != nil {
+			t.Fatalf("Unable to write file: %s", err)
+		}
+	}
+	git := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("Unexpected error from git %v: %s\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	writeFile("CREATE TABLE foo (id int);\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "first")
+	writeFile("CREATE TABLE foo (id bigint);\n")
+
+	exportedPath, err := GitExportTree(subDir, "HEAD", destDir)
+	if err != nil {
+		t.Fatalf("Unexpected error from GitExportTree: %s", err)
+	}
+	if expected := filepath.Join(destDir, "mydb", "product"); exportedPath != expected {
+		t.Errorf("Expected returned path %s, instead found %s", expected, exportedPath)
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(exportedPath, "foo.sql"))
+	if err != nil {
+		t.Fatalf("Unable to read exported file: %s", err)
+	} else if string(contents) != "CREATE TABLE foo (id int);\n" {
+		t.Errorf("Unexpected contents of exported file: %q", contents)
+	}
+	if fi, err := os.Stat(filepath.Join(destDir, ".git")); err != nil || !fi.IsDir() {
+		t.Errorf("Expected .git dir to be created in destination, but it was not: %v", err)
+	}
+
+	if _, err := GitExportTree(subDir, "does-not-exist", destDir); err == nil {
+		t.Error("Expected error from GitExportTree with nonexistent ref, but err was nil")
+	}
+}
+
+func TestExtractTarSymlinks(t *testing.T) {
+	type entry struct {
+		name     string
+		linkname string // if non-empty, entry is a symlink; otherwise a file
+	}
+	makeTar := func(entries ...entry) *bytes.Buffer {
+		t.Helper()
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, e := range entries {
+			hdr := &tar.Header{Name: e.name, Mode: 0644}
+			if e.linkname != "" {
+				hdr.Typeflag = tar.TypeSymlink
+				hdr.Linkname = e.linkname
+			} else {
+				hdr.Typeflag = tar.TypeReg
+				hdr.Size = 4
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				t.Fatalf("Unable to write tar header: %s", err)
+			}
+			if e.linkname == "" {
+				tw.Write([]byte("test"))
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatalf("Unable to close tar writer: %s", err)
+		}
+		return &buf
+	}
+
+	cases := []struct {
+		entries []entry
+		allowed bool
+	}{
+		{[]entry{{"a/foo.sql", ""}, {"a/bar.sql", "foo.sql"}}, true},
+		{[]entry{{"a/foo.sql", ""}, {"b/bar.sql", "../a/foo.sql"}}, true},
+		{[]entry{{"a/bar.sql", "/etc/passwd"}}, false},
+		{[]entry{{"a/bar.sql", "../../outside.sql"}}, false},
+		{[]entry{{"bar.sql", ".."}}, false},
+		{[]entry{{"a/b", "."}, {"a/b/c", "../../.."}}, false},
+		{[]entry{{"a/b", ".."}, {"a/b/foo.sql", ""}}, false},
+	}
+	for n, c := range cases {
+		destDir, err := ioutil.TempDir("", "skeema-tar-dest")
+		if err != nil {
+			t.Fatalf("Unable to create temp dir: %s", err)
+		}
+		err = extractTar(makeTar(c.entries...), destDir)
+		if c.allowed && err != nil {
+			t.Errorf("cases[%d]: Unexpected error from extractTar: %s", n, err)
+		} else if !c.allowed && err == nil {
+			t.Errorf("cases[%d]: Expected error from extractTar, but err was nil", n)
+		}
+		os.RemoveAll(destDir)
+	}
+}
@@ -0,0 +1,43 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// JSONFormatter formats log entries as one JSON object per line, for use with
+// log-format=json. Any fields supplied at the call site, such as instance or
+// schema, are included as top-level keys alongside the time, level, and
+// message.
+type JSONFormatter struct {
+	log.JSONFormatter
+}
+
+// Format renders a single log entry. Trailing newlines in the message, which
+// separate groups of lines in human-oriented output, are removed.
+func (f *JSONFormatter) Format(entry *log.Entry) ([]byte, error) {
+	entry.Message = strings.TrimRight(entry.Message, "\n")
+	return f.JSONFormatter.Format(entry)
+}
+
+// CountAndNoun returns a string combining n with the singular or plural form
+// of a noun, for use in log messages. Supply 1 noun if pluralization is just
+// adding an s, or 2 nouns if using another word entirely. A count of 0 is
+// expressed as "no" followed by the plural form.
+func CountAndNoun(n int, nouns ...string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", nouns[0])
+	}
+	var plural string
+	if len(nouns) == 1 {
+		plural = fmt.Sprintf("%ss", nouns[0])
+	} else {
+		plural = nouns[1]
+	}
+	if n == 0 {
+		return fmt.Sprintf("no %s", plural)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
@@ -0,0 +1,111 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+)
+
+func TestLogFormatOption(t *testing.T) {
+	cmdSuite := mybase.NewCommandSuite("skeematest", "", "")
+	AddGlobalOptions(cmdSuite)
+	cmdSuite.AddSubCommand(mybase.NewCommand("diff", "", "", nil))
+
+	origFormatter := log.StandardLogger().Formatter
+	defer log.SetFormatter(origFormatter)
+
+	cfg := mybase.ParseFakeCLI(t, cmdSuite, "skeema diff --log-format=xml")
+	if err := ProcessSpecialGlobalOptions(cfg); err == nil {
+		t.Error("Expected error from ProcessSpecialGlobalOptions with invalid log-format, but err was nil")
+	}
+	cfg = mybase.ParseFakeCLI(t, cmdSuite, "skeema diff")
+	if err := ProcessSpecialGlobalOptions(cfg); err != nil {
+		t.Errorf("Unexpected error from ProcessSpecialGlobalOptions: %v", err)
+	} else if log.StandardLogger().Formatter != origFormatter {
+		t.Error("Expected default log-format to leave formatter unchanged")
+	}
+	cfg = mybase.ParseFakeCLI(t, cmdSuite, "skeema diff --log-format=json")
+	if err := ProcessSpecialGlobalOptions(cfg); err != nil {
+		t.Errorf("Unexpected error from ProcessSpecialGlobalOptions: %v", err)
+	} else if _, ok := log.StandardLogger().Formatter.(*JSONFormatter); !ok {
+		t.Errorf("Expected log-format=json to set a JSONFormatter, instead found %T", log.StandardLogger().Formatter)
+	}
+}
+
+func TestQuietOption(t *testing.T) {
+	cmdSuite := mybase.NewCommandSuite("skeematest", "", "")
+	AddGlobalOptions(cmdSuite)
+	cmdSuite.AddSubCommand(mybase.NewCommand("diff", "", "", nil))
+
+	origLevel := log.GetLevel()
+	defer log.SetLevel(origLevel)
+
+	cfg := mybase.ParseFakeCLI(t, cmdSuite, "skeema diff --quiet")
+	if err := ProcessSpecialGlobalOptions(cfg); err != nil {
+		t.Errorf("Unexpected error from ProcessSpecialGlobalOptions: %v", err)
+	} else if log.GetLevel() != log.WarnLevel {
+		t.Errorf("Expected --quiet to set log level to warn, instead found %s", log.GetLevel())
+	}
+
+	// debug takes precedence over quiet
+	cfg = mybase.ParseFakeCLI(t, cmdSuite, "skeema diff --quiet --debug")
+	if err := ProcessSpecialGlobalOptions(cfg); err != nil {
+		t.Errorf("Unexpected error from ProcessSpecialGlobalOptions: %v", err)
+	} else if log.GetLevel() != log.DebugLevel {
+		t.Errorf("Expected --debug to override --quiet, instead found log level %s", log.GetLevel())
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	entry := log.WithFields(log.Fields{"instance": "db1:3306", "schema": "product", "duration": 1.5})
+	entry.Time = time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	entry.Level = log.InfoLevel
+	entry.Message = "db1:3306 product: diff complete\n"
+	b, err := (&JSONFormatter{}).Format(entry)
+	if err != nil {
+		t.Fatalf("Unexpected error from Format: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("Unable to decode output %q: %v", b, err)
+	}
+	expected := map[string]interface{}{
+		"instance": "db1:3306",
+		"schema":   "product",
+		"duration": 1.5,
+		"level":    "info",
+		"msg":      "db1:3306 product: diff complete",
+		"time":     "2020-03-04T05:06:07Z",
+	}
+	for key, value := range expected {
+		if decoded[key] != value {
+			t.Errorf("Expected %s to be %v, instead found %v", key, value, decoded[key])
+		}
+	}
+	if b[len(b)-1] != '\n' || len(decoded) != len(expected) {
+		t.Errorf("Unexpected output from Format: %q", b)
+	}
+}
+
+func TestCountAndNoun(t *testing.T) {
+	cases := []struct {
+		n        int
+		nouns    []string
+		expected string
+	}{
+		{0, []string{"error"}, "no errors"},
+		{1, []string{"error"}, "1 error"},
+		{3, []string{"error"}, "3 errors"},
+		{0, []string{"query", "queries"}, "no queries"},
+		{1, []string{"query", "queries"}, "1 query"},
+		{2, []string{"query", "queries"}, "2 queries"},
+	}
+	for _, c := range cases {
+		if actual := CountAndNoun(c.n, c.nouns...); actual != c.expected {
+			t.Errorf("CountAndNoun(%d, %v): expected %q, found %q", c.n, c.nouns, c.expected, actual)
+		}
+	}
+}
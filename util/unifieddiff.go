@@ -0,0 +1,130 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnifiedDiff returns a unified-format diff of the lines of from vs to, using
+// the supplied names in the header lines, and the supplied number of lines of
+// context around each change. If from and to are identical, an empty string
+// is returned. This is intended for display of relatively small inputs, such
+// as CREATE statements; its memory usage is quadratic in the number of lines.
+func UnifiedDiff(fromName, toName, from, to string, context int) string {
+	if from == to {
+		return ""
+	}
+	a, b := splitDiffLines(from), splitDiffLines(to)
+	ops := diffLines(a, b)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(ops); {
+		// Find the next changed op; if none, we're done
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start >= len(ops) {
+			break
+		}
+
+		// Extend hunk to include subsequent changes separated by at most 2*context
+		// unchanged lines
+		hunkStart := start - context
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		end := start
+		for n := start; n < len(ops) && n-end-1 <= 2*context; n++ {
+			if ops[n].kind != ' ' {
+				end = n
+			}
+		}
+		hunkEnd := end + context + 1
+		if hunkEnd > len(ops) {
+			hunkEnd = len(ops)
+		}
+
+		var aStart, aLen, bStart, bLen int
+		aStart, bStart = ops[hunkStart].aLine, ops[hunkStart].bLine
+		for _, op := range ops[hunkStart:hunkEnd] {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+		for _, op := range ops[hunkStart:hunkEnd] {
+			fmt.Fprintf(&out, "%c%s\n", op.kind, op.text)
+		}
+		start = hunkEnd
+	}
+	return out.String()
+}
+
+type diffOp struct {
+	kind  byte // ' ' for unchanged, '-' for removed, '+' for added
+	text  string
+	aLine int // 1-based line number in a at this point
+	bLine int // 1-based line number in b at this point
+}
+
+func splitDiffLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines computes a line-level edit script from a to b, using a longest
+// common subsequence table.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	var i, j int
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', text: a[i], aLine: i + 1, bLine: j + 1})
+			i++
+			j++
+		case j >= len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', text: a[i], aLine: i + 1, bLine: j + 1})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', text: b[j], aLine: i + 1, bLine: j + 1})
+			j++
+		}
+	}
+	return ops
+}
+
+// hunkRange formats a line range for a unified diff hunk header. Per the
+// format's conventions, an empty range refers to the line before it.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		start--
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, length)
+}
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	if actual := UnifiedDiff("a", "b", "same\n", "same\n", 3); actual != "" {
+		t.Errorf("Expected empty diff for identical input, instead found %q", actual)
+	}
+
+	from := "CREATE TABLE `foo` (\n  `id` int NOT NULL,\n  `name` varchar(30),\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB"
+	to := "CREATE TABLE `foo` (\n  `id` int NOT NULL,\n  `name` varchar(40),\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB"
+	expected := "--- a\n+++ b\n@@ -2,3 +2,3 @@\n   `id` int NOT NULL,\n-  `name` varchar(30),\n+  `name` varchar(40),\n   PRIMARY KEY (`id`)\n"
+	if actual := UnifiedDiff("a", "b", from, to, 1); actual != expected {
+		t.Errorf("Unexpected result from UnifiedDiff\nexpected:\n%s\nactual:\n%s", expected, actual)
+	}
+
+	expected = "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+one\n+two\n"
+	if actual := UnifiedDiff("a", "b", "", "one\ntwo\n", 3); actual != expected {
+		t.Errorf("Unexpected result from UnifiedDiff\nexpected:\n%s\nactual:\n%s", expected, actual)
+	}
+
+	// Two changes far apart should yield two hunks; close together should yield one
+	from = "1\n2\n3\n4\n5\n6\n7\n8\n9\n10"
+	to = "1\nX\n3\n4\n5\n6\n7\n8\nY\n10"
+	expected = "--- a\n+++ b\n@@ -1,3 +1,3 @@\n 1\n-2\n+X\n 3\n@@ -8,3 +8,3 @@\n 8\n-9\n+Y\n 10\n"
+	if actual := UnifiedDiff("a", "b", from, to, 1); actual != expected {
+		t.Errorf("Unexpected result from UnifiedDiff\nexpected:\n%s\nactual:\n%s", expected, actual)
+	}
+	expected = "--- a\n+++ b\n@@ -1,10 +1,10 @@\n 1\n-2\n+X\n 3\n 4\n 5\n 6\n 7\n 8\n-9\n+Y\n 10\n"
+	if actual := UnifiedDiff("a", "b", from, to, 3); actual != expected {
+		t.Errorf("Unexpected result from UnifiedDiff\nexpected:\n%s\nactual:\n%s", expected, actual)
+	}
+}
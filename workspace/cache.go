@@ -0,0 +1,249 @@
+package workspace
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// cacheFormatVersion should be incremented whenever the cache file format, or
+// the behavior of any workspace type, changes in a way that invalidates
+// previously-cached results.
+const cacheFormatVersion = 4
+
+// Cache entries are pruned whenever a new entry is stored: entries which have
+// not been used within cacheMaxAge are removed, and then the least-recently
+// used entries are removed until at most cacheMaxEntries remain.
+const (
+	cacheMaxEntries = 1000
+	cacheMaxAge     = 30 * 24 * time.Hour
+)
+
+// cachedSchema is the on-disk representation of a Schema. Failures are stored
+// by statement identity rather than location, so that a cache hit reports the
+// current file and line of each failing statement.
+type cachedSchema struct {
+	Schema   *tengo.Schema            `json:"schema"`
+	Failures []cachedFailure          `json:"failures,omitempty"`
+	Data     map[string]*fs.TableData `json:"data,omitempty"`
+	Parity   *cachedParity            `json:"parity,omitempty"`
+}
+
+// cachedParity records the parityVariables of the workspace instance which
+// produced a cachedSchema, so that parity with a target can be checked again
+// upon a cache hit.
+type cachedParity struct {
+	Instance string            `json:"instance"`
+	Vars     map[string]string `json:"vars"`
+}
+
+// cachedFailure represents a StatementError in a cachedSchema.
+type cachedFailure struct {
+	ObjectType  tengo.ObjectType `json:"objectType,omitempty"`  // only set for CREATEs
+	Name        string           `json:"name,omitempty"`        // only set for CREATEs
+	AlterIndex  int              `json:"alterIndex,omitempty"`  // 1-based position in LogicalSchema.Alters, if an ALTER
+	InsertIndex int              `json:"insertIndex,omitempty"` // 1-based position in LogicalSchema.Inserts, if an INSERT
+	Message     string           `json:"error"`
+}
+
+// DefaultCacheDir returns the directory used for caching workspace results
+// when the workspace-cache option is enabled. The location follows the
+// operating system's conventions for per-user cache data, for example
+// $XDG_CACHE_HOME/skeema/workspace or ~/.cache/skeema/workspace on Linux.
+func DefaultCacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "skeema", "workspace"), nil
+}
+
+// cacheKey returns a hex-encoded hash identifying the result of executing
+// logicalSchema in a workspace configured by opts. Any input which may affect
+// the result is included in the hash: the workspace type and location, the
+// full server version or Docker image ID, the schema name and defaults, data
+// tables, and the exact text of every statement. If the server version or
+// image ID cannot be determined, an empty string is returned, indicating the
+// result should not be cached.
+func cacheKey(logicalSchema *fs.LogicalSchema, opts Options) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "version=%d\ntype=%d\nschema=%s\ncharset=%s\ncollation=%s\nparams=%s\ndata=%s\n",
+		cacheFormatVersion, opts.Type, opts.SchemaName, opts.DefaultCharacterSet, opts.DefaultCollation, opts.SessionParams, strings.Join(logicalSchema.DataTables, ","))
+	if opts.Instance != nil {
+		major, minor, patch := opts.Instance.Version()
+		if major == 0 {
+			return ""
+		}
+		fmt.Fprintf(h, "instance=%s\nflavor=%s\nserver=%d.%d.%d\n", opts.Instance, opts.Instance.Flavor(), major, minor, patch)
+	} else if opts.Type == TypeLocalDocker {
+		image := opts.Image
+		if image == "" {
+			image = opts.Flavor.String()
+		}
+		imageID, err := dockerImageID(image)
+		if err != nil {
+			log.Debugf("Skipping workspace cache: unable to determine ID of image %s: %s", image, err)
+			return ""
+		}
+		fmt.Fprintf(h, "container=%s\nimage=%s\nimageid=%s\nargs=%s\ntmpfs=%t\n", opts.ContainerName, image, imageID, strings.Join(opts.ServerArgs, " "), opts.Tmpfs)
+	} else {
+		fmt.Fprintf(h, "flavor=%s\n", opts.Flavor)
+	}
+
+	keys := make([]tengo.ObjectKey, 0, len(logicalSchema.Creates))
+	for key := range logicalSchema.Creates {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	for _, key := range keys {
+		body := logicalSchema.Creates[key].Body()
+		fmt.Fprintf(h, "create %s %d\n%s\n", key, len(body), body)
+	}
+	for _, stmt := range logicalSchema.Alters {
+		body := stmt.Body()
+		fmt.Fprintf(h, "alter %d\n%s\n", len(body), body)
+	}
+	for _, stmt := range logicalSchema.Inserts {
+		body := stmt.Body()
+		fmt.Fprintf(h, "insert %d\n%s\n", len(body), body)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// loadCachedSchema returns a previously-cached Schema for key, along with the
+// workspace's parity information if it was recorded. The Schema is nil if no
+// usable cache entry exists.
+func loadCachedSchema(cacheDir, key string, logicalSchema *fs.LogicalSchema) (*Schema, *cachedParity) {
+	data, err := ioutil.ReadFile(filepath.Join(cacheDir, key+".json"))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Debugf("Ignoring unreadable workspace cache entry: %s", err)
+		}
+		return nil, nil
+	}
+	var cached cachedSchema
+	if err := json.Unmarshal(data, &cached); err != nil || cached.Schema == nil {
+		log.Debugf("Ignoring malformed workspace cache entry %s.json", key)
+		return nil, nil
+	}
+	now := time.Now()
+	os.Chtimes(filepath.Join(cacheDir, key+".json"), now, now) // track recent use for pruning
+	wsSchema := &Schema{
+		Schema:        cached.Schema,
+		LogicalSchema: logicalSchema,
+		Failures:      []*StatementError{},
+		Data:          cached.Data,
+	}
+	for _, failure := range cached.Failures {
+		var stmt *fs.Statement
+		if failure.AlterIndex > 0 && failure.AlterIndex <= len(logicalSchema.Alters) {
+			stmt = logicalSchema.Alters[failure.AlterIndex-1]
+		} else if failure.InsertIndex > 0 && failure.InsertIndex <= len(logicalSchema.Inserts) {
+			stmt = logicalSchema.Inserts[failure.InsertIndex-1]
+		} else if failure.AlterIndex == 0 && failure.InsertIndex == 0 {
+			stmt = logicalSchema.Creates[tengo.ObjectKey{Type: failure.ObjectType, Name: failure.Name}]
+		}
+		if stmt == nil {
+			log.Debugf("Ignoring inconsistent workspace cache entry %s.json", key)
+			return nil, nil
+		}
+		wsSchema.Failures = append(wsSchema.Failures, &StatementError{
+			Statement: stmt,
+			Err:       errors.New(failure.Message),
+		})
+	}
+	return wsSchema, cached.Parity
+}
+
+// storeCachedSchema writes wsSchema to the cache under key, along with the
+// workspace's parity information if non-nil, and then prunes old entries. The file is written to a temporary location and then renamed,
+// so that concurrent processes never observe a partially-written entry.
+func storeCachedSchema(cacheDir, key string, wsSchema *Schema, parity *cachedParity) error {
+	alterIndexes := make(map[*fs.Statement]int, len(wsSchema.LogicalSchema.Alters))
+	for n, stmt := range wsSchema.LogicalSchema.Alters {
+		alterIndexes[stmt] = n + 1
+	}
+	insertIndexes := make(map[*fs.Statement]int, len(wsSchema.LogicalSchema.Inserts))
+	for n, stmt := range wsSchema.LogicalSchema.Inserts {
+		insertIndexes[stmt] = n + 1
+	}
+	cached := cachedSchema{Schema: wsSchema.Schema, Data: wsSchema.Data, Parity: parity}
+	for _, stmtErr := range wsSchema.Failures {
+		failure := cachedFailure{Message: stmtErr.Err.Error()}
+		if n, ok := alterIndexes[stmtErr.Statement]; ok {
+			failure.AlterIndex = n
+		} else if n, ok := insertIndexes[stmtErr.Statement]; ok {
+			failure.InsertIndex = n
+		} else {
+			key := stmtErr.ObjectKey()
+			failure.ObjectType, failure.Name = key.Type, key.Name
+		}
+		cached.Failures = append(cached.Failures, failure)
+	}
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return err
+	}
+	tmpFile, err := ioutil.TempFile(cacheDir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), filepath.Join(cacheDir, key+".json"))
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	pruneCache(cacheDir, cacheMaxEntries, cacheMaxAge)
+	return nil
+}
+
+// pruneCache removes cache entries which were last used more than maxAge ago,
+// as well as the least-recently-used entries beyond maxEntries. Errors are
+// logged but otherwise ignored, since a concurrent process may be pruning the
+// same directory.
+func pruneCache(cacheDir string, maxEntries int, maxAge time.Duration) {
+	fileInfos, err := ioutil.ReadDir(cacheDir)
+	if err != nil {
+		log.Debugf("Unable to prune workspace cache: %s", err)
+		return
+	}
+	entries := make([]os.FileInfo, 0, len(fileInfos))
+	for _, fi := range fileInfos {
+		if fi.Mode().IsRegular() && strings.HasSuffix(fi.Name(), ".json") {
+			entries = append(entries, fi)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().After(entries[j].ModTime())
+	})
+	cutoff := time.Now().Add(-maxAge)
+	for n, fi := range entries {
+		if n >= maxEntries || fi.ModTime().Before(cutoff) {
+			if err := os.Remove(filepath.Join(cacheDir, fi.Name())); err != nil && !os.IsNotExist(err) {
+				log.Debugf("Unable to prune workspace cache entry: %s", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package workspace
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestExecLogicalSchemaCache(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "skeema-test-cache")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(cacheDir)
+
+	newLogicalSchema := func(tableSQL, file string) *fs.LogicalSchema {
+		logicalSchema := &fs.LogicalSchema{
+			CharSet:   "latin1",
+			Collation: "latin1_swedish_ci",
+			Creates:   make(map[tengo.ObjectKey]*fs.Statement),
+		}
+		stmts := []*fs.Statement{
+			{File: file, LineNo: 1, Text: tableSQL, Type: fs.StatementTypeCreate, ObjectType: tengo.ObjectTypeTable, ObjectName: "a"},
+			{File: file, LineNo: 2, Text: "CREATE TABLE b (id int, CHECK (id > 0));\n", Type: fs.StatementTypeCreate, ObjectType: tengo.ObjectTypeTable, ObjectName: "b"},
+			{File: file, LineNo: 3, Text: "ALTER TABLE a ADD COLUMN x int;\n", Type: fs.StatementTypeAlter, ObjectType: tengo.ObjectTypeTable, ObjectName: "a"},
+		}
+		for _, stmt := range stmts {
+			if err := logicalSchema.AddStatement(stmt); err != nil {
+				t.Fatalf("Unexpected error from AddStatement: %s", err)
+			}
+		}
+		return logicalSchema
+	}
+	opts := Options{
+		Type:       TypeOffline,
+		Flavor:     tengo.NewFlavor("mysql:5.7"),
+		SchemaName: "_skeema_tmp",
+		CacheDir:   cacheDir,
+	}
+	tableSQL := "CREATE TABLE a (id int PRIMARY KEY);\n"
+	orig, err := ExecLogicalSchema(newLogicalSchema(tableSQL, "one.sql"), opts)
+	if err != nil {
+		t.Fatalf("Unexpected error from ExecLogicalSchema: %s", err)
+	}
+	if entries, _ := filepath.Glob(filepath.Join(cacheDir, "*.json")); len(entries) != 1 {
+		t.Fatalf("Expected 1 cache entry, instead found %d", len(entries))
+	}
+
+	// Replace the cache entry's table with a sentinel, to confirm that the
+	// next call actually uses the cache
+	keyOpts := opts
+	keyOpts.DefaultCharacterSet, keyOpts.DefaultCollation = "latin1", "latin1_swedish_ci"
+	key := cacheKey(newLogicalSchema(tableSQL, "one.sql"), keyOpts)
+	origCreate := orig.Tables[0].CreateStatement
+	orig.Tables[0].CreateStatement = "sentinel"
+	if err := storeCachedSchema(cacheDir, key, orig, nil); err != nil {
+		t.Fatalf("Unexpected error from storeCachedSchema: %s", err)
+	}
+
+	// Statement locations differ, but that should not affect the cache key.
+	// Failures should reference the new locations.
+	cached, err := ExecLogicalSchema(newLogicalSchema(tableSQL, "two.sql"), opts)
+	if err != nil {
+		t.Fatalf("Unexpected error from ExecLogicalSchema: %s", err)
+	}
+	if len(cached.Tables) != 1 || cached.Tables[0].CreateStatement != "sentinel" {
+		t.Errorf("Cache entry was not used as expected")
+	}
+	if len(cached.Failures) != 2 {
+		t.Fatalf("Expected 2 failures, instead found %d", len(cached.Failures))
+	}
+	for n, failure := range cached.Failures {
+		if failure.File != "two.sql" || failure.Err.Error() != orig.Failures[n].Err.Error() {
+			t.Errorf("Unexpected failure[%d]: %s", n, failure)
+		}
+	}
+	if cached.Failures[1].Type != fs.StatementTypeAlter {
+		t.Errorf("Expected failures[1] to be the ALTER, instead found %s", cached.Failures[1].Statement.Text)
+	}
+
+	// Changing statement text, flavor, or charset should each result in a miss
+	variations := []struct {
+		tableSQL string
+		opts     Options
+		charSet  string
+	}{
+		{"CREATE TABLE a (id int PRIMARY KEY, name char(2));\n", opts, ""},
+		{tableSQL, opts, "utf8mb4"},
+		{tableSQL, Options{Type: TypeOffline, Flavor: tengo.NewFlavor("mysql:8.0"), SchemaName: "_skeema_tmp", CacheDir: cacheDir}, ""},
+	}
+	for n, v := range variations {
+		logicalSchema := newLogicalSchema(v.tableSQL, "one.sql")
+		if v.charSet != "" {
+			logicalSchema.CharSet, logicalSchema.Collation = v.charSet, ""
+		}
+		wsSchema, err := ExecLogicalSchema(logicalSchema, v.opts)
+		if err != nil {
+			t.Fatalf("Unexpected error from ExecLogicalSchema on variation[%d]: %s", n, err)
+		}
+		if wsSchema.Tables[0].CreateStatement == "sentinel" {
+			t.Errorf("Variation[%d] unexpectedly used cached result", n)
+		}
+	}
+	if entries, _ := filepath.Glob(filepath.Join(cacheDir, "*.json")); len(entries) != 1+len(variations) {
+		t.Errorf("Expected %d cache entries, instead found %d", 1+len(variations), len(entries))
+	}
+
+	// A corrupted cache entry should be ignored and then overwritten
+	if err := ioutil.WriteFile(filepath.Join(cacheDir, key+".json"), []byte("{bad json"), 0644); err != nil {
+		t.Fatalf("Unable to write file: %s", err)
+	}
+	wsSchema, err := ExecLogicalSchema(newLogicalSchema(tableSQL, "one.sql"), opts)
+	if err != nil {
+		t.Fatalf("Unexpected error from ExecLogicalSchema: %s", err)
+	}
+	if wsSchema.Tables[0].CreateStatement != origCreate {
+		t.Errorf("Unexpected CREATE TABLE after cache corruption: %s", wsSchema.Tables[0].CreateStatement)
+	}
+	if cached, _ := loadCachedSchema(cacheDir, key, newLogicalSchema(tableSQL, "one.sql")); cached == nil {
+		t.Error("Expected corrupted cache entry to be replaced, but it was not")
+	}
+
+	// Parity information should be stored and loaded along with the schema
+	parity := &cachedParity{Instance: "ws:3306", Vars: map[string]string{"lower_case_table_names": "0"}}
+	if err := storeCachedSchema(cacheDir, key, wsSchema, parity); err != nil {
+		t.Fatalf("Unexpected error from storeCachedSchema: %s", err)
+	}
+	if cached, loadedParity := loadCachedSchema(cacheDir, key, newLogicalSchema(tableSQL, "one.sql")); cached == nil || loadedParity == nil || loadedParity.Instance != parity.Instance || loadedParity.Vars["lower_case_table_names"] != "0" {
+		t.Errorf("Unexpected parity information loaded from cache: %+v", loadedParity)
+	}
+}
+
+func TestPruneCache(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "skeema-test-cache")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(cacheDir)
+
+	// Entries "0" through "4" were last used 0 through 4 days ago; a non-entry
+	// file should never be pruned
+	now := time.Now()
+	for n, name := range []string{"0.json", "1.json", "2.json", "3.json", "4.json", "other.tmp"} {
+		path := filepath.Join(cacheDir, name)
+		if err := ioutil.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatalf("Unable to write file: %s", err)
+		}
+		mtime := now.Add(time.Duration(-n) * 24 * time.Hour)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("Unable to set file times: %s", err)
+		}
+	}
+	assertRemaining := func(expected ...string) {
+		t.Helper()
+		entries, _ := filepath.Glob(filepath.Join(cacheDir, "*"))
+		if len(entries) != len(expected) {
+			t.Fatalf("Expected %d remaining files, instead found %v", len(expected), entries)
+		}
+		for n, name := range expected {
+			if filepath.Base(entries[n]) != name {
+				t.Errorf("Expected remaining file %q, instead found %q", name, filepath.Base(entries[n]))
+			}
+		}
+	}
+
+	pruneCache(cacheDir, 10, 72*time.Hour)
+	assertRemaining("0.json", "1.json", "2.json", "other.tmp")
+	pruneCache(cacheDir, 2, 72*time.Hour)
+	assertRemaining("0.json", "1.json", "other.tmp")
+}
@@ -0,0 +1,111 @@
+package workspace
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/VividCortex/mysqlerr"
+	"github.com/jmoiron/sqlx"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// QueryTableData returns all rows of the supplied table, using db, which must
+// already be using the table's schema as its default database. An error is
+// returned if the table lacks a primary key.
+func QueryTableData(db *sqlx.DB, table *tengo.Table) (*fs.TableData, error) {
+	if table.PrimaryKey == nil {
+		return nil, fmt.Errorf("Table %s is listed in data-tables but lacks a primary key", tengo.EscapeIdentifier(table.Name))
+	}
+	td := &fs.TableData{TableName: table.Name}
+	positions := make(map[string]int, len(table.Columns))
+	selectCols := make([]string, 0, len(table.Columns))
+	for _, col := range table.Columns {
+		if col.GenerationExpr != "" {
+			continue
+		}
+		positions[col.Name] = len(td.Columns)
+		td.Columns = append(td.Columns, col.Name)
+		td.Types = append(td.Types, col.TypeInDB)
+		td.Collations = append(td.Collations, col.Collation)
+		selectCols = append(selectCols, tengo.EscapeIdentifier(col.Name))
+	}
+	orderBy := make([]string, 0, len(table.PrimaryKey.Parts))
+	for _, part := range table.PrimaryKey.Parts {
+		pos, ok := positions[part.ColumnName]
+		if !ok {
+			return nil, fmt.Errorf("Table %s is listed in data-tables but its primary key cannot be used for row comparisons", tengo.EscapeIdentifier(table.Name))
+		}
+		td.PrimaryKey = append(td.PrimaryKey, pos)
+		orderBy = append(orderBy, tengo.EscapeIdentifier(part.ColumnName))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s ORDER BY %s", strings.Join(selectCols, ", "), tengo.EscapeIdentifier(table.Name), strings.Join(orderBy, ", "))
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		row := make([]sql.NullString, len(td.Columns))
+		dest := make([]interface{}, len(row))
+		for n := range row {
+			dest[n] = &row[n]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		td.Rows = append(td.Rows, row)
+	}
+	return td, rows.Err()
+}
+
+// queryWorkspaceData returns TableData for each of logicalSchema's DataTables
+// which exists in wsSchema. Tables affected by any failed statement are
+// omitted, since their rows cannot be fully determined.
+func queryWorkspaceData(ws Workspace, wsSchema *Schema, opts Options) (map[string]*fs.TableData, error) {
+	failed := make(map[string]bool)
+	for _, key := range wsSchema.FailedKeys() {
+		if key.Type == tengo.ObjectTypeTable {
+			failed[key.Name] = true
+		}
+	}
+	data := make(map[string]*fs.TableData)
+	for _, name := range wsSchema.LogicalSchema.DataTables {
+		table := wsSchema.Table(name)
+		if table == nil || failed[name] {
+			continue
+		}
+		db, err := ws.ConnectionPool(paramsForStatement(&fs.Statement{ObjectType: tengo.ObjectTypeTable}, opts))
+		if err != nil {
+			return nil, fmt.Errorf("Cannot connect to workspace: %s", err)
+		}
+		if data[name], err = QueryTableData(db, table); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// clearInsertedRows deletes all rows from tables affected by logicalSchema's
+// Inserts. This must be done before cleaning up the workspace, since workspace
+// cleanup refuses to drop tables that contain rows.
+func clearInsertedRows(ws Workspace, logicalSchema *fs.LogicalSchema, opts Options) error {
+	cleared := make(map[string]bool)
+	for _, stmt := range logicalSchema.Inserts {
+		if cleared[stmt.ObjectName] {
+			continue
+		}
+		cleared[stmt.ObjectName] = true
+		db, err := ws.ConnectionPool(paramsForStatement(stmt, opts))
+		if err != nil {
+			return fmt.Errorf("Cannot connect to workspace: %s", err)
+		}
+		_, err = db.Exec("DELETE FROM " + tengo.EscapeIdentifier(stmt.ObjectName))
+		if err != nil && !tengo.IsDatabaseError(err, mysqlerr.ER_NO_SUCH_TABLE) {
+			return fmt.Errorf("Cannot remove rows from workspace table %s: %s", tengo.EscapeIdentifier(stmt.ObjectName), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package workspace
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/tengo"
+)
+
+// DedicatedHost is a Workspace that exists as a uniquely-named schema on a
+// separate database instance reserved for workspace usage, such as a shared CI
+// database server. Since each workspace has a unique schema name, concurrent
+// processes using the same server never collide. The schema is dropped when
+// done interacting with the workspace.
+type DedicatedHost struct {
+	schemaName  string
+	concurrency int
+	skipBinlog  bool
+	inst        *tengo.Instance
+}
+
+// cleanedHosts tracks which dedicated workspace hosts have already had their
+// abandoned schemas dropped by this process.
+var cleanedHosts sync.Map
+
+// NewDedicatedHost creates a uniquely-named schema on opts.Instance and returns
+// it. If opts.Flavor is known, the instance's flavor must be from the same
+// family, to ensure the workspace behaves like the target database. If
+// opts.MaxSchemaAge is positive, any abandoned workspace schemas older than
+// that duration are dropped first, at most once per process per instance.
+func NewDedicatedHost(opts Options) (*DedicatedHost, error) {
+	if opts.Instance == nil {
+		return nil, errors.New("No workspace host defined in options")
+	}
+	dh := &DedicatedHost{
+		inst:        opts.Instance,
+		concurrency: opts.Concurrency,
+		skipBinlog:  opts.SkipBinlog,
+	}
+	if ok, err := dh.inst.CanConnect(); !ok {
+		return nil, fmt.Errorf("Unable to connect to workspace host %s: %s", dh.inst, err)
+	}
+	if opts.Flavor.Known() && dh.inst.Flavor().Family() != opts.Flavor.Family() {
+		return nil, fmt.Errorf("Workspace host %s has flavor %s, which does not match flavor %s", dh.inst, dh.inst.Flavor(), opts.Flavor)
+	}
+
+	if opts.MaxSchemaAge > 0 {
+		if _, already := cleanedHosts.LoadOrStore(dh.inst.String(), true); !already {
+			dh.dropAbandonedSchemas(opts.SchemaName, opts.MaxSchemaAge)
+		}
+	}
+
+	var err error
+	if dh.schemaName, err = uniqueSchemaName(opts.SchemaName, time.Now()); err != nil {
+		return nil, err
+	}
+	createOpts := tengo.SchemaCreationOptions{
+		DefaultCharSet:   opts.DefaultCharacterSet,
+		DefaultCollation: opts.DefaultCollation,
+		SkipBinlog:       opts.SkipBinlog,
+	}
+	if _, err := dh.inst.CreateSchema(dh.schemaName, createOpts); err != nil {
+		return nil, fmt.Errorf("Cannot create workspace schema on %s: %s", dh.inst, err)
+	}
+	return dh, nil
+}
+
+// uniqueSchemaName returns a schema name beginning with prefix, which encodes
+// the creation time and a random suffix.
+func uniqueSchemaName(prefix string, created time.Time) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s_%d_%x", prefix, created.Unix(), suffix)
+	if len(name) > 64 {
+		return "", fmt.Errorf("temp-schema value %q is too long for use with workspace=dedicated-host", prefix)
+	}
+	return name, nil
+}
+
+// parseSchemaCreationTime returns the creation time encoded in a schema name
+// previously generated by uniqueSchemaName with the same prefix. If the name
+// was not generated in this manner, ok will be false.
+func parseSchemaCreationTime(prefix, name string) (created time.Time, ok bool) {
+	if !strings.HasPrefix(name, prefix+"_") {
+		return
+	}
+	parts := strings.Split(name[len(prefix)+1:], "_")
+	if len(parts) != 2 || len(parts[1]) != 8 {
+		return
+	}
+	if _, err := strconv.ParseUint(parts[1], 16, 32); err != nil {
+		return
+	}
+	unixTime, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return
+	}
+	return time.Unix(unixTime, 0), true
+}
+
+// dropAbandonedSchemas drops workspace schemas that were created more than
+// maxAge ago, for example by a process that was killed before it could clean
+// up. Errors are logged but otherwise ignored, since another process may be
+// concurrently dropping the same schemas.
+func (dh *DedicatedHost) dropAbandonedSchemas(prefix string, maxAge time.Duration) {
+	names, err := dh.inst.SchemaNames()
+	if err != nil {
+		log.WithField("instance", dh.inst.String()).Warnf("Unable to list schemas on workspace host %s: %s", dh.inst, err)
+		return
+	}
+	dropOpts := tengo.BulkDropOptions{
+		MaxConcurrency: dh.concurrency,
+		OnlyIfEmpty:    true,
+		SkipBinlog:     dh.skipBinlog,
+	}
+	for _, name := range names {
+		if created, ok := parseSchemaCreationTime(prefix, name); ok && time.Since(created) > maxAge {
+			entry := log.WithFields(log.Fields{"instance": dh.inst.String(), "schema": name})
+			if err := dh.inst.DropSchema(name, dropOpts); err != nil {
+				entry.Warnf("Unable to drop abandoned workspace schema %s on %s: %s", name, dh.inst, err)
+			} else {
+				entry.Debugf("Dropped abandoned workspace schema %s on %s", name, dh.inst)
+			}
+		}
+	}
+}
+
+// ConnectionPool returns a connection pool (*sqlx.DB) to the workspace schema,
+// using the supplied connection params (which may be blank).
+func (dh *DedicatedHost) ConnectionPool(params string) (*sqlx.DB, error) {
+	return dh.inst.Connect(dh.schemaName, params)
+}
+
+// IntrospectSchema introspects and returns the workspace schema.
+func (dh *DedicatedHost) IntrospectSchema() (*tengo.Schema, error) {
+	return dh.inst.Schema(dh.schemaName)
+}
+
+// Cleanup drops the workspace schema. If any tables have any rows, the cleanup
+// aborts and an error is returned.
+func (dh *DedicatedHost) Cleanup() error {
+	if dh.schemaName == "" {
+		return errors.New("Cleanup() called multiple times on same DedicatedHost")
+	}
+	dropOpts := tengo.BulkDropOptions{
+		MaxConcurrency: dh.concurrency,
+		OnlyIfEmpty:    true,
+		SkipBinlog:     dh.skipBinlog,
+	}
+	err := dh.inst.DropSchema(dh.schemaName, dropOpts)
+	dh.schemaName = ""
+	if err != nil {
+		return fmt.Errorf("Cannot drop workspace schema on %s: %s", dh.inst, err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package workspace
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skeema/tengo"
+)
+
+func (s WorkspaceIntegrationSuite) TestDedicatedHost(t *testing.T) {
+	opts := Options{
+		Type:                TypeDedicatedHost,
+		CleanupAction:       CleanupActionDrop,
+		Instance:            s.d.Instance,
+		Flavor:              s.d.Flavor(),
+		SchemaName:          "_skeema_tmp",
+		DefaultCharacterSet: "latin1",
+		DefaultCollation:    "latin1_swedish_ci",
+		Concurrency:         5,
+		MaxSchemaAge:        time.Hour,
+	}
+
+	// Simulate an abandoned workspace schema from 2 hours ago, as well as a
+	// recent one from a concurrent process; only the former should be dropped
+	abandonedName, _ := uniqueSchemaName(opts.SchemaName, time.Now().Add(-2*time.Hour))
+	recentName, _ := uniqueSchemaName(opts.SchemaName, time.Now().Add(-time.Minute))
+	for _, name := range []string{abandonedName, recentName} {
+		if _, err := s.d.CreateSchema(name, tengo.SchemaCreationOptions{}); err != nil {
+			t.Fatalf("Unexpected error from CreateSchema: %s", err)
+		}
+	}
+	cleanedHosts.Delete(s.d.Instance.String())
+
+	ws1, err := NewDedicatedHost(opts)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewDedicatedHost: %s", err)
+	}
+	ws2, err := NewDedicatedHost(opts)
+	if err != nil {
+		t.Fatalf("Unexpected error from NewDedicatedHost: %s", err)
+	}
+	if ws1.schemaName == ws2.schemaName || !strings.HasPrefix(ws1.schemaName, opts.SchemaName+"_") {
+		t.Errorf("Unexpected schema names %s and %s", ws1.schemaName, ws2.schemaName)
+	}
+	if has, err := s.d.HasSchema(abandonedName); has || err != nil {
+		t.Errorf("Expected abandoned schema %s to be dropped; has=%t err=%v", abandonedName, has, err)
+	}
+	if has, err := s.d.HasSchema(recentName); !has || err != nil {
+		t.Errorf("Expected recent schema %s to be retained; has=%t err=%v", recentName, has, err)
+	}
+
+	db, err := ws1.ConnectionPool("")
+	if err != nil {
+		t.Fatalf("Unexpected error from ConnectionPool: %s", err)
+	}
+	if _, err := db.Exec("CREATE TABLE foo (id int)"); err != nil {
+		t.Fatalf("Unexpected error creating table: %s", err)
+	}
+	if schema, err := ws1.IntrospectSchema(); err != nil || !schema.HasTable("foo") {
+		t.Errorf("Unexpected result from IntrospectSchema: %v / %v", schema, err)
+	}
+	if schema, err := ws2.IntrospectSchema(); err != nil || schema.HasTable("foo") {
+		t.Errorf("Unexpected result from IntrospectSchema: %v / %v", schema, err)
+	}
+	for _, ws := range []*DedicatedHost{ws1, ws2} {
+		name := ws.schemaName
+		if err := ws.Cleanup(); err != nil {
+			t.Errorf("Unexpected error from Cleanup: %s", err)
+		}
+		if has, err := s.d.HasSchema(name); has || err != nil {
+			t.Errorf("Expected schema %s to be dropped; has=%t err=%v", name, has, err)
+		}
+		if err := ws.Cleanup(); err == nil {
+			t.Error("Expected repeated Cleanup to return an error, but it did not")
+		}
+	}
+	if err := s.d.DropSchema(recentName, tengo.BulkDropOptions{}); err != nil {
+		t.Errorf("Unexpected error from DropSchema: %s", err)
+	}
+
+	// Flavor mismatch should be an error
+	if s.d.Flavor().Vendor == tengo.VendorMariaDB {
+		opts.Flavor = tengo.NewFlavor("mysql:5.7")
+	} else {
+		opts.Flavor = tengo.NewFlavor("mariadb:10.3")
+	}
+	if _, err := NewDedicatedHost(opts); err == nil {
+		t.Error("Expected flavor mismatch error, but err was nil")
+	}
+}
+
+func TestDedicatedHostNilInstance(t *testing.T) {
+	opts := Options{
+		Type:       TypeDedicatedHost,
+		SchemaName: "_skeema_tmp",
+	}
+	if _, err := NewDedicatedHost(opts); err == nil {
+		t.Error("Expected error from NewDedicatedHost with nil instance, but err was nil")
+	}
+}
+
+func TestUniqueSchemaName(t *testing.T) {
+	created := time.Unix(1600000000, 0)
+	name, err := uniqueSchemaName("_skeema_tmp", created)
+	if err != nil {
+		t.Fatalf("Unexpected error from uniqueSchemaName: %s", err)
+	}
+	if !strings.HasPrefix(name, "_skeema_tmp_1600000000_") || len(name) != len("_skeema_tmp_1600000000_")+8 {
+		t.Errorf("Unexpected name %s", name)
+	}
+	if other, _ := uniqueSchemaName("_skeema_tmp", created); other == name {
+		t.Errorf("Expected unique names, but both were %s", name)
+	}
+	if parsed, ok := parseSchemaCreationTime("_skeema_tmp", name); !ok || !parsed.Equal(created) {
+		t.Errorf("Unexpected result from parseSchemaCreationTime: %s / %t", parsed, ok)
+	}
+	if _, err := uniqueSchemaName(strings.Repeat("x", 50), created); err == nil {
+		t.Error("Expected error from excessively long prefix, but err was nil")
+	}
+
+	for _, name := range []string{"_skeema_tmp", "_skeema_tmp_1600000000", "_skeema_tmp_1600000000_zzzzzzzz", "_skeema_tmp_abc_0badf00d", "other_1600000000_0badf00d", "_skeema_tmp_1600000000_0badf00d_x"} {
+		if _, ok := parseSchemaCreationTime("_skeema_tmp", name); ok {
+			t.Errorf("Expected parseSchemaCreationTime to reject %s, but it did not", name)
+		}
+	}
+}
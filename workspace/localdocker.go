@@ -3,10 +3,13 @@ package workspace
 import (
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"net/url"
+	"regexp"
 	"strings"
 	"sync"
 
+	docker "github.com/fsouza/go-dockerclient"
 	"github.com/jmoiron/sqlx"
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/tengo"
@@ -26,24 +29,32 @@ type LocalDocker struct {
 
 var cstore struct {
 	dockerClient *tengo.DockerClient
+	apiClient    *docker.Client // only used for containers with a custom config
 	containers   map[string]*tengo.DockerizedInstance
+	inUse        map[string]int // number of active LocalDocker workspaces per container name
 	sync.Mutex
 }
 
 // NewLocalDocker finds or creates a containerized MySQL instance, creates a
-// temporary schema on it, and returns it.
+// temporary schema on it, and returns it. If opts.PoolSize is greater than 1,
+// up to that many containers may be used for the same image, allowing
+// workspaces to be used concurrently without contending for the same
+// container. Additional containers are only created when all existing ones
+// are in use, unless opts.WarmPool is true, in which case all containers in the
+// pool are started upon first use.
 func NewLocalDocker(opts Options) (ld *LocalDocker, err error) {
 	if !opts.Flavor.Supported() {
 		return nil, fmt.Errorf("NewLocalDocker: unsupported flavor %s", opts.Flavor)
 	}
 
 	cstore.Lock()
-	defer cstore.Unlock()
 	if cstore.dockerClient == nil {
 		if cstore.dockerClient, err = tengo.NewDockerClient(tengo.DockerClientOptions{}); err != nil {
+			cstore.Unlock()
 			return
 		}
 		cstore.containers = make(map[string]*tengo.DockerizedInstance)
+		cstore.inUse = make(map[string]int)
 		tengo.UseFilteredDriverLogger()
 	}
 
@@ -52,37 +63,47 @@ func NewLocalDocker(opts Options) (ld *LocalDocker, err error) {
 		cleanupAction:     opts.CleanupAction,
 		defaultConnParams: opts.DefaultConnParams,
 	}
-	image := opts.Flavor.String()
+	image := opts.Image
+	if image == "" {
+		image = opts.Flavor.String()
+	}
 	if opts.ContainerName == "" {
-		opts.ContainerName = fmt.Sprintf("skeema-%s", strings.Replace(image, ":", "-", -1))
+		opts.ContainerName = ContainerNameForImage(image) + containerConfigSuffix(opts.ServerArgs, opts.Tmpfs)
+	}
+	if opts.WarmPool && opts.PoolSize > 1 && cstore.containers[opts.ContainerName] == nil {
+		if err = warmPool(opts, image); err != nil {
+			cstore.Unlock()
+			return nil, err
+		}
 	}
-	if cstore.containers[opts.ContainerName] != nil {
-		ld.d = cstore.containers[opts.ContainerName]
+	containerName := pickContainerName(opts.ContainerName, opts.PoolSize, cstore.containers, cstore.inUse)
+	if cstore.containers[containerName] != nil {
+		ld.d = cstore.containers[containerName]
 	} else {
-		log.Infof("Using container %s (image=%s) for workspace operations", opts.ContainerName, image)
-		ld.d, err = cstore.dockerClient.GetOrCreateInstance(tengo.DockerizedInstanceOptions{
-			Name:              opts.ContainerName,
-			Image:             image,
-			RootPassword:      opts.RootPassword,
-			DefaultConnParams: "", // intentionally not set here; see important comment in ConnectionPool()
-		})
+		log.WithFields(log.Fields{"container": containerName, "image": image}).Infof("Using container %s (image=%s) for workspace operations", containerName, image)
+		ld.d, err = getOrCreateContainer(containerName, image, opts)
 		if ld.d != nil {
-			cstore.containers[opts.ContainerName] = ld.d
+			cstore.containers[containerName] = ld.d
 			RegisterShutdownFunc(ld.shutdown)
 		}
 		if err != nil {
+			cstore.Unlock()
 			return nil, err
 		}
 	}
+	cstore.inUse[containerName]++
+	cstore.Unlock()
 
 	lockName := fmt.Sprintf("skeema.%s", ld.schemaName)
 	if ld.releaseLock, err = getLock(ld.d.Instance, lockName, opts.LockWaitTimeout); err != nil {
+		ld.release()
 		return nil, fmt.Errorf("Unable to obtain lock on %s: %s", ld.d.Instance, err)
 	}
-	// If this function errors, don't continue to hold the lock
+	// If this function errors, don't continue to hold the lock or container
 	defer func() {
 		if err != nil {
 			ld.releaseLock()
+			ld.release()
 			ld = nil
 		}
 	}()
@@ -115,6 +136,209 @@ func NewLocalDocker(opts Options) (ld *LocalDocker, err error) {
 	return ld, nil
 }
 
+// getOrCreateContainer returns the container with the supplied name, creating
+// it if it does not exist yet. The caller must hold the lock on cstore.
+func getOrCreateContainer(name, image string, opts Options) (*tengo.DockerizedInstance, error) {
+	tengoOpts := tengo.DockerizedInstanceOptions{
+		Name:              name,
+		Image:             image,
+		RootPassword:      opts.RootPassword,
+		DefaultConnParams: "", // intentionally not set here; see important comment in ConnectionPool()
+	}
+	if !needsCustomContainer(image, opts) {
+		return cstore.dockerClient.GetOrCreateInstance(tengoOpts)
+	}
+
+	// tengo.DockerClient cannot pass a command or host config to new containers,
+	// and does not properly pull images from registries with a port number, so
+	// these containers are created here instead. tengo then takes care of
+	// starting the container and waiting for the server to accept connections.
+	d, err := cstore.dockerClient.GetInstance(tengoOpts)
+	if _, ok := err.(*docker.NoSuchContainer); !ok {
+		return d, err
+	}
+	if err := createContainer(name, image, opts); err != nil {
+		return nil, err
+	}
+	return cstore.dockerClient.GetInstance(tengoOpts)
+}
+
+// needsCustomContainer returns true if a container for image and opts must be
+// created by createContainer, rather than by tengo.DockerClient. This is the
+// case for any non-default image, as well as any use of opts.ServerArgs or
+// opts.Tmpfs.
+func needsCustomContainer(image string, opts Options) bool {
+	return image != opts.Flavor.String() || len(opts.ServerArgs) > 0 || opts.Tmpfs
+}
+
+// createContainer creates, but does not start, a container using opts.ServerArgs
+// as arguments to the database server, and with its data directory on a tmpfs
+// mount if opts.Tmpfs is true. The caller must hold the lock on cstore.
+func createContainer(name, image string, opts Options) (err error) {
+	if cstore.apiClient == nil {
+		if cstore.apiClient, err = docker.NewClientFromEnv(); err != nil {
+			return err
+		}
+	}
+	if _, err := cstore.apiClient.InspectImage(image); err != nil {
+		repository, tag := splitImageTag(image)
+		pullOpts := docker.PullImageOptions{Repository: repository, Tag: tag}
+		if err := cstore.apiClient.PullImage(pullOpts, docker.AuthConfiguration{}); err != nil {
+			return err
+		}
+	}
+	env := []string{"MYSQL_ALLOW_EMPTY_PASSWORD=1"}
+	if opts.RootPassword != "" {
+		env = []string{"MYSQL_ROOT_PASSWORD=" + opts.RootPassword}
+	}
+	hostConfig := &docker.HostConfig{
+		PortBindings: map[docker.Port][]docker.PortBinding{
+			"3306/tcp": {{HostIP: "127.0.0.1"}},
+		},
+	}
+	if opts.Tmpfs {
+		hostConfig.Tmpfs = map[string]string{"/var/lib/mysql": ""}
+	}
+	_, err = cstore.apiClient.CreateContainer(docker.CreateContainerOptions{
+		Name: name,
+		Config: &docker.Config{
+			Image: image,
+			Env:   env,
+			Cmd:   opts.ServerArgs, // the official images' entrypoint passes args beginning with "-" to the server
+		},
+		HostConfig: hostConfig,
+	})
+	return err
+}
+
+// warmPool starts all of the containers in the pool for opts concurrently,
+// rather than waiting for them to be needed. The first container is started
+// before the others, so that the image is only pulled once if necessary. The
+// caller must hold the lock on cstore.
+func warmPool(opts Options, image string) error {
+	names := make([]string, opts.PoolSize)
+	for n := range names {
+		names[n] = poolContainerName(opts.ContainerName, n+1)
+	}
+	log.WithFields(log.Fields{"container": opts.ContainerName, "image": image}).Infof("Starting %d containers (image=%s) for workspace operations", len(names), image)
+	results := make([]*tengo.DockerizedInstance, len(names))
+	errs := make([]error, len(names))
+	results[0], errs[0] = getOrCreateContainer(names[0], image, opts)
+	if errs[0] == nil {
+		var wg sync.WaitGroup
+		for n := 1; n < len(names); n++ {
+			wg.Add(1)
+			go func(n int) {
+				defer wg.Done()
+				results[n], errs[n] = getOrCreateContainer(names[n], image, opts)
+			}(n)
+		}
+		wg.Wait()
+	}
+	for n, d := range results {
+		if d != nil {
+			cstore.containers[names[n]] = d
+			owner := &LocalDocker{d: d, cleanupAction: opts.CleanupAction}
+			RegisterShutdownFunc(owner.shutdown)
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// poolContainerName returns the name of the nth container in a pool, where n
+// starts at 1. The first container in the pool is named baseName, and
+// subsequent ones have a numeric suffix.
+func poolContainerName(baseName string, n int) string {
+	if n > 1 {
+		return fmt.Sprintf("%s-%d", baseName, n)
+	}
+	return baseName
+}
+
+// pickContainerName returns the name of the container to use for a new
+// workspace, from a pool of up to poolSize containers. The first container in
+// the pool is named baseName, and subsequent ones have a numeric suffix. An
+// existing idle container is preferred; otherwise a new container is added to
+// the pool if it is not yet full; otherwise the least-busy existing container
+// is used.
+func pickContainerName(baseName string, poolSize int, containers map[string]*tengo.DockerizedInstance, inUse map[string]int) string {
+	var leastBusy, firstMissing string
+	for n := 1; n <= poolSize || n == 1; n++ {
+		name := poolContainerName(baseName, n)
+		if containers[name] == nil {
+			if firstMissing == "" {
+				firstMissing = name
+			}
+		} else if inUse[name] == 0 {
+			return name
+		} else if leastBusy == "" || inUse[name] < inUse[leastBusy] {
+			leastBusy = name
+		}
+	}
+	if firstMissing != "" {
+		return firstMissing
+	}
+	return leastBusy
+}
+
+// release marks the workspace's container as no longer in use by this
+// workspace.
+func (ld *LocalDocker) release() {
+	cstore.Lock()
+	defer cstore.Unlock()
+	if cstore.inUse[ld.d.Name] > 0 {
+		cstore.inUse[ld.d.Name]--
+	}
+}
+
+// DockerImage returns the Docker image to use for workspaces of the supplied
+// flavor. If template is blank, the image is simply the flavor string, for
+// example "mysql:5.7", which corresponds to an image on DockerHub. Otherwise,
+// template is returned with any {VENDOR}, {VERSION}, or {FLAVOR} placeholders
+// replaced with the corresponding portion of the flavor string. This permits
+// use of registry mirrors or custom images, for example
+// "registry.example.com/{VENDOR}:{VERSION}".
+func DockerImage(template string, flavor tengo.Flavor) string {
+	if template == "" {
+		return flavor.String()
+	}
+	vendor, version := flavor.String(), ""
+	if parts := strings.SplitN(vendor, ":", 2); len(parts) == 2 {
+		vendor, version = parts[0], parts[1]
+	}
+	r := strings.NewReplacer("{VENDOR}", vendor, "{VERSION}", version, "{FLAVOR}", flavor.String())
+	return r.Replace(template)
+}
+
+var containerNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// ContainerNameForImage returns the name of the container that Skeema manages
+// for the supplied image. For example, image "mysql:5.7" corresponds to a
+// container called "skeema-mysql-5.7".
+func ContainerNameForImage(image string) string {
+	return "skeema-" + containerNameInvalidChars.ReplaceAllString(image, "-")
+}
+
+// containerConfigSuffix returns a suffix for the names of containers using the
+// supplied server args or tmpfs setting, so that containers with differing
+// configurations are kept separate. If neither is used, the suffix is blank,
+// and the container name is based solely on the image.
+func containerConfigSuffix(serverArgs []string, tmpfs bool) string {
+	if len(serverArgs) == 0 && !tmpfs {
+		return ""
+	}
+	config := strings.Join(serverArgs, " ")
+	if tmpfs {
+		config += " tmpfs"
+	}
+	return fmt.Sprintf("-%08x", crc32.ChecksumIEEE([]byte(config)))
+}
+
 // ConnectionPool returns a connection pool (*sqlx.DB) to the temporary
 // workspace schema, using the supplied connection params (which may be blank).
 func (ld *LocalDocker) ConnectionPool(params string) (*sqlx.DB, error) {
@@ -161,6 +385,7 @@ func (ld *LocalDocker) Cleanup() error {
 	defer func() {
 		ld.releaseLock()
 		ld.releaseLock = nil
+		ld.release()
 	}()
 
 	dropOpts := tengo.BulkDropOptions{
@@ -188,12 +413,47 @@ func (ld *LocalDocker) shutdown(args ...interface{}) bool {
 	defer cstore.Unlock()
 
 	if ld.cleanupAction == CleanupActionStop {
-		log.Infof("Stopping container %s", ld.d.Name)
+		log.WithField("container", ld.d.Name).Infof("Stopping container %s", ld.d.Name)
 		ld.d.Stop()
 	} else if ld.cleanupAction == CleanupActionDestroy {
-		log.Infof("Destroying container %s", ld.d.Name)
+		log.WithField("container", ld.d.Name).Infof("Destroying container %s", ld.d.Name)
 		ld.d.Destroy()
 	}
 	delete(cstore.containers, ld.d.Name)
+	delete(cstore.inUse, ld.d.Name)
 	return true
 }
+
+// dockerImageID returns the ID of the local copy of image, pulling the image
+// first if it is not already present.
+func dockerImageID(image string) (string, error) {
+	cstore.Lock()
+	defer cstore.Unlock()
+	if cstore.apiClient == nil {
+		var err error
+		if cstore.apiClient, err = docker.NewClientFromEnv(); err != nil {
+			return "", err
+		}
+	}
+	info, err := cstore.apiClient.InspectImage(image)
+	if err == docker.ErrNoSuchImage {
+		repository, tag := splitImageTag(image)
+		pullOpts := docker.PullImageOptions{Repository: repository, Tag: tag}
+		if err = cstore.apiClient.PullImage(pullOpts, docker.AuthConfiguration{}); err == nil {
+			info, err = cstore.apiClient.InspectImage(image)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return info.ID, nil
+}
+
+// splitImageTag splits an image reference into its repository and tag. If no
+// tag is present, "latest" is returned as the tag.
+func splitImageTag(image string) (repository, tag string) {
+	if pos := strings.LastIndex(image, ":"); pos > strings.LastIndex(image, "/") {
+		return image[:pos], image[pos+1:]
+	}
+	return image, "latest"
+}
@@ -193,3 +193,116 @@ func (s WorkspaceIntegrationSuite) TestLocalDockerConnParams(t *testing.T) {
 		t.Errorf("Unexpected error from cleanup: %s", err)
 	}
 }
+
+func TestDockerImage(t *testing.T) {
+	cases := []struct {
+		template string
+		flavor   string
+		expected string
+	}{
+		{"", "mysql:5.7", "mysql:5.7"},
+		{"", "mariadb:10.3", "mariadb:10.3"},
+		{"mirror.example.com/library/{FLAVOR}", "percona:8.0", "mirror.example.com/library/percona:8.0"},
+		{"registry.example.com:5000/{VENDOR}-custom:{VERSION}", "mysql:8.0", "registry.example.com:5000/mysql-custom:8.0"},
+		{"my-mysql-image", "mysql:5.6", "my-mysql-image"},
+	}
+	for _, c := range cases {
+		if actual := DockerImage(c.template, tengo.NewFlavor(c.flavor)); actual != c.expected {
+			t.Errorf("Expected DockerImage(%q, %s) to return %q, instead found %q", c.template, c.flavor, c.expected, actual)
+		}
+	}
+
+	names := map[string]string{
+		"mysql:5.7":                           "skeema-mysql-5.7",
+		"registry.example.com:5000/mysql:8.0": "skeema-registry.example.com-5000-mysql-8.0",
+		"mirror.example.com/library/mariadb":  "skeema-mirror.example.com-library-mariadb",
+	}
+	for image, expected := range names {
+		if actual := ContainerNameForImage(image); actual != expected {
+			t.Errorf("Expected ContainerNameForImage(%q) to return %q, instead found %q", image, expected, actual)
+		}
+	}
+}
+
+func TestContainerConfigSuffix(t *testing.T) {
+	if suffix := containerConfigSuffix(nil, false); suffix != "" {
+		t.Errorf("Expected blank suffix for default config, instead found %q", suffix)
+	}
+	suffixes := map[string]bool{}
+	for _, suffix := range []string{
+		containerConfigSuffix(nil, true),
+		containerConfigSuffix([]string{"--lower-case-table-names=1"}, false),
+		containerConfigSuffix([]string{"--lower-case-table-names=1"}, true),
+		containerConfigSuffix([]string{"--innodb-page-size=4k"}, true),
+	} {
+		if len(suffix) != 9 || suffix[0] != '-' || suffixes[suffix] {
+			t.Errorf("Unexpected or duplicate suffix %q", suffix)
+		}
+		suffixes[suffix] = true
+	}
+}
+
+func TestSplitImageTag(t *testing.T) {
+	cases := map[string][2]string{
+		"mysql:8.0":                              {"mysql", "8.0"},
+		"mysql":                                  {"mysql", "latest"},
+		"registry.example.com/mysql:5.7":         {"registry.example.com/mysql", "5.7"},
+		"registry.example.com:5000/mysql:8.0":    {"registry.example.com:5000/mysql", "8.0"},
+		"registry.example.com:5000/percona":      {"registry.example.com:5000/percona", "latest"},
+		"localhost:5000/team/mariadb:10.6-focal": {"localhost:5000/team/mariadb", "10.6-focal"},
+	}
+	for image, expected := range cases {
+		if repository, tag := splitImageTag(image); repository != expected[0] || tag != expected[1] {
+			t.Errorf("Expected splitImageTag(%q) to return %q, %q; instead found %q, %q", image, expected[0], expected[1], repository, tag)
+		}
+	}
+}
+
+func TestNeedsCustomContainer(t *testing.T) {
+	opts := Options{Flavor: tengo.NewFlavor("mysql:8.0")}
+	if needsCustomContainer("mysql:8.0", opts) {
+		t.Error("Expected default image to not need a custom container")
+	}
+	if !needsCustomContainer("registry.example.com:5000/mysql:8.0", opts) {
+		t.Error("Expected image from registry with port to need a custom container")
+	}
+	opts.Tmpfs = true
+	if !needsCustomContainer("mysql:8.0", opts) {
+		t.Error("Expected tmpfs to need a custom container")
+	}
+	opts.Tmpfs, opts.ServerArgs = false, []string{"--lower-case-table-names=1"}
+	if !needsCustomContainer("mysql:8.0", opts) {
+		t.Error("Expected server args to need a custom container")
+	}
+}
+
+func TestPickContainerName(t *testing.T) {
+	containers := make(map[string]*tengo.DockerizedInstance)
+	inUse := make(map[string]int)
+	use := func(poolSize int, expected string) {
+		t.Helper()
+		name := pickContainerName("skeema-mysql-5.7", poolSize, containers, inUse)
+		if name != expected {
+			t.Errorf("Expected pickContainerName to return %s, instead found %s", expected, name)
+		}
+		if containers[name] == nil {
+			containers[name] = &tengo.DockerizedInstance{}
+		}
+		inUse[name]++
+	}
+
+	// With a pool size of 1 (or invalid lower values), the base name is always
+	// used
+	use(0, "skeema-mysql-5.7")
+	use(1, "skeema-mysql-5.7")
+
+	// With a larger pool, additional containers are only added when all existing
+	// ones are busy, and then the least-busy one is used once the pool is full
+	use(3, "skeema-mysql-5.7-2")
+	use(3, "skeema-mysql-5.7-3")
+	use(3, "skeema-mysql-5.7-2")
+	inUse["skeema-mysql-5.7"] = 0
+	use(3, "skeema-mysql-5.7")
+	inUse["skeema-mysql-5.7-3"] = 0
+	use(3, "skeema-mysql-5.7-3")
+}
@@ -0,0 +1,272 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// Offline is a Workspace which does not require any database server. Instead
+// of executing statements, it parses CREATE TABLE, CREATE PROCEDURE, and
+// CREATE FUNCTION statements directly, and builds a tengo.Schema whose
+// CreateStatement values are normalized to match what SHOW CREATE would return
+// in the configured flavor. Any statement using features that cannot be
+// modeled this way is reported as a failure, rather than silently producing an
+// inaccurate schema.
+type Offline struct {
+	schemaName string
+	charSet    string
+	collation  string
+	flavor     tengo.Flavor
+	tables     map[string]*tengo.Table
+	routines   map[tengo.ObjectKey]*tengo.Routine
+}
+
+// NewOffline returns a new offline workspace. The supplied options must
+// include a supported flavor.
+func NewOffline(opts Options) (*Offline, error) {
+	if !opts.Flavor.Supported() {
+		return nil, fmt.Errorf("NewOffline: unsupported flavor %s", opts.Flavor)
+	}
+	o := &Offline{
+		schemaName: opts.SchemaName,
+		charSet:    opts.DefaultCharacterSet,
+		collation:  opts.DefaultCollation,
+		flavor:     opts.Flavor,
+		tables:     make(map[string]*tengo.Table),
+		routines:   make(map[tengo.ObjectKey]*tengo.Routine),
+	}
+	if o.charSet == "" && o.collation == "" {
+		if opts.Flavor.HasDataDictionary() {
+			o.charSet = "utf8mb4"
+		} else {
+			o.charSet = "latin1"
+		}
+	}
+	var err error
+	if o.charSet, o.collation, err = o.resolveCharSet(o.charSet, o.collation); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// ConnectionPool always returns an error for an offline workspace, since no
+// database server is involved.
+func (o *Offline) ConnectionPool(params string) (*sqlx.DB, error) {
+	return nil, errors.New("Offline workspace does not support database connections")
+}
+
+// IntrospectSchema returns a *tengo.Schema representing all statements
+// successfully processed by the workspace so far.
+func (o *Offline) IntrospectSchema() (*tengo.Schema, error) {
+	schema := &tengo.Schema{
+		Name:      o.schemaName,
+		CharSet:   o.charSet,
+		Collation: o.collation,
+		Tables:    make([]*tengo.Table, 0, len(o.tables)),
+		Routines:  make([]*tengo.Routine, 0, len(o.routines)),
+	}
+	for _, t := range o.tables {
+		schema.Tables = append(schema.Tables, t)
+	}
+	for _, r := range o.routines {
+		schema.Routines = append(schema.Routines, r)
+	}
+	sort.Slice(schema.Tables, func(i, j int) bool {
+		return schema.Tables[i].Name < schema.Tables[j].Name
+	})
+	sort.Slice(schema.Routines, func(i, j int) bool {
+		if schema.Routines[i].Type != schema.Routines[j].Type {
+			return schema.Routines[i].Type < schema.Routines[j].Type
+		}
+		return schema.Routines[i].Name < schema.Routines[j].Name
+	})
+	return schema, nil
+}
+
+// Cleanup discards all objects in the workspace.
+func (o *Offline) Cleanup() error {
+	o.tables = make(map[string]*tengo.Table)
+	o.routines = make(map[tengo.ObjectKey]*tengo.Routine)
+	return nil
+}
+
+// Exec processes a single CREATE statement, adding the corresponding object
+// to the workspace. An error is returned if the statement cannot be modeled.
+func (o *Offline) Exec(stmt *fs.Statement) error {
+	if stmt.Type != fs.StatementTypeCreate {
+		return errors.New("only CREATE statements are supported")
+	}
+	switch stmt.ObjectType {
+	case tengo.ObjectTypeTable:
+		if _, already := o.tables[stmt.ObjectName]; already {
+			return fmt.Errorf("Table %s already exists", tengo.EscapeIdentifier(stmt.ObjectName))
+		}
+		t, err := o.parseCreateTable(stmt.Body())
+		if err != nil {
+			return err
+		}
+		o.tables[t.Name] = t
+	case tengo.ObjectTypeProc, tengo.ObjectTypeFunc:
+		key := stmt.ObjectKey()
+		if _, already := o.routines[key]; already {
+			return fmt.Errorf("%s %s already exists", key.Type.Caps(), tengo.EscapeIdentifier(key.Name))
+		}
+		r, err := o.parseCreateRoutine(stmt.Body())
+		if err != nil {
+			return err
+		}
+		o.routines[key] = r
+	default:
+		return fmt.Errorf("%s statements are not supported", stmt.ObjectType.Caps())
+	}
+	return nil
+}
+
+// execLogicalSchema processes all statements in logicalSchema, returning a
+// workspace Schema. Unlike other workspace types, ALTER and INSERT statements
+// cannot be processed, and are always reported as failures.
+func (o *Offline) execLogicalSchema(logicalSchema *fs.LogicalSchema) (*Schema, error) {
+	wsSchema := &Schema{
+		LogicalSchema: logicalSchema,
+		Failures:      []*StatementError{},
+	}
+	statements := make([]*fs.Statement, 0, len(logicalSchema.Creates)+len(logicalSchema.Alters)+len(logicalSchema.Inserts))
+	for _, stmt := range logicalSchema.Creates {
+		statements = append(statements, stmt)
+	}
+	sort.Slice(statements, func(i, j int) bool {
+		return statements[i].Location() < statements[j].Location()
+	})
+	statements = append(statements, logicalSchema.Alters...)
+	statements = append(statements, logicalSchema.Inserts...)
+	for _, stmt := range statements {
+		if err := o.Exec(stmt); err != nil {
+			wsSchema.Failures = append(wsSchema.Failures, &StatementError{
+				Statement: stmt,
+				Err:       fmt.Errorf("Offline workspace cannot process statement: %s", err),
+			})
+		}
+	}
+	var err error
+	wsSchema.Schema, err = o.IntrospectSchema()
+	return wsSchema, err
+}
+
+// defaultCollations maps character sets to their default collations, for all
+// character sets other than utf8mb4, whose default collation varies by flavor.
+var defaultCollations = map[string]string{
+	"armscii8": "armscii8_general_ci",
+	"ascii":    "ascii_general_ci",
+	"big5":     "big5_chinese_ci",
+	"binary":   "binary",
+	"cp1250":   "cp1250_general_ci",
+	"cp1251":   "cp1251_general_ci",
+	"cp1256":   "cp1256_general_ci",
+	"cp1257":   "cp1257_general_ci",
+	"cp850":    "cp850_general_ci",
+	"cp852":    "cp852_general_ci",
+	"cp866":    "cp866_general_ci",
+	"cp932":    "cp932_japanese_ci",
+	"dec8":     "dec8_swedish_ci",
+	"eucjpms":  "eucjpms_japanese_ci",
+	"euckr":    "euckr_korean_ci",
+	"gb18030":  "gb18030_chinese_ci",
+	"gb2312":   "gb2312_chinese_ci",
+	"gbk":      "gbk_chinese_ci",
+	"geostd8":  "geostd8_general_ci",
+	"greek":    "greek_general_ci",
+	"hebrew":   "hebrew_general_ci",
+	"hp8":      "hp8_english_ci",
+	"keybcs2":  "keybcs2_general_ci",
+	"koi8r":    "koi8r_general_ci",
+	"koi8u":    "koi8u_general_ci",
+	"latin1":   "latin1_swedish_ci",
+	"latin2":   "latin2_general_ci",
+	"latin5":   "latin5_turkish_ci",
+	"latin7":   "latin7_general_ci",
+	"macce":    "macce_general_ci",
+	"macroman": "macroman_general_ci",
+	"sjis":     "sjis_japanese_ci",
+	"swe7":     "swe7_swedish_ci",
+	"tis620":   "tis620_thai_ci",
+	"ucs2":     "ucs2_general_ci",
+	"ujis":     "ujis_japanese_ci",
+	"utf16":    "utf16_general_ci",
+	"utf16le":  "utf16le_general_ci",
+	"utf32":    "utf32_general_ci",
+	"utf8":     "utf8_general_ci",
+}
+
+// defaultCollation returns the default collation for charSet in the
+// workspace's flavor, or an empty string if charSet is not recognized.
+func (o *Offline) defaultCollation(charSet string) string {
+	if charSet == "utf8mb4" {
+		return o.flavor.DefaultUtf8mb4Collation()
+	}
+	return defaultCollations[charSet]
+}
+
+// resolveCharSet returns a normalized character set and collation pair, given
+// a character set and/or collation. Either input may be blank, but not both.
+func (o *Offline) resolveCharSet(charSet, collation string) (string, string, error) {
+	charSet, collation = strings.ToLower(charSet), strings.ToLower(collation)
+	if charSet == "utf8mb3" {
+		charSet = "utf8"
+	}
+	if strings.HasPrefix(collation, "utf8mb3_") {
+		collation = "utf8_" + strings.TrimPrefix(collation, "utf8mb3_")
+	}
+	if collation == "" {
+		collation = o.defaultCollation(charSet)
+		if collation == "" {
+			return "", "", fmt.Errorf("Unknown character set %s", charSet)
+		}
+		return charSet, collation, nil
+	}
+
+	// Determine the collation's character set: the longest known character set
+	// name which prefixes the collation name
+	var collationCharSet string
+	if collation == "binary" {
+		collationCharSet = "binary"
+	} else {
+		for cs := range defaultCollations {
+			if strings.HasPrefix(collation, cs+"_") && len(cs) > len(collationCharSet) {
+				collationCharSet = cs
+			}
+		}
+		if strings.HasPrefix(collation, "utf8mb4_") {
+			collationCharSet = "utf8mb4"
+		}
+	}
+	if collationCharSet == "" {
+		return "", "", fmt.Errorf("Unknown collation %s", collation)
+	} else if charSet != "" && charSet != collationCharSet {
+		return "", "", fmt.Errorf("Collation %s is not valid for character set %s", collation, charSet)
+	}
+	return collationCharSet, collation, nil
+}
+
+// defaultSQLMode returns the default global sql_mode of the workspace's
+// flavor. Stored procedures and functions remember the sql_mode in effect at
+// creation time, and other workspace types use the global value when creating
+// them.
+func (o *Offline) defaultSQLMode() string {
+	switch {
+	case o.flavor.VendorMinVersion(tengo.VendorMariaDB, 10, 2):
+		return "STRICT_TRANS_TABLES,ERROR_FOR_DIVISION_BY_ZERO,NO_AUTO_CREATE_USER,NO_ENGINE_SUBSTITUTION"
+	case o.flavor.Vendor == tengo.VendorMariaDB:
+		return "NO_AUTO_CREATE_USER,NO_ENGINE_SUBSTITUTION"
+	case o.flavor.MySQLishMinVersion(8, 0):
+		return "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ZERO_IN_DATE,NO_ZERO_DATE,ERROR_FOR_DIVISION_BY_ZERO,NO_ENGINE_SUBSTITUTION"
+	case o.flavor.MySQLishMinVersion(5, 7):
+		return "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ZERO_IN_DATE,NO_ZERO_DATE,ERROR_FOR_DIVISION_BY_ZERO,NO_AUTO_CREATE_USER,NO_ENGINE_SUBSTITUTION"
+	}
+	return "NO_ENGINE_SUBSTITUTION"
+}
@@ -0,0 +1,248 @@
+package workspace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestOfflineCreateTable(t *testing.T) {
+	cases := []struct {
+		flavor   string
+		input    string
+		expected string
+	}{
+		{
+			flavor: "mysql:5.7",
+			input: `CREATE TABLE posts (
+				id int unsigned NOT NULL AUTO_INCREMENT,
+				author_id integer NOT NULL,
+				title varchar(80) CHARACTER SET utf8mb4 NOT NULL DEFAULT '',
+				body text, -- comment here
+				score decimal(5,2) DEFAULT 1.5,
+				flags bit(3) DEFAULT 5,
+				status enum('draft','live') NOT NULL DEFAULT 'DRAFT',
+				created_at timestamp NOT NULL,
+				updated_at datetime(3) NULL ON UPDATE CURRENT_TIMESTAMP(3),
+				PRIMARY KEY (id),
+				KEY (author_id),
+				UNIQUE KEY title (title(20)) USING BTREE,
+				FULLTEXT KEY (body),
+				CONSTRAINT posts_author FOREIGN KEY (author_id) REFERENCES authors (id) ON DELETE CASCADE
+			) ENGINE=innodb ROW_FORMAT=dynamic /*!50100 STATS_PERSISTENT=1 */;`,
+			expected: "CREATE TABLE `posts` (\n" +
+				"  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,\n" +
+				"  `author_id` int(11) NOT NULL,\n" +
+				"  `title` varchar(80) CHARACTER SET utf8mb4 NOT NULL DEFAULT '',\n" +
+				"  `body` text,\n" +
+				"  `score` decimal(5,2) DEFAULT '1.50',\n" +
+				"  `flags` bit(3) DEFAULT b'101',\n" +
+				"  `status` enum('draft','live') NOT NULL DEFAULT 'draft',\n" +
+				"  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+				"  `updated_at` datetime(3) DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP(3),\n" +
+				"  PRIMARY KEY (`id`),\n" +
+				"  UNIQUE KEY `title` (`title`(20)),\n" +
+				"  KEY `author_id` (`author_id`),\n" +
+				"  FULLTEXT KEY `body` (`body`),\n" +
+				"  CONSTRAINT `posts_author` FOREIGN KEY (`author_id`) REFERENCES `authors` (`id`) ON DELETE CASCADE\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=latin1 STATS_PERSISTENT=1 ROW_FORMAT=DYNAMIC",
+		},
+		{
+			flavor: "mysql:8.0.22",
+			input: "CREATE TABLE `comments` (" +
+				"`id` bigint(20) unsigned NOT NULL, " +
+				"post_id int NOT NULL, " +
+				"flag bool DEFAULT FALSE, " +
+				"name char CHARACTER SET latin1, " +
+				"created_at timestamp, " +
+				"PRIMARY KEY (id DESC), " +
+				"FOREIGN KEY (post_id) REFERENCES posts (id) ON UPDATE NO ACTION" +
+				") AUTO_INCREMENT=100 DEFAULT CHARSET=utf8mb4",
+			expected: "CREATE TABLE `comments` (\n" +
+				"  `id` bigint unsigned NOT NULL,\n" +
+				"  `post_id` int NOT NULL,\n" +
+				"  `flag` tinyint(1) DEFAULT '0',\n" +
+				"  `name` char(1) CHARACTER SET latin1 COLLATE latin1_swedish_ci DEFAULT NULL,\n" +
+				"  `created_at` timestamp NULL DEFAULT NULL,\n" +
+				"  PRIMARY KEY (`id` DESC),\n" +
+				"  KEY `post_id` (`post_id`),\n" +
+				"  CONSTRAINT `comments_ibfk_1` FOREIGN KEY (`post_id`) REFERENCES `posts` (`id`)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci",
+		},
+		{
+			flavor: "mariadb:10.3",
+			input: `create table if not exists tags (
+				id smallint auto_increment primary key,
+				name varchar(20) collate latin1_bin not null unique,
+				weight float default '2.5',
+				seen_at timestamp(6) null default current_timestamp(6),
+				notes mediumtext default 'n/a',
+				secret int invisible
+			) auto_increment=7 comment='it''s a table'`,
+			expected: "CREATE TABLE `tags` (\n" +
+				"  `id` smallint(6) NOT NULL AUTO_INCREMENT,\n" +
+				"  `name` varchar(20) CHARACTER SET latin1 COLLATE latin1_bin NOT NULL,\n" +
+				"  `weight` float DEFAULT 2.5,\n" +
+				"  `seen_at` timestamp(6) NULL DEFAULT current_timestamp(6),\n" +
+				"  `notes` mediumtext DEFAULT 'n/a',\n" +
+				"  `secret` int(11) INVISIBLE DEFAULT NULL,\n" +
+				"  PRIMARY KEY (`id`),\n" +
+				"  UNIQUE KEY `name` (`name`)\n" +
+				") ENGINE=InnoDB AUTO_INCREMENT=7 DEFAULT CHARSET=latin1 COMMENT='it''s a table'",
+		},
+	}
+	for _, c := range cases {
+		o, err := NewOffline(Options{Flavor: tengo.NewFlavor(c.flavor)})
+		if err != nil {
+			t.Fatalf("Unexpected error from NewOffline: %s", err)
+		}
+		table, err := o.parseCreateTable(c.input)
+		if err != nil {
+			t.Errorf("Unexpected error parsing CREATE TABLE for flavor %s: %s", c.flavor, err)
+		} else if table.CreateStatement != c.expected {
+			t.Errorf("Unexpected CREATE TABLE for flavor %s:\nexpected:\n%s\nactual:\n%s", c.flavor, c.expected, table.CreateStatement)
+		}
+	}
+
+	// Index prefixes must be valid for the column's type and length. A prefix
+	// covering the entire column is equivalent to no prefix.
+	o, err := NewOffline(Options{Flavor: tengo.NewFlavor("mysql:5.7")})
+	if err != nil {
+		t.Fatalf("Unexpected error from NewOffline: %s", err)
+	}
+	invalidPrefixes := []string{
+		"CREATE TABLE a (v varchar(10), KEY (v(20)))",
+		"CREATE TABLE a (c char, KEY (c(2)))",
+		"CREATE TABLE a (b varbinary(4) NOT NULL, PRIMARY KEY (b(5)))",
+		"CREATE TABLE a (v varchar(10) CHARACTER SET binary, KEY (v(11)))",
+		"CREATE TABLE a (id int, KEY (id(2)))",
+		"CREATE TABLE a (d datetime, KEY (d(4)))",
+		"CREATE TABLE a (e enum('x','y'), KEY (e(1)))",
+	}
+	for _, input := range invalidPrefixes {
+		if table, err := o.parseCreateTable(input); err == nil {
+			t.Errorf("Expected error parsing %q, but instead got:\n%s", input, table.CreateStatement)
+		}
+	}
+	input := "CREATE TABLE a (v varchar(10), c char(4), t text, KEY (v(10)), KEY (c(2)), KEY (t(100)))"
+	table, err := o.parseCreateTable(input)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing %q: %s", input, err)
+	}
+	for _, expected := range []string{"KEY `v` (`v`)", "KEY `c` (`c`(2))", "KEY `t` (`t`(100))"} {
+		if !strings.Contains(table.CreateStatement, expected) {
+			t.Errorf("Expected CREATE TABLE to contain %q, but it did not:\n%s", expected, table.CreateStatement)
+		}
+	}
+}
+
+func TestOfflineCreateTableUnsupported(t *testing.T) {
+	o, err := NewOffline(Options{Flavor: tengo.NewFlavor("mysql:5.7")})
+	if err != nil {
+		t.Fatalf("Unexpected error from NewOffline: %s", err)
+	}
+	inputs := []string{
+		"CREATE TABLE foo (id int, full_name varchar(60) AS (CONCAT(id, 'x')))",
+		"CREATE TABLE foo (id int, CHECK (id > 0))",
+		"CREATE TABLE foo (id int) PARTITION BY HASH(id) PARTITIONS 4",
+		"CREATE TABLE foo (created_at datetime DEFAULT (NOW() + INTERVAL 1 DAY))",
+		"CREATE TABLE foo (id int, ts timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, ts2 timestamp NOT NULL)",
+		"CREATE TABLE foo (body text DEFAULT 'x')",
+		"CREATE TABLE foo (id int, KEY ((id + 1)))",
+		"CREATE TABLE foo LIKE bar",
+		"CREATE TABLE foo (id int AUTO_INCREMENT)",
+		"CREATE TABLE foo (id int) ENGINE=InnoDB ENCRYPTION='Y'",
+		"CREATE TABLE foo (id int, id bigint)",
+		"CREATE TABLE foo (id int, KEY idx (id), KEY idx (id))",
+		"CREATE TABLE foo (id serial)",
+		"CREATE TABLE foo (id int NULL PRIMARY KEY)",
+		"CREATE TABLE foo (id int, KEY (nope))",
+	}
+	for _, input := range inputs {
+		if table, err := o.parseCreateTable(input); err == nil {
+			t.Errorf("Expected error parsing %q, but instead got:\n%s", input, table.CreateStatement)
+		}
+	}
+}
+
+func TestOfflineCreateRoutine(t *testing.T) {
+	o, err := NewOffline(Options{Flavor: tengo.NewFlavor("mysql:5.7")})
+	if err != nil {
+		t.Fatalf("Unexpected error from NewOffline: %s", err)
+	}
+	input := "CREATE DEFINER=`app`@`%` FUNCTION `greet`(name varchar(20)) RETURNS varchar(30)\n" +
+		"    DETERMINISTIC\n    NO SQL\n    COMMENT 'says hi'\n" +
+		"RETURN CONCAT('hi ', name)"
+	r, err := o.parseCreateRoutine(input)
+	if err != nil {
+		t.Fatalf("Unexpected error from parseCreateRoutine: %s", err)
+	}
+	expected := "CREATE DEFINER=`app`@`%` FUNCTION `greet`(name varchar(20)) RETURNS varchar(30) CHARSET latin1\n" +
+		"    NO SQL\n    DETERMINISTIC\n    COMMENT 'says hi'\n" +
+		"RETURN CONCAT('hi ', name)"
+	if r.CreateStatement != expected {
+		t.Errorf("Unexpected CreateStatement:\nexpected:\n%s\nactual:\n%s", expected, r.CreateStatement)
+	}
+	if r.Type != tengo.ObjectTypeFunc || r.Name != "greet" || r.DatabaseCollation != "latin1_swedish_ci" || r.SQLMode == "" {
+		t.Errorf("Unexpected field values in %+v", *r)
+	}
+
+	input = "CREATE PROCEDURE noop() SQL SECURITY INVOKER BEGIN SELECT 1; END"
+	if r, err = o.parseCreateRoutine(input); err != nil {
+		t.Fatalf("Unexpected error from parseCreateRoutine: %s", err)
+	}
+	expected = "CREATE DEFINER=`root`@`%` PROCEDURE `noop`()\n    SQL SECURITY INVOKER\nBEGIN SELECT 1; END"
+	if r.CreateStatement != expected {
+		t.Errorf("Unexpected CreateStatement:\nexpected:\n%s\nactual:\n%s", expected, r.CreateStatement)
+	}
+}
+
+func TestOfflineExecLogicalSchema(t *testing.T) {
+	logicalSchema := &fs.LogicalSchema{
+		CharSet:   "utf8mb4",
+		Collation: "utf8mb4_unicode_ci",
+		Creates:   make(map[tengo.ObjectKey]*fs.Statement),
+	}
+	stmts := []*fs.Statement{
+		{File: "a.sql", LineNo: 1, Text: "CREATE TABLE a (id int PRIMARY KEY, name varchar(10));\n", Type: fs.StatementTypeCreate, ObjectType: tengo.ObjectTypeTable, ObjectName: "a"},
+		{File: "b.sql", LineNo: 1, Text: "CREATE TABLE b (id int, CHECK (id > 0));\n", Type: fs.StatementTypeCreate, ObjectType: tengo.ObjectTypeTable, ObjectName: "b"},
+		{File: "c.sql", LineNo: 1, Text: "CREATE PROCEDURE c() SELECT 1;\n", Type: fs.StatementTypeCreate, ObjectType: tengo.ObjectTypeProc, ObjectName: "c"},
+		{File: "a.sql", LineNo: 2, Text: "ALTER TABLE a ADD COLUMN x int;\n", Type: fs.StatementTypeAlter, ObjectType: tengo.ObjectTypeTable, ObjectName: "a"},
+		{File: "a.sql", LineNo: 3, Text: "INSERT INTO a VALUES (1, 'one');\n", Type: fs.StatementTypeInsert, ObjectType: tengo.ObjectTypeTable, ObjectName: "a"},
+	}
+	logicalSchema.DataTables = []string{"a"}
+	for _, stmt := range stmts {
+		if err := logicalSchema.AddStatement(stmt); err != nil {
+			t.Fatalf("Unexpected error from AddStatement: %s", err)
+		}
+	}
+	opts := Options{
+		Type:       TypeOffline,
+		Flavor:     tengo.NewFlavor("mysql:5.7"),
+		SchemaName: "_skeema_tmp",
+	}
+	wsSchema, err := ExecLogicalSchema(logicalSchema, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error from ExecLogicalSchema: %s", err)
+	}
+	if len(wsSchema.Tables) != 1 || wsSchema.Tables[0].Name != "a" || len(wsSchema.Routines) != 1 {
+		t.Errorf("Unexpected objects in schema: %d tables, %d routines", len(wsSchema.Tables), len(wsSchema.Routines))
+	}
+	if wsSchema.CharSet != "utf8mb4" || wsSchema.Collation != "utf8mb4_unicode_ci" {
+		t.Errorf("Unexpected schema defaults: %s / %s", wsSchema.CharSet, wsSchema.Collation)
+	}
+	if !strings.Contains(wsSchema.Tables[0].CreateStatement, "`name` varchar(10) COLLATE utf8mb4_unicode_ci DEFAULT NULL") {
+		t.Errorf("Unexpected CREATE TABLE:\n%s", wsSchema.Tables[0].CreateStatement)
+	}
+	if len(wsSchema.Failures) != 3 {
+		t.Fatalf("Expected 3 failures, instead found %d: %v", len(wsSchema.Failures), wsSchema.Failures)
+	}
+	if wsSchema.Failures[0].ObjectName != "b" || wsSchema.Failures[1].Type != fs.StatementTypeAlter || wsSchema.Failures[2].Type != fs.StatementTypeInsert {
+		t.Errorf("Unexpected failures: %v", wsSchema.Failures)
+	}
+	if len(wsSchema.Data) != 0 {
+		t.Errorf("Expected no table data from offline workspace, instead found %v", wsSchema.Data)
+	}
+}
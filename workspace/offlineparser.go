@@ -0,0 +1,376 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// offlineTokenType enumerates the kinds of tokens handled by the offline
+// workspace's lexer.
+type offlineTokenType int
+
+const (
+	tokenEOF    offlineTokenType = iota
+	tokenWord                    // keyword or unquoted identifier
+	tokenIdent                   // backtick-quoted identifier
+	tokenString                  // single- or double-quoted string literal
+	tokenNumber                  // numeric literal
+	tokenBitHex                  // b'...' or x'...' or 0x... literal
+	tokenSymbol                  // any other single character
+)
+
+// offlineToken is a single lexical token of a SQL statement. The token's
+// position in the original input is retained, so that verbatim portions of
+// the input (for example stored procedure bodies) can be extracted.
+type offlineToken struct {
+	typ   offlineTokenType
+	val   string // unescaped value for identifiers and strings
+	start int
+	end   int
+}
+
+// is returns true if the token is a keyword or symbol matching s, using a
+// case-insensitive comparison.
+func (tok offlineToken) is(s string) bool {
+	return (tok.typ == tokenWord || tok.typ == tokenSymbol) && strings.EqualFold(tok.val, s)
+}
+
+// lexOffline splits a SQL statement into tokens. Comments are skipped, but the
+// contents of MySQL version-gated comments (/*!NNNNN ... */) are lexed as
+// regular code, matching the behavior of the server.
+func lexOffline(input string) ([]offlineToken, error) {
+	var tokens []offlineToken
+	var versionComment bool
+	runes := []rune(input)
+
+	// Track byte offsets alongside rune offsets, for extracting substrings
+	offsets := make([]int, len(runes)+1)
+	var byteOffset int
+	for n, r := range runes {
+		offsets[n] = byteOffset
+		byteOffset += len(string(r))
+	}
+	offsets[len(runes)] = byteOffset
+
+	for pos := 0; pos < len(runes); {
+		r := runes[pos]
+		peek := func(n int) rune {
+			if pos+n < len(runes) {
+				return runes[pos+n]
+			}
+			return 0
+		}
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+		case r == '#' || (r == '-' && peek(1) == '-' && (unicode.IsSpace(peek(2)) || peek(2) == 0)):
+			for pos < len(runes) && runes[pos] != '\n' {
+				pos++
+			}
+		case r == '/' && peek(1) == '*' && peek(2) == '!':
+			pos += 3
+			for pos < len(runes) && unicode.IsDigit(runes[pos]) {
+				pos++
+			}
+			versionComment = true
+		case r == '/' && peek(1) == '*':
+			end := pos + 2
+			for end+1 < len(runes) && !(runes[end] == '*' && runes[end+1] == '/') {
+				end++
+			}
+			if end+1 >= len(runes) {
+				return nil, errors.New("unterminated comment")
+			}
+			pos = end + 2
+		case r == '*' && peek(1) == '/' && versionComment:
+			pos += 2
+			versionComment = false
+		case r == '`' || r == '\'' || r == '"':
+			val, end, err := lexQuoted(runes, pos)
+			if err != nil {
+				return nil, err
+			}
+			typ := tokenString
+			if r == '`' {
+				typ = tokenIdent
+			}
+			tokens = append(tokens, offlineToken{typ: typ, val: val, start: offsets[pos], end: offsets[end]})
+			pos = end
+		case (r == 'b' || r == 'B' || r == 'x' || r == 'X') && peek(1) == '\'':
+			end := pos + 2
+			for end < len(runes) && runes[end] != '\'' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, errors.New("unterminated literal")
+			}
+			val := strings.ToLower(string(r)) + string(runes[pos+1:end+1])
+			tokens = append(tokens, offlineToken{typ: tokenBitHex, val: val, start: offsets[pos], end: offsets[end+1]})
+			pos = end + 1
+		case r == '0' && (peek(1) == 'x' || peek(1) == 'b'):
+			end := pos + 2
+			for end < len(runes) && isWordRune(runes[end]) {
+				end++
+			}
+			val := string(runes[pos:end])
+			if val[1] == 'x' {
+				val = "x'" + val[2:] + "'"
+			} else {
+				val = "b'" + val[2:] + "'"
+			}
+			tokens = append(tokens, offlineToken{typ: tokenBitHex, val: val, start: offsets[pos], end: offsets[end]})
+			pos = end
+		case unicode.IsDigit(r) || (r == '.' && unicode.IsDigit(peek(1))):
+			end := pos
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			if end < len(runes) && (runes[end] == 'e' || runes[end] == 'E') {
+				end++
+				if end < len(runes) && (runes[end] == '-' || runes[end] == '+') {
+					end++
+				}
+				for end < len(runes) && unicode.IsDigit(runes[end]) {
+					end++
+				}
+			}
+			// Identifiers may begin with a digit, as long as they aren't entirely numeric
+			typ := tokenNumber
+			for end < len(runes) && isWordRune(runes[end]) {
+				typ = tokenWord
+				end++
+			}
+			tokens = append(tokens, offlineToken{typ: typ, val: string(runes[pos:end]), start: offsets[pos], end: offsets[end]})
+			pos = end
+		case isWordRune(r):
+			end := pos
+			for end < len(runes) && isWordRune(runes[end]) {
+				end++
+			}
+			tokens = append(tokens, offlineToken{typ: tokenWord, val: string(runes[pos:end]), start: offsets[pos], end: offsets[end]})
+			pos = end
+		default:
+			tokens = append(tokens, offlineToken{typ: tokenSymbol, val: string(r), start: offsets[pos], end: offsets[pos+1]})
+			pos++
+		}
+	}
+	tokens = append(tokens, offlineToken{typ: tokenEOF, start: len(input), end: len(input)})
+	return tokens, nil
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// lexQuoted handles a quoted string or identifier beginning at runes[pos]. It
+// returns the unescaped value, and the position just past the closing quote.
+func lexQuoted(runes []rune, pos int) (string, int, error) {
+	quote := runes[pos]
+	var b strings.Builder
+	for n := pos + 1; n < len(runes); n++ {
+		r := runes[n]
+		if r == quote {
+			if n+1 < len(runes) && runes[n+1] == quote {
+				b.WriteRune(quote)
+				n++
+				continue
+			}
+			return b.String(), n + 1, nil
+		}
+		if r == '\\' && quote != '`' && n+1 < len(runes) {
+			n++
+			switch runes[n] {
+			case '0':
+				b.WriteRune(0)
+			case 'b':
+				b.WriteRune('\b')
+			case 'n':
+				b.WriteRune('\n')
+			case 'r':
+				b.WriteRune('\r')
+			case 't':
+				b.WriteRune('\t')
+			case 'Z':
+				b.WriteRune(26)
+			case '%', '_':
+				b.WriteRune('\\')
+				b.WriteRune(runes[n])
+			default:
+				b.WriteRune(runes[n])
+			}
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return "", 0, fmt.Errorf("unterminated quoted value beginning with %c", quote)
+}
+
+// offlineParser provides helper methods for walking a token stream produced
+// by lexOffline.
+type offlineParser struct {
+	input  string
+	tokens []offlineToken
+	pos    int
+}
+
+func newOfflineParser(input string) (*offlineParser, error) {
+	tokens, err := lexOffline(input)
+	if err != nil {
+		return nil, err
+	}
+	return &offlineParser{input: input, tokens: tokens}, nil
+}
+
+// peek returns the token n positions ahead of the current one, without
+// consuming anything.
+func (p *offlineParser) peek(n int) offlineToken {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+// next consumes and returns the current token.
+func (p *offlineParser) next() offlineToken {
+	tok := p.peek(0)
+	if tok.typ != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// atEnd returns true if all tokens have been consumed, ignoring any trailing
+// semicolon.
+func (p *offlineParser) atEnd() bool {
+	return p.peek(0).typ == tokenEOF || (p.peek(0).is(";") && p.peek(1).typ == tokenEOF)
+}
+
+// peekWords returns true if the upcoming tokens match the supplied keywords or
+// symbols, without consuming them.
+func (p *offlineParser) peekWords(words ...string) bool {
+	for n, word := range words {
+		if !p.peek(n).is(word) {
+			return false
+		}
+	}
+	return true
+}
+
+// accept consumes the upcoming tokens and returns true if they match the
+// supplied keywords or symbols. Otherwise nothing is consumed and false is
+// returned.
+func (p *offlineParser) accept(words ...string) bool {
+	if !p.peekWords(words...) {
+		return false
+	}
+	p.pos += len(words)
+	return true
+}
+
+// expect consumes the upcoming tokens if they match the supplied keywords or
+// symbols, or returns an error otherwise.
+func (p *offlineParser) expect(words ...string) error {
+	if !p.accept(words...) {
+		return p.unexpected(strings.Join(words, " "))
+	}
+	return nil
+}
+
+// unexpected returns an error describing the current token.
+func (p *offlineParser) unexpected(wanted string) error {
+	tok := p.peek(0)
+	if tok.typ == tokenEOF {
+		return fmt.Errorf("expected %s, found end of statement", wanted)
+	}
+	return fmt.Errorf("expected %s, found %s", wanted, p.input[tok.start:tok.end])
+}
+
+// ident consumes and returns an identifier, which may be backtick-quoted or
+// unquoted.
+func (p *offlineParser) ident() (string, error) {
+	tok := p.peek(0)
+	if tok.typ != tokenIdent && tok.typ != tokenWord {
+		return "", p.unexpected("identifier")
+	}
+	p.pos++
+	return tok.val, nil
+}
+
+// qualifiedIdent consumes an optionally schema-qualified identifier, returning
+// the qualifier (or an empty string) and the name.
+func (p *offlineParser) qualifiedIdent() (qualifier, name string, err error) {
+	if name, err = p.ident(); err != nil {
+		return "", "", err
+	}
+	if p.accept(".") {
+		qualifier = name
+		name, err = p.ident()
+	}
+	return qualifier, name, err
+}
+
+// identList consumes a parenthesized, comma-separated list of identifiers.
+func (p *offlineParser) identList() ([]string, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var names []string
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if p.accept(")") {
+			return names, nil
+		} else if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// stringLiteral consumes and returns a string literal.
+func (p *offlineParser) stringLiteral() (string, error) {
+	tok := p.peek(0)
+	if tok.typ != tokenString {
+		return "", p.unexpected("quoted string")
+	}
+	p.pos++
+	return tok.val, nil
+}
+
+// word consumes and returns a keyword, number, or identifier, for use in
+// option values.
+func (p *offlineParser) word() (string, error) {
+	tok := p.peek(0)
+	if tok.typ != tokenWord && tok.typ != tokenIdent && tok.typ != tokenNumber && tok.typ != tokenString {
+		return "", p.unexpected("value")
+	}
+	p.pos++
+	return tok.val, nil
+}
+
+// skipParens consumes a parenthesized token sequence, including any nested
+// parens, and returns the verbatim input between the outer parens.
+func (p *offlineParser) skipParens() (string, error) {
+	open := p.peek(0)
+	if err := p.expect("("); err != nil {
+		return "", err
+	}
+	for depth := 1; depth > 0; {
+		tok := p.next()
+		if tok.typ == tokenEOF {
+			return "", errors.New("unbalanced parentheses")
+		} else if tok.is("(") {
+			depth++
+		} else if tok.is(")") {
+			depth--
+		}
+		if depth == 0 {
+			return p.input[open.end:tok.start], nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,159 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// offlineDefaultDefiner is the definer used for routines lacking an explicit
+// DEFINER clause. This matches the user that other workspace types typically
+// connect as.
+const offlineDefaultDefiner = "root@%"
+
+// parseCreateRoutine parses a CREATE PROCEDURE or CREATE FUNCTION statement,
+// returning a *tengo.Routine with a normalized CreateStatement.
+func (o *Offline) parseCreateRoutine(input string) (*tengo.Routine, error) {
+	p, err := newOfflineParser(input)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("CREATE"); err != nil {
+		return nil, err
+	}
+	r := &tengo.Routine{
+		Definer:           offlineDefaultDefiner,
+		DatabaseCollation: o.collation,
+		SQLDataAccess:     "CONTAINS SQL",
+		SecurityType:      "DEFINER",
+		SQLMode:           o.defaultSQLMode(),
+	}
+	if p.accept("DEFINER") {
+		if r.Definer, err = parseDefiner(p); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("PROCEDURE") {
+		r.Type = tengo.ObjectTypeProc
+	} else if p.accept("FUNCTION") {
+		r.Type = tengo.ObjectTypeFunc
+	} else {
+		return nil, p.unexpected("PROCEDURE or FUNCTION")
+	}
+	p.accept("IF", "NOT", "EXISTS")
+	if _, r.Name, err = p.qualifiedIdent(); err != nil {
+		return nil, err
+	}
+	if r.ParamString, err = p.skipParens(); err != nil {
+		return nil, err
+	}
+	if r.Type == tengo.ObjectTypeFunc {
+		if err := p.expect("RETURNS"); err != nil {
+			return nil, err
+		}
+		if r.ReturnDataType, err = o.parseReturnType(p); err != nil {
+			return nil, fmt.Errorf("return type: %s", err)
+		}
+	}
+
+	for {
+		var err error
+		switch {
+		case p.accept("COMMENT"):
+			r.Comment, err = p.stringLiteral()
+		case p.accept("LANGUAGE", "SQL"), p.accept("NOT", "DETERMINISTIC"):
+			// No effect on SHOW CREATE output
+		case p.accept("DETERMINISTIC"):
+			r.Deterministic = true
+		case p.accept("CONTAINS", "SQL"), p.accept("NO", "SQL"), p.accept("READS", "SQL", "DATA"), p.accept("MODIFIES", "SQL", "DATA"):
+			r.SQLDataAccess = strings.ToUpper(p.input[p.peek(-2).start:p.peek(-1).end])
+			if r.SQLDataAccess == "READS SQL" || r.SQLDataAccess == "MODIFIES SQL" {
+				r.SQLDataAccess += " DATA"
+			}
+		case p.accept("SQL", "SECURITY", "DEFINER"):
+			r.SecurityType = "DEFINER"
+		case p.accept("SQL", "SECURITY", "INVOKER"):
+			r.SecurityType = "INVOKER"
+		default:
+			if p.atEnd() {
+				return nil, errors.New("routine body is missing")
+			}
+			r.Body = p.input[p.peek(0).start:]
+			r.CreateStatement = r.Definition(o.flavor)
+			return r, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseDefiner parses the value of a DEFINER clause, returning it in the
+// user@host form used by information_schema.
+func parseDefiner(p *offlineParser) (string, error) {
+	if err := p.expect("="); err != nil {
+		return "", err
+	}
+	if p.accept("CURRENT_USER") {
+		p.accept("(", ")")
+		return offlineDefaultDefiner, nil
+	}
+	user, err := p.word()
+	if err != nil {
+		return "", err
+	}
+	if err := p.expect("@"); err != nil {
+		return "", err
+	}
+	host, err := p.word()
+	for err == nil && p.accept(".") {
+		var part string
+		part, err = p.word()
+		host += "." + part
+	}
+	return user + "@" + host, err
+}
+
+// parseReturnType parses the data type in a function's RETURNS clause,
+// returning it in the form displayed by SHOW CREATE FUNCTION.
+func (o *Offline) parseReturnType(p *offlineParser) (string, error) {
+	ot := &offlineTable{
+		Table: &tengo.Table{CharSet: o.charSet, Collation: o.collation},
+		o:     o,
+	}
+	col := &offlineColumn{Column: &tengo.Column{}}
+	if err := ot.parseColumnType(p, col); err != nil {
+		return "", err
+	}
+	for {
+		var err error
+		if p.accept("CHARACTER", "SET") || p.accept("CHARSET") {
+			col.charSet, err = p.word()
+		} else if p.accept("COLLATE") {
+			col.collation, err = p.word()
+		} else if p.accept("BINARY") {
+			col.binary = true
+		} else {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if err := ot.resolveColumnCharSet(col); err != nil {
+		return "", err
+	}
+	dataType, err := ot.columnType(col)
+	if err != nil {
+		return "", err
+	}
+	if col.CharSet != "" {
+		dataType += " CHARSET " + col.CharSet
+		if !col.CollationIsDefault {
+			dataType += " COLLATE " + col.Collation
+		}
+	}
+	return dataType, nil
+}
@@ -0,0 +1,1395 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// offlineTable tracks the state of a CREATE TABLE statement being parsed by an
+// offline workspace, prior to converting it into a *tengo.Table.
+type offlineTable struct {
+	*tengo.Table
+	o             *Offline
+	columns       []*offlineColumn
+	indexes       []*offlineIndex
+	foreignKeys   []*tengo.ForeignKey
+	charSet       string
+	collation     string
+	autoIncrement uint64
+	createOptions map[string]string
+}
+
+// offlineColumn tracks a column definition prior to normalization.
+type offlineColumn struct {
+	*tengo.Column
+	baseType   string
+	args       []string
+	unsigned   bool
+	zerofill   bool
+	notNull    bool
+	null       bool
+	primary    bool
+	unique     bool
+	binary     bool
+	charSet    string
+	collation  string
+	def        *offlineDefault
+	onUpdate   *offlineDefault
+	typeIsText bool
+}
+
+// offlineIndex tracks an index definition prior to normalization.
+type offlineIndex struct {
+	*tengo.Index
+	symbol      string // constraint symbol, if any
+	generatedFK bool   // true if implicitly created to support a foreign key
+}
+
+// offlineDefaultKind enumerates the kinds of values that may be supplied in
+// DEFAULT or ON UPDATE clauses.
+type offlineDefaultKind int
+
+const (
+	defaultNull offlineDefaultKind = iota
+	defaultString
+	defaultNumber
+	defaultBitHex
+	defaultNow
+)
+
+// offlineDefault represents a literal DEFAULT or ON UPDATE value.
+type offlineDefault struct {
+	kind offlineDefaultKind
+	val  string // for defaultNow, this is the fractional seconds precision
+}
+
+// intDisplayWidths maps integer types to their default display widths, for
+// signed and unsigned columns respectively.
+var intDisplayWidths = map[string][2]int{
+	"tinyint":   {4, 3},
+	"smallint":  {6, 5},
+	"mediumint": {9, 8},
+	"int":       {11, 10},
+	"bigint":    {20, 20},
+}
+
+// typeAliases maps alternative type names to their canonical equivalents.
+var typeAliases = map[string]string{
+	"int1":         "tinyint",
+	"int2":         "smallint",
+	"int3":         "mediumint",
+	"middleint":    "mediumint",
+	"int4":         "int",
+	"integer":      "int",
+	"int8":         "bigint",
+	"dec":          "decimal",
+	"numeric":      "decimal",
+	"fixed":        "decimal",
+	"real":         "double",
+	"float4":       "float",
+	"float8":       "double",
+	"nchar":        "char",
+	"nvarchar":     "varchar",
+	"character":    "char",
+	"varcharacter": "varchar",
+}
+
+// simpleTypes lists types which do not take any arguments, and are displayed
+// as-is.
+var simpleTypes = map[string]bool{
+	"date":               true,
+	"tinytext":           true,
+	"text":               true,
+	"mediumtext":         true,
+	"longtext":           true,
+	"tinyblob":           true,
+	"blob":               true,
+	"mediumblob":         true,
+	"longblob":           true,
+	"json":               true,
+	"geometry":           true,
+	"point":              true,
+	"linestring":         true,
+	"polygon":            true,
+	"multipoint":         true,
+	"multilinestring":    true,
+	"multipolygon":       true,
+	"geometrycollection": true,
+	"geomcollection":     true,
+}
+
+// canonicalEngines maps lowercased storage engine names to their display form.
+var canonicalEngines = map[string]string{
+	"innodb":     "InnoDB",
+	"myisam":     "MyISAM",
+	"memory":     "MEMORY",
+	"heap":       "MEMORY",
+	"csv":        "CSV",
+	"archive":    "ARCHIVE",
+	"blackhole":  "BLACKHOLE",
+	"aria":       "Aria",
+	"rocksdb":    "ROCKSDB",
+	"tokudb":     "TokuDB",
+	"merge":      "MRG_MYISAM",
+	"mrg_myisam": "MRG_MYISAM",
+	"federated":  "FEDERATED",
+}
+
+// createOptionOrder lists the table options which are displayed in the
+// create_options column of information_schema.tables, in the order the server
+// displays them.
+var createOptionOrder = []string{
+	"MIN_ROWS",
+	"MAX_ROWS",
+	"AVG_ROW_LENGTH",
+	"PACK_KEYS",
+	"STATS_PERSISTENT",
+	"STATS_AUTO_RECALC",
+	"STATS_SAMPLE_PAGES",
+	"CHECKSUM",
+	"DELAY_KEY_WRITE",
+	"ROW_FORMAT",
+	"KEY_BLOCK_SIZE",
+}
+
+// knownCreateOptions is a set of the option names in createOptionOrder.
+var knownCreateOptions = func() map[string]bool {
+	result := make(map[string]bool, len(createOptionOrder))
+	for _, name := range createOptionOrder {
+		result[name] = true
+	}
+	return result
+}()
+
+// parseCreateTable parses a CREATE TABLE statement, returning a *tengo.Table
+// with a normalized CreateStatement.
+func (o *Offline) parseCreateTable(input string) (*tengo.Table, error) {
+	p, err := newOfflineParser(input)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("CREATE"); err != nil {
+		return nil, err
+	}
+	if p.peekWords("TEMPORARY") {
+		return nil, errors.New("temporary tables are not supported")
+	} else if err := p.expect("TABLE"); err != nil {
+		return nil, err
+	}
+	p.accept("IF", "NOT", "EXISTS")
+	_, name, err := p.qualifiedIdent()
+	if err != nil {
+		return nil, err
+	}
+	if p.peekWords("LIKE") || p.peekWords("(", "LIKE") {
+		return nil, errors.New("CREATE TABLE ... LIKE is not supported")
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	ot := &offlineTable{
+		Table: &tengo.Table{
+			Name:   name,
+			Engine: "InnoDB",
+		},
+		o:             o,
+		createOptions: make(map[string]string),
+	}
+	for {
+		if err := ot.parseDefinition(p); err != nil {
+			return nil, err
+		}
+		if p.accept(")") {
+			break
+		} else if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+	if err := ot.parseTableOptions(p); err != nil {
+		return nil, err
+	}
+	if p.peekWords("PARTITION") {
+		return nil, errors.New("partitioned tables are not supported")
+	} else if p.peekWords("AS") || p.peekWords("SELECT") || p.peekWords("IGNORE") || p.peekWords("REPLACE") {
+		return nil, errors.New("CREATE TABLE ... SELECT is not supported")
+	} else if !p.atEnd() {
+		return nil, p.unexpected("end of statement")
+	}
+	if err := ot.finalize(); err != nil {
+		return nil, err
+	}
+	return ot.Table, nil
+}
+
+// parseDefinition parses a single column, index, or constraint definition.
+func (ot *offlineTable) parseDefinition(p *offlineParser) error {
+	var symbol string
+	if p.accept("CONSTRAINT") {
+		if !p.peekWords("PRIMARY") && !p.peekWords("UNIQUE") && !p.peekWords("FOREIGN") && !p.peekWords("CHECK") {
+			var err error
+			if symbol, err = p.ident(); err != nil {
+				return err
+			}
+		}
+		if !p.peekWords("PRIMARY") && !p.peekWords("UNIQUE") && !p.peekWords("FOREIGN") && !p.peekWords("CHECK") {
+			return p.unexpected("PRIMARY KEY, UNIQUE, or FOREIGN KEY")
+		}
+	}
+	switch {
+	case p.peekWords("CHECK"):
+		return errors.New("CHECK constraints are not supported")
+	case p.accept("PRIMARY", "KEY"):
+		idx := &offlineIndex{Index: &tengo.Index{Name: "PRIMARY", PrimaryKey: true, Unique: true, Type: "BTREE"}}
+		return ot.parseIndex(p, idx, false)
+	case p.accept("UNIQUE"):
+		_ = p.accept("KEY") || p.accept("INDEX")
+		idx := &offlineIndex{Index: &tengo.Index{Unique: true, Type: "BTREE"}, symbol: symbol}
+		return ot.parseIndex(p, idx, true)
+	case p.accept("FULLTEXT"), p.accept("SPATIAL"):
+		idxType := strings.ToUpper(p.peek(-1).val)
+		_ = p.accept("KEY") || p.accept("INDEX")
+		idx := &offlineIndex{Index: &tengo.Index{Type: idxType}}
+		return ot.parseIndex(p, idx, true)
+	case p.accept("KEY"), p.accept("INDEX"):
+		idx := &offlineIndex{Index: &tengo.Index{Type: "BTREE"}}
+		return ot.parseIndex(p, idx, true)
+	case p.accept("FOREIGN", "KEY"):
+		return ot.parseForeignKey(p, symbol)
+	}
+	return ot.parseColumn(p)
+}
+
+// parseIndex parses the remainder of an index definition, after the keywords
+// indicating its type.
+func (ot *offlineTable) parseIndex(p *offlineParser, idx *offlineIndex, allowName bool) error {
+	if allowName && !p.peekWords("(") && !p.peekWords("USING") {
+		var err error
+		if idx.Name, err = p.ident(); err != nil {
+			return err
+		}
+	}
+	if idx.Name == "" {
+		idx.Name = idx.symbol
+	}
+	if err := ot.parseIndexOptions(p, idx); err != nil {
+		return err
+	}
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for {
+		if p.peekWords("(") {
+			return errors.New("functional index expressions are not supported")
+		}
+		colName, err := p.ident()
+		if err != nil {
+			return err
+		}
+		part := tengo.IndexPart{ColumnName: colName}
+		if p.accept("(") {
+			tok := p.next()
+			length, err := strconv.ParseUint(tok.val, 10, 16)
+			if tok.typ != tokenNumber || err != nil {
+				return fmt.Errorf("invalid prefix length for column %s", tengo.EscapeIdentifier(colName))
+			}
+			part.PrefixLength = uint16(length)
+			if err := p.expect(")"); err != nil {
+				return err
+			}
+		}
+		if p.accept("DESC") {
+			part.Descending = ot.o.flavor.MySQLishMinVersion(8, 0)
+		} else {
+			p.accept("ASC")
+		}
+		idx.Parts = append(idx.Parts, part)
+		if p.accept(")") {
+			break
+		} else if err := p.expect(","); err != nil {
+			return err
+		}
+	}
+	if err := ot.parseIndexOptions(p, idx); err != nil {
+		return err
+	}
+	ot.indexes = append(ot.indexes, idx)
+	return nil
+}
+
+// parseIndexOptions parses any index options, which may appear before or after
+// the list of index parts.
+func (ot *offlineTable) parseIndexOptions(p *offlineParser, idx *offlineIndex) error {
+	for {
+		switch {
+		case p.accept("USING"), p.accept("TYPE"):
+			// Index algorithm is not displayed in SHOW CREATE TABLE for InnoDB, and
+			// tengo does not track it for other engines
+			if _, err := p.word(); err != nil {
+				return err
+			}
+		case p.accept("KEY_BLOCK_SIZE"):
+			// Index-level KEY_BLOCK_SIZE is not displayed for InnoDB, and is normalized
+			// away by tengo for comparison purposes
+			p.accept("=")
+			if _, err := p.word(); err != nil {
+				return err
+			}
+		case p.accept("COMMENT"):
+			comment, err := p.stringLiteral()
+			if err != nil {
+				return err
+			}
+			idx.Comment = comment
+		case p.accept("VISIBLE"):
+			idx.Invisible = false
+		case p.accept("INVISIBLE"):
+			if !ot.o.flavor.MySQLishMinVersion(8, 0) {
+				return fmt.Errorf("invisible indexes are not supported in %s", ot.o.flavor)
+			}
+			idx.Invisible = true
+		case p.peekWords("WITH", "PARSER"):
+			return errors.New("full-text parser plugins are not supported")
+		default:
+			return nil
+		}
+	}
+}
+
+// parseForeignKey parses the remainder of a foreign key definition, after the
+// FOREIGN KEY keywords.
+func (ot *offlineTable) parseForeignKey(p *offlineParser, symbol string) error {
+	var indexName string
+	if !p.peekWords("(") {
+		var err error
+		if indexName, err = p.ident(); err != nil {
+			return err
+		}
+	}
+	cols, err := p.identList()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("REFERENCES"); err != nil {
+		return err
+	}
+	refSchema, refTable, err := p.qualifiedIdent()
+	if err != nil {
+		return err
+	}
+	refCols, err := p.identList()
+	if err != nil {
+		return err
+	} else if len(refCols) != len(cols) {
+		return errors.New("foreign key column count does not match referenced column count")
+	}
+	if refSchema == ot.o.schemaName {
+		refSchema = ""
+	}
+	fk := &tengo.ForeignKey{
+		Name:                  symbol,
+		ColumnNames:           cols,
+		ReferencedSchemaName:  refSchema,
+		ReferencedTableName:   refTable,
+		ReferencedColumnNames: refCols,
+		UpdateRule:            "RESTRICT",
+		DeleteRule:            "RESTRICT",
+	}
+	if ot.o.flavor.HasDataDictionary() {
+		fk.UpdateRule, fk.DeleteRule = "NO ACTION", "NO ACTION"
+	}
+	if fk.Name == "" && indexName != "" && !ot.o.flavor.MySQLishMinVersion(8, 0) {
+		fk.Name = indexName
+	}
+	if err := parseReferenceOptions(p, fk); err != nil {
+		return err
+	}
+	ot.foreignKeys = append(ot.foreignKeys, fk)
+
+	// Track the implicitly-created supporting index; this is removed later if
+	// another index already covers the foreign key's columns
+	idx := &offlineIndex{
+		Index:       &tengo.Index{Name: indexName, Type: "BTREE"},
+		symbol:      symbol,
+		generatedFK: true,
+	}
+	if idx.Name == "" {
+		idx.Name = symbol
+	}
+	for _, col := range cols {
+		idx.Parts = append(idx.Parts, tengo.IndexPart{ColumnName: col})
+	}
+	ot.indexes = append(ot.indexes, idx)
+	return nil
+}
+
+// parseReferenceOptions parses the MATCH, ON DELETE, and ON UPDATE clauses of
+// a REFERENCES clause. If fk is nil, the clauses are parsed but ignored.
+func parseReferenceOptions(p *offlineParser, fk *tengo.ForeignKey) error {
+	rules := [][]string{
+		{"RESTRICT"},
+		{"CASCADE"},
+		{"SET", "NULL"},
+		{"NO", "ACTION"},
+		{"SET", "DEFAULT"},
+	}
+	for {
+		var target *string
+		switch {
+		case p.accept("MATCH"):
+			if _, err := p.word(); err != nil {
+				return err
+			}
+			continue
+		case p.accept("ON", "DELETE"):
+			if fk != nil {
+				target = &fk.DeleteRule
+			}
+		case p.accept("ON", "UPDATE"):
+			if fk != nil {
+				target = &fk.UpdateRule
+			}
+		default:
+			return nil
+		}
+		var found bool
+		for _, rule := range rules {
+			if p.accept(rule...) {
+				if target != nil {
+					*target = strings.Join(rule, " ")
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return p.unexpected("foreign key reference option")
+		}
+	}
+}
+
+// parseColumn parses a column definition.
+func (ot *offlineTable) parseColumn(p *offlineParser) error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	col := &offlineColumn{Column: &tengo.Column{Name: name}}
+	if err := ot.parseColumnType(p, col); err != nil {
+		return fmt.Errorf("column %s: %s", tengo.EscapeIdentifier(name), err)
+	}
+	if err := ot.parseColumnAttributes(p, col); err != nil {
+		return fmt.Errorf("column %s: %s", tengo.EscapeIdentifier(name), err)
+	}
+	ot.columns = append(ot.columns, col)
+	if col.primary {
+		ot.indexes = append(ot.indexes, &offlineIndex{
+			Index: &tengo.Index{Name: "PRIMARY", PrimaryKey: true, Unique: true, Type: "BTREE", Parts: []tengo.IndexPart{{ColumnName: name}}},
+		})
+	}
+	if col.unique {
+		ot.indexes = append(ot.indexes, &offlineIndex{
+			Index: &tengo.Index{Unique: true, Type: "BTREE", Parts: []tengo.IndexPart{{ColumnName: name}}},
+		})
+	}
+	return nil
+}
+
+// parseColumnType parses a column's data type, including any type modifiers
+// which directly follow it.
+func (ot *offlineTable) parseColumnType(p *offlineParser, col *offlineColumn) error {
+	tok := p.next()
+	if tok.typ != tokenWord {
+		p.pos--
+		return p.unexpected("data type")
+	}
+	typeName := strings.ToLower(tok.val)
+	switch {
+	case typeName == "national":
+		if !p.accept("CHAR") && !p.accept("CHARACTER") && !p.accept("VARCHAR") {
+			return p.unexpected("CHAR or VARCHAR")
+		}
+		typeName = strings.ToLower(p.peek(-1).val)
+		col.charSet = "utf8"
+	case typeName == "nchar" || typeName == "nvarchar":
+		col.charSet = "utf8"
+	case typeName == "double":
+		p.accept("PRECISION")
+	case typeName == "serial":
+		return errors.New("SERIAL type alias is not supported")
+	case typeName == "bool" || typeName == "boolean":
+		typeName = "tinyint"
+		col.args = []string{"1"}
+	}
+	if (typeName == "char" || typeName == "character" || typeName == "nchar") && p.accept("VARYING") {
+		typeName = "varchar"
+	}
+	if alias, ok := typeAliases[typeName]; ok {
+		typeName = alias
+	}
+	col.baseType = typeName
+
+	if p.accept("(") {
+		for {
+			tok := p.next()
+			if tok.typ == tokenNumber || tok.typ == tokenString {
+				col.args = append(col.args, tok.val)
+			} else {
+				p.pos--
+				return p.unexpected("type argument")
+			}
+			if p.accept(")") {
+				break
+			} else if err := p.expect(","); err != nil {
+				return err
+			}
+		}
+	}
+	for {
+		if p.accept("UNSIGNED") {
+			col.unsigned = true
+		} else if p.accept("ZEROFILL") {
+			col.zerofill, col.unsigned = true, true
+		} else if !p.accept("SIGNED") {
+			break
+		}
+	}
+	switch col.baseType {
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set":
+		col.typeIsText = true
+	}
+	return nil
+}
+
+// parseColumnAttributes parses everything in a column definition after the
+// data type.
+func (ot *offlineTable) parseColumnAttributes(p *offlineParser, col *offlineColumn) error {
+	for !p.peekWords(",") && !p.peekWords(")") {
+		var err error
+		switch {
+		case p.accept("NOT", "NULL"):
+			col.notNull = true
+		case p.accept("NULL"):
+			col.null = true
+		case p.accept("DEFAULT"):
+			col.def, err = parseDefaultValue(p)
+		case p.accept("ON", "UPDATE"):
+			col.onUpdate, err = parseDefaultValue(p)
+			if err == nil && col.onUpdate.kind != defaultNow {
+				err = errors.New("ON UPDATE only supports CURRENT_TIMESTAMP")
+			}
+		case p.accept("AUTO_INCREMENT"):
+			col.AutoIncrement = true
+		case p.accept("UNIQUE"):
+			p.accept("KEY")
+			col.unique = true
+		case p.accept("PRIMARY", "KEY"), p.accept("KEY"):
+			col.primary = true
+		case p.accept("COMMENT"):
+			col.Comment, err = p.stringLiteral()
+		case p.accept("COLLATE"):
+			col.collation, err = p.word()
+		case p.accept("CHARACTER", "SET"), p.accept("CHARSET"):
+			col.charSet, err = p.word()
+		case p.accept("BINARY"):
+			col.binary = true
+		case p.accept("ASCII"):
+			col.charSet = "latin1"
+		case p.accept("UNICODE"):
+			col.charSet = "ucs2"
+		case p.accept("VISIBLE"):
+			col.Invisible = false
+		case p.accept("INVISIBLE"):
+			if !ot.o.flavor.VendorMinVersion(tengo.VendorMariaDB, 10, 3) {
+				return fmt.Errorf("invisible columns are not supported in %s", ot.o.flavor)
+			}
+			col.Invisible = true
+		case p.accept("REFERENCES"):
+			// Inline REFERENCES clauses are parsed but silently ignored by the server
+			if _, _, err = p.qualifiedIdent(); err == nil {
+				if _, err = p.identList(); err == nil {
+					err = parseReferenceOptions(p, nil)
+				}
+			}
+		case p.peekWords("GENERATED"), p.peekWords("AS"):
+			return errors.New("generated columns are not supported")
+		case p.peekWords("CHECK"), p.peekWords("CONSTRAINT"):
+			return errors.New("CHECK constraints are not supported")
+		case p.peekWords("COLUMN_FORMAT"), p.peekWords("STORAGE"), p.peekWords("SRID"):
+			return fmt.Errorf("column attribute %s is not supported", strings.ToUpper(p.peek(0).val))
+		default:
+			return p.unexpected("column attribute")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseDefaultValue parses a literal value in a DEFAULT or ON UPDATE clause.
+// Default expressions are not supported.
+func parseDefaultValue(p *offlineParser) (*offlineDefault, error) {
+	tok := p.peek(0)
+	switch {
+	case p.accept("NULL"):
+		return &offlineDefault{kind: defaultNull}, nil
+	case p.accept("TRUE"):
+		return &offlineDefault{kind: defaultNumber, val: "1"}, nil
+	case p.accept("FALSE"):
+		return &offlineDefault{kind: defaultNumber, val: "0"}, nil
+	case tok.typ == tokenString:
+		p.pos++
+		return &offlineDefault{kind: defaultString, val: tok.val}, nil
+	case tok.typ == tokenWord && strings.HasPrefix(tok.val, "_") && p.peek(1).typ == tokenString:
+		// string with character set introducer
+		p.pos += 2
+		return &offlineDefault{kind: defaultString, val: p.peek(-1).val}, nil
+	case tok.typ == tokenNumber:
+		p.pos++
+		return &offlineDefault{kind: defaultNumber, val: tok.val}, nil
+	case (tok.is("-") || tok.is("+")) && p.peek(1).typ == tokenNumber:
+		p.pos += 2
+		return &offlineDefault{kind: defaultNumber, val: tok.val + p.peek(-1).val}, nil
+	case tok.typ == tokenBitHex:
+		p.pos++
+		return &offlineDefault{kind: defaultBitHex, val: tok.val}, nil
+	case tok.is("CURRENT_TIMESTAMP"), tok.is("NOW"), tok.is("LOCALTIME"), tok.is("LOCALTIMESTAMP"):
+		p.pos++
+		def := &offlineDefault{kind: defaultNow}
+		if p.accept("(") {
+			if p.peek(0).typ == tokenNumber {
+				def.val = p.next().val
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+		if def.val == "0" {
+			def.val = ""
+		}
+		return def, nil
+	}
+	return nil, errors.New("default expressions are not supported; only literal values and CURRENT_TIMESTAMP may be used")
+}
+
+// parseTableOptions parses the table options following the list of
+// definitions.
+func (ot *offlineTable) parseTableOptions(p *offlineParser) error {
+	for !p.atEnd() && !p.peekWords("PARTITION") && !p.peekWords("AS") && !p.peekWords("SELECT") {
+		p.accept(",")
+		p.accept("DEFAULT")
+		var err error
+		switch {
+		case p.accept("ENGINE"), p.accept("TYPE"):
+			p.accept("=")
+			var engine string
+			if engine, err = p.word(); err == nil {
+				var ok bool
+				if ot.Engine, ok = canonicalEngines[strings.ToLower(engine)]; !ok {
+					err = fmt.Errorf("storage engine %s is not supported", engine)
+				}
+			}
+		case p.accept("CHARACTER", "SET"), p.accept("CHARSET"):
+			p.accept("=")
+			ot.charSet, err = p.word()
+		case p.accept("COLLATE"):
+			p.accept("=")
+			ot.collation, err = p.word()
+		case p.accept("COMMENT"):
+			p.accept("=")
+			ot.Comment, err = p.stringLiteral()
+		case p.accept("AUTO_INCREMENT"):
+			p.accept("=")
+			var value string
+			if value, err = p.word(); err == nil {
+				ot.autoIncrement, err = strconv.ParseUint(value, 10, 64)
+			}
+		default:
+			name := strings.ToUpper(p.peek(0).val)
+			if p.peek(0).typ != tokenWord || !knownCreateOptions[name] {
+				return fmt.Errorf("table option %s is not supported", p.input[p.peek(0).start:p.peek(0).end])
+			}
+			p.pos++
+			p.accept("=")
+			var value string
+			if value, err = p.word(); err == nil {
+				value = strings.ToUpper(value)
+				if value == "DEFAULT" || (value == "0" && name != "PACK_KEYS" && name != "STATS_PERSISTENT" && name != "STATS_AUTO_RECALC") {
+					delete(ot.createOptions, name)
+				} else {
+					ot.createOptions[name] = value
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finalize resolves character sets, nullability, default values, index names
+// and ordering, and then generates the table's CREATE statement.
+func (ot *offlineTable) finalize() (err error) {
+	o, flavor := ot.o, ot.o.flavor
+	if ot.charSet == "" && ot.collation == "" {
+		ot.CharSet, ot.Collation = o.charSet, o.collation
+	} else if ot.CharSet, ot.Collation, err = o.resolveCharSet(ot.charSet, ot.collation); err != nil {
+		return err
+	}
+	ot.CollationIsDefault = (ot.Collation == o.defaultCollation(ot.CharSet))
+
+	// Columns
+	colsByName := make(map[string]*offlineColumn, len(ot.columns))
+	var seenTimestamp, seenAutoInc bool
+	for _, col := range ot.columns {
+		lowerName := strings.ToLower(col.Name)
+		if colsByName[lowerName] != nil {
+			return fmt.Errorf("duplicate column name %s", tengo.EscapeIdentifier(col.Name))
+		}
+		colsByName[lowerName] = col
+		if col.AutoIncrement {
+			if seenAutoInc {
+				return errors.New("there can be only one auto_increment column")
+			}
+			seenAutoInc = true
+		}
+		if err := ot.resolveColumnCharSet(col); err != nil {
+			return fmt.Errorf("column %s: %s", tengo.EscapeIdentifier(col.Name), err)
+		}
+		if col.TypeInDB, err = ot.columnType(col); err != nil {
+			return fmt.Errorf("column %s: %s", tengo.EscapeIdentifier(col.Name), err)
+		}
+	}
+
+	// Indexes: mark primary key columns as NOT NULL, validate parts, and then
+	// remove redundant foreign key indexes and assign names
+	var pkCount int
+	for _, idx := range ot.indexes {
+		for n, part := range idx.Parts {
+			col := colsByName[strings.ToLower(part.ColumnName)]
+			if col == nil {
+				return fmt.Errorf("key column %s does not exist in table", tengo.EscapeIdentifier(part.ColumnName))
+			}
+			idx.Parts[n].ColumnName = col.Name
+			if part.PrefixLength > 0 {
+				if idx.Parts[n].PrefixLength, err = prefixLength(col, part.PrefixLength); err != nil {
+					return err
+				}
+			}
+			if idx.PrimaryKey {
+				if col.null {
+					return fmt.Errorf("column %s is part of the primary key, but is explicitly nullable", tengo.EscapeIdentifier(col.Name))
+				}
+				col.notNull = true
+			}
+		}
+		if idx.PrimaryKey {
+			pkCount++
+		}
+	}
+	if pkCount > 1 {
+		return errors.New("multiple primary keys defined")
+	}
+	ot.removeRedundantFKIndexes()
+	if err := ot.nameIndexes(); err != nil {
+		return err
+	}
+
+	for _, col := range ot.columns {
+		col.Nullable = !col.notNull
+		if col.baseType == "timestamp" && !flavor.MySQLishMinVersion(8, 0) {
+			// explicit_defaults_for_timestamp is disabled by default in these flavors
+			col.Nullable = col.null
+			if !seenTimestamp && !col.Nullable && col.def == nil && col.onUpdate == nil {
+				fsp := fspOf(col)
+				col.def = &offlineDefault{kind: defaultNow, val: fsp}
+				col.onUpdate = &offlineDefault{kind: defaultNow, val: fsp}
+			} else if !col.Nullable && col.def == nil {
+				return fmt.Errorf("column %s: implicit default for NOT NULL timestamp depends on server sql_mode; supply an explicit DEFAULT", tengo.EscapeIdentifier(col.Name))
+			}
+		}
+		if col.baseType == "timestamp" {
+			seenTimestamp = true
+		}
+		if col.Default, err = ot.columnDefault(col); err != nil {
+			return fmt.Errorf("column %s: %s", tengo.EscapeIdentifier(col.Name), err)
+		}
+		if col.onUpdate != nil {
+			if col.OnUpdate, err = ot.nowValue(col, col.onUpdate); err != nil {
+				return fmt.Errorf("column %s: %s", tengo.EscapeIdentifier(col.Name), err)
+			}
+		}
+		if col.AutoIncrement && !ot.autoIncIndexed(col) {
+			return fmt.Errorf("column %s: auto_increment column must be defined as a key", tengo.EscapeIdentifier(col.Name))
+		}
+		ot.Columns = append(ot.Columns, col.Column)
+	}
+	if ot.HasAutoIncrement() {
+		ot.NextAutoIncrement = 1
+		if ot.autoIncrement > 1 {
+			ot.NextAutoIncrement = ot.autoIncrement
+		}
+	}
+
+	ot.sortIndexes(colsByName)
+
+	// Foreign keys
+	if len(ot.foreignKeys) > 0 && ot.Engine != "InnoDB" {
+		return fmt.Errorf("foreign keys are not supported with storage engine %s", ot.Engine)
+	}
+	usedNames := make(map[string]bool, len(ot.foreignKeys))
+	for _, fk := range ot.foreignKeys {
+		if usedNames[strings.ToLower(fk.Name)] && fk.Name != "" {
+			return fmt.Errorf("duplicate foreign key constraint name %s", tengo.EscapeIdentifier(fk.Name))
+		}
+		usedNames[strings.ToLower(fk.Name)] = true
+	}
+	var fkCounter int
+	for _, fk := range ot.foreignKeys {
+		for fk.Name == "" {
+			fkCounter++
+			if name := fmt.Sprintf("%s_ibfk_%d", ot.Name, fkCounter); !usedNames[strings.ToLower(name)] {
+				fk.Name = name
+			}
+		}
+		for n, colName := range fk.ColumnNames {
+			col := colsByName[strings.ToLower(colName)]
+			if col == nil {
+				return fmt.Errorf("foreign key column %s does not exist in table", tengo.EscapeIdentifier(colName))
+			}
+			fk.ColumnNames[n] = col.Name
+		}
+	}
+	ot.ForeignKeys = ot.foreignKeys
+	if flavor.SortedForeignKeys() {
+		sort.Slice(ot.ForeignKeys, func(i, j int) bool {
+			return ot.ForeignKeys[i].Name < ot.ForeignKeys[j].Name
+		})
+	}
+
+	// Create options
+	var createOptions []string
+	for _, name := range createOptionOrder {
+		if value, ok := ot.createOptions[name]; ok {
+			createOptions = append(createOptions, fmt.Sprintf("%s=%s", name, value))
+		}
+	}
+	ot.CreateOptions = strings.Join(createOptions, " ")
+
+	ot.CreateStatement = ot.GeneratedCreateStatement(flavor)
+	return nil
+}
+
+// prefixLength validates an index prefix length for col, returning the
+// prefix length as the server would store it. A prefix covering the entire
+// length of a fixed- or variable-length string column is the same as no prefix.
+// An error is returned if the column type does not permit prefixes, or if the
+// prefix is longer than the column.
+func prefixLength(col *offlineColumn, length uint16) (uint16, error) {
+	switch col.baseType {
+	case "char", "varchar", "binary", "varbinary":
+		colLength := 1
+		if len(col.args) > 0 {
+			colLength, _ = strconv.Atoi(col.args[0]) // already validated by columnType
+		}
+		if int(length) > colLength {
+			return 0, fmt.Errorf("prefix length %d for key column %s is longer than the column", length, tengo.EscapeIdentifier(col.Name))
+		} else if int(length) == colLength {
+			return 0, nil
+		}
+		return length, nil
+	case "tinytext", "text", "mediumtext", "longtext", "tinyblob", "blob", "mediumblob", "longblob",
+		"geometry", "point", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon", "geometrycollection", "geomcollection":
+		return length, nil
+	}
+	return 0, fmt.Errorf("key column %s of type %s cannot have a prefix length", tengo.EscapeIdentifier(col.Name), col.baseType)
+}
+
+// resolveColumnCharSet determines the character set and collation of textual
+// columns, converting columns with the binary character set to the equivalent
+// binary type.
+func (ot *offlineTable) resolveColumnCharSet(col *offlineColumn) (err error) {
+	if !col.typeIsText {
+		if col.charSet != "" || col.collation != "" {
+			return errors.New("character set and collation may only be specified for textual types")
+		}
+		return nil
+	}
+	if col.charSet == "" && col.collation == "" {
+		col.CharSet, col.Collation = ot.CharSet, ot.Collation
+	} else if col.CharSet, col.Collation, err = ot.o.resolveCharSet(col.charSet, col.collation); err != nil {
+		return err
+	} else if col.charSet == "" && col.collation != "" {
+		// COLLATE without CHARACTER SET is only valid for the table's charset
+		if col.CharSet != ot.CharSet {
+			return fmt.Errorf("collation %s is not valid for table character set %s", col.Collation, ot.CharSet)
+		}
+	}
+	if col.binary && col.CharSet != "binary" {
+		col.Collation = col.CharSet + "_bin"
+	}
+	if col.CharSet == "binary" {
+		binaryTypes := map[string]string{
+			"char":       "binary",
+			"varchar":    "varbinary",
+			"tinytext":   "tinyblob",
+			"text":       "blob",
+			"mediumtext": "mediumblob",
+			"longtext":   "longblob",
+		}
+		if binaryType, ok := binaryTypes[col.baseType]; ok {
+			col.baseType, col.typeIsText = binaryType, false
+			col.CharSet, col.Collation = "", ""
+			return nil
+		}
+		return fmt.Errorf("character set binary is not supported for type %s", col.baseType)
+	}
+	col.CollationIsDefault = (col.Collation == ot.o.defaultCollation(col.CharSet))
+	return nil
+}
+
+// columnType returns the normalized type of col, as it would be displayed in
+// SHOW CREATE TABLE.
+func (ot *offlineTable) columnType(col *offlineColumn) (string, error) {
+	flavor := ot.o.flavor
+	var suffix string
+	if col.unsigned {
+		suffix = " unsigned"
+	}
+	if col.zerofill {
+		suffix += " zerofill"
+	}
+	numArg := func(n int) (int, error) {
+		val, err := strconv.Atoi(col.args[n])
+		if err != nil {
+			return 0, fmt.Errorf("invalid type argument %q", col.args[n])
+		}
+		return val, nil
+	}
+	argCount := func(min, max int) error {
+		if len(col.args) < min || len(col.args) > max {
+			return fmt.Errorf("wrong number of arguments for type %s", col.baseType)
+		}
+		return nil
+	}
+
+	if widths, ok := intDisplayWidths[col.baseType]; ok {
+		if err := argCount(0, 1); err != nil {
+			return "", err
+		}
+		width := widths[0]
+		if col.unsigned {
+			width = widths[1]
+		}
+		if len(col.args) > 0 {
+			var err error
+			if width, err = numArg(0); err != nil {
+				return "", err
+			}
+		}
+		if flavor.OmitIntDisplayWidth() && !col.zerofill && !(col.baseType == "tinyint" && width == 1) {
+			return col.baseType + suffix, nil
+		}
+		return fmt.Sprintf("%s(%d)%s", col.baseType, width, suffix), nil
+	}
+
+	switch col.baseType {
+	case "bit", "char", "binary":
+		if err := argCount(0, 1); err != nil {
+			return "", err
+		}
+		length := 1
+		if len(col.args) > 0 {
+			var err error
+			if length, err = numArg(0); err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("%s(%d)", col.baseType, length), nil
+	case "varchar", "varbinary":
+		if err := argCount(1, 1); err != nil {
+			return "", err
+		}
+		length, err := numArg(0)
+		return fmt.Sprintf("%s(%d)", col.baseType, length), err
+	case "decimal":
+		if err := argCount(0, 2); err != nil {
+			return "", err
+		}
+		precision, scale := 10, 0
+		var err error
+		if len(col.args) > 0 {
+			if precision, err = numArg(0); err != nil {
+				return "", err
+			}
+		}
+		if len(col.args) > 1 {
+			if scale, err = numArg(1); err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("decimal(%d,%d)%s", precision, scale, suffix), nil
+	case "float", "double":
+		if err := argCount(0, 2); err != nil {
+			return "", err
+		}
+		if len(col.args) == 1 {
+			if col.baseType == "double" {
+				return "", errors.New("wrong number of arguments for type double")
+			}
+			precision, err := numArg(0)
+			if err != nil {
+				return "", err
+			} else if precision > 24 {
+				col.baseType = "double"
+			}
+			col.args = nil
+		}
+		if len(col.args) == 2 {
+			return fmt.Sprintf("%s(%s,%s)%s", col.baseType, col.args[0], col.args[1], suffix), nil
+		}
+		return col.baseType + suffix, nil
+	case "time", "datetime", "timestamp":
+		if err := argCount(0, 1); err != nil {
+			return "", err
+		}
+		if fsp := fspOf(col); fsp != "" {
+			return fmt.Sprintf("%s(%s)", col.baseType, fsp), nil
+		}
+		return col.baseType, nil
+	case "year":
+		if len(col.args) > 1 || (len(col.args) == 1 && col.args[0] != "4") {
+			return "", errors.New("only 4-digit year type is supported")
+		}
+		if flavor.OmitIntDisplayWidth() {
+			return "year", nil
+		}
+		return "year(4)", nil
+	case "enum", "set":
+		if len(col.args) == 0 {
+			return "", fmt.Errorf("type %s requires a list of values", col.baseType)
+		}
+		values := make([]string, len(col.args))
+		for n, val := range col.args {
+			values[n] = fmt.Sprintf("'%s'", strings.Replace(strings.Replace(val, "\\", "\\\\", -1), "'", "''", -1))
+		}
+		return fmt.Sprintf("%s(%s)", col.baseType, strings.Join(values, ",")), nil
+	case "json":
+		if flavor.Vendor == tengo.VendorMariaDB {
+			return "", errors.New("type json is implemented as a constraint in MariaDB, which is not supported")
+		}
+	}
+	if simpleTypes[col.baseType] {
+		if len(col.args) > 0 {
+			return "", fmt.Errorf("arguments for type %s are not supported", col.baseType)
+		}
+		return col.baseType, nil
+	}
+	return "", fmt.Errorf("type %s is not supported", col.baseType)
+}
+
+// fspOf returns the fractional seconds precision of a temporal column, or an
+// empty string if it has none.
+func fspOf(col *offlineColumn) string {
+	if len(col.args) == 0 || col.args[0] == "0" {
+		return ""
+	}
+	return col.args[0]
+}
+
+var (
+	reDateDefault     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	reDateTimeDefault = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})(?:[ T](\d{2}:\d{2}:\d{2})(?:\.(\d+))?)?$`)
+	reTimeDefault     = regexp.MustCompile(`^(-?\d{2,3}:\d{2}:\d{2})(?:\.(\d+))?$`)
+	reYearDefault     = regexp.MustCompile(`^\d{4}$`)
+)
+
+// columnDefault returns the normalized DEFAULT clause value for col, in the
+// same form that tengo obtains from introspection of a live server.
+func (ot *offlineTable) columnDefault(col *offlineColumn) (string, error) {
+	flavor := ot.o.flavor
+	def := col.def
+	blobLike := strings.HasSuffix(col.TypeInDB, "blob") || strings.HasSuffix(col.TypeInDB, "text")
+	if def == nil || def.kind == defaultNull {
+		if def != nil && !col.Nullable {
+			return "", errors.New("NOT NULL column cannot have a NULL default")
+		}
+		if col.Nullable && !col.AutoIncrement && (!blobLike || flavor.AllowBlobDefaults()) {
+			return "NULL", nil
+		}
+		return "", nil
+	}
+	if col.AutoIncrement {
+		return "", errors.New("auto_increment column cannot have a default")
+	}
+	if simpleTypes[col.baseType] && col.baseType != "date" && !flavor.AllowBlobDefaults() {
+		return "", fmt.Errorf("type %s cannot have a default value in %s", col.baseType, flavor)
+	}
+	if def.kind == defaultNow {
+		return ot.nowValue(col, def)
+	}
+
+	// Numeric defaults are quoted in MySQL, but not in MariaDB 10.2+
+	quoteNumber := func(val string) string {
+		if flavor.VendorMinVersion(tengo.VendorMariaDB, 10, 2) {
+			return val
+		}
+		return fmt.Sprintf("'%s'", val)
+	}
+	literal := def.val
+	if def.kind == defaultBitHex {
+		base := 16
+		if literal[0] == 'b' {
+			base = 2
+		}
+		value, ok := new(big.Int).SetString(literal[2:len(literal)-1], base)
+		if !ok {
+			return "", fmt.Errorf("invalid literal %s", literal)
+		}
+		if col.baseType == "bit" {
+			return fmt.Sprintf("b'%s'", value.Text(2)), nil
+		} else if col.typeIsText || col.baseType == "binary" || col.baseType == "varbinary" {
+			return "", errors.New("hexadecimal and bit literal defaults are only supported for numeric types")
+		}
+		literal = value.String()
+	}
+
+	if _, isInt := intDisplayWidths[col.baseType]; isInt || col.baseType == "bit" {
+		if col.baseType == "bit" && def.kind == defaultString {
+			return "", errors.New("string defaults are not supported for type bit; use a bit-value literal")
+		}
+		value, ok := new(big.Int).SetString(strings.TrimPrefix(strings.TrimSpace(literal), "+"), 10)
+		if !ok {
+			return "", fmt.Errorf("invalid default value %q for integer type", literal)
+		} else if col.unsigned && value.Sign() < 0 {
+			return "", fmt.Errorf("invalid default value %q for unsigned type", literal)
+		}
+		if col.baseType == "bit" {
+			return fmt.Sprintf("b'%s'", value.Text(2)), nil
+		}
+		return quoteNumber(value.String()), nil
+	}
+
+	switch col.baseType {
+	case "decimal", "float", "double":
+		value, ok := new(big.Rat).SetString(strings.TrimSpace(literal))
+		if !ok {
+			return "", fmt.Errorf("invalid default value %q for numeric type", literal)
+		}
+		if len(col.args) == 2 {
+			scale, _ := strconv.Atoi(col.args[1])
+			return quoteNumber(value.FloatString(scale)), nil
+		} else if col.baseType == "decimal" {
+			return quoteNumber(value.FloatString(0)), nil
+		}
+		f, _ := value.Float64()
+		return quoteNumber(strconv.FormatFloat(f, 'f', -1, 64)), nil
+	case "date", "datetime", "timestamp", "time", "year":
+		value, err := normalizeTemporalDefault(col, literal)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("'%s'", value), nil
+	case "enum", "set":
+		var values []string
+		if col.baseType == "set" && literal != "" {
+			values = strings.Split(literal, ",")
+		} else if col.baseType == "enum" {
+			values = []string{literal}
+		}
+		// Set members are displayed in definition order
+		var result []string
+		for _, member := range col.args {
+			for _, val := range values {
+				if strings.EqualFold(val, member) {
+					result = append(result, member)
+					break
+				}
+			}
+		}
+		if len(result) != len(values) {
+			return "", fmt.Errorf("invalid default value %q for type %s", literal, col.baseType)
+		}
+		return fmt.Sprintf("'%s'", tengo.EscapeValueForCreateTable(strings.Join(result, ","))), nil
+	}
+	return fmt.Sprintf("'%s'", tengo.EscapeValueForCreateTable(literal)), nil
+}
+
+// nowValue returns the normalized form of CURRENT_TIMESTAMP for use in a
+// DEFAULT or ON UPDATE clause of col.
+func (ot *offlineTable) nowValue(col *offlineColumn, def *offlineDefault) (string, error) {
+	if col.baseType != "timestamp" && col.baseType != "datetime" {
+		return "", fmt.Errorf("CURRENT_TIMESTAMP is not permitted for type %s", col.baseType)
+	} else if !ot.o.flavor.MySQLishMinVersion(5, 6) && !ot.o.flavor.VendorMinVersion(tengo.VendorMariaDB, 10) && col.baseType == "datetime" {
+		return "", fmt.Errorf("CURRENT_TIMESTAMP is not permitted for type datetime in %s", ot.o.flavor)
+	}
+	if def.val != fspOf(col) {
+		return "", errors.New("CURRENT_TIMESTAMP precision must match column precision")
+	}
+	if ot.o.flavor.VendorMinVersion(tengo.VendorMariaDB, 10, 2) {
+		return fmt.Sprintf("current_timestamp(%s)", def.val), nil
+	} else if def.val != "" {
+		return fmt.Sprintf("CURRENT_TIMESTAMP(%s)", def.val), nil
+	}
+	return "CURRENT_TIMESTAMP", nil
+}
+
+// normalizeTemporalDefault returns the canonical form of a literal default
+// value for a temporal column. Only unambiguous formats are supported.
+func normalizeTemporalDefault(col *offlineColumn, literal string) (string, error) {
+	fsp, _ := strconv.Atoi(fspOf(col))
+	padFraction := func(fraction string) (string, error) {
+		if len(fraction) > fsp && strings.Trim(fraction[fsp:], "0") != "" {
+			return "", fmt.Errorf("default value %q has more fractional precision than column", literal)
+		} else if fsp == 0 {
+			return "", nil
+		}
+		fraction += strings.Repeat("0", fsp)
+		return "." + fraction[:fsp], nil
+	}
+	invalid := fmt.Errorf("unsupported default value %q for type %s; use a canonical format", literal, col.baseType)
+	switch col.baseType {
+	case "date":
+		if reDateDefault.MatchString(literal) {
+			return literal, nil
+		}
+	case "year":
+		if reYearDefault.MatchString(literal) {
+			return literal, nil
+		}
+	case "time":
+		if matches := reTimeDefault.FindStringSubmatch(literal); matches != nil {
+			fraction, err := padFraction(matches[2])
+			return matches[1] + fraction, err
+		}
+	default:
+		if matches := reDateTimeDefault.FindStringSubmatch(literal); matches != nil {
+			if matches[2] == "" {
+				matches[2] = "00:00:00"
+			}
+			fraction, err := padFraction(matches[3])
+			return matches[1] + " " + matches[2] + fraction, err
+		}
+	}
+	return "", invalid
+}
+
+// autoIncIndexed returns true if col is the first column of any index.
+func (ot *offlineTable) autoIncIndexed(col *offlineColumn) bool {
+	for _, idx := range ot.indexes {
+		if idx.Type == "BTREE" && idx.Parts[0].ColumnName == col.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// removeRedundantFKIndexes removes implicitly-created foreign key indexes if
+// some other index already has the foreign key's columns as a leftmost prefix.
+func (ot *offlineTable) removeRedundantFKIndexes() {
+	covers := func(idx, fkIdx *offlineIndex) bool {
+		if idx.Type != "BTREE" || len(idx.Parts) < len(fkIdx.Parts) {
+			return false
+		}
+		for n, part := range fkIdx.Parts {
+			if idx.Parts[n].ColumnName != part.ColumnName || idx.Parts[n].PrefixLength > 0 {
+				return false
+			}
+		}
+		return true
+	}
+	kept := make([]*offlineIndex, 0, len(ot.indexes))
+	for n, idx := range ot.indexes {
+		redundant := false
+		for m, other := range ot.indexes {
+			if idx.generatedFK && m != n && (!other.generatedFK || m < n) && covers(other, idx) {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			kept = append(kept, idx)
+		}
+	}
+	ot.indexes = kept
+}
+
+// nameIndexes assigns names to any unnamed indexes, based on the name of the
+// first column, and then confirms there are no duplicate names.
+func (ot *offlineTable) nameIndexes() error {
+	exists := func(name string, upTo int) bool {
+		if strings.EqualFold(name, "PRIMARY") {
+			return true
+		}
+		for _, idx := range ot.indexes[:upTo] {
+			if strings.EqualFold(idx.Name, name) {
+				return true
+			}
+		}
+		return false
+	}
+	for n, idx := range ot.indexes {
+		if idx.Name != "" {
+			continue
+		}
+		base := idx.Parts[0].ColumnName
+		idx.Name = base
+		for suffix := 2; exists(idx.Name, n); suffix++ {
+			idx.Name = fmt.Sprintf("%s_%d", base, suffix)
+		}
+	}
+	seen := make(map[string]bool, len(ot.indexes))
+	for _, idx := range ot.indexes {
+		lowerName := strings.ToLower(idx.Name)
+		if seen[lowerName] {
+			return fmt.Errorf("duplicate key name %s", tengo.EscapeIdentifier(idx.Name))
+		}
+		seen[lowerName] = true
+	}
+	return nil
+}
+
+// sortIndexes places the indexes in the same order that the server uses:
+// primary key first, then unique indexes, then non-unique indexes, and finally
+// full-text indexes.
+func (ot *offlineTable) sortIndexes(colsByName map[string]*offlineColumn) {
+	rank := func(idx *offlineIndex) int {
+		if idx.Unique {
+			var nullable, partial bool
+			for _, part := range idx.Parts {
+				nullable = nullable || colsByName[strings.ToLower(part.ColumnName)].Nullable
+				partial = partial || part.PrefixLength > 0
+			}
+			if nullable && partial {
+				return 3
+			} else if nullable {
+				return 2
+			} else if partial {
+				return 1
+			}
+			return 0
+		} else if idx.Type == "FULLTEXT" {
+			return 5
+		}
+		return 4
+	}
+	sort.SliceStable(ot.indexes, func(i, j int) bool {
+		return rank(ot.indexes[i]) < rank(ot.indexes[j])
+	})
+	for _, idx := range ot.indexes {
+		if idx.PrimaryKey {
+			ot.PrimaryKey = idx.Index
+		} else {
+			ot.SecondaryIndexes = append(ot.SecondaryIndexes, idx.Index)
+		}
+	}
+}
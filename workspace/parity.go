@@ -0,0 +1,203 @@
+package workspace
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/tengo"
+)
+
+// ParityCheck represents how to handle differences in server configuration
+// between a workspace's database instance and the target instance that the
+// workspace's results will be compared against.
+type ParityCheck int
+
+// Constants enumerating different parity check behaviors
+const (
+	// ParityCheckNone means server configurations are not compared
+	ParityCheckNone ParityCheck = iota
+
+	// ParityCheckWarn means differences are logged as warnings
+	ParityCheckWarn
+
+	// ParityCheckError means differences cause ExecLogicalSchema to return a
+	// fatal error
+	ParityCheckError
+
+	// ParityCheckCopy means settings from the target are applied to the
+	// workspace where possible, and any remaining differences are logged as
+	// warnings
+	ParityCheckCopy
+)
+
+// parityVariables lists global variables which affect the behavior of CREATE
+// statements or the resulting SHOW CREATE output, and therefore should match
+// between a workspace and its target.
+var parityVariables = []string{
+	"character_set_server",
+	"collation_server",
+	"explicit_defaults_for_timestamp",
+	"innodb_default_row_format",
+	"lower_case_table_names",
+}
+
+// instanceWorkspace is implemented by Workspace types that exist on a database
+// instance.
+type instanceWorkspace interface {
+	instance() *tengo.Instance
+}
+
+func (ts *TempSchema) instance() *tengo.Instance    { return ts.inst }
+func (ld *LocalDocker) instance() *tengo.Instance   { return ld.d.Instance }
+func (dh *DedicatedHost) instance() *tengo.Instance { return dh.inst }
+
+var parityCache struct {
+	vars    map[string]map[string]string // instance String() -> variable name -> value
+	checked map[string]error             // "workspace|target" -> result of check
+	sync.Mutex
+}
+
+// globalVariables returns the values of parityVariables for the supplied
+// instance. Variables which do not exist in the instance's flavor are omitted.
+// Results are cached for the lifetime of the process.
+func globalVariables(inst *tengo.Instance) (map[string]string, error) {
+	parityCache.Lock()
+	defer parityCache.Unlock()
+	if vars, ok := parityCache.vars[inst.String()]; ok {
+		return vars, nil
+	}
+	db, err := inst.Connect("", "")
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Name  string `db:"Variable_name"`
+		Value string `db:"Value"`
+	}
+	query := fmt.Sprintf("SHOW GLOBAL VARIABLES WHERE Variable_name IN ('%s')", strings.Join(parityVariables, "', '"))
+	if err := db.Select(&rows, query); err != nil {
+		return nil, err
+	}
+	vars := make(map[string]string, len(rows))
+	for _, row := range rows {
+		vars[strings.ToLower(row.Name)] = row.Value
+	}
+	if parityCache.vars == nil {
+		parityCache.vars = make(map[string]map[string]string)
+	}
+	parityCache.vars[inst.String()] = vars
+	return vars, nil
+}
+
+// copyableVariables returns the subset of parityVariables which ParityCheckCopy
+// can apply to a workspace of the supplied flavor.
+func copyableVariables(flavor tengo.Flavor) map[string]bool {
+	copyable := map[string]bool{
+		"character_set_server": true, // via workspace schema's default charset
+		"collation_server":     true, // via workspace schema's default collation
+	}
+	// explicit_defaults_for_timestamp only became a dynamic session variable
+	// in MySQL 8
+	if flavor.MySQLishMinVersion(8, 0) {
+		copyable["explicit_defaults_for_timestamp"] = true
+	}
+	return copyable
+}
+
+// applyParityCopy modifies opts to use settings from target's global
+// variables, for the subset of parityVariables that may be copied to a
+// workspace. Schema-level character set and collation in the LogicalSchema
+// still take precedence, since ExecLogicalSchema applies them afterwards.
+func applyParityCopy(opts *Options, target *tengo.Instance) error {
+	vars, err := globalVariables(target)
+	if err != nil {
+		return fmt.Errorf("Unable to query global variables on %s: %s", target, err)
+	}
+	opts.DefaultCharacterSet = vars["character_set_server"]
+	opts.DefaultCollation = vars["collation_server"]
+	if value, ok := vars["explicit_defaults_for_timestamp"]; ok && copyableVariables(opts.Flavor)["explicit_defaults_for_timestamp"] {
+		opts.SessionParams = "explicit_defaults_for_timestamp=" + value
+	}
+	return nil
+}
+
+// parityMismatches returns a description of each variable which differs
+// between the workspace and target, excluding any in skip. Variables which only
+// exist on one side are ignored, since flavor differences are handled
+// separately.
+func parityMismatches(wsVars, targetVars map[string]string, skip map[string]bool) []string {
+	var mismatches []string
+	for name, targetValue := range targetVars {
+		wsValue, ok := wsVars[name]
+		if ok && !skip[name] && !strings.EqualFold(wsValue, targetValue) {
+			mismatches = append(mismatches, fmt.Sprintf("%s is %q in workspace but %q on target", name, wsValue, targetValue))
+		}
+	}
+	sort.Strings(mismatches)
+	return mismatches
+}
+
+// checkParity compares server configuration between wsInst and opts.Target.
+// With ParityCheckError, an error is returned if any differences are found.
+// Otherwise, differences are logged as warnings.
+func checkParity(wsInst *tengo.Instance, opts Options) error {
+	if wsInst.String() == opts.ParityTarget.String() {
+		return nil
+	}
+	wsVars, err := globalVariables(wsInst)
+	if err != nil {
+		return fmt.Errorf("Unable to query global variables on workspace %s: %s", wsInst, err)
+	}
+	return compareParity(wsInst.String(), wsVars, opts)
+}
+
+// compareParity compares wsVars, the parityVariables of the workspace instance
+// named wsName, to those of opts.Target. This permits checking parity of a
+// cached workspace result, without connecting to the workspace instance. The
+// result is otherwise identical to checkParity.
+func compareParity(wsName string, wsVars map[string]string, opts Options) error {
+	target := opts.ParityTarget
+	if wsName == target.String() {
+		return nil
+	}
+	pairKey := wsName + "|" + target.String()
+	parityCache.Lock()
+	err, alreadyChecked := parityCache.checked[pairKey]
+	parityCache.Unlock()
+	if alreadyChecked {
+		if opts.ParityCheck == ParityCheckError {
+			return err
+		}
+		return nil
+	}
+
+	targetVars, err := globalVariables(target)
+	if err != nil {
+		return fmt.Errorf("Unable to query global variables on %s: %s", target, err)
+	}
+	var skip map[string]bool
+	if opts.ParityCheck == ParityCheckCopy {
+		skip = copyableVariables(opts.Flavor)
+	}
+	mismatches := parityMismatches(wsVars, targetVars, skip)
+	if len(mismatches) > 0 {
+		err = fmt.Errorf("Workspace %s does not match server configuration of %s: %s", wsName, target, strings.Join(mismatches, "; "))
+		if opts.ParityCheck != ParityCheckError {
+			log.Warn(err.Error())
+		}
+	}
+
+	parityCache.Lock()
+	if parityCache.checked == nil {
+		parityCache.checked = make(map[string]error)
+	}
+	parityCache.checked[pairKey] = err
+	parityCache.Unlock()
+	if opts.ParityCheck == ParityCheckError {
+		return err
+	}
+	return nil
+}
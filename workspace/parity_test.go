@@ -0,0 +1,111 @@
+package workspace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestCheckParity(t *testing.T) {
+	wsInst, _ := tengo.NewInstance("mysql", "root@tcp(workspace.example.com:3306)/")
+	targetInst, _ := tengo.NewInstance("mysql", "root@tcp(prod.example.com:3306)/")
+	otherInst, _ := tengo.NewInstance("mysql", "root@tcp(other.example.com:3306)/")
+
+	// Pre-populate cached variables, so that no connections are attempted
+	parityCache.Lock()
+	parityCache.vars = map[string]map[string]string{
+		wsInst.String(): {
+			"character_set_server":            "latin1",
+			"collation_server":                "latin1_swedish_ci",
+			"explicit_defaults_for_timestamp": "OFF",
+			"lower_case_table_names":          "0",
+		},
+		targetInst.String(): {
+			"character_set_server":            "utf8mb4",
+			"collation_server":                "utf8mb4_general_ci",
+			"explicit_defaults_for_timestamp": "ON",
+			"innodb_default_row_format":       "dynamic",
+			"lower_case_table_names":          "1",
+		},
+		otherInst.String(): {
+			"character_set_server":            "LATIN1",
+			"collation_server":                "latin1_swedish_ci",
+			"explicit_defaults_for_timestamp": "OFF",
+			"lower_case_table_names":          "0",
+		},
+	}
+	parityCache.checked = nil
+	parityCache.Unlock()
+
+	// Same instance, or matching configuration: no error
+	opts := Options{ParityCheck: ParityCheckError, ParityTarget: wsInst, Flavor: tengo.NewFlavor("mysql:5.7")}
+	if err := checkParity(wsInst, opts); err != nil {
+		t.Errorf("Unexpected error from checkParity: %s", err)
+	}
+	opts.ParityTarget = otherInst
+	if err := checkParity(wsInst, opts); err != nil {
+		t.Errorf("Unexpected error from checkParity: %s", err)
+	}
+
+	// Mismatches should be an error with ParityCheckError, including on repeated
+	// calls. Variables only present on one side are ignored.
+	opts.ParityTarget = targetInst
+	for n := 0; n < 2; n++ {
+		err := checkParity(wsInst, opts)
+		if err == nil {
+			t.Fatal("Expected error from checkParity, but err was nil")
+		}
+		for _, name := range []string{"character_set_server", "collation_server", "explicit_defaults_for_timestamp", "lower_case_table_names"} {
+			if !strings.Contains(err.Error(), name) {
+				t.Errorf("Expected error to mention %s, but it did not: %s", name, err)
+			}
+		}
+		if strings.Contains(err.Error(), "innodb_default_row_format") {
+			t.Errorf("Expected error to ignore innodb_default_row_format, but it did not: %s", err)
+		}
+	}
+
+	// Parity of a cached workspace result is checked using its recorded
+	// variables, without connecting to the workspace
+	opts.ParityTarget = otherInst
+	cachedVars := map[string]string{"lower_case_table_names": "1"}
+	if err := compareParity("cached.example.com:3306", cachedVars, opts); err == nil || !strings.Contains(err.Error(), "lower_case_table_names") {
+		t.Errorf("Expected error from compareParity to mention lower_case_table_names, instead found %v", err)
+	}
+	if err := compareParity(otherInst.String(), cachedVars, opts); err != nil {
+		t.Errorf("Unexpected error from compareParity for same instance: %s", err)
+	}
+	opts.ParityTarget = targetInst
+
+	// Mismatches are not an error with ParityCheckWarn
+	opts.ParityCheck = ParityCheckWarn
+	if err := checkParity(wsInst, opts); err != nil {
+		t.Errorf("Unexpected error from checkParity: %s", err)
+	}
+
+	// Copyable variables should be excluded from mismatches in ParityCheckCopy,
+	// and copied into Options
+	mismatches := parityMismatches(parityCache.vars[wsInst.String()], parityCache.vars[targetInst.String()], copyableVariables(tengo.NewFlavor("mysql:5.7")))
+	if len(mismatches) != 2 || !strings.HasPrefix(mismatches[0], "explicit_defaults_for_timestamp") || !strings.HasPrefix(mismatches[1], "lower_case_table_names") {
+		t.Errorf("Unexpected mismatches: %v", mismatches)
+	}
+	mismatches = parityMismatches(parityCache.vars[wsInst.String()], parityCache.vars[targetInst.String()], copyableVariables(tengo.NewFlavor("mysql:8.0")))
+	if len(mismatches) != 1 || !strings.HasPrefix(mismatches[0], "lower_case_table_names") {
+		t.Errorf("Unexpected mismatches: %v", mismatches)
+	}
+	copyOpts := Options{Flavor: tengo.NewFlavor("mysql:8.0")}
+	if err := applyParityCopy(&copyOpts, targetInst); err != nil {
+		t.Fatalf("Unexpected error from applyParityCopy: %s", err)
+	}
+	if copyOpts.DefaultCharacterSet != "utf8mb4" || copyOpts.DefaultCollation != "utf8mb4_general_ci" || copyOpts.SessionParams != "explicit_defaults_for_timestamp=ON" {
+		t.Errorf("Unexpected result from applyParityCopy: %+v", copyOpts)
+	}
+	copyOpts = Options{Flavor: tengo.NewFlavor("mysql:5.7")}
+	if err := applyParityCopy(&copyOpts, targetInst); err != nil {
+		t.Fatalf("Unexpected error from applyParityCopy: %s", err)
+	}
+	if copyOpts.SessionParams != "" {
+		t.Errorf("Expected no session params to be copied for flavor %s, but found %s", copyOpts.Flavor, copyOpts.SessionParams)
+	}
+}
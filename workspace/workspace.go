@@ -2,7 +2,8 @@
 // schema. It manages creating a schema on a desired location (either an
 // existing MySQL instance, or a dynamically-controlled Docker instance),
 // running SQL DDL or DML, introspecting the resulting schema, and cleaning
-// up the schema when it is no longer needed.
+// up the schema when it is no longer needed. Alternatively, an offline
+// workspace may be used, which parses CREATE statements without any server.
 package workspace
 
 import (
@@ -43,9 +44,11 @@ type Type int
 
 // Constants enumerating different types of workspaces
 const (
-	TypeTempSchema  Type = iota // A temporary schema on a real pre-supplied Instance
-	TypeLocalDocker             // A schema on an ephemeral Docker container on localhost
-	TypePrefab                  // A pre-supplied Workspace, possibly from another package
+	TypeTempSchema    Type = iota // A temporary schema on a real pre-supplied Instance
+	TypeLocalDocker               // A schema on an ephemeral Docker container on localhost
+	TypePrefab                    // A pre-supplied Workspace, possibly from another package
+	TypeOffline                   // Parsed CREATE statements, without any database server
+	TypeDedicatedHost             // A uniquely-named schema on a separate pre-supplied Instance
 )
 
 // CleanupAction represents how to clean up a workspace.
@@ -75,9 +78,14 @@ const (
 type Options struct {
 	Type                Type
 	CleanupAction       CleanupAction
-	Instance            *tengo.Instance // only TypeTempSchema
-	Flavor              tengo.Flavor    // only TypeLocalDocker
+	Instance            *tengo.Instance // only TypeTempSchema or TypeDedicatedHost
+	Flavor              tengo.Flavor    // only TypeLocalDocker, TypeOffline, or TypeDedicatedHost
 	ContainerName       string          // only TypeLocalDocker
+	Image               string          // only TypeLocalDocker; if blank, derived from Flavor
+	PoolSize            int             // only TypeLocalDocker; max containers per image
+	WarmPool            bool            // only TypeLocalDocker; start all PoolSize containers upon first use
+	ServerArgs          []string        // only TypeLocalDocker; extra args for the containerized server
+	Tmpfs               bool            // only TypeLocalDocker; use tmpfs for the container's data dir
 	SchemaName          string
 	DefaultCharacterSet string
 	DefaultCollation    string
@@ -85,8 +93,13 @@ type Options struct {
 	RootPassword        string    // only TypeLocalDocker
 	PrefabWorkspace     Workspace // only TypePrefab
 	LockWaitTimeout     time.Duration
+	MaxSchemaAge        time.Duration // only TypeDedicatedHost
 	Concurrency         int
 	SkipBinlog          bool
+	CacheDir            string // if non-empty, ExecLogicalSchema results are cached here
+	ParityCheck         ParityCheck
+	ParityTarget        *tengo.Instance // instance to compare server configuration against
+	SessionParams       string          // additional session variables for executing statements
 }
 
 // New returns a pointer to a ready-to-use Workspace, using the configuration
@@ -99,6 +112,10 @@ func New(opts Options) (Workspace, error) {
 		return NewLocalDocker(opts)
 	case TypePrefab:
 		return opts.PrefabWorkspace, nil
+	case TypeOffline:
+		return NewOffline(opts)
+	case TypeDedicatedHost:
+		return NewDedicatedHost(opts)
 	}
 	return nil, fmt.Errorf("Unsupported workspace type %v", opts.Type)
 }
@@ -108,9 +125,12 @@ func New(opts Options) (Workspace, error) {
 // workspace won't be temp-schema based.
 // This method relies on option definitions from util.AddGlobalOptions(),
 // including "workspace", "temp-schema", "flavor", "docker-cleanup",
-// "reuse-temp-schema", "temp-schema-threads", "temp-schema-binlog"
+// "reuse-temp-schema", "temp-schema-threads", "temp-schema-binlog",
+// "workspace-cache", "workspace-host", "workspace-cleanup-age", "docker-image",
+// "docker-containers", "docker-warm-pool", "docker-args", "docker-tmpfs",
+// "workspace-parity"
 func OptionsForDir(dir *fs.Dir, instance *tengo.Instance) (Options, error) {
-	requestedType, err := dir.Config.GetEnum("workspace", "temp-schema", "docker")
+	requestedType, err := dir.Config.GetEnum("workspace", "temp-schema", "docker", "offline", "dedicated-host")
 	if err != nil {
 		return Options{}, err
 	}
@@ -120,14 +140,37 @@ func OptionsForDir(dir *fs.Dir, instance *tengo.Instance) (Options, error) {
 		LockWaitTimeout: 30 * time.Second,
 		Concurrency:     10,
 	}
-	if requestedType == "docker" {
+	if requestedType == "offline" {
+		opts.Type = TypeOffline
+		opts.Flavor = tengo.NewFlavor(dir.Config.Get("flavor"))
+		if !opts.Flavor.Known() && instance != nil {
+			opts.Flavor = instance.Flavor()
+		}
+		if !opts.Flavor.Supported() {
+			return Options{}, fmt.Errorf("workspace=offline requires a supported flavor, but flavor is %q", dir.Config.Get("flavor"))
+		}
+	} else if requestedType == "docker" {
 		opts.Type = TypeLocalDocker
 		opts.Flavor = tengo.NewFlavor(dir.Config.Get("flavor"))
 		opts.SkipBinlog = true
 		if !opts.Flavor.Known() && instance != nil {
 			opts.Flavor = instance.Flavor().Family()
 		}
-		opts.ContainerName = fmt.Sprintf("skeema-%s", strings.Replace(opts.Flavor.String(), ":", "-", -1))
+		opts.Image = DockerImage(dir.Config.Get("docker-image"), opts.Flavor)
+		opts.ServerArgs = dir.Config.GetSlice("docker-args", ' ', true)
+		opts.Tmpfs = dir.Config.GetBool("docker-tmpfs")
+		opts.WarmPool = dir.Config.GetBool("docker-warm-pool")
+		opts.ContainerName = ContainerNameForImage(opts.Image) + containerConfigSuffix(opts.ServerArgs, opts.Tmpfs)
+		for _, arg := range opts.ServerArgs {
+			if !strings.HasPrefix(arg, "-") {
+				return Options{}, fmt.Errorf("docker-args: each server argument must begin with a dash, but found %q", arg)
+			}
+		}
+		if opts.PoolSize, err = dir.Config.GetInt("docker-containers"); err != nil {
+			return Options{}, err
+		} else if opts.PoolSize < 1 {
+			return Options{}, errors.New("docker-containers cannot be less than 1")
+		}
 		if cleanup, err := dir.Config.GetEnum("docker-cleanup", "none", "stop", "destroy"); err != nil {
 			return Options{}, err
 		} else if cleanup == "stop" {
@@ -139,10 +182,29 @@ func OptionsForDir(dir *fs.Dir, instance *tengo.Instance) (Options, error) {
 			return Options{}, err
 		}
 	} else {
-		opts.Type = TypeTempSchema
-		opts.Instance = instance
-		if !dir.Config.GetBool("reuse-temp-schema") {
+		// temp-schema and dedicated-host both operate on a pre-existing instance,
+		// and share options for concurrency and binlogging
+		if requestedType == "dedicated-host" {
+			opts.Type = TypeDedicatedHost
 			opts.CleanupAction = CleanupActionDrop
+			if opts.Instance, err = dir.WorkspaceInstance(); err != nil {
+				return Options{}, err
+			} else if opts.Instance == nil {
+				return Options{}, errors.New("workspace=dedicated-host requires the workspace-host option to be set")
+			}
+			opts.Flavor = tengo.NewFlavor(dir.Config.Get("flavor"))
+			if instance != nil {
+				opts.Flavor = instance.Flavor()
+			}
+			if opts.MaxSchemaAge, err = time.ParseDuration(dir.Config.Get("workspace-cleanup-age")); err != nil {
+				return Options{}, fmt.Errorf("Invalid value for workspace-cleanup-age: %s", err)
+			}
+		} else {
+			opts.Type = TypeTempSchema
+			opts.Instance = instance
+			if !dir.Config.GetBool("reuse-temp-schema") {
+				opts.CleanupAction = CleanupActionDrop
+			}
 		}
 		if concurrency, err := dir.Config.GetInt("temp-schema-threads"); err != nil {
 			return Options{}, err
@@ -155,11 +217,32 @@ func OptionsForDir(dir *fs.Dir, instance *tengo.Instance) (Options, error) {
 		if err != nil {
 			return Options{}, err
 		}
-		opts.SkipBinlog = (binlogEnum == "off" || (binlogEnum == "auto" && instance.CanSkipBinlog()))
+		opts.SkipBinlog = (binlogEnum == "off" || (binlogEnum == "auto" && opts.Instance.CanSkipBinlog()))
 
 		// Note: no support for opts.DefaultConnParams for temp-schema because the
 		// supplied instance already has default params
 	}
+	if parity, err := dir.Config.GetEnum("workspace-parity", "off", "warn", "error", "copy"); err != nil {
+		return Options{}, err
+	} else if parity != "off" && instance != nil && opts.Type != TypeTempSchema && opts.Type != TypeOffline {
+		opts.ParityTarget = instance
+		switch parity {
+		case "warn":
+			opts.ParityCheck = ParityCheckWarn
+		case "error":
+			opts.ParityCheck = ParityCheckError
+		case "copy":
+			opts.ParityCheck = ParityCheckCopy
+			if err := applyParityCopy(&opts, instance); err != nil {
+				return Options{}, err
+			}
+		}
+	}
+	if dir.Config.GetBool("workspace-cache") {
+		if opts.CacheDir, err = DefaultCacheDir(); err != nil {
+			return Options{}, fmt.Errorf("Unable to determine workspace cache location: %s", err)
+		}
+	}
 	return opts, nil
 }
 
@@ -222,6 +305,16 @@ func (se *StatementError) String() string {
 	return se.Error()
 }
 
+// Message returns the underlying error message, without the location or the
+// generic prefix describing which type of statement failed in the workspace.
+func (se *StatementError) Message() string {
+	message := se.Err.Error()
+	for _, prefix := range []string{ddlFailurePrefix, insertFailurePrefix} {
+		message = strings.TrimPrefix(message, prefix)
+	}
+	return message
+}
+
 // Schema captures the result of executing the SQL from an fs.LogicalSchema
 // in a workspace, and then introspecting the resulting schema. It wraps the
 // introspected tengo.Schema alongside the original fs.LogicalSchema and any
@@ -230,6 +323,7 @@ type Schema struct {
 	*tengo.Schema
 	LogicalSchema *fs.LogicalSchema
 	Failures      []*StatementError
+	Data          map[string]*fs.TableData // rows of LogicalSchema.DataTables, keyed by table name
 }
 
 // FailedKeys returns a slice of tengo.ObjectKey values corresponding to
@@ -248,23 +342,97 @@ func (wsSchema *Schema) FailedKeys() (result []tengo.ObjectKey) {
 // tables that could not be created). Such individual statement errors are not
 // fatal and are not included in the error return value. The error return value
 // only represents fatal errors that prevented the entire process.
+// If the LogicalSchema has any DataTables, its INSERTs are executed as well,
+// and the resulting rows are included in the returned value.
+// If opts.CacheDir is set, a previously-cached result for identical input is
+// returned without using a Workspace at all, and new results are added to the
+// cache.
 func ExecLogicalSchema(logicalSchema *fs.LogicalSchema, opts Options) (wsSchema *Schema, fatalErr error) {
 	if logicalSchema.CharSet != "" {
+		if logicalSchema.CharSet != opts.DefaultCharacterSet {
+			opts.DefaultCollation = "" // avoid mismatched charset and collation
+		}
 		opts.DefaultCharacterSet = logicalSchema.CharSet
 	}
 	if logicalSchema.Collation != "" {
 		opts.DefaultCollation = logicalSchema.Collation
 	}
+	var key string
+	var parity *cachedParity
+	checkingParity := opts.ParityCheck != ParityCheckNone && opts.ParityTarget != nil
+	if opts.CacheDir != "" && opts.Type != TypePrefab {
+		key = cacheKey(logicalSchema, opts)
+	}
+	if key != "" {
+		if cached, recorded := loadCachedSchema(opts.CacheDir, key, logicalSchema); cached != nil {
+			// Parity must be checked against the target's current configuration,
+			// using the workspace configuration recorded in the cache entry.
+			// Offline workspaces have no configuration to check.
+			usable := true
+			if checkingParity && opts.Type != TypeOffline {
+				if recorded == nil {
+					log.Debug("Ignoring workspace cache entry which lacks parity information")
+					usable = false
+				} else if err := compareParity(recorded.Instance, recorded.Vars, opts); err != nil {
+					return nil, err
+				}
+			}
+			if usable {
+				log.Debugf("Using cached workspace result for %d statements", len(logicalSchema.Creates)+len(logicalSchema.Alters))
+				return cached, nil
+			}
+		}
+		defer func() {
+			if fatalErr == nil {
+				if err := storeCachedSchema(opts.CacheDir, key, wsSchema, parity); err != nil {
+					log.Warnf("Unable to write workspace cache: %s", err)
+				}
+			}
+		}()
+	}
 	var ws Workspace
 	ws, fatalErr = New(opts)
 	if fatalErr != nil {
 		return
 	}
+	start := time.Now()
+	defer func() {
+		if fatalErr == nil {
+			log.WithFields(log.Fields{
+				"schema":   logicalSchema.Name,
+				"duration": time.Since(start).Seconds(),
+			}).Debugf("Executed %d statements in workspace, with %d failures", len(logicalSchema.Creates)+len(logicalSchema.Alters)+len(logicalSchema.Inserts), len(wsSchema.Failures))
+		}
+	}()
 	defer func() {
 		if cleanupErr := ws.Cleanup(); fatalErr == nil {
 			fatalErr = cleanupErr
 		}
 	}()
+	if offline, ok := ws.(*Offline); ok {
+		return offline.execLogicalSchema(logicalSchema)
+	}
+	if len(logicalSchema.Inserts) > 0 {
+		// Runs before the deferred Cleanup above
+		defer func() {
+			if err := clearInsertedRows(ws, logicalSchema, opts); fatalErr == nil {
+				fatalErr = err
+			}
+		}()
+	}
+	if iw, ok := ws.(instanceWorkspace); ok && checkingParity {
+		if fatalErr = checkParity(iw.instance(), opts); fatalErr != nil {
+			return
+		}
+		if key != "" {
+			parity = &cachedParity{Instance: iw.instance().String()}
+			if iw.instance().String() != opts.ParityTarget.String() {
+				if parity.Vars, fatalErr = globalVariables(iw.instance()); fatalErr != nil {
+					return
+				}
+			}
+		}
+	}
 
 	// Run CREATEs in parallel
 	th := throttler.New(opts.Concurrency, len(logicalSchema.Creates))
@@ -302,8 +470,9 @@ func ExecLogicalSchema(logicalSchema *fs.LogicalSchema, opts Options) (wsSchema
 	}
 
 	// Run ALTERs sequentially, since foreign key manipulations don't play
-	// nice with concurrency.
+	// nice with concurrency. INSERTs follow, in the order they appear.
 	sequentialStatements = append(sequentialStatements, logicalSchema.Alters...)
+	sequentialStatements = append(sequentialStatements, logicalSchema.Inserts...)
 
 	for _, statement := range sequentialStatements {
 		db, connErr := ws.ConnectionPool(paramsForStatement(statement, opts))
@@ -316,7 +485,9 @@ func ExecLogicalSchema(logicalSchema *fs.LogicalSchema, opts Options) (wsSchema
 		}
 	}
 
-	wsSchema.Schema, fatalErr = ws.IntrospectSchema()
+	if wsSchema.Schema, fatalErr = ws.IntrospectSchema(); fatalErr == nil && len(logicalSchema.DataTables) > 0 {
+		wsSchema.Data, fatalErr = queryWorkspaceData(ws, wsSchema, opts)
+	}
 	return
 }
 
@@ -330,6 +501,11 @@ func paramsForStatement(statement *fs.Statement, opts Options) string {
 		params = append(params, "sql_log_bin=0")
 	}
 
+	// Apply any session variables copied from the target instance
+	if opts.SessionParams != "" {
+		params = append(params, opts.SessionParams)
+	}
+
 	// Disable FK checks when operating on tables, since otherwise DDL would
 	// need to be ordered to resolve dependencies, and circular references would
 	// be highly problematic
@@ -354,6 +530,12 @@ func paramsForStatement(statement *fs.Statement, opts Options) string {
 	return strings.Join(params, "&")
 }
 
+// Prefixes for errors returned by wrapFailure
+const (
+	ddlFailurePrefix    = "Error executing DDL in workspace: "
+	insertFailurePrefix = "Error executing INSERT in workspace: "
+)
+
 func wrapFailure(statement *fs.Statement, err error) *StatementError {
 	stmtErr := &StatementError{
 		Statement: statement,
@@ -362,8 +544,10 @@ func wrapFailure(statement *fs.Statement, err error) *StatementError {
 		stmtErr.Err = fmt.Errorf("SQL syntax error: %s", err)
 	} else if tengo.IsDatabaseError(err, mysqlerr.ER_LOCK_DEADLOCK) {
 		stmtErr.Err = err // Need to maintain original type
+	} else if statement.Type == fs.StatementTypeInsert {
+		stmtErr.Err = fmt.Errorf("%s%s", insertFailurePrefix, err)
 	} else {
-		stmtErr.Err = fmt.Errorf("Error executing DDL in workspace: %s", err)
+		stmtErr.Err = fmt.Errorf("%s%s", ddlFailurePrefix, err)
 	}
 	return stmtErr
 }
@@ -393,13 +577,13 @@ func getLock(instance *tengo.Instance, lockName string, maxWait time.Duration) (
 			case <-done:
 				err := lockConn.QueryRowContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName).Scan(&result)
 				if err != nil || result != 1 {
-					log.Warnf("%s: Failed to release lock, or lock released early due to connection being dropped: %s [%d]", instance, err, result)
+					log.WithField("instance", instance.String()).Warnf("%s: Failed to release lock, or lock released early due to connection being dropped: %s [%d]", instance, err, result)
 				}
 				return
 			case <-time.After(750 * time.Millisecond):
 				err := lockConn.QueryRowContext(context.Background(), "SELECT 1").Scan(&result)
 				if err != nil {
-					log.Warnf("%s: Lock released early due to connection being dropped: %s", instance, err)
+					log.WithField("instance", instance.String()).Warnf("%s: Lock released early due to connection being dropped: %s", instance, err)
 					return
 				}
 			}
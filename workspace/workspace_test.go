@@ -36,6 +36,22 @@ func TestIntegration(t *testing.T) {
 	tengo.RunSuite(suite, t, images)
 }
 
+func TestStatementErrorMessage(t *testing.T) {
+	cases := map[*fs.Statement]string{
+		{File: "a.sql", LineNo: 1, Text: "CREATE TABLE a (id int);\n", Type: fs.StatementTypeCreate}:  "Error 1050: already exists",
+		{File: "a.sql", LineNo: 2, Text: "INSERT INTO a VALUES (1);\n", Type: fs.StatementTypeInsert}: "Error 1062: duplicate entry",
+	}
+	for stmt, message := range cases {
+		stmtErr := wrapFailure(stmt, fmt.Errorf("%s", message))
+		if actual := stmtErr.Message(); actual != message {
+			t.Errorf("Expected Message() to return %q, instead found %q", message, actual)
+		}
+		if !strings.HasSuffix(stmtErr.Error(), "in workspace: "+message) {
+			t.Errorf("Expected Error() to retain workspace prefix, instead found %q", stmtErr.Error())
+		}
+	}
+}
+
 type WorkspaceIntegrationSuite struct {
 	manager *tengo.DockerClient
 	d       *tengo.DockerizedInstance
@@ -232,11 +248,94 @@ func (s WorkspaceIntegrationSuite) TestOptionsForDir(t *testing.T) {
 	}
 
 	// Test docker with specific flavor
-	if opts = getOpts("--workspace=docker --flavor=mysql:5.5"); opts.Flavor.String() != "mysql:5.5" {
+	if opts = getOpts("--workspace=docker --flavor=mysql:5.5"); opts.Flavor.String() != "mysql:5.5" || opts.Image != "mysql:5.5" || opts.ContainerName != "skeema-mysql-5.5" {
+		t.Errorf("Unexpected return from OptionsForDir: %+v", opts)
+	}
+
+	// Test docker with custom image
+	opts = getOpts("--workspace=docker --flavor=mysql:5.7 --docker-image=mirror.example.com/{VENDOR}:{VERSION}")
+	if opts.Image != "mirror.example.com/mysql:5.7" || opts.ContainerName != "skeema-mirror.example.com-mysql-5.7" {
+		t.Errorf("Unexpected return from OptionsForDir: %+v", opts)
+	}
+
+	// Test docker with server args and tmpfs, which should use a separate
+	// container from the default configuration
+	assertOptsError("--workspace=docker --docker-args='lower-case-table-names=1'")
+	opts = getOpts("--workspace=docker --flavor=mysql:5.7 --docker-args='--lower-case-table-names=1 --sql-mode=ANSI' --docker-tmpfs --docker-warm-pool --docker-containers=2")
+	if len(opts.ServerArgs) != 2 || opts.ServerArgs[1] != "--sql-mode=ANSI" || !opts.Tmpfs || !opts.WarmPool || opts.PoolSize != 2 {
+		t.Errorf("Unexpected return from OptionsForDir: %+v", opts)
+	} else if !strings.HasPrefix(opts.ContainerName, "skeema-mysql-5.7-") || opts.ContainerName == getOpts("--workspace=docker --flavor=mysql:5.7 --docker-tmpfs").ContainerName {
+		t.Errorf("Unexpected container name %q", opts.ContainerName)
+	}
+
+	// Test offline, with and without specific flavor
+	if opts = getOpts("--workspace=offline --flavor=mariadb:10.3"); opts.Type != TypeOffline || opts.Flavor.String() != "mariadb:10.3" {
+		t.Errorf("Unexpected return from OptionsForDir: %+v", opts)
+	}
+	if opts = getOpts("--workspace=offline"); opts.Type != TypeOffline || opts.Flavor != s.d.Flavor() {
+		t.Errorf("Unexpected return from OptionsForDir: %+v", opts)
+	}
+	assertOptsError("--workspace=offline --flavor=mysql:9.9")
+
+	// Test workspace-parity, which is not used with temp-schema since the
+	// workspace and target are the same instance
+	assertOptsError("--workspace=docker --workspace-parity=sometimes")
+	if opts = getOpts("--workspace=docker --workspace-parity=error"); opts.ParityCheck != ParityCheckError || opts.ParityTarget != s.d.Instance {
+		t.Errorf("Unexpected return from OptionsForDir: %+v", opts)
+	}
+	if opts = getOpts("--workspace=temp-schema --workspace-parity=error"); opts.ParityCheck != ParityCheckNone || opts.ParityTarget != nil {
+		t.Errorf("Unexpected return from OptionsForDir: %+v", opts)
+	}
+	if opts = getOpts("--workspace=docker --workspace-parity=copy"); opts.ParityCheck != ParityCheckCopy || opts.DefaultCharacterSet == "" || opts.DefaultCollation == "" {
+		t.Errorf("Unexpected return from OptionsForDir: %+v", opts)
+	}
+
+	// Test dedicated-host, which requires workspace-host
+	assertOptsError("--workspace=dedicated-host")
+	assertOptsError("--workspace=dedicated-host --workspace-host=ci.db.host --workspace-cleanup-age=soon")
+	if opts = getOpts("--workspace=dedicated-host --workspace-host=" + s.d.Instance.String() + " --workspace-cleanup-age=30m"); opts.Type != TypeDedicatedHost || opts.Instance.String() != s.d.Instance.String() || opts.MaxSchemaAge != 30*time.Minute || opts.Flavor != s.d.Flavor() {
 		t.Errorf("Unexpected return from OptionsForDir: %+v", opts)
 	}
 }
 
+// TestOfflineMatchesServer confirms that an offline workspace generates the
+// same CREATE TABLE statements as a real server, for the golden test files.
+func (s WorkspaceIntegrationSuite) TestOfflineMatchesServer(t *testing.T) {
+	dirPath := "../testdata/golden/init/mydb/product"
+	if major, minor, _ := s.d.Version(); major == 5 && minor == 5 {
+		dirPath = strings.Replace(dirPath, "golden", "golden-mysql55", 1)
+	}
+	dir := s.getParsedDir(t, dirPath, "")
+	opts, err := OptionsForDir(dir, s.d.Instance)
+	if err != nil {
+		t.Fatalf("Unexpected error from OptionsForDir: %s", err)
+	}
+	opts.LockWaitTimeout = 100 * time.Millisecond
+	serverSchema, err := ExecLogicalSchema(dir.LogicalSchemas[0], opts)
+	if err != nil {
+		t.Fatalf("Unexpected error from ExecLogicalSchema: %s", err)
+	}
+
+	dir = s.getParsedDir(t, dirPath, "--workspace=offline")
+	if opts, err = OptionsForDir(dir, s.d.Instance); err != nil {
+		t.Fatalf("Unexpected error from OptionsForDir: %s", err)
+	}
+	offlineSchema, err := ExecLogicalSchema(dir.LogicalSchemas[0], opts)
+	if err != nil {
+		t.Fatalf("Unexpected error from ExecLogicalSchema: %s", err)
+	} else if len(offlineSchema.Failures) > 0 {
+		t.Fatalf("Expected no StatementErrors, instead found %d; first err %v", len(offlineSchema.Failures), offlineSchema.Failures[0])
+	}
+	for _, table := range serverSchema.Tables {
+		offlineTable := offlineSchema.Table(table.Name)
+		if offlineTable == nil {
+			t.Errorf("Table %s missing from offline workspace", table.Name)
+		} else if offlineTable.CreateStatement != table.CreateStatement {
+			t.Errorf("Mismatch for table %s:\nserver:\n%s\noffline:\n%s", table.Name, table.CreateStatement, offlineTable.CreateStatement)
+		}
+	}
+}
+
 // TestPrefab confirms that ExecLogicalSchema still functions properly with a
 // pre-supplied workspace. This provides a way of using Workspace providers from
 // other packages with ExecLogicalSchema.